@@ -0,0 +1,109 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssns"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+)
+
+// Approval configures human-in-the-loop gate for dangerous tools
+type Approval struct {
+	// Tools requiring approval, names or prefixes ending with "*"
+	Tools []string
+
+	// Callers allowed to approve calls of listed tools, the caller does not
+	// approve its own calls
+	Approvers []AccessGrant
+
+	// Slack compatible incoming webhook notified about pending approvals
+	Webhook string
+
+	// SNS topic notified about pending approvals, the topic policy shall
+	// allow publishing by EventBridge (events.amazonaws.com)
+	Topic string
+}
+
+// Requires approval of tools before execution, critical for agents with
+// write access. The call of the tool is held and approvers are notified,
+// the client receives JSON-RPC error mcperr.CodeApprovalPending carrying
+// the approval and retries the same call until the decision. Approvers
+// decide using `POST {endpoint}/approvals?id={id}` with body
+// {"status": "approved"} or {"status": "rejected"}, the status is polled
+// using `GET {endpoint}/approvals?id={id}`. Pending approvals expire in 24
+// hours, the decision is applicable to a single call.
+//
+//	RequireApproval(cloudmcp.Approval{
+//		Tools:     []string{"delete_*"},
+//		Approvers: []cloudmcp.AccessGrant{{Claim: "scope", Value: "admin", Tools: []string{"*"}}},
+//		Topic:     "arn:aws:sns:eu-west-1:123456789012:approvals",
+//	})
+func (c *Gateway) RequireApproval(spec Approval) *Gateway {
+	if len(spec.Tools) == 0 {
+		panic("approval requires tools")
+	}
+	if len(spec.Approvers) == 0 {
+		panic("approval requires approvers")
+	}
+
+	config := struct {
+		Tools     []string      `json:"tools"`
+		Approvers []AccessGrant `json:"approvers"`
+		Webhook   string        `json:"webhook,omitempty"`
+		Bus       string        `json:"bus,omitempty"`
+	}{
+		Tools:     spec.Tools,
+		Approvers: spec.Approvers,
+		Webhook:   spec.Webhook,
+	}
+
+	if spec.Topic != "" {
+		config.Bus = "default"
+
+		bus := awsevents.EventBus_FromEventBusName(c.stack, jsii.String("ApprovalBus"), jsii.String(config.Bus))
+		topic := awssns.Topic_FromTopicArn(c.stack, jsii.String("ApprovalTopic"), jsii.String(spec.Topic))
+
+		awsevents.NewRule(c.stack, jsii.String("ApprovalRule"),
+			&awsevents.RuleProps{
+				EventBus: bus,
+				EventPattern: &awsevents.EventPattern{
+					Source:     jsii.Strings("cloudmcp"),
+					DetailType: jsii.Strings("Tool Call Approval Requested"),
+					Detail: &map[string]any{
+						"server": []string{servername(c.f)},
+					},
+				},
+				Targets: &[]awsevents.IRuleTarget{
+					awseventstargets.NewSnsTopic(topic, nil),
+				},
+			},
+		)
+
+		c.grants = append(c.grants, func(f awsiam.IGrantable) {
+			bus.GrantPutEventsTo(f, nil)
+		})
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		panic(err)
+	}
+
+	c.sessionTable()
+	c.env[envvar.Approvals] = jsii.String(string(data))
+	c.env[envvar.Server] = jsii.String(servername(c.f))
+
+	return c
+}
@@ -0,0 +1,309 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	apigw2 "github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2"
+	authorizers "github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2authorizers"
+	integrations "github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2integrations"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/cloudmcp/internal/introspect"
+	"github.com/fogfish/cloudmcp/internal/jwt"
+	"github.com/fogfish/scud"
+)
+
+// API Key authorizer, which validates access/secret keys against salted hash
+// of the secret. Only the hash is synthesized into the authorizer config.
+type AuthorizerApiKeyHashed struct {
+	RestAPI    apigw2.HttpApi
+	Handler    awslambda.Function
+	authorizer authorizers.HttpLambdaAuthorizer
+}
+
+// Creates API Key authorizer using access key and salted hash of the secret
+// key. Use `go run github.com/fogfish/cloudmcp/cmd/apikey` to generate keys.
+func NewAuthorizerApiKeyHashed(gw *scud.Gateway, access, hash string) *AuthorizerApiKeyHashed {
+	return newAuthorizerApiKeyHashed(gw, access, hash, nil)
+}
+
+func newAuthorizerApiKeyHashed(gw *scud.Gateway, access, hash string, role awsiam.IRole) *AuthorizerApiKeyHashed {
+	f := newApiKeyHashedFunction(gw.Construct, access, hash, role)
+	return newAuthorizerApiKey(gw, f)
+}
+
+// API Key authorizer validating keys managed in the table, keys are created,
+// rotated and revoked at runtime (see Gateway.AccessApiKeys).
+func newAuthorizerApiKeyManaged(gw *scud.Gateway, table awsdynamodb.ITable, role awsiam.IRole) *AuthorizerApiKeyHashed {
+	f := newApiKeyFunction(gw.Construct,
+		map[string]*string{"CONFIG_AUTHORIZER_TABLE": table.TableName()},
+		role,
+	)
+	table.GrantReadData(f)
+
+	return newAuthorizerApiKey(gw, f)
+}
+
+func newAuthorizerApiKey(gw *scud.Gateway, f awslambda.Function) *AuthorizerApiKeyHashed {
+	authorizer := authorizers.NewHttpLambdaAuthorizer(jsii.String("LambdaAuthorizerApiKey"), f,
+		&authorizers.HttpLambdaAuthorizerProps{
+			IdentitySource:  jsii.Strings("$request.header.Authorization"),
+			ResultsCacheTtl: awscdk.Duration_Seconds(jsii.Number(0)),
+		},
+	)
+
+	return &AuthorizerApiKeyHashed{
+		RestAPI:    gw.RestAPI,
+		Handler:    f,
+		authorizer: authorizer,
+	}
+}
+
+// lambda authorizer validating API key, it responds with IAM policy supported
+// by both HTTP and REST API.
+func newApiKeyHashedFunction(scope constructs.Construct, access, hash string, role awsiam.IRole) awslambda.Function {
+	return newApiKeyFunction(scope,
+		map[string]*string{
+			"CONFIG_AUTHORIZER_ACCESS":      jsii.String(access),
+			"CONFIG_AUTHORIZER_SECRET_HASH": jsii.String(hash),
+		},
+		role,
+	)
+}
+
+func newApiKeyFunction(scope constructs.Construct, env map[string]*string, role awsiam.IRole) awslambda.Function {
+	return scud.NewFunctionGo(scope, jsii.String("AuthorizerApiKey"),
+		&scud.FunctionGoProps{
+			SourceCodeModule: "github.com/fogfish/cloudmcp",
+			SourceCodeLambda: "internal/cmd/apikey",
+			FunctionProps: &awslambda.FunctionProps{
+				Role:        role,
+				Timeout:     awscdk.Duration_Seconds(jsii.Number(5)),
+				Environment: &env,
+			},
+		},
+	)
+}
+
+// Associate a Lambda function with a REST API path, including all subpaths.
+func (api *AuthorizerApiKeyHashed) AddResource(
+	endpoint string,
+	handler awslambda.Function,
+) *AuthorizerApiKeyHashed {
+	lambda := integrations.NewHttpLambdaIntegration(
+		jsii.String(filepath.Base(endpoint)),
+		handler,
+		&integrations.HttpLambdaIntegrationProps{
+			PayloadFormatVersion: apigw2.PayloadFormatVersion_VERSION_1_0(),
+		},
+	)
+
+	for _, path := range []string{endpoint, endpoint + "/{any+}"} {
+		api.RestAPI.AddRoutes(&apigw2.AddRoutesOptions{
+			Path:        jsii.String(path),
+			Integration: lambda,
+			Authorizer:  api.authorizer,
+		})
+	}
+
+	return api
+}
+
+// JWT authorizer of multiple issuers, each issuer has own list of audiences.
+// The lambda authorizer validates tokens using keys of issuers fetched with
+// OpenID discovery, HTTP API JWT authorizer supports single issuer only.
+type AuthorizerJwtIssuers struct {
+	RestAPI    apigw2.HttpApi
+	Handler    awslambda.Function
+	authorizer authorizers.HttpLambdaAuthorizer
+	issuers    []JWTIssuer
+}
+
+// Creates JWT authorizer accepting tokens of given issuers
+func NewAuthorizerJwtIssuers(gw *scud.Gateway, issuers ...JWTIssuer) *AuthorizerJwtIssuers {
+	return newAuthorizerJwtIssuers(gw, issuers, nil)
+}
+
+func newAuthorizerJwtIssuers(gw *scud.Gateway, issuers []JWTIssuer, role awsiam.IRole) *AuthorizerJwtIssuers {
+	spec := make([]jwt.Issuer, len(issuers))
+	for i, iss := range issuers {
+		spec[i] = jwt.Issuer{Issuer: iss.Issuer, Audience: iss.Audience}
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		panic(err)
+	}
+
+	f := scud.NewFunctionGo(gw.Construct, jsii.String("AuthorizerJwt"),
+		&scud.FunctionGoProps{
+			SourceCodeModule: "github.com/fogfish/cloudmcp",
+			SourceCodeLambda: "internal/cmd/jwt",
+			FunctionProps: &awslambda.FunctionProps{
+				Role:    role,
+				Timeout: awscdk.Duration_Seconds(jsii.Number(10)),
+				Environment: &map[string]*string{
+					"CONFIG_AUTHORIZER_ISSUERS": jsii.String(string(data)),
+				},
+			},
+		},
+	)
+
+	authorizer := authorizers.NewHttpLambdaAuthorizer(jsii.String("LambdaAuthorizerJwt"), f,
+		&authorizers.HttpLambdaAuthorizerProps{
+			IdentitySource:  jsii.Strings("$request.header.Authorization"),
+			ResultsCacheTtl: awscdk.Duration_Seconds(jsii.Number(0)),
+		},
+	)
+
+	return &AuthorizerJwtIssuers{
+		RestAPI:    gw.RestAPI,
+		Handler:    f,
+		authorizer: authorizer,
+		issuers:    issuers,
+	}
+}
+
+// Associate a Lambda function with a REST API path, including all subpaths.
+func (api *AuthorizerJwtIssuers) AddResource(
+	endpoint string,
+	handler awslambda.Function,
+) *AuthorizerJwtIssuers {
+	lambda := integrations.NewHttpLambdaIntegration(
+		jsii.String(filepath.Base(endpoint)),
+		handler,
+		&integrations.HttpLambdaIntegrationProps{
+			PayloadFormatVersion: apigw2.PayloadFormatVersion_VERSION_1_0(),
+		},
+	)
+
+	for _, path := range []string{endpoint, endpoint + "/{any+}"} {
+		api.RestAPI.AddRoutes(&apigw2.AddRoutesOptions{
+			Path:        jsii.String(path),
+			Integration: lambda,
+			Authorizer:  api.authorizer,
+		})
+	}
+
+	return api
+}
+
+// Token introspection authorizer, which validates opaque tokens using OAuth
+// 2.0 Token Introspection (RFC 7662). The secret of the client is kept in
+// Secrets Manager, only its ARN is synthesized into the authorizer config.
+type AuthorizerIntrospection struct {
+	RestAPI    apigw2.HttpApi
+	Handler    awslambda.Function
+	authorizer authorizers.HttpLambdaAuthorizer
+}
+
+// Creates token introspection authorizer using introspection endpoint of
+// identity provider and credentials of the client.
+func NewAuthorizerIntrospection(gw *scud.Gateway, introspectionUrl, clientId, clientSecretArn string) *AuthorizerIntrospection {
+	return newAuthorizerIntrospection(gw, introspectionUrl, clientId, clientSecretArn, nil)
+}
+
+func newAuthorizerIntrospection(gw *scud.Gateway, introspectionUrl, clientId, clientSecretArn string, role awsiam.IRole) *AuthorizerIntrospection {
+	if !strings.HasPrefix(clientSecretArn, "arn:") {
+		panic("client secret must be ARN of Secrets Manager secret")
+	}
+
+	data, err := json.Marshal(
+		introspect.Config{Url: introspectionUrl, ClientID: clientId, ClientSecret: clientSecretArn},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	f := scud.NewFunctionGo(gw.Construct, jsii.String("AuthorizerIntrospection"),
+		&scud.FunctionGoProps{
+			SourceCodeModule: "github.com/fogfish/cloudmcp",
+			SourceCodeLambda: "internal/cmd/introspect",
+			FunctionProps: &awslambda.FunctionProps{
+				Role:    role,
+				Timeout: awscdk.Duration_Seconds(jsii.Number(10)),
+				Environment: &map[string]*string{
+					"CONFIG_AUTHORIZER_INTROSPECTION": jsii.String(string(data)),
+				},
+			},
+		},
+	)
+
+	// the secret is loaded via SSM reference to Secrets Manager
+	stack := awscdk.Stack_Of(gw.Construct)
+	f.AddToRolePolicy(
+		awsiam.NewPolicyStatement(
+			&awsiam.PolicyStatementProps{
+				Actions: jsii.Strings("ssm:GetParameter"),
+				Resources: &[]*string{
+					stack.FormatArn(
+						&awscdk.ArnComponents{
+							Service:      jsii.String("ssm"),
+							Resource:     jsii.String("parameter"),
+							ResourceName: jsii.String("aws/reference/secretsmanager/" + clientSecretArn),
+						},
+					),
+				},
+			},
+		),
+	)
+	f.AddToRolePolicy(
+		awsiam.NewPolicyStatement(
+			&awsiam.PolicyStatementProps{
+				Actions:   jsii.Strings("secretsmanager:GetSecretValue"),
+				Resources: jsii.Strings(clientSecretArn),
+			},
+		),
+	)
+
+	authorizer := authorizers.NewHttpLambdaAuthorizer(jsii.String("LambdaAuthorizerIntrospection"), f,
+		&authorizers.HttpLambdaAuthorizerProps{
+			IdentitySource:  jsii.Strings("$request.header.Authorization"),
+			ResultsCacheTtl: awscdk.Duration_Seconds(jsii.Number(0)),
+		},
+	)
+
+	return &AuthorizerIntrospection{
+		RestAPI:    gw.RestAPI,
+		Handler:    f,
+		authorizer: authorizer,
+	}
+}
+
+// Associate a Lambda function with a REST API path, including all subpaths.
+func (api *AuthorizerIntrospection) AddResource(
+	endpoint string,
+	handler awslambda.Function,
+) *AuthorizerIntrospection {
+	lambda := integrations.NewHttpLambdaIntegration(
+		jsii.String(filepath.Base(endpoint)),
+		handler,
+		&integrations.HttpLambdaIntegrationProps{
+			PayloadFormatVersion: apigw2.PayloadFormatVersion_VERSION_1_0(),
+		},
+	)
+
+	for _, path := range []string{endpoint, endpoint + "/{any+}"} {
+		api.RestAPI.AddRoutes(&apigw2.AddRoutesOptions{
+			Path:        jsii.String(path),
+			Integration: lambda,
+			Authorizer:  api.authorizer,
+		})
+	}
+
+	return api
+}
@@ -0,0 +1,37 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Utility to generate API key and its salted hash for AccessApiKeyHashed.
+// The secret is always random, hashes of human-chosen secrets are guessable.
+//
+//	go run github.com/fogfish/cloudmcp/cmd/apikey
+//	go run github.com/fogfish/cloudmcp/cmd/apikey -access myaccess
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+
+	"github.com/fogfish/cloudmcp/internal/apikey"
+)
+
+func main() {
+	access := flag.String("access", "", "access key (generated if empty)")
+	flag.Parse()
+
+	ak, sk := apikey.Generate()
+	if *access != "" {
+		ak = *access
+	}
+
+	fmt.Printf("access: %s\n", ak)
+	fmt.Printf("secret: %s\n", sk)
+	fmt.Printf("hash:   %s\n", apikey.Hash(sk))
+	fmt.Printf("header: Authorization: Basic %s\n", base64.RawStdEncoding.EncodeToString([]byte(ak+":"+sk)))
+}
@@ -0,0 +1,36 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+
+	"github.com/fogfish/cloudmcp/internal/apikey"
+)
+
+// generates API key and its salted hash, same as cmd/apikey
+func genApiKey(args []string) error {
+	fs := flag.NewFlagSet("apikey", flag.ExitOnError)
+	access := fs.String("access", "", "access key (generated if empty)")
+	fs.Parse(args)
+
+	ak, sk := apikey.Generate()
+	if *access != "" {
+		ak = *access
+	}
+
+	fmt.Printf("access: %s\n", ak)
+	fmt.Printf("secret: %s\n", sk)
+	fmt.Printf("hash:   %s\n", apikey.Hash(sk))
+	fmt.Printf("header: Authorization: Basic %s\n", base64.RawStdEncoding.EncodeToString([]byte(ak+":"+sk)))
+
+	return nil
+}
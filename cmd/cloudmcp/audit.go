@@ -0,0 +1,70 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/fogfish/cloudmcp/pkg/audit"
+)
+
+// verifies the audit trail of the deployed server, the table is read from
+// the stack output "AuditTable", the chain is named after the server and
+// sharded per tenant and per day
+func auditTrail(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	stack := fs.String("stack", "", "name of the stack, discovered from the Gateway program if omitted")
+	stage := fs.String("stage", "", "stage (environment) of the deployment")
+	chain := fs.String("chain", "", "name of the server, defaults to the name of the stack")
+	tenant := fs.String("tenant", "", "tenant of the chain")
+	day := fs.String("day", time.Now().UTC().Format(time.DateOnly), "day (UTC) of the chain, YYYY-MM-DD")
+	fs.Parse(args)
+
+	t, err := time.Parse(time.DateOnly, *day)
+	if err != nil {
+		return err
+	}
+
+	name, err := stackName(ctx, *stack, *stage)
+	if err != nil {
+		return err
+	}
+
+	table, err := stackOutput(ctx, name, "AuditTable")
+	if err != nil {
+		return err
+	}
+
+	if *chain == "" {
+		*chain = name
+		if *stage != "" {
+			*chain = strings.TrimSuffix(name, "-"+*stage)
+		}
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	shard := audit.Chain(*chain, *tenant, t)
+	n, err := audit.NewTrail(dynamodb.NewFromConfig(cfg), table).Verify(ctx, shard)
+	if err != nil {
+		return fmt.Errorf("%d records verified: %w", n, err)
+	}
+
+	fmt.Printf("%s: %d records verified\n", shard, n)
+	return nil
+}
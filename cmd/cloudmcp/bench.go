@@ -0,0 +1,147 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/fogfish/cloudmcp/internal/gateway"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// benchmarks overhead of the gateway (lambda adapter) per request. The echo
+// tool is called directly via MCP handler and through the gateway with
+// payloads of given sizes, the difference is the cost of the gateway.
+func bench(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	sizes := fs.String("size", "1024,65536,1048576", "comma separated sizes of payloads in bytes")
+	cpuprofile := fs.String("cpuprofile", "", "write CPU profile of the gateway to the file")
+	fs.Parse(args)
+
+	handler := echoHandler()
+	gw := gateway.New(handler)
+
+	if *cpuprofile != "" {
+		f, err := os.Create(*cpuprofile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "size\tpath\tns/op\tB/op\tallocs/op\t")
+
+	for _, s := range strings.Split(*sizes, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("invalid size %s: %w", s, err)
+		}
+
+		body := echoCall(size)
+		event, err := json.Marshal(echoEvent(body))
+		if err != nil {
+			return err
+		}
+
+		direct := testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+				r.Header.Set("Content-Type", "application/json")
+				r.Header.Set("Accept", "application/json, text/event-stream")
+				handler.ServeHTTP(httptest.NewRecorder(), r)
+			}
+		})
+
+		viaGateway := testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := gw.Handle(ctx, event); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		for _, r := range []struct {
+			path string
+			res  testing.BenchmarkResult
+		}{{"direct", direct}, {"gateway", viaGateway}} {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t\n", size, r.path, r.res.NsPerOp(), r.res.AllocedBytesPerOp(), r.res.AllocsPerOp())
+		}
+		fmt.Fprintf(w, "%d\toverhead\t%d\t%d\t%d\t\n", size,
+			viaGateway.NsPerOp()-direct.NsPerOp(),
+			viaGateway.AllocedBytesPerOp()-direct.AllocedBytesPerOp(),
+			viaGateway.AllocsPerOp()-direct.AllocsPerOp(),
+		)
+	}
+
+	return w.Flush()
+}
+
+// MCP handler of the server with echo tool, configured as the lambda does
+func echoHandler() http.Handler {
+	server := mcp.NewServer(&mcp.Implementation{Name: "bench", Version: "v0.0.0"}, nil)
+
+	type Echo struct {
+		Text string `json:"text"`
+	}
+	mcp.AddTool(server, &mcp.Tool{Name: "echo", Description: "echoes the text"},
+		func(ctx context.Context, req *mcp.CallToolRequest, in Echo) (*mcp.CallToolResult, Echo, error) {
+			return nil, in, nil
+		},
+	)
+
+	return mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		return server
+	}, &mcp.StreamableHTTPOptions{
+		Stateless:    true,
+		JSONResponse: true,
+	})
+}
+
+// tools/call of echo tool with the text of given size
+func echoCall(size int) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"text":"` +
+		strings.Repeat("x", size) + `"}}}`
+}
+
+// API Gateway event of the request, as the lambda receives it
+func echoEvent(body string) *events.APIGatewayProxyRequest {
+	return &events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/bench",
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+			"Accept":       "application/json, text/event-stream",
+		},
+		MultiValueHeaders: map[string][]string{
+			"Content-Type": {"application/json"},
+			"Accept":       {"application/json, text/event-stream"},
+		},
+		Body: body,
+	}
+}
@@ -0,0 +1,119 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+)
+
+// synth, deploy and destroy the stack using AWS CDK, flags after "--" are
+// passed to cdk as-is.
+func cdk(ctx context.Context, cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	stage := fs.String("stage", "", "stage (environment) of the deployment")
+	fs.Parse(args)
+
+	cdkArgs := []string{cmd}
+	if _, err := os.Stat("cdk.json"); err != nil {
+		cdkArgs = append(cdkArgs, "--app", "go run .")
+	}
+	if *stage != "" {
+		cdkArgs = append(cdkArgs, "-c", "stage="+*stage)
+	}
+	switch cmd {
+	case "deploy":
+		cdkArgs = append(cdkArgs, "--require-approval", "never")
+	case "destroy":
+		cdkArgs = append(cdkArgs, "--force")
+	}
+	cdkArgs = append(cdkArgs, fs.Args()...)
+
+	return run(ctx, os.Stdout, cdkArgs...)
+}
+
+// runs cdk cli, npx is used if cdk is not installed
+func run(ctx context.Context, stdout io.Writer, args ...string) error {
+	bin := "cdk"
+	if _, err := exec.LookPath(bin); err != nil {
+		bin, args = "npx", append([]string{"aws-cdk"}, args...)
+	}
+
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// name of the stack, discovered from the Gateway program if omitted
+func stackName(ctx context.Context, stack, stage string) (string, error) {
+	if stack != "" {
+		return stack, nil
+	}
+
+	args := []string{"list"}
+	if _, err := os.Stat("cdk.json"); err != nil {
+		args = append(args, "--app", "go run .")
+	}
+	if stage != "" {
+		args = append(args, "-c", "stage="+stage)
+	}
+
+	out := bytes.Buffer{}
+	if err := run(ctx, &out, args...); err != nil {
+		return "", err
+	}
+
+	stacks := strings.Fields(out.String())
+	switch len(stacks) {
+	case 0:
+		return "", errors.New("no stacks found, use -stack")
+	case 1:
+		return stacks[0], nil
+	default:
+		return "", errors.New("multiple stacks found, use -stack")
+	}
+}
+
+// output of the deployed stack
+func stackOutput(ctx context.Context, stack, key string) (string, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := cloudformation.NewFromConfig(cfg).DescribeStacks(ctx,
+		&cloudformation.DescribeStacksInput{StackName: aws.String(stack)},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	for _, s := range out.Stacks {
+		for _, o := range s.Outputs {
+			if aws.ToString(o.OutputKey) == key {
+				return aws.ToString(o.OutputValue), nil
+			}
+		}
+	}
+
+	return "", errors.New("output " + key + " is not found in stack " + stack)
+}
@@ -0,0 +1,109 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/fogfish/cloudmcp/internal/codegen"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// generates typed client stubs and client config of the deployed (or local) server
+func codegenClient(ctx context.Context, args []string) error {
+	hdrs := headers{}
+	fs := flag.NewFlagSet("codegen", flag.ExitOnError)
+	stack := fs.String("stack", "", "name of the stack, discovered from the Gateway program if omitted")
+	stage := fs.String("stage", "", "stage (environment) of the deployment")
+	endpoint := fs.String("endpoint", "", "endpoint of MCP server, read from the stack if omitted")
+	command := fs.String("command", "", "command running the local server over stdio (e.g. \"go run . stdio\"), used instead of the deployed server")
+	lang := fs.String("lang", "ts", "output: ts, python, claude (claude_desktop_config.json) or cursor (mcp.json)")
+	name := fs.String("name", "", "name of the server, read from the server if omitted")
+	output := fs.String("o", "", "output file, stdout if omitted")
+	fs.Var(hdrs, "H", "HTTP header \"Key: Value\" (e.g. Authorization), repeatable")
+	fs.Parse(args)
+
+	gen, has := codegen.Generators[*lang]
+	if !has {
+		return fmt.Errorf("unsupported -lang %q", *lang)
+	}
+
+	server := &codegen.Server{Headers: http.Header(hdrs)}
+
+	var conn mcp.Transport
+	if *command != "" {
+		server.Command = strings.Fields(*command)
+		cmd := exec.CommandContext(ctx, server.Command[0], server.Command[1:]...)
+		cmd.Stderr = os.Stderr
+		conn = &mcp.CommandTransport{Command: cmd}
+	} else {
+		if *endpoint == "" {
+			name, err := stackName(ctx, *stack, *stage)
+			if err != nil {
+				return err
+			}
+
+			*endpoint, err = stackOutput(ctx, name, "Endpoint")
+			if err != nil {
+				return err
+			}
+		}
+
+		server.Endpoint = *endpoint
+		conn = &mcp.StreamableClientTransport{
+			Endpoint:   *endpoint,
+			HTTPClient: &http.Client{Transport: &transport{headers: server.Headers, next: http.DefaultTransport}},
+			MaxRetries: -1,
+		}
+	}
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "cloudmcp", Version: "v1.0.0"}, nil)
+	session, err := client.Connect(ctx, conn, nil)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	for tool, err := range session.Tools(ctx, nil) {
+		if err != nil {
+			return err
+		}
+		server.Tools = append(server.Tools, tool)
+	}
+
+	server.Name = *name
+	if server.Name == "" {
+		if info := session.InitializeResult().ServerInfo; info != nil {
+			server.Name = info.Name
+		}
+	}
+	if server.Name == "" {
+		return errors.New("server has no name, use -name")
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return gen(w, server)
+}
@@ -0,0 +1,107 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// repeatable flag of HTTP headers
+type headers http.Header
+
+func (h headers) String() string { return "" }
+
+func (h headers) Set(v string) error {
+	key, val, ok := strings.Cut(v, ":")
+	if !ok {
+		return fmt.Errorf("invalid header %q, expected \"Key: Value\"", v)
+	}
+	http.Header(h).Add(strings.TrimSpace(key), strings.TrimSpace(val))
+	return nil
+}
+
+// transport injects headers into requests of MCP client
+type transport struct {
+	headers http.Header
+	next    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, vals := range t.headers {
+		req.Header[key] = vals
+	}
+	return t.next.RoundTrip(req)
+}
+
+// calls the tool of the deployed server
+func invoke(ctx context.Context, args []string) error {
+	hdrs := headers{}
+	fs := flag.NewFlagSet("invoke", flag.ExitOnError)
+	stack := fs.String("stack", "", "name of the stack, discovered from the Gateway program if omitted")
+	stage := fs.String("stage", "", "stage (environment) of the deployment")
+	endpoint := fs.String("endpoint", "", "endpoint of MCP server, read from the stack if omitted")
+	tool := fs.String("tool", "", "name of the tool, lists tools if omitted")
+	input := fs.String("input", "{}", "arguments of the tool as JSON")
+	fs.Var(hdrs, "H", "HTTP header \"Key: Value\" (e.g. Authorization), repeatable")
+	fs.Parse(args)
+
+	if *endpoint == "" {
+		name, err := stackName(ctx, *stack, *stage)
+		if err != nil {
+			return err
+		}
+
+		*endpoint, err = stackOutput(ctx, name, "Endpoint")
+		if err != nil {
+			return err
+		}
+	}
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "cloudmcp", Version: "v1.0.0"}, nil)
+	session, err := client.Connect(ctx,
+		&mcp.StreamableClientTransport{
+			Endpoint:   *endpoint,
+			HTTPClient: &http.Client{Transport: &transport{headers: http.Header(hdrs), next: http.DefaultTransport}},
+			MaxRetries: -1,
+		},
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	var result any
+	if *tool == "" {
+		result, err = session.ListTools(ctx, nil)
+	} else {
+		result, err = session.CallTool(ctx,
+			&mcp.CallToolParams{
+				Name:      *tool,
+				Arguments: json.RawMessage(*input),
+			},
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
@@ -0,0 +1,104 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+// prints logs of the deployed server, the log group is "/app/{stack}"
+func logs(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	stack := fs.String("stack", "", "name of the stack, discovered from the Gateway program if omitted")
+	stage := fs.String("stage", "", "stage (environment) of the deployment")
+	since := fs.Duration("since", 10*time.Minute, "print logs newer than relative duration")
+	filter := fs.String("filter", "", "CloudWatch Logs filter pattern")
+	tail := fs.Bool("tail", false, "follow logs")
+	fs.Parse(args)
+
+	name, err := stackName(ctx, *stack, *stage)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return err
+	}
+	api := cloudwatchlogs.NewFromConfig(cfg)
+
+	// events of the same millisecond are seen twice while following
+	seen := map[string]struct{}{}
+	start := time.Now().Add(-*since).UnixMilli()
+	for {
+		input := &cloudwatchlogs.FilterLogEventsInput{
+			LogGroupName: aws.String("/app/" + name),
+			StartTime:    aws.Int64(start),
+		}
+		if *filter != "" {
+			input.FilterPattern = filter
+		}
+
+		last := start
+		fresh := map[string]struct{}{}
+		pages := cloudwatchlogs.NewFilterLogEventsPaginator(api, input)
+		for pages.HasMorePages() {
+			page, err := pages.NextPage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+
+			for _, e := range page.Events {
+				id := aws.ToString(e.EventId)
+				if _, has := seen[id]; has {
+					continue
+				}
+
+				t := aws.ToInt64(e.Timestamp)
+				if t > last {
+					last, fresh = t, map[string]struct{}{}
+				}
+				fresh[id] = struct{}{}
+
+				fmt.Printf("%s %s\n",
+					time.UnixMilli(t).Format(time.RFC3339Nano),
+					strings.TrimRight(aws.ToString(e.Message), "\n"),
+				)
+			}
+		}
+
+		if !*tail {
+			return nil
+		}
+
+		if last > start {
+			start, seen = last, fresh
+		} else {
+			maps.Copy(seen, fresh)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Command line utility to deploy and operate serverless MCP servers defined
+// with cloudmcp. It wraps AWS CDK for the Gateway program in the current
+// directory, calls tools of the deployed server and tails its logs.
+//
+//	go install github.com/fogfish/cloudmcp/cmd/cloudmcp@latest
+//
+//	cloudmcp deploy -stage dev
+//	cloudmcp invoke -tool sayer -input '{"name":"world"}' -H "Authorization: Basic ..."
+//	cloudmcp logs -tail
+//	cloudmcp verify -apikey access:secret -tool sayer
+//	cloudmcp audit
+//	cloudmcp bench -size 1024,1048576
+//	cloudmcp traffic -stage prod -percent 10
+//	cloudmcp codegen -lang ts -o client.ts
+//	cloudmcp manifest -stage prod -o server.json
+//	cloudmcp destroy -stage dev
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+const usage = `usage: cloudmcp <command> [flags]
+
+commands:
+  synth     synthesize the stack of the Gateway program
+  deploy    deploy the stack of the Gateway program
+  destroy   destroy the stack of the Gateway program
+  invoke    call the tool of the deployed server, lists tools if the tool is omitted
+  logs      print logs of the deployed server
+  verify    smoke test the deployed server with each auth mode
+  audit     verify hash chain of the audit trail of the deployed server
+  apikey    generate API key and its salted hash for AccessApiKeyHashed
+  bench     benchmark overhead of the gateway per request
+  traffic   shift traffic of blue/green deployment, prints the split if percent is omitted
+  codegen   generate typed client (ts, python) or client config (claude, cursor) of the server
+  manifest  print manifest of the deployed server for MCP registry (server.json)
+
+Use "cloudmcp <command> -h" for flags of the command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	var err error
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "synth", "deploy", "destroy":
+		err = cdk(ctx, cmd, args)
+	case "invoke":
+		err = invoke(ctx, args)
+	case "logs":
+		err = logs(ctx, args)
+	case "verify":
+		err = verifyServer(ctx, args)
+	case "audit":
+		err = auditTrail(ctx, args)
+	case "apikey":
+		err = genApiKey(args)
+	case "bench":
+		err = bench(ctx, args)
+	case "traffic":
+		err = traffic(ctx, args)
+	case "codegen":
+		err = codegenClient(ctx, args)
+	case "manifest":
+		err = manifest(ctx, args)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cloudmcp %s: %s\n", cmd, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,72 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// placeholder of the endpoint, same as cloudmcp.ManifestEndpoint
+const manifestEndpoint = "${Endpoint}"
+
+// prints the manifest of the deployed server for MCP registry (server.json),
+// the manifest is written into the cloud assembly by Gateway.Manifest, the
+// endpoint is read from the stack output "Endpoint".
+func manifest(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("manifest", flag.ExitOnError)
+	stack := fs.String("stack", "", "name of the stack, discovered from the Gateway program if omitted")
+	stage := fs.String("stage", "", "stage (environment) of the deployment")
+	assembly := fs.String("assembly", "cdk.out", "directory of the cloud assembly")
+	endpoint := fs.String("endpoint", "", "endpoint of MCP server, read from the stack if omitted")
+	output := fs.String("o", "", "output file, stdout if omitted")
+	fs.Parse(args)
+
+	name, err := stackName(ctx, *stack, *stage)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filepath.Join(*assembly, name+".server.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("manifest of %s is not found, configure Manifest(...) of the gateway and synthesize the stack", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if bytes.Contains(data, []byte(manifestEndpoint)) {
+		if *endpoint == "" {
+			*endpoint, err = stackOutput(ctx, name, "Endpoint")
+			if err != nil {
+				return err
+			}
+		}
+		data = bytes.ReplaceAll(data, []byte(manifestEndpoint), []byte(*endpoint))
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
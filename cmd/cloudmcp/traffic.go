@@ -0,0 +1,68 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/fogfish/cloudmcp/pkg/bluegreen"
+)
+
+// shifts traffic of the server deployed in blue/green mode, the function
+// and its green version are read from the stack outputs "ServerFunction"
+// and "GreenVersion". The traffic split is printed if percent is omitted.
+func traffic(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("traffic", flag.ExitOnError)
+	stack := fs.String("stack", "", "name of the stack, discovered from the Gateway program if omitted")
+	stage := fs.String("stage", "", "stage (environment) of the deployment")
+	percent := fs.Float64("percent", -1, "percent of traffic shifted to the green version, 100 promotes it, 0 rolls back")
+	fs.Parse(args)
+
+	name, err := stackName(ctx, *stack, *stage)
+	if err != nil {
+		return err
+	}
+
+	function, err := stackOutput(ctx, name, "ServerFunction")
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return err
+	}
+	api := lambda.NewFromConfig(cfg)
+
+	if *percent < 0 {
+		status, err := bluegreen.Status(ctx, api, function)
+		if err != nil {
+			return err
+		}
+		fmt.Println(status)
+		return nil
+	}
+
+	green, err := stackOutput(ctx, name, "GreenVersion")
+	if err != nil {
+		return err
+	}
+
+	status, err := bluegreen.Shift(ctx, api, function, green, *percent)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(status)
+	return nil
+}
@@ -0,0 +1,163 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/fogfish/cloudmcp/pkg/verify"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// smoke tests the deployed server with each configured auth mode
+func verifyServer(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("usage: cloudmcp verify [flags] [endpoint]\n"))
+		fs.PrintDefaults()
+	}
+	stack := fs.String("stack", "", "name of the stack, discovered from the Gateway program if endpoint is omitted")
+	stage := fs.String("stage", "", "stage (environment) of the deployment")
+	tool := fs.String("tool", "", "name of the tool to call, the call is skipped if omitted")
+	input := fs.String("input", "{}", "arguments of the tool as JSON")
+	apikey := fs.String("apikey", "", "API key \"access:secret\"")
+	bearer := fs.String("bearer", "", "bearer token (e.g. JWT)")
+	iam := fs.Bool("iam", false, "sign requests with AWS credentials (SigV4)")
+	public := fs.Bool("public", false, "the server is public, anonymous access is expected to succeed")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of each auth mode")
+	fs.Parse(args)
+
+	endpoint := fs.Arg(0)
+	if endpoint == "" {
+		name, err := stackName(ctx, *stack, *stage)
+		if err != nil {
+			return err
+		}
+
+		endpoint, err = stackOutput(ctx, name, "Endpoint")
+		if err != nil {
+			return err
+		}
+	}
+
+	modes := []verify.Mode{
+		{
+			Name:      "anonymous",
+			Transport: &mcp.StreamableClientTransport{Endpoint: endpoint, MaxRetries: -1},
+			Rejected:  !*public,
+		},
+	}
+
+	if *apikey != "" {
+		modes = append(modes, verify.Mode{
+			Name:      "apikey",
+			Transport: withHeader(endpoint, "Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(*apikey))),
+		})
+	}
+
+	if *bearer != "" {
+		modes = append(modes, verify.Mode{
+			Name:      "bearer",
+			Transport: withHeader(endpoint, "Authorization", "Bearer "+*bearer),
+		})
+	}
+
+	if *iam {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return err
+		}
+
+		modes = append(modes, verify.Mode{
+			Name: "iam",
+			Transport: &mcp.StreamableClientTransport{
+				Endpoint:   endpoint,
+				HTTPClient: &http.Client{Transport: &iamTransport{config: cfg, signer: v4.NewSigner(), next: http.DefaultTransport}},
+				MaxRetries: -1,
+			},
+		})
+	}
+
+	report := verify.Run(ctx,
+		verify.Config{Tool: *tool, Input: json.RawMessage(*input), Timeout: *timeout},
+		modes...,
+	)
+
+	if _, err := report.WriteTo(os.Stdout); err != nil {
+		return err
+	}
+
+	if !report.Passed() {
+		return errors.New("server is not verified")
+	}
+
+	return nil
+}
+
+func withHeader(endpoint, key, val string) *mcp.StreamableClientTransport {
+	return &mcp.StreamableClientTransport{
+		Endpoint:   endpoint,
+		HTTPClient: &http.Client{Transport: &transport{headers: http.Header{key: []string{val}}, next: http.DefaultTransport}},
+		MaxRetries: -1,
+	}
+}
+
+// transport signs requests with SigV4 for API Gateway (execute-api)
+type iamTransport struct {
+	config aws.Config
+	signer *v4.Signer
+	next   http.RoundTripper
+}
+
+func (t *iamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	credential, err := t.config.Credentials.Retrieve(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	body := []byte{}
+	if req.Body != nil {
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	hash := sha256.Sum256(body)
+
+	// region of execute-api endpoint, e.g. {id}.execute-api.{region}.amazonaws.com
+	region := t.config.Region
+	if labels := strings.Split(req.URL.Hostname(), "."); len(labels) > 3 && labels[1] == "execute-api" {
+		region = labels[2]
+	}
+
+	err = t.signer.SignHTTP(req.Context(), credential, req, hex.EncodeToString(hash[:]), "execute-api", region, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req)
+}
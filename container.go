@@ -0,0 +1,312 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	apigw2 "github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2"
+	authorizers "github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2authorizers"
+	integrations "github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2integrations"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsecrassets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsecs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsecspatterns"
+	elb "github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/cloudmcp/pkg/middleware"
+)
+
+// Builds the stack, the server is deployed as Fargate service instead of
+// Lambda function, for servers streaming long-lived responses or running
+// work beyond 15 minutes limit of Lambda. The service is behind the same
+// gateway, authorizers and log group, it is reachable through the private
+// load balancer and VPC link only. API Gateway limits integrations to 30
+// seconds, longer work has to be reported using progress notifications or
+// resumed by the client.
+//
+// The container runs the server using streamable HTTP transport, it is
+// configured with same environment and permissions as the function. The
+// image is built using Dockerfile (see FromImage), a default one is
+// generated otherwise. Options specific to Lambda runtime (API key access,
+// policy, rate limits, keep warm, canary, WebSocket) are not supported.
+func (c *Gateway) BuildContainer() {
+	if c.gateway == nil && c.private == nil && c.restapi == nil {
+		c.Hostless()
+	}
+
+	switch {
+	case c.authkey != nil:
+		panic("container supports only public, jwt, cognito, iam and hashed api key access")
+	case c.websocket:
+		panic("container does not support websocket api")
+	case c.private != nil, c.restapi != nil:
+		panic("container does not support private and imported api")
+	case c.deploy != nil, c.keepwarm != nil, len(c.hooks) > 0, c.health:
+		panic("container does not support canary, keep warm, health check and layers")
+	case c.version != "", len(c.versions) > 0:
+		panic("container does not support versions")
+	case c.partition != nil:
+		panic("container does not support partitions")
+	}
+
+	module, lambda := c.sourcecode(c.f)
+	name, path := cautogen(c.f, c.middlewares, module)
+	uri := "/" + strings.ToLower(name)
+
+	file := c.dockerfile
+	if file == "" {
+		file = filepath.Join(strings.TrimPrefix(path, "/"), containerdir, "Dockerfile")
+	}
+
+	vpc, subnets, public := c.vpc, c.subnets, false
+	if vpc == nil {
+		// no NAT, the task pulls image using public IP
+		vpc = awsec2.NewVpc(c.stack, jsii.String("Network"),
+			&awsec2.VpcProps{
+				MaxAzs:      jsii.Number(2),
+				NatGateways: jsii.Number(0),
+				SubnetConfiguration: &[]*awsec2.SubnetConfiguration{
+					{Name: jsii.String("Public"), SubnetType: awsec2.SubnetType_PUBLIC},
+				},
+			},
+		)
+		subnets = &awsec2.SubnetSelection{SubnetType: awsec2.SubnetType_PUBLIC}
+		public = true
+	}
+
+	lb := elb.NewApplicationLoadBalancer(c.stack, jsii.String("LoadBalancer"),
+		&elb.ApplicationLoadBalancerProps{
+			Vpc:            vpc,
+			VpcSubnets:     subnets,
+			InternetFacing: jsii.Bool(false),
+		},
+	)
+
+	props := &awsecspatterns.ApplicationLoadBalancedFargateServiceProps{
+		Vpc:                vpc,
+		LoadBalancer:       lb,
+		PublicLoadBalancer: jsii.Bool(false),
+		OpenListener:       jsii.Bool(false),
+		TaskSubnets:        subnets,
+		AssignPublicIp:     jsii.Bool(public),
+		Cpu:                jsii.Number(512),
+		MemoryLimitMiB:     jsii.Number(1024),
+		DesiredCount:       jsii.Number(1),
+		RuntimePlatform: &awsecs.RuntimePlatform{
+			CpuArchitecture:       awsecs.CpuArchitecture_ARM64(),
+			OperatingSystemFamily: awsecs.OperatingSystemFamily_LINUX(),
+		},
+		TaskImageOptions: &awsecspatterns.ApplicationLoadBalancedTaskImageOptions{
+			Image: awsecs.ContainerImage_FromAsset(jsii.String(rootSourceCode(module)),
+				&awsecs.AssetImageProps{
+					File:     jsii.String(file),
+					Platform: awsecrassets.Platform_LINUX_ARM64(),
+					BuildArgs: &map[string]*string{
+						"LAMBDA": jsii.String(strings.TrimPrefix(path, "/") + "/" + containerdir),
+					},
+				},
+			),
+			ContainerPort: jsii.Number(8080),
+			Environment:   &c.env,
+			LogDriver: awsecs.LogDrivers_AwsLogs(
+				&awsecs.AwsLogDriverProps{
+					LogGroup:     c.loggroup,
+					StreamPrefix: jsii.String(filepath.Base(lambda)),
+				},
+			),
+		},
+	}
+	if len(c.securityGroups) > 0 {
+		props.SecurityGroups = &c.securityGroups
+	}
+
+	service := awsecspatterns.NewApplicationLoadBalancedFargateService(c.stack, jsii.String("Container"), props)
+	service.TargetGroup().ConfigureHealthCheck(
+		&elb.HealthCheck{Path: jsii.String("/healthz")},
+	)
+	service.Listener().Connections().AllowDefaultPortFrom(
+		awsec2.Peer_Ipv4(vpc.VpcCidrBlock()), nil,
+	)
+
+	for _, grant := range c.grants {
+		grant(service.TaskDefinition().TaskRole())
+	}
+
+	link := apigw2.NewVpcLink(c.stack, jsii.String("VpcLink"),
+		&apigw2.VpcLinkProps{Vpc: vpc, Subnets: subnets},
+	)
+
+	integration := integrations.NewHttpAlbIntegration(jsii.String("Container"), service.Listener(),
+		&integrations.HttpAlbIntegrationProps{VpcLink: link},
+	)
+
+	var authorizer apigw2.IHttpRouteAuthorizer
+	switch {
+	case c.authjwts != nil:
+		authorizer = c.authjwts.authorizer
+	case c.jwt != nil:
+		authorizer = authorizers.NewHttpJwtAuthorizer(jsii.String("ContainerAuthorizer"), jsii.String(c.jwt.issuer),
+			&authorizers.HttpJwtAuthorizerProps{JwtAudience: jsii.Strings(c.jwt.audience...)},
+		)
+	case c.authint != nil:
+		authorizer = c.authint.authorizer
+	case c.authhsh != nil:
+		authorizer = c.authhsh.authorizer
+	case c.authiam != nil:
+		authorizer = authorizers.NewHttpIamAuthorizer()
+	}
+
+	for _, path := range []string{uri, uri + "/{any+}"} {
+		routes := c.gateway.RestAPI.AddRoutes(&apigw2.AddRoutesOptions{
+			Path:        jsii.String(path),
+			Integration: integration,
+			Authorizer:  authorizer,
+		})
+
+		if c.authiam != nil {
+			for _, route := range *routes {
+				route.GrantInvoke(c.grantee, nil)
+			}
+		}
+	}
+
+	if c.authiam != nil {
+		c.outputPolicyIAM(c.gateway.RestAPI, uri)
+	}
+
+	if c.edge {
+		c.buildEdge(uri)
+	}
+
+	c.encrypt()
+
+	c.output("Host", c.gateway.RestAPI.ApiEndpoint())
+	c.output("Endpoint", jsii.String(*c.gateway.RestAPI.ApiEndpoint()+uri))
+
+	c.synth()
+}
+
+//------------------------------------------------------------------------------
+
+const containerdir = "autogen/container"
+
+// generates main package and Dockerfile of the server running in container
+func cautogen(f any, mws []middleware.Middleware, scModule string) (string, string) {
+	fptr := reflect.ValueOf(f).Pointer()
+	fobj := runtime.FuncForPC(fptr)
+	if fobj == nil {
+		panic(fmt.Errorf("failed to discover function metadata"))
+	}
+
+	name := fobj.Name()
+	serv := filepath.Ext(name)[1:]
+	path := strings.TrimSuffix(name, filepath.Ext(name))
+	base := filepath.Base(name)
+	uri := "/" + strings.ToLower(serv)
+	imports, use := mautogen(mws)
+	fimports, call := factoryautogen(f, base)
+
+	code := fmt.Sprintf(`// DO NOT EDIT !!!
+// THE FILE IS AUTO GENERATED BY github.com/fogfish/cloudmcp
+// %s
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/fogfish/cloudmcp/internal/gateway"
+	"github.com/fogfish/cloudmcp/pkg/warm"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"%s"%s
+)
+
+func main() {
+	%s
+	if err != nil {
+		panic(err)
+	}
+%s
+	if err := gateway.ServerFromEnv(context.Background(), server); err != nil {
+		panic(err)
+	}
+
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		return server
+	}, &mcp.StreamableHTTPOptions{
+		Stateless: true,
+	})
+
+	if err := warm.Run(context.Background()); err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("%s", handler)
+	mux.Handle("%s/", handler)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	addr := ":8080"
+	if port := os.Getenv("PORT"); port != "" {
+		addr = ":" + port
+	}
+
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		panic(err)
+	}
+}
+`, time.Now(), path, imports+fimports, call, use, uri, uri)
+
+	docker := `# DO NOT EDIT !!!
+# THE FILE IS AUTO GENERATED BY github.com/fogfish/cloudmcp
+FROM golang:1 AS build
+ARG LAMBDA
+WORKDIR /src
+COPY . .
+RUN CGO_ENABLED=0 go build -o /server ./${LAMBDA}
+
+FROM gcr.io/distroless/static
+COPY --from=build /server /server
+EXPOSE 8080
+ENTRYPOINT ["/server"]
+`
+
+	gofile, _ := fobj.FileLine(fptr)
+	codepath := filepath.Join(filepath.Dir(gofile), containerdir, "main.go")
+
+	// the file generated before is kept unless middlewares are changed
+	if file, err := os.ReadFile(codepath); err == nil && isAutogen(file, use, call) {
+		return serv, strings.TrimPrefix(path, scModule)
+	}
+
+	err := os.MkdirAll(filepath.Dir(codepath), 0766)
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(codepath, []byte(code), 0766)
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(filepath.Join(filepath.Dir(codepath), "Dockerfile"), []byte(docker), 0766)
+	if err != nil {
+		panic(err)
+	}
+
+	return serv, strings.TrimPrefix(path, scModule)
+}
@@ -0,0 +1,228 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package awsactions exposes selected read-only operations of AWS SDK as MCP
+// tools, so that agents introspect cloud resources safely. The input schema
+// of the tool is derived from the SDK input type, the output is the SDK
+// output encoded as JSON. Each tool declares IAM action and resources it
+// requires, the builder grants exactly those to the server (see
+// cloudmcp.Gateway.GrantToolActions).
+//
+//	func Infra() (*mcp.Server, error) {
+//		server := mcp.NewServer(&mcp.Implementation{Name: "infra", Version: "v1.0.0"}, nil)
+//		err := awsactions.AddTool(server,
+//			awsactions.Action[s3.ListObjectsV2Input, s3.ListObjectsV2Output]{
+//				Name:       "s3_list_objects",
+//				Permission: "s3:ListBucket",
+//				Resources:  []string{"arn:aws:s3:::my-bucket"},
+//				Call: func(ctx context.Context, cfg aws.Config, in *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+//					return s3.NewFromConfig(cfg).ListObjectsV2(ctx, in)
+//				},
+//			},
+//		)
+//		return server, err
+//	}
+package awsactions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MetaIAM is the key of tool metadata declaring IAM permissions required by
+// the tool, the value is Permissions.
+const MetaIAM = "cloudmcp/iam"
+
+// Permissions required by the tool
+type Permissions struct {
+	Actions   []string `json:"actions"`
+	Resources []string `json:"resources"`
+}
+
+// Action of AWS service, exposed as tool
+type Action[I, O any] struct {
+	// Name of the tool, default is derived from permission (e.g. s3_ListBucket)
+	Name string
+
+	// Description of the tool, default is the permission
+	Description string
+
+	// IAM action of the operation (e.g. ec2:DescribeInstances), it must be
+	// read-only (Describe, Get, List, ...).
+	Permission string
+
+	// IAM resources accessible by the tool, default "*"
+	Resources []string
+
+	// Call of the operation using AWS SDK
+	Call func(context.Context, aws.Config, *I) (*O, error)
+}
+
+// prefixes of read-only IAM actions
+var readOnly = []string{"Describe", "Get", "List", "Head", "Search", "Lookup", "BatchGet", "Query", "Scan", "Select"}
+
+// AddTool registers the action as read-only tool at the server
+func AddTool[I, O any](server *mcp.Server, action Action[I, O]) error {
+	service, verb, ok := strings.Cut(action.Permission, ":")
+	if !ok || service == "" || verb == "" {
+		return fmt.Errorf("invalid permission %q, service:Action is expected", action.Permission)
+	}
+	if !isReadOnly(verb) {
+		return fmt.Errorf("permission %s is not read-only", action.Permission)
+	}
+	if action.Call == nil {
+		return fmt.Errorf("call of %s is not defined", action.Permission)
+	}
+
+	if action.Name == "" {
+		action.Name = service + "_" + verb
+	}
+	if action.Description == "" {
+		action.Description = "AWS " + action.Permission
+	}
+	if len(action.Resources) == 0 {
+		action.Resources = []string{"*"}
+	}
+
+	schema, err := jsonschema.For[I](&jsonschema.ForOptions{IgnoreInvalidTypes: true})
+	if err != nil {
+		return fmt.Errorf("failed to derive schema of %s: %w", action.Permission, err)
+	}
+	if schema.Type == "" {
+		schema.Type = "object"
+	}
+
+	tool := &mcp.Tool{
+		Name:        action.Name,
+		Description: action.Description,
+		InputSchema: schema,
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true},
+		Meta: mcp.Meta{
+			MetaIAM: Permissions{
+				Actions:   []string{action.Permission},
+				Resources: action.Resources,
+			},
+		},
+	}
+
+	server.AddTool(tool, handler(action))
+	return nil
+}
+
+func handler[I, O any](action Action[I, O]) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		in := new(I)
+		if len(req.Params.Arguments) > 0 {
+			if err := json.Unmarshal(req.Params.Arguments, in); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+
+		cfg, err := config(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := action.Call(ctx, cfg, in)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}},
+				IsError: true,
+			}, nil
+		}
+
+		data, err := encode(out)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode output of %s: %w", action.Permission, err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil
+	}
+}
+
+// encodes output of SDK as JSON, empty fields and metadata are omitted
+func encode(out any) ([]byte, error) {
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+
+	if obj, ok := tree.(map[string]any); ok {
+		delete(obj, "ResultMetadata")
+	}
+
+	return json.Marshal(compact(tree))
+}
+
+func compact(node any) any {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, val := range v {
+			if x := compact(val); x == nil {
+				delete(v, key)
+			} else {
+				v[key] = x
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+		return v
+	case []any:
+		for i, val := range v {
+			v[i] = compact(val)
+		}
+		return v
+	case string:
+		if v == "" {
+			return nil
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isReadOnly(verb string) bool {
+	for _, prefix := range readOnly {
+		if strings.HasPrefix(verb, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	defaultConfig aws.Config
+	defaultOnce   sync.Once
+	defaultErr    error
+)
+
+// AWS config is shared by tools within the lambda
+func config(ctx context.Context) (aws.Config, error) {
+	defaultOnce.Do(func() {
+		defaultConfig, defaultErr = awsconfig.LoadDefaultConfig(ctx)
+	})
+
+	return defaultConfig, defaultErr
+}
@@ -0,0 +1,310 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package graphql publishes queries and mutations of GraphQL API as MCP
+// tools. The schema of the API is discovered by introspection, each root
+// field becomes the tool: arguments of the field are the input schema, the
+// selection set is derived from the result type. Queries are exposed unless
+// allowlisted otherwise, mutations only if allowlisted.
+//
+//	func Shop() (*mcp.Server, error) {
+//		server := mcp.NewServer(&mcp.Implementation{Name: "shop", Version: "v1.0.0"}, nil)
+//		err := graphql.AddTools(server,
+//			graphql.Config{
+//				Endpoint:  "https://api.example.com/graphql",
+//				Auth:      graphql.AuthBearer(os.Getenv("SHOP_TOKEN")),
+//				Queries:   []string{"product", "products"},
+//				Mutations: []string{"addToCart"},
+//			},
+//		)
+//		return server, err
+//	}
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// the largest response of upstream API passed to the model
+const maxResponseSize = 1 << 20
+
+// Auth authenticates requests to upstream API
+type Auth func(*http.Request) error
+
+// AuthBearer authenticates requests with bearer token
+func AuthBearer(token string) Auth {
+	return func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// AuthApiKey authenticates requests with API key passed in the header
+func AuthApiKey(header, key string) Auth {
+	return func(r *http.Request) error {
+		r.Header.Set(header, key)
+		return nil
+	}
+}
+
+// Config of generated tools
+type Config struct {
+	// Endpoint of GraphQL API
+	Endpoint string
+
+	// Authentication of requests to upstream API
+	Auth Auth
+
+	// HTTP client, default client has 30 seconds timeout
+	Client *http.Client
+
+	// Result of introspection query (see IntrospectionQuery), the schema is
+	// introspected from the endpoint if it is not defined. Embed the schema
+	// if the endpoint is not reachable while the server is built.
+	Schema []byte
+
+	// Allowlist of queries, default all queries
+	Queries []string
+
+	// Allowlist of mutations, default none
+	Mutations []string
+
+	// Depth of nested objects selected from results, default 2
+	Depth int
+}
+
+// AddTools registers one tool per allowed query and mutation
+func AddTools(server *mcp.Server, cfg Config) error {
+	if cfg.Endpoint == "" {
+		return errors.New("endpoint of graphql api is not defined")
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if cfg.Depth <= 0 {
+		cfg.Depth = 2
+	}
+
+	if cfg.Schema == nil {
+		data, err := execute(context.Background(), cfg, IntrospectionQuery, nil)
+		if err != nil {
+			return fmt.Errorf("failed to introspect %s: %w", cfg.Endpoint, err)
+		}
+		cfg.Schema = data
+	}
+
+	schema, err := ParseSchema(cfg.Schema)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range schema.roots(schema.QueryType) {
+		if len(cfg.Queries) > 0 && !slices.Contains(cfg.Queries, f.Name) {
+			continue
+		}
+		server.AddTool(schema.tool(f, false), handler(cfg, schema.document("query", f, cfg.Depth), f.Name))
+	}
+
+	for _, f := range schema.roots(schema.MutationType) {
+		if !slices.Contains(cfg.Mutations, f.Name) {
+			continue
+		}
+		server.AddTool(schema.tool(f, true), handler(cfg, schema.document("mutation", f, cfg.Depth), f.Name))
+	}
+
+	return nil
+}
+
+// tool definition of the root field
+func (s *Schema) tool(f Field, mutation bool) *mcp.Tool {
+	desc := f.Description
+	switch {
+	case desc != "":
+	case mutation:
+		desc = "GraphQL mutation " + f.Name
+	default:
+		desc = "GraphQL query " + f.Name
+	}
+
+	return &mcp.Tool{
+		Name:        f.Name,
+		Description: desc,
+		InputSchema: s.objectSchema(f.Args, 3),
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: !mutation},
+	}
+}
+
+// document of the operation, arguments of the field are variables
+func (s *Schema) document(op string, f Field, depth int) string {
+	var doc strings.Builder
+	doc.WriteString(op)
+
+	if len(f.Args) > 0 {
+		vars := make([]string, 0, len(f.Args))
+		args := make([]string, 0, len(f.Args))
+		for _, a := range f.Args {
+			vars = append(vars, "$"+a.Name+": "+a.Type.String())
+			args = append(args, a.Name+": $"+a.Name)
+		}
+		doc.WriteString("(" + strings.Join(vars, ", ") + ") { " + f.Name + "(" + strings.Join(args, ", ") + ")")
+	} else {
+		doc.WriteString(" { " + f.Name)
+	}
+
+	doc.WriteString(s.selection(f.Type, depth))
+	doc.WriteString(" }")
+
+	return doc.String()
+}
+
+// selection set of the result type, nested objects are selected up to depth,
+// fields with required arguments are skipped.
+func (s *Schema) selection(ref TypeRef, depth int) string {
+	t := s.Type(ref.Named())
+	if t == nil {
+		return ""
+	}
+
+	switch t.Kind {
+	case "OBJECT", "INTERFACE":
+		fields := []string{}
+		for _, f := range t.Fields {
+			if requiresArgs(f) {
+				continue
+			}
+
+			nested := s.Type(f.Type.Named())
+			if nested == nil || nested.Kind == "SCALAR" || nested.Kind == "ENUM" {
+				fields = append(fields, f.Name)
+				continue
+			}
+
+			if depth > 0 {
+				if sel := s.selection(f.Type, depth-1); sel != "" {
+					fields = append(fields, f.Name+sel)
+				}
+			}
+		}
+
+		if len(fields) == 0 {
+			fields = append(fields, "__typename")
+		}
+		return " { " + strings.Join(fields, " ") + " }"
+
+	case "UNION":
+		return " { __typename }"
+
+	default:
+		return ""
+	}
+}
+
+func requiresArgs(f Field) bool {
+	for _, a := range f.Args {
+		if a.Type.Kind == "NON_NULL" {
+			return true
+		}
+	}
+	return false
+}
+
+// executes the operation of the tool
+func handler(cfg Config, document, field string) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		vars := map[string]any{}
+		if len(req.Params.Arguments) > 0 {
+			if err := json.Unmarshal(req.Params.Arguments, &vars); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+
+		data, err := execute(ctx, cfg, document, vars)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}},
+				IsError: true,
+			}, nil
+		}
+
+		var result map[string]json.RawMessage
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("invalid response of graphql api: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(result[field])}},
+		}, nil
+	}
+}
+
+// executes the document, it returns data of the response
+func execute(ctx context.Context, cfg Config, document string, vars map[string]any) (json.RawMessage, error) {
+	body, err := json.Marshal(map[string]any{"query": document, "variables": vars})
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "application/json")
+
+	if cfg.Auth != nil {
+		if err := cfg.Auth(r); err != nil {
+			return nil, err
+		}
+	}
+
+	rsp, err := cfg.Client.Do(r)
+	if err != nil {
+		return nil, fmt.Errorf("graphql api failed: %w", err)
+	}
+	defer rsp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rsp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("graphql api failed: %w", err)
+	}
+
+	var reply struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return nil, fmt.Errorf("graphql api failed with status %d: %s", rsp.StatusCode, data)
+	}
+
+	if len(reply.Errors) > 0 {
+		msgs := make([]string, 0, len(reply.Errors))
+		for _, e := range reply.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return nil, errors.New(strings.Join(msgs, "; "))
+	}
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("graphql api failed with status %d", rsp.StatusCode)
+	}
+
+	return reply.Data, nil
+}
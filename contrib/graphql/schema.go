@@ -0,0 +1,228 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Schema of GraphQL API, as returned by introspection query
+type Schema struct {
+	QueryType    *TypeName `json:"queryType"`
+	MutationType *TypeName `json:"mutationType"`
+	Types        []Type    `json:"types"`
+
+	index map[string]*Type
+}
+
+// TypeName refers to the named type
+type TypeName struct {
+	Name string `json:"name"`
+}
+
+// Type of the schema
+type Type struct {
+	Kind        string       `json:"kind"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Fields      []Field      `json:"fields"`
+	InputFields []InputValue `json:"inputFields"`
+	EnumValues  []struct {
+		Name string `json:"name"`
+	} `json:"enumValues"`
+}
+
+// Field of object type
+type Field struct {
+	Name              string       `json:"name"`
+	Description       string       `json:"description"`
+	Args              []InputValue `json:"args"`
+	Type              TypeRef      `json:"type"`
+	IsDeprecated      bool         `json:"isDeprecated"`
+	DeprecationReason string       `json:"deprecationReason"`
+}
+
+// InputValue is argument of the field or field of input type
+type InputValue struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Type        TypeRef `json:"type"`
+}
+
+// TypeRef is reference to the type, wrapped into LIST or NON_NULL
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *TypeRef `json:"ofType"`
+}
+
+// String renders the reference as GraphQL type (e.g. [String!]!)
+func (t TypeRef) String() string {
+	switch {
+	case t.Kind == "NON_NULL" && t.OfType != nil:
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST" && t.OfType != nil:
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+// Named type of the reference, wrappers are removed
+func (t TypeRef) Named() string {
+	if t.OfType != nil {
+		return t.OfType.Named()
+	}
+	return t.Name
+}
+
+// IntrospectionQuery fetches the schema of GraphQL API
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    types {
+      kind name description
+      fields(includeDeprecated: false) {
+        name description isDeprecated deprecationReason
+        args { name description type { ...TypeRef } }
+        type { ...TypeRef }
+      }
+      inputFields { name description type { ...TypeRef } }
+      enumValues(includeDeprecated: false) { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } }
+}`
+
+// ParseSchema decodes result of introspection query, both the complete
+// response ({"data": {"__schema": ...}}) and the schema object are accepted.
+func ParseSchema(data []byte) (*Schema, error) {
+	var doc struct {
+		Data *struct {
+			Schema *Schema `json:"__schema"`
+		} `json:"data"`
+		Schema *Schema `json:"__schema"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid graphql schema: %w", err)
+	}
+
+	schema := doc.Schema
+	if doc.Data != nil && doc.Data.Schema != nil {
+		schema = doc.Data.Schema
+	}
+	if schema == nil || schema.QueryType == nil {
+		return nil, fmt.Errorf("invalid graphql schema: query type is not defined")
+	}
+
+	schema.index = make(map[string]*Type, len(schema.Types))
+	for i := range schema.Types {
+		schema.index[schema.Types[i].Name] = &schema.Types[i]
+	}
+
+	return schema, nil
+}
+
+// Type of the schema by name
+func (s *Schema) Type(name string) *Type {
+	return s.index[name]
+}
+
+// root fields of the operation type (query or mutation)
+func (s *Schema) roots(op *TypeName) []Field {
+	if op == nil {
+		return nil
+	}
+
+	t := s.Type(op.Name)
+	if t == nil {
+		return nil
+	}
+
+	return t.Fields
+}
+
+// JSON schema of the input type reference
+func (s *Schema) jsonSchema(ref TypeRef, depth int) map[string]any {
+	switch ref.Kind {
+	case "NON_NULL":
+		if ref.OfType == nil {
+			return map[string]any{}
+		}
+		return s.jsonSchema(*ref.OfType, depth)
+	case "LIST":
+		if ref.OfType == nil {
+			return map[string]any{"type": "array"}
+		}
+		return map[string]any{"type": "array", "items": s.jsonSchema(*ref.OfType, depth)}
+	}
+
+	switch ref.Name {
+	case "Int":
+		return map[string]any{"type": "integer"}
+	case "Float":
+		return map[string]any{"type": "number"}
+	case "Boolean":
+		return map[string]any{"type": "boolean"}
+	case "String", "ID":
+		return map[string]any{"type": "string"}
+	}
+
+	t := s.Type(ref.Name)
+	if t == nil {
+		return map[string]any{}
+	}
+
+	switch t.Kind {
+	case "ENUM":
+		values := make([]string, 0, len(t.EnumValues))
+		for _, v := range t.EnumValues {
+			values = append(values, v.Name)
+		}
+		return map[string]any{"type": "string", "enum": values}
+	case "INPUT_OBJECT":
+		if depth <= 0 {
+			return map[string]any{"type": "object"}
+		}
+		return s.objectSchema(t.InputFields, depth-1)
+	default:
+		// custom scalars
+		return map[string]any{}
+	}
+}
+
+// JSON schema of object with given fields, NON_NULL fields are required
+func (s *Schema) objectSchema(fields []InputValue, depth int) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for _, f := range fields {
+		schema := s.jsonSchema(f.Type, depth)
+		if f.Description != "" {
+			schema["description"] = f.Description
+		}
+		properties[f.Name] = schema
+		if f.Type.Kind == "NON_NULL" {
+			required = append(required, f.Name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
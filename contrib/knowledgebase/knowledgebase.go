@@ -0,0 +1,198 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package knowledgebase generates MCP tool "retrieve", semantic search over
+// documents of Amazon Bedrock Knowledge Base. The knowledge base is attached
+// to the server by builder (see cloudmcp.Gateway.WithKnowledgeBase), which
+// grants the access and injects its id into the environment.
+//
+//	func Docs() (*mcp.Server, error) {
+//		server := mcp.NewServer(&mcp.Implementation{Name: "docs", Version: "v1.0.0"}, nil)
+//		knowledgebase.AddTool(server, knowledgebase.Config{})
+//		return server, nil
+//	}
+package knowledgebase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Environment variable with id of the knowledge base, injected by builder
+const EnvKnowledgeBase = envvar.KnowledgeBase
+
+// Retriever interface required by the tool
+type Retriever interface {
+	Retrieve(context.Context, *bedrockagentruntime.RetrieveInput, ...func(*bedrockagentruntime.Options)) (*bedrockagentruntime.RetrieveOutput, error)
+}
+
+// Config of the tool, zero values are defaults
+type Config struct {
+	// Name of the tool, default "retrieve"
+	Name string
+
+	// Description of the tool, it guides the model when to use the tool
+	Description string
+
+	// Id of the knowledge base, default is read from environment
+	KnowledgeBase string
+
+	// Default number of results, default 5
+	Limit int
+
+	// Client of Bedrock Agent Runtime, default is created from environment
+	Retriever Retriever
+}
+
+// Input of the tool
+type Input struct {
+	Query string `json:"query" jsonschema:"natural language query to search documents for"`
+	Limit int    `json:"limit,omitempty" jsonschema:"maximum number of passages to return"`
+}
+
+// Output of the tool
+type Output struct {
+	Results []Result `json:"results" jsonschema:"passages of documents relevant to the query, most relevant first"`
+}
+
+// Result is the passage of the document
+type Result struct {
+	Text   string  `json:"text" jsonschema:"text of the passage"`
+	Source string  `json:"source,omitempty" jsonschema:"location of the document (e.g. s3 or web url)"`
+	Score  float64 `json:"score,omitempty" jsonschema:"relevance score of the passage"`
+}
+
+// AddTool registers retrieve tool at the server
+func AddTool(server *mcp.Server, cfg Config) {
+	if cfg.Name == "" {
+		cfg.Name = "retrieve"
+	}
+	if cfg.Description == "" {
+		cfg.Description = "semantic search over documents of knowledge base, returns passages relevant to the query"
+	}
+	if cfg.Limit <= 0 {
+		cfg.Limit = 5
+	}
+
+	tool := &mcp.Tool{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true, OpenWorldHint: aws.Bool(false)},
+	}
+
+	mcp.AddTool(server, tool, New(cfg))
+}
+
+// New creates handler of the tool. The client and the knowledge base are
+// resolved on the first call, so that the server is defined without AWS
+// environment (e.g. synthesis of the deployment).
+func New(cfg Config) mcp.ToolHandlerFor[Input, Output] {
+	var (
+		once sync.Once
+		err  error
+	)
+
+	resolve := func(ctx context.Context) error {
+		once.Do(func() {
+			if cfg.KnowledgeBase == "" {
+				cfg.KnowledgeBase = os.Getenv(EnvKnowledgeBase)
+			}
+			if cfg.KnowledgeBase == "" {
+				err = errors.New("knowledge base is not configured")
+				return
+			}
+
+			if cfg.Retriever == nil {
+				conf, e := awsconfig.LoadDefaultConfig(ctx)
+				if e != nil {
+					err = e
+					return
+				}
+				cfg.Retriever = bedrockagentruntime.NewFromConfig(conf)
+			}
+		})
+
+		return err
+	}
+
+	return func(ctx context.Context, req *mcp.CallToolRequest, input Input) (*mcp.CallToolResult, Output, error) {
+		if input.Query == "" {
+			return nil, Output{}, errors.New("query is required")
+		}
+
+		if err := resolve(ctx); err != nil {
+			return nil, Output{}, err
+		}
+
+		limit := cfg.Limit
+		if input.Limit > 0 && input.Limit < 100 {
+			limit = input.Limit
+		}
+
+		out, err := cfg.Retriever.Retrieve(ctx,
+			&bedrockagentruntime.RetrieveInput{
+				KnowledgeBaseId: aws.String(cfg.KnowledgeBase),
+				RetrievalQuery:  &types.KnowledgeBaseQuery{Text: aws.String(input.Query)},
+				RetrievalConfiguration: &types.KnowledgeBaseRetrievalConfiguration{
+					VectorSearchConfiguration: &types.KnowledgeBaseVectorSearchConfiguration{
+						NumberOfResults: aws.Int32(int32(limit)),
+					},
+				},
+			},
+		)
+		if err != nil {
+			return nil, Output{}, fmt.Errorf("failed to retrieve: %w", err)
+		}
+
+		results := make([]Result, 0, len(out.RetrievalResults))
+		for _, r := range out.RetrievalResults {
+			if r.Content == nil || r.Content.Text == nil {
+				continue
+			}
+
+			results = append(results,
+				Result{
+					Text:   *r.Content.Text,
+					Source: source(r.Location),
+					Score:  aws.ToFloat64(r.Score),
+				},
+			)
+		}
+
+		return nil, Output{Results: results}, nil
+	}
+}
+
+func source(loc *types.RetrievalResultLocation) string {
+	switch {
+	case loc == nil:
+		return ""
+	case loc.S3Location != nil:
+		return aws.ToString(loc.S3Location.Uri)
+	case loc.WebLocation != nil:
+		return aws.ToString(loc.WebLocation.Url)
+	case loc.ConfluenceLocation != nil:
+		return aws.ToString(loc.ConfluenceLocation.Url)
+	case loc.SharePointLocation != nil:
+		return aws.ToString(loc.SharePointLocation.Url)
+	case loc.SalesforceLocation != nil:
+		return aws.ToString(loc.SalesforceLocation.Url)
+	default:
+		return ""
+	}
+}
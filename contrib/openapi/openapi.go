@@ -0,0 +1,282 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package openapi turns existing REST services into MCP servers. It reads
+// OpenAPI 3 document (JSON or YAML) and registers one MCP tool per operation,
+// the input schema of the tool is derived from parameters and request body of
+// the operation, the call is proxied to the upstream API.
+//
+//	//go:embed petstore.yaml
+//	var spec []byte
+//
+//	func PetStore() (*mcp.Server, error) {
+//		server := mcp.NewServer(&mcp.Implementation{Name: "petstore", Version: "v1.0.0"}, nil)
+//		err := openapi.AddTools(server, spec,
+//			openapi.Config{
+//				Endpoint: "https://petstore.example.com/v1",
+//				Auth:     openapi.AuthBearer(os.Getenv("PETSTORE_TOKEN")),
+//			},
+//		)
+//		return server, err
+//	}
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is OpenAPI 3 document with resolved references
+type Document struct {
+	OpenAPI string `json:"openapi"`
+	Info    struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+// Operation of the API
+type Operation struct {
+	Method      string       `json:"-"`
+	Path        string       `json:"-"`
+	OperationID string       `json:"operationId"`
+	Summary     string       `json:"summary"`
+	Description string       `json:"description"`
+	Deprecated  bool         `json:"deprecated"`
+	Parameters  []Parameter  `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+// Parameter of the operation, located in path, query, header or cookie
+type Parameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description"`
+	Required    bool           `json:"required"`
+	Schema      map[string]any `json:"schema"`
+}
+
+// RequestBody of the operation
+type RequestBody struct {
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+	Content     map[string]struct {
+		Schema map[string]any `json:"schema"`
+	} `json:"content"`
+}
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Parse decodes OpenAPI 3 document, local references ($ref: "#/...") are
+// inlined. Recursive schemas are cut at the point of recursion.
+func Parse(spec []byte) (*Document, error) {
+	var tree any
+	if err := yaml.Unmarshal(spec, &tree); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+
+	tree = normalize(tree)
+	tree, err := resolve(tree, tree, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q", doc.OpenAPI)
+	}
+
+	return &doc, nil
+}
+
+// Operations of the document, ordered by path and method
+func (doc *Document) Operations() ([]Operation, error) {
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	seq := []Operation{}
+	for _, path := range paths {
+		item := doc.Paths[path]
+
+		var shared []Parameter
+		if raw, has := item["parameters"]; has {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("invalid parameters of %s: %w", path, err)
+			}
+		}
+
+		for _, method := range methods {
+			raw, has := item[method]
+			if !has {
+				continue
+			}
+
+			op := Operation{Method: strings.ToUpper(method), Path: path}
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("invalid operation %s %s: %w", op.Method, path, err)
+			}
+			op.Parameters = mergeParameters(shared, op.Parameters)
+
+			seq = append(seq, op)
+		}
+	}
+
+	return seq, nil
+}
+
+// operation parameters override path item parameters with same name and location
+func mergeParameters(shared, own []Parameter) []Parameter {
+	seq := append([]Parameter{}, own...)
+	for _, p := range shared {
+		overridden := false
+		for _, o := range own {
+			if o.Name == p.Name && o.In == p.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			seq = append(seq, p)
+		}
+	}
+	return seq
+}
+
+var invalidToolName = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// ToolName of the operation, it is operationId or method and path if the
+// id is not defined.
+func (op Operation) ToolName() string {
+	name := invalidToolName.ReplaceAllString(op.OperationID, "_")
+	if name == "" {
+		name = invalidToolName.ReplaceAllString(strings.ToLower(op.Method)+" "+strings.Trim(op.Path, "/"), "_")
+	}
+
+	name = strings.Trim(name, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	return name
+}
+
+// yaml mappings with non-string keys (e.g. status codes) are converted to objects
+func normalize(node any) any {
+	switch v := node.(type) {
+	case map[any]any:
+		obj := make(map[string]any, len(v))
+		for key, val := range v {
+			obj[fmt.Sprint(key)] = normalize(val)
+		}
+		return obj
+	case map[string]any:
+		for key, val := range v {
+			v[key] = normalize(val)
+		}
+		return v
+	case []any:
+		for i, val := range v {
+			v[i] = normalize(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// inlines local references of the document
+func resolve(root, node any, stack []string) (any, error) {
+	switch v := node.(type) {
+	case map[string]any:
+		if ref, has := v["$ref"].(string); has {
+			for _, seen := range stack {
+				if seen == ref {
+					// recursive schema
+					return map[string]any{}, nil
+				}
+			}
+
+			target, err := pointer(root, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			return resolve(root, target, append(stack, ref))
+		}
+
+		out := make(map[string]any, len(v))
+		for key, val := range v {
+			x, err := resolve(root, val, stack)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = x
+		}
+		return out, nil
+
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			x, err := resolve(root, val, stack)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = x
+		}
+		return out, nil
+
+	default:
+		return v, nil
+	}
+}
+
+// JSON pointer of local reference (e.g. #/components/schemas/Pet)
+func pointer(root any, ref string) (any, error) {
+	path, local := strings.CutPrefix(ref, "#/")
+	if !local {
+		return nil, fmt.Errorf("unsupported reference %s, only local references are supported", ref)
+	}
+
+	node := root
+	for _, seg := range strings.Split(path, "/") {
+		seg = strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", ref)
+		}
+
+		node, ok = obj[seg]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", ref)
+		}
+	}
+
+	return node, nil
+}
@@ -0,0 +1,358 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// the largest response of upstream API passed to the model
+const maxResponseSize = 1 << 20
+
+// Auth authenticates requests to upstream API
+type Auth func(*http.Request) error
+
+// AuthBearer authenticates requests with bearer token
+func AuthBearer(token string) Auth {
+	return func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// AuthApiKey authenticates requests with API key passed in the header
+func AuthApiKey(header, key string) Auth {
+	return func(r *http.Request) error {
+		r.Header.Set(header, key)
+		return nil
+	}
+}
+
+// AuthBasic authenticates requests with username and password
+func AuthBasic(user, password string) Auth {
+	return func(r *http.Request) error {
+		r.SetBasicAuth(user, password)
+		return nil
+	}
+}
+
+// Config of generated tools
+type Config struct {
+	// Base URL of upstream API, default is the first server of the document
+	Endpoint string
+
+	// Authentication of requests to upstream API
+	Auth Auth
+
+	// HTTP client, default client has 30 seconds timeout
+	Client *http.Client
+
+	// Allowlist of tools (names or operation ids), default all operations
+	Operations []string
+
+	// Only GET and HEAD operations are exposed as tools
+	ReadOnly bool
+}
+
+// AddTools registers one tool per operation of OpenAPI 3 document
+func AddTools(server *mcp.Server, spec []byte, cfg Config) error {
+	doc, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Endpoint == "" {
+		if len(doc.Servers) == 0 {
+			return errors.New("endpoint of upstream api is not defined")
+		}
+		cfg.Endpoint = doc.Servers[0].URL
+	}
+	if u, err := url.Parse(cfg.Endpoint); err != nil || !u.IsAbs() {
+		return fmt.Errorf("invalid endpoint of upstream api %q", cfg.Endpoint)
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	ops, err := doc.Operations()
+	if err != nil {
+		return err
+	}
+
+	for _, op := range ops {
+		if op.Deprecated {
+			continue
+		}
+		if cfg.ReadOnly && op.Method != http.MethodGet && op.Method != http.MethodHead {
+			continue
+		}
+		if len(cfg.Operations) > 0 && !slices.Contains(cfg.Operations, op.ToolName()) && !slices.Contains(cfg.Operations, op.OperationID) {
+			continue
+		}
+
+		server.AddTool(op.Tool(), handler(cfg, op))
+	}
+
+	return nil
+}
+
+// Tool definition of the operation
+func (op Operation) Tool() *mcp.Tool {
+	desc := strings.TrimSpace(op.Summary + "\n\n" + op.Description)
+	if desc == "" {
+		desc = op.Method + " " + op.Path
+	}
+
+	annotations := &mcp.ToolAnnotations{}
+	switch op.Method {
+	case http.MethodGet, http.MethodHead:
+		annotations.ReadOnlyHint = true
+	case http.MethodPut:
+		annotations.IdempotentHint = true
+	case http.MethodDelete:
+		annotations.IdempotentHint = true
+		destructive := true
+		annotations.DestructiveHint = &destructive
+	}
+
+	return &mcp.Tool{
+		Name:        op.ToolName(),
+		Description: desc,
+		InputSchema: op.InputSchema(),
+		Annotations: annotations,
+	}
+}
+
+// InputSchema of the tool, parameters are properties of the object, request
+// body is the property "body".
+func (op Operation) InputSchema() map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for _, p := range op.Parameters {
+		schema := map[string]any{"type": "string"}
+		if p.Schema != nil {
+			schema = p.Schema
+		}
+		if p.Description != "" {
+			schema = withDescription(schema, p.Description)
+		}
+
+		properties[p.Name] = schema
+		if p.Required || p.In == "path" {
+			required = append(required, p.Name)
+		}
+	}
+
+	if _, schema, has := op.body(); has {
+		if op.RequestBody.Description != "" {
+			schema = withDescription(schema, op.RequestBody.Description)
+		}
+
+		properties["body"] = schema
+		if op.RequestBody.Required {
+			required = append(required, "body")
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+func withDescription(schema map[string]any, desc string) map[string]any {
+	out := make(map[string]any, len(schema)+1)
+	for k, v := range schema {
+		out[k] = v
+	}
+	out["description"] = desc
+	return out
+}
+
+// content type and schema of request body, JSON is preferred
+func (op Operation) body() (string, map[string]any, bool) {
+	if op.RequestBody == nil || len(op.RequestBody.Content) == 0 {
+		return "", nil, false
+	}
+
+	types := make([]string, 0, len(op.RequestBody.Content))
+	for ct := range op.RequestBody.Content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+
+	for _, ct := range types {
+		if isJSON(ct) {
+			schema := op.RequestBody.Content[ct].Schema
+			if schema == nil {
+				schema = map[string]any{}
+			}
+			return ct, schema, true
+		}
+	}
+
+	return types[0], map[string]any{"type": "string"}, true
+}
+
+func isJSON(ct string) bool {
+	return ct == "application/json" || strings.HasSuffix(ct, "+json")
+}
+
+// proxies the call of the tool to upstream API
+func handler(cfg Config, op Operation) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := map[string]any{}
+		if len(req.Params.Arguments) > 0 {
+			if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+
+		r, err := op.request(ctx, cfg.Endpoint, args)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Auth != nil {
+			if err := cfg.Auth(r); err != nil {
+				return nil, err
+			}
+		}
+
+		rsp, err := cfg.Client.Do(r)
+		if err != nil {
+			return nil, fmt.Errorf("upstream api failed: %w", err)
+		}
+		defer rsp.Body.Close()
+
+		data, err := io.ReadAll(io.LimitReader(rsp.Body, maxResponseSize))
+		if err != nil {
+			return nil, fmt.Errorf("upstream api failed: %w", err)
+		}
+
+		text := string(data)
+		if rsp.StatusCode >= 300 {
+			text = rsp.Status + "\n" + text
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: text}},
+			IsError: rsp.StatusCode >= 300,
+		}, nil
+	}
+}
+
+// builds request to upstream API from arguments of the tool
+func (op Operation) request(ctx context.Context, endpoint string, args map[string]any) (*http.Request, error) {
+	path := op.Path
+	query := url.Values{}
+	header := http.Header{}
+	cookies := []string{}
+
+	for _, p := range op.Parameters {
+		val, has := args[p.Name]
+		if !has || val == nil {
+			if p.Required || p.In == "path" {
+				return nil, fmt.Errorf("parameter %s is required", p.Name)
+			}
+			continue
+		}
+
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(format(val)))
+		case "query":
+			if seq, ok := val.([]any); ok {
+				for _, x := range seq {
+					query.Add(p.Name, format(x))
+				}
+			} else {
+				query.Set(p.Name, format(val))
+			}
+		case "header":
+			header.Set(p.Name, format(val))
+		case "cookie":
+			cookies = append(cookies, p.Name+"="+url.QueryEscape(format(val)))
+		}
+	}
+
+	uri := endpoint + path
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if ct, _, has := op.body(); has {
+		if val, has := args["body"]; has && val != nil {
+			if isJSON(ct) {
+				data, err := json.Marshal(val)
+				if err != nil {
+					return nil, err
+				}
+				body = bytes.NewReader(data)
+			} else {
+				body = strings.NewReader(format(val))
+			}
+			header.Set("Content-Type", ct)
+		} else if op.RequestBody.Required {
+			return nil, errors.New("body is required")
+		}
+	}
+
+	r, err := http.NewRequestWithContext(ctx, op.Method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, vals := range header {
+		r.Header[key] = vals
+	}
+	if len(cookies) > 0 {
+		r.Header.Set("Cookie", strings.Join(cookies, "; "))
+	}
+	if r.Header.Get("Accept") == "" {
+		r.Header.Set("Accept", "application/json")
+	}
+
+	return r, nil
+}
+
+// formats value of the parameter
+func format(val any) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
@@ -0,0 +1,265 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/cxapi"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+)
+
+// CostReport defines the traffic assumed by the cost estimation. Zero values
+// default to 1M tool calls per month, 100ms per invocation and 1KB of logs
+// per invocation.
+type CostReport struct {
+	Requests int
+	Duration time.Duration
+	LogSize  int
+}
+
+// Configures estimation of monthly cost of the stack at synth time. Resources
+// of the synthesized template (Lambda memory and architecture, API Gateway
+// type, DynamoDB tables, logs retention, events and analytics) are priced
+// using on-demand list prices of us-east-1 for the assumed traffic. The
+// breakdown is written into the cloud assembly as `{stack}.cost.json`.
+// The estimate excludes free tier and the cost of tools (e.g. Bedrock).
+func (c *Gateway) CostReport(traffic CostReport) *Gateway {
+	if traffic.Requests == 0 {
+		traffic.Requests = 1_000_000
+	}
+	if traffic.Duration == 0 {
+		traffic.Duration = 100 * time.Millisecond
+	}
+	if traffic.LogSize == 0 {
+		traffic.LogSize = 1024
+	}
+
+	c.cost = &traffic
+	return c
+}
+
+// On-demand list prices (USD) of us-east-1
+const (
+	priceLambdaRequest    = 0.20 / 1e6
+	priceLambdaGBs        = 0.0000166667
+	priceLambdaGBsArm     = 0.0000133334
+	priceRestApi          = 3.50 / 1e6
+	priceHttpApi          = 1.00 / 1e6
+	priceWebSocketMessage = 1.00 / 1e6
+	priceDynamoDBWrite    = 0.625 / 1e6
+	priceDynamoDBRead     = 0.125 / 1e6
+	priceLogsIngestion    = 0.50
+	priceLogsStorage      = 0.03
+	priceEventBridge      = 1.00 / 1e6
+	priceFirehose         = 0.029
+	priceFirehoseParquet  = 0.018
+)
+
+const gb = 1024 * 1024 * 1024
+
+// CostEstimate is monthly cost breakdown of the stack
+type CostEstimate struct {
+	Stack    string     `json:"stack"`
+	Currency string     `json:"currency"`
+	Requests int        `json:"requests"`
+	Duration float64    `json:"durationMs"`
+	LogSize  int        `json:"logSize"`
+	Items    []CostItem `json:"items"`
+	Total    float64    `json:"total"`
+}
+
+// CostItem is monthly cost of the resource
+type CostItem struct {
+	Service  string  `json:"service"`
+	Resource string  `json:"resource"`
+	Monthly  float64 `json:"monthly"`
+	Note     string  `json:"note,omitempty"`
+}
+
+func (e *CostEstimate) add(service, resource string, monthly float64, note string) {
+	monthly = math.Round(monthly*100) / 100
+	e.Items = append(e.Items, CostItem{Service: service, Resource: resource, Monthly: monthly, Note: note})
+	e.Total = math.Round((e.Total+monthly)*100) / 100
+}
+
+// subset of CloudFormation template required by the estimation
+type cfnTemplate struct {
+	Resources map[string]cfnResource `json:"Resources"`
+}
+
+type cfnResource struct {
+	Type       string        `json:"Type"`
+	Properties cfnProperties `json:"Properties"`
+	raw        string
+}
+
+// properties of resources used by the estimation, across resource types
+type cfnProperties struct {
+	MemorySize    any      `json:"MemorySize"`
+	Architectures []string `json:"Architectures"`
+	LoggingConfig struct {
+		LogGroup any `json:"LogGroup"`
+	} `json:"LoggingConfig"`
+	Environment struct {
+		Variables map[string]any `json:"Variables"`
+	} `json:"Environment"`
+	RetentionInDays any    `json:"RetentionInDays"`
+	BillingMode     string `json:"BillingMode"`
+	ProtocolType    string `json:"ProtocolType"`
+}
+
+// Estimates the cost of synthesized stack, the report is written next to
+// the template of the stack.
+func (c *Gateway) reportCost(assembly cxapi.CloudAssembly) {
+	artifact := assembly.GetStackArtifact(c.stack.ArtifactId())
+
+	data, err := json.Marshal(artifact.Template())
+	if err != nil {
+		panic(err)
+	}
+
+	var template cfnTemplate
+	if err := json.Unmarshal(data, &template); err != nil {
+		panic(err)
+	}
+
+	var raw struct {
+		Resources map[string]json.RawMessage `json:"Resources"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		panic(err)
+	}
+	for id, r := range template.Resources {
+		r.raw = string(raw.Resources[id])
+		template.Resources[id] = r
+	}
+
+	report := estimateCost(template, *c.cost)
+	report.Stack = *c.stack.StackName()
+
+	file := filepath.Join(*assembly.Directory(), *c.stack.ArtifactId()+".cost.json")
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(file, out, 0644); err != nil {
+		panic(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: estimated cost %.2f USD/month for %d requests (%s)\n",
+		report.Stack, report.Total, report.Requests, file)
+}
+
+func estimateCost(template cfnTemplate, traffic CostReport) CostEstimate {
+	report := CostEstimate{
+		Currency: "USD",
+		Requests: traffic.Requests,
+		Duration: float64(traffic.Duration.Milliseconds()),
+		LogSize:  traffic.LogSize,
+	}
+	requests := float64(traffic.Requests)
+
+	ids := make([]string, 0, len(template.Resources))
+	apis := strings.Builder{}
+	for id, r := range template.Resources {
+		ids = append(ids, id)
+		if strings.HasPrefix(r.Type, "AWS::ApiGateway") {
+			apis.WriteString(r.raw)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		r := template.Resources[id]
+		switch r.Type {
+		case "AWS::ApiGateway::RestApi":
+			report.add("API Gateway", id, requests*priceRestApi, "REST API")
+		case "AWS::ApiGatewayV2::Api":
+			if r.Properties.ProtocolType == "WEBSOCKET" {
+				report.add("API Gateway", id, requests*priceWebSocketMessage, "WebSocket API, excludes connection minutes")
+			} else {
+				report.add("API Gateway", id, requests*priceHttpApi, "HTTP API")
+			}
+		case "AWS::DynamoDB::Table":
+			if r.Properties.BillingMode != "PAY_PER_REQUEST" {
+				report.add("DynamoDB", id, 0, "provisioned capacity is not estimated")
+				continue
+			}
+			report.add("DynamoDB", id, requests*(priceDynamoDBRead+priceDynamoDBWrite), "on-demand, one read and one write per request")
+		}
+	}
+
+	// only functions invoked by API Gateway (servers and authorizers) are
+	// billed per request, other functions (e.g. deployment hooks) are not
+	for _, id := range ids {
+		r := template.Resources[id]
+		if r.Type != "AWS::Lambda::Function" || !strings.Contains(apis.String(), `"`+id+`"`) {
+			continue
+		}
+
+		memory := number(r.Properties.MemorySize, 128)
+		price, arch := priceLambdaGBs, "x86_64"
+		if len(r.Properties.Architectures) > 0 && r.Properties.Architectures[0] == "arm64" {
+			price, arch = priceLambdaGBsArm, "arm64"
+		}
+		seconds := requests * traffic.Duration.Seconds()
+		report.add("Lambda", id, requests*priceLambdaRequest+seconds*memory/1024*price,
+			fmt.Sprintf("%.0f MB, %s", memory, arch))
+
+		ingested := requests * float64(traffic.LogSize) / gb
+		retention := logRetention(template, r.Properties.LoggingConfig.LogGroup)
+		report.add("CloudWatch Logs", id, ingested*priceLogsIngestion+ingested*retention/30*priceLogsStorage,
+			fmt.Sprintf("retention %.0f days", retention))
+
+		vars := r.Properties.Environment.Variables
+		if _, has := vars[envvar.Events]; has {
+			report.add("EventBridge", id, requests*priceEventBridge, "one event per tool call")
+		}
+		if _, has := vars[envvar.Analytics]; has {
+			// Firehose rounds records up to 5KB
+			ingested := requests * 5 * 1024 / gb
+			report.add("Firehose", id, ingested*(priceFirehose+priceFirehoseParquet), "one record per tool call, Parquet conversion")
+		}
+	}
+
+	return report
+}
+
+// retention of log group referenced by the function, the default log group of
+// lambda never expires, it is estimated as a year
+func logRetention(template cfnTemplate, ref any) float64 {
+	if r, ok := ref.(map[string]any); ok {
+		if id, ok := r["Ref"].(string); ok {
+			return number(template.Resources[id].Properties.RetentionInDays, 365)
+		}
+	}
+
+	return 365
+}
+
+func number(v any, def float64) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		var f float64
+		if _, err := fmt.Sscan(n, &f); err == nil {
+			return f
+		}
+	}
+	return def
+}
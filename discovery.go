@@ -0,0 +1,117 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apigw2 "github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2"
+	integrations "github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2integrations"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/cloudmcp/internal/gateway"
+)
+
+// Configures discovery endpoint `GET /.well-known/mcp.json` at the host of
+// the gateway, clients and registries discover connection details of the
+// server from it: name, version, endpoint (and endpoints of versions),
+// accepted authentication modes and capabilities. The document is generated
+// at build time from the configuration of the builder and the snapshot of
+// the factory's server, it is served by the function of the server without
+// invoking the server. The endpoint is public.
+func (c *Gateway) Discovery() *Gateway {
+	c.discovery = true
+	return c
+}
+
+// document of the discovery endpoint, it requires access modes configured
+func (c *Gateway) discoveryDocument() string {
+	if c.private != nil || c.restapi != nil {
+		panic("discovery is not supported by private and imported api")
+	}
+
+	server, err := c.newServer()
+	if err != nil {
+		panic(fmt.Errorf("failed to create server %s: %w", servername(c.f), err))
+	}
+
+	snapshot, err := gateway.Snapshot(context.Background(), server)
+	if err != nil {
+		panic(fmt.Errorf("failed to snapshot tools of %s: %w", servername(c.f), err))
+	}
+
+	uri := "/" + strings.ToLower(servername(c.f))
+	doc := gateway.Discovery{
+		Name:            c.name(),
+		ProtocolVersion: snapshot.Initialize.ProtocolVersion,
+		Transport:       "streamable-http",
+		Endpoint:        uri,
+		Auth:            []gateway.DiscoveryAuth{},
+		Capabilities:    snapshot.Initialize.Capabilities,
+	}
+
+	if info := snapshot.Initialize.ServerInfo; info != nil {
+		doc.Title = info.Title
+		doc.Version = info.Version
+	}
+
+	for _, v := range c.versions {
+		if doc.Versions == nil {
+			doc.Versions = map[string]string{}
+		}
+		doc.Versions[v.name] = "/" + v.name + uri
+	}
+
+	switch {
+	case c.authjwt != nil:
+		auth := gateway.DiscoveryAuth{Type: "jwt", Scheme: "Bearer"}
+		if c.jwt != nil {
+			auth.Issuer, auth.Audience = c.jwt.issuer, c.jwt.audience
+		}
+		doc.Auth = append(doc.Auth, auth)
+	case c.authjwts != nil:
+		for _, iss := range c.authjwts.issuers {
+			doc.Auth = append(doc.Auth,
+				gateway.DiscoveryAuth{Type: "jwt", Scheme: "Bearer", Issuer: iss.Issuer, Audience: iss.Audience},
+			)
+		}
+	case c.authint != nil:
+		doc.Auth = append(doc.Auth, gateway.DiscoveryAuth{Type: "opaque", Scheme: "Bearer"})
+	case c.authkey != nil, c.authhsh != nil:
+		doc.Auth = append(doc.Auth, gateway.DiscoveryAuth{Type: "apikey", Scheme: "Basic"})
+	case c.authiam != nil:
+		doc.Auth = append(doc.Auth, gateway.DiscoveryAuth{Type: "iam", Scheme: "AWS4-HMAC-SHA256", Service: "execute-api"})
+	case c.authpub != nil:
+		doc.Auth = append(doc.Auth, gateway.DiscoveryAuth{Type: "public"})
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(data)
+}
+
+// routes discovery endpoint to the function of the server
+func (c *Gateway) buildDiscovery(server *Server) {
+	c.gateway.RestAPI.AddRoutes(
+		&apigw2.AddRoutesOptions{
+			Path:    jsii.String("/.well-known/mcp.json"),
+			Methods: &[]apigw2.HttpMethod{apigw2.HttpMethod_GET},
+			Integration: integrations.NewHttpLambdaIntegration(jsii.String("Discovery"), server.Function,
+				&integrations.HttpLambdaIntegrationProps{
+					PayloadFormatVersion: apigw2.PayloadFormatVersion_VERSION_1_0(),
+				},
+			),
+		},
+	)
+}
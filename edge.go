@@ -0,0 +1,219 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudfront"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudfront/experimental"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudfrontorigins"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Configures gateway with JWT access, the tokens are pre-checked at the edge.
+// The CloudFront distribution is deployed in front of API Gateway, the
+// Lambda@Edge function validates signature, expiry, issuer and audience of
+// the token before the request leaves the edge location. Invalid requests
+// are rejected without invocation of API Gateway and Lambda, the gateway
+// still validates tokens using JWT authorizer (see AccessJWT).
+//
+// The JSON Web Key Set of the issuer is replicated to each edge location,
+// it is fetched using OpenID discovery and cached for an hour. The edge
+// passes requests through while the issuer is not reachable. CloudFront
+// Functions are not used, they cannot verify RSA or ECDSA signatures.
+//
+// Lambda@Edge is deployed to us-east-1 by a companion stack, the account
+// and region of the stack must be defined.
+func (c *Gateway) AccessJWTAtEdge(issuer string, audience ...string) *Gateway {
+	c.AccessJWT(issuer, audience...)
+	c.edge = true
+	return c
+}
+
+// CloudFront distribution with Lambda@Edge validating tokens of the endpoint
+func (c *Gateway) buildEdge(uri string) {
+	audience, err := json.Marshal(append([]string{}, c.jwt.audience...))
+	if err != nil {
+		panic(err)
+	}
+
+	code := fmt.Sprintf(edgeJWTCode, jsonString(c.jwt.issuer), audience, jsonString(uri))
+
+	f := experimental.NewEdgeFunction(c.stack, jsii.String("EdgeAuthorizer"),
+		&experimental.EdgeFunctionProps{
+			Runtime:    awslambda.Runtime_NODEJS_20_X(),
+			Handler:    jsii.String("index.handler"),
+			Code:       awslambda.Code_FromInline(jsii.String(code)),
+			MemorySize: jsii.Number(128),
+			Timeout:    awscdk.Duration_Seconds(jsii.Number(5)),
+		},
+	)
+
+	// API endpoint is https://{id}.execute-api.{region}.amazonaws.com
+	domain := awscdk.Fn_Select(jsii.Number(2),
+		awscdk.Fn_Split(jsii.String("/"), c.gateway.RestAPI.ApiEndpoint(), nil),
+	)
+
+	cdn := awscloudfront.NewDistribution(c.stack, jsii.String("Edge"),
+		&awscloudfront.DistributionProps{
+			Comment: jsii.String(c.name()),
+			DefaultBehavior: &awscloudfront.BehaviorOptions{
+				Origin: awscloudfrontorigins.NewHttpOrigin(domain,
+					&awscloudfrontorigins.HttpOriginProps{
+						ProtocolPolicy: awscloudfront.OriginProtocolPolicy_HTTPS_ONLY,
+					},
+				),
+				AllowedMethods:       awscloudfront.AllowedMethods_ALLOW_ALL(),
+				ViewerProtocolPolicy: awscloudfront.ViewerProtocolPolicy_HTTPS_ONLY,
+				CachePolicy:          awscloudfront.CachePolicy_CACHING_DISABLED(),
+				OriginRequestPolicy:  awscloudfront.OriginRequestPolicy_ALL_VIEWER_EXCEPT_HOST_HEADER(),
+				EdgeLambdas: &[]*awscloudfront.EdgeLambda{
+					{
+						EventType:       awscloudfront.LambdaEdgeEventType_VIEWER_REQUEST,
+						FunctionVersion: f.CurrentVersion(),
+					},
+				},
+			},
+		},
+	)
+
+	c.output("EdgeHost", jsii.String("https://"+*cdn.DistributionDomainName()))
+	c.output("EdgeEndpoint", jsii.String("https://"+*cdn.DistributionDomainName()+uri))
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// Lambda@Edge does not support environment variables, the configuration is
+// inlined into the code: issuer, audiences and path of the endpoint.
+const edgeJWTCode = `'use strict';
+const crypto = require('crypto');
+const https = require('https');
+
+const ISSUER = %s;
+const AUDIENCE = %s;
+const ENDPOINT = %s;
+const TTL = 60 * 60 * 1000;
+const RETRY = 60 * 1000;
+const HASH = { RS256: 'sha256', RS384: 'sha384', RS512: 'sha512', ES256: 'sha256', ES384: 'sha384', ES512: 'sha512' };
+
+let keys = {};
+let fetched = 0;
+
+class Unavailable extends Error {}
+
+function get(url) {
+  return new Promise((resolve, reject) => {
+    https.get(url, { timeout: 2000 }, (res) => {
+      let body = '';
+      res.on('data', (chunk) => body += chunk);
+      res.on('end', () => {
+        if (res.statusCode !== 200) return reject(new Error(url + ' status ' + res.statusCode));
+        try { resolve(JSON.parse(body)); } catch (e) { reject(e); }
+      });
+    }).on('timeout', function () { this.destroy(new Error(url + ' timeout')); }).on('error', reject);
+  });
+}
+
+async function jwks() {
+  const base = ISSUER.replace(/\/$/, '');
+  let uri = base + '/.well-known/jwks.json';
+  try {
+    const conf = await get(base + '/.well-known/openid-configuration');
+    if (conf.jwks_uri) uri = conf.jwks_uri;
+  } catch (e) {}
+
+  const set = await get(uri);
+  const seq = {};
+  for (const jwk of set.keys || []) {
+    if (jwk.kid && (!jwk.use || jwk.use === 'sig')) {
+      seq[jwk.kid] = crypto.createPublicKey({ key: jwk, format: 'jwk' });
+    }
+  }
+  return seq;
+}
+
+async function key(kid) {
+  const age = Date.now() - fetched;
+  if (age > TTL || (!keys[kid] && age > RETRY)) {
+    try {
+      keys = await jwks();
+      fetched = Date.now();
+    } catch (e) {
+      if (fetched === 0 || age > TTL) throw new Unavailable(e.message);
+    }
+  }
+  return keys[kid];
+}
+
+function decode(seg) {
+  return JSON.parse(Buffer.from(seg, 'base64url').toString());
+}
+
+async function verify(token) {
+  const [h, p, s] = token.split('.');
+  if (!h || !p || !s) return false;
+
+  const header = decode(h);
+  const hash = HASH[header.alg];
+  if (!hash) return false;
+
+  const pub = await key(header.kid);
+  if (!pub) return false;
+
+  const opts = header.alg.startsWith('ES') ? { key: pub, dsaEncoding: 'ieee-p1363' } : pub;
+  if (!crypto.verify(hash, Buffer.from(h + '.' + p), opts, Buffer.from(s, 'base64url'))) return false;
+
+  const claims = decode(p);
+  const now = Math.floor(Date.now() / 1000);
+  if (typeof claims.exp !== 'number' || claims.exp <= now) return false;
+  if (typeof claims.nbf === 'number' && claims.nbf > now + 60) return false;
+  if (claims.iss !== ISSUER) return false;
+  if (AUDIENCE.length > 0) {
+    const aud = [].concat(claims.aud || [], claims.client_id || []);
+    if (!aud.some((a) => AUDIENCE.includes(a))) return false;
+  }
+
+  return true;
+}
+
+function unauthorized() {
+  return {
+    status: '401',
+    statusDescription: 'Unauthorized',
+    headers: {
+      'content-type': [{ key: 'Content-Type', value: 'application/json' }],
+      'www-authenticate': [{ key: 'WWW-Authenticate', value: 'Bearer' }],
+    },
+    body: '{"message":"Unauthorized"}',
+  };
+}
+
+exports.handler = async (event) => {
+  const request = event.Records[0].cf.request;
+  if (request.method === 'OPTIONS') return request;
+  if (request.uri !== ENDPOINT && !request.uri.startsWith(ENDPOINT + '/')) return request;
+
+  const auth = request.headers.authorization;
+  const match = auth && /^Bearer\s+(.+)$/i.exec(auth[0].value);
+  if (!match) return unauthorized();
+
+  try {
+    return (await verify(match[1])) ? request : unauthorized();
+  } catch (e) {
+    return (e instanceof Unavailable) ? request : unauthorized();
+  }
+};
+`
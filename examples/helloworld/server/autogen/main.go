@@ -1,13 +1,15 @@
 // DO NOT EDIT !!!
 // THE FILE IS AUTO GENERATED BY github.com/fogfish/cloudmcp
-// 2025-10-27 18:38:04.544025 +0200 EET m=+0.841218792
+// 2026-10-15 06:40:10.787870955 +0000 UTC m=+1.951664689
 package main
 
 import (
+	"context"
 	"net/http"
 
   "github.com/aws/aws-lambda-go/lambda"
 	"github.com/fogfish/cloudmcp/internal/gateway"
+	"github.com/fogfish/cloudmcp/pkg/warm"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/fogfish/cloudmcp/examples/helloworld/server"
 )
@@ -18,6 +20,10 @@ func main() {
 		panic(err)
 	}
 
+	if err := gateway.ServerFromEnv(context.Background(), server); err != nil {
+		panic(err)
+	}
+
 	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
 		return server
 	}, &mcp.StreamableHTTPOptions{
@@ -25,7 +31,16 @@ func main() {
 		JSONResponse: true,
 	})
 
-	srv := gateway.New(handler)
+	opts, err := gateway.FromEnv(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	srv := gateway.New(handler, opts...)
+
+	if err := warm.Run(context.Background()); err != nil {
+		panic(err)
+	}
 
-	lambda.Start(srv.Serve)
+	lambda.Start(srv.Handle)
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/fogfish/cloudmcp"
 	"github.com/fogfish/cloudmcp/examples/serverless/sayer"
 	"github.com/fogfish/scud"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func main() {
@@ -45,7 +46,10 @@ func main() {
 				SourceCodeModule: "github.com/fogfish/cloudmcp",
 				SourceCodeLambda: "cmd/cloudmcp/sayer",
 			},
-		),
+		).WithTool(&mcp.Tool{
+			Title:       "Say Hi",
+			Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true},
+		}),
 	)
 	f.AllowAccessApiKey(authroizer)
 
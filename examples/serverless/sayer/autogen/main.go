@@ -1,13 +1,16 @@
 // DO NOT EDIT !!!
 // THE FILE IS AUTO GENERATED BY github.com/fogfish/cloudmcp
-// 2025-10-27 19:04:36.736371 +0200 EET m=+1.380776501
+// 2026-10-15 06:43:55.807309438 +0000 UTC m=+2.058583585
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 
   "github.com/aws/aws-lambda-go/lambda"
 	"github.com/fogfish/cloudmcp/internal/gateway"
+	"github.com/fogfish/cloudmcp/pkg/warm"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/fogfish/cloudmcp/examples/serverless/sayer"
 )
@@ -17,7 +20,16 @@ func main() {
 		&mcp.Implementation{Name: "Sayer", Version: "v0.0.0"},
 		nil,
 	)
-	mcp.AddTool(server, &mcp.Tool{Name: "Sayer", Description: "says hi"}, sayer.Sayer)
+
+	tool := &mcp.Tool{}
+	if err := json.Unmarshal([]byte("{\"annotations\":{\"idempotentHint\":true,\"readOnlyHint\":true},\"description\":\"says hi\",\"inputSchema\":null,\"name\":\"Sayer\",\"title\":\"Say Hi\"}"), tool); err != nil {
+		panic(err)
+	}
+	mcp.AddTool(server, tool, sayer.Sayer)
+
+	if err := gateway.ServerFromEnv(context.Background(), server); err != nil {
+		panic(err)
+	}
 
 	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
 		return server
@@ -26,7 +38,16 @@ func main() {
 		JSONResponse: true,
 	})
 
-	srv := gateway.New(handler)
+	opts, err := gateway.FromEnv(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	srv := gateway.New(handler, opts...)
+
+	if err := warm.Run(context.Background()); err != nil {
+		panic(err)
+	}
 
-	lambda.Start(srv.Serve)
+	lambda.Start(srv.Handle)
 }
@@ -9,61 +9,518 @@
 package cloudmcp
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"net/netip"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-cdk-go/awscdk/v2"
+	apigw2 "github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2"
+	authorizers "github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2authorizers"
+	integrations "github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2integrations"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsappsync"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscertificatemanager"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudwatch"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudwatchactions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscodedeploy"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsglue"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awskinesisfirehose"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awskms"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambdaeventsources"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53targets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3assets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssns"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/customresources"
+	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+	"github.com/fogfish/cloudmcp/internal/gateway"
+	"github.com/fogfish/cloudmcp/internal/jwt"
+	"github.com/fogfish/cloudmcp/pkg/bluegreen"
+	"github.com/fogfish/cloudmcp/pkg/middleware"
 	"github.com/fogfish/scud"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // Serverless MCP Gateway, wraps MCP Server as defined by official Go SDK
 // into AWS API Gateway and Lambda deployment.
 type Gateway struct {
-	f        Factory
+	f        any
+	stage    string
+	prefix   string
+	named    string
+	tags     map[string]string
 	app      awscdk.App
 	stack    awscdk.Stack
-	loggroup awslogs.LogGroup
+	target   *Target
+	loggroup awslogs.ILogGroup
+	logging  *Logging
+	kms      awskms.IKey
 
-	gateway *scud.Gateway
-	authpub *scud.AuthorizerPublic
-	authkey *scud.AuthorizerBasic
-	authjwt *scud.AuthorizerJwt
-}
+	// stack is owned by the application, it embeds the gateway among other
+	// constructs and synthesizes it.
+	embedded bool
+
+	// constructs of the stack defined before the builder
+	base int
+
+	gateway  *scud.Gateway
+	authpub  *scud.AuthorizerPublic
+	authkey  *scud.AuthorizerBasic
+	authhsh  *AuthorizerApiKeyHashed
+	authjwt  *scud.AuthorizerJwt
+	authjwts *AuthorizerJwtIssuers
+	authint  *AuthorizerIntrospection
+	authiam  *scud.AuthorizerIAM
+	grantee  awsiam.IGrantable
+
+	// runtime configuration of the server function
+	env   map[string]*string
+	hooks []func(awslambda.Function)
+
+	// version of the server, prefixes the path (e.g. /v1/helloworld)
+	version string
+
+	// other versions of the server, deployed as independent functions
+	versions []serverVersion
+
+	// public health endpoint of the server
+	health bool
+
+	// public discovery endpoint of the server (/.well-known/mcp.json)
+	discovery bool
+
+	// permissions of the server, granted to function or container task
+	grants []func(awsiam.IGrantable)
+
+	// pre-created execution role of functions, roles are created otherwise
+	role awsiam.IRole
+
+	// deployment of the server function, applied once routes are defined
+	deploy func(awslambda.Function)
+
+	// alias of the server function serving the traffic, if deployment uses it
+	alias awslambda.IFunction
+
+	// warm-up of the server function, applied once deployment is defined
+	keepwarm func(awslambda.IFunction)
+
+	// limits of concurrency and dead-letter queue of the server function
+	concurrency int
+	deadletters awssqs.IQueue
+
+	// network of the server function
+	vpc            awsec2.IVpc
+	subnets        *awsec2.SubnetSelection
+	securityGroups []awsec2.ISecurityGroup
+
+	// container image packaging of the server function
+	dockerfile string
+
+	// explicit module and package of the factory, discovered otherwise
+	source *sourceCode
+
+	// WebSocket API, bidirectional transport for MCP
+	websocket bool
+
+	// issuer of tokens accepted by JWT or Cognito access
+	jwt *jwtIssuer
+
+	// OAuth 2.0 endpoints of JWT access
+	oauth2 awslambda.Function
+
+	// grace period of signing keys, it bounds lifetime of exchanged tokens
+	signing time.Duration
+
+	// validation of tokens at CloudFront edge
+	edge bool
+
+	// interface VPC endpoints of private REST API, replaces HTTP API
+	private []string
+
+	// imported REST API, replaces HTTP API
+	restapi *restApi
+
+	// sources of callers allowed by the gateway
+	network struct {
+		CIDR         []string `json:"cidr,omitempty"`
+		VpcEndpoints []string `json:"vpce,omitempty"`
+		Private      bool     `json:"private,omitempty"`
+	}
+
+	// state of MCP sessions shared across lambda instances
+	sessions awsdynamodb.Table
+
+	// middlewares of tool calls, applied inside the lambda runtime
+	middlewares []middleware.Middleware
+
+	// traffic assumed by estimation of the cost
+	cost *CostReport
+
+	// translation of the stack to Terraform configuration
+	terraform bool
+
+	// security posture checks of the synthesized stack
+	nag          bool
+	suppressions []NagSuppression
+
+	// environment of the server, injected into the factory
+	runtime map[string]string
 
-// Creates new Gateway builder for given MCP Server factory
-func New(f Factory) *Gateway {
-	name := servername(f)
+	// tools served by own functions
+	partition *PartitionStrategy
 
-	c := &Gateway{f: f}
+	// listing of the server at MCP registry and its endpoint
+	listing  *Listing
+	endpoint string
+}
+
+// Creates new Gateway builder for given MCP Server factory. The stage is
+// read from context variable `stage` (e.g. `cdk deploy -c stage=dev`).
+func New[F Factories](f F) *Gateway {
+	c := &Gateway{f: f, env: map[string]*string{}}
 	c.app = awscdk.NewApp(nil)
 
+	if stage, ok := c.app.Node().TryGetContext(jsii.String("stage")).(string); ok {
+		c.stage = stage
+	}
+	linkSourceCodeLibs()
+	c.init()
+
+	return c
+}
+
+// Creates new Gateway builder for given MCP Server factory, resources are
+// defined within the existing stack of the CDK application, sharing it
+// with other constructs (VPCs, domains, pipelines). The stage is read from
+// context variable `stage`. The application synthesizes the stack, Build
+// only defines resources of the gateway. The stack embeds one gateway, its
+// constructs (e.g. Gateway, Logs) must not clash with other constructs.
+func NewInStack[F Factories](stack awscdk.Stack, f F) *Gateway {
+	c := &Gateway{f: f, env: map[string]*string{}, stack: stack, embedded: true}
+
+	if stage, ok := stack.Node().TryGetContext(jsii.String("stage")).(string); ok {
+		c.stage = stage
+		c.env[envvar.Stage] = jsii.String(stage)
+	}
+	c.base = len(*stack.Node().Children())
+	linkSourceCodeLibs()
+	c.initLogGroup(c.name())
+
+	return c
+}
+
+func (c *Gateway) init() {
+	name := c.name()
+	if c.stage != "" {
+		c.env[envvar.Stage] = jsii.String(c.stage)
+	}
+	target := Target{
+		Account: os.Getenv("CDK_DEFAULT_ACCOUNT"),
+		Region:  os.Getenv("CDK_DEFAULT_REGION"),
+	}
+	if c.target != nil {
+		target = *c.target
+		if target.Account == "" {
+			target.Account = os.Getenv("CDK_DEFAULT_ACCOUNT")
+		}
+		if target.Region == "" {
+			target.Region = os.Getenv("CDK_DEFAULT_REGION")
+		}
+	}
+	if v := c.context("account"); v != "" {
+		target.Account = v
+	}
+	if v := c.context("region"); v != "" {
+		target.Region = v
+	}
+	if v := c.context("qualifier"); v != "" {
+		target.Qualifier = v
+	}
+
 	c.stack = awscdk.NewStack(c.app, jsii.String(name),
 		&awscdk.StackProps{
 			Env: &awscdk.Environment{
-				Account: jsii.String(os.Getenv("CDK_DEFAULT_ACCOUNT")),
-				Region:  jsii.String(os.Getenv("CDK_DEFAULT_REGION")),
+				Account: jsii.String(target.Account),
+				Region:  jsii.String(target.Region),
 			},
+			Synthesizer: target.synthesizer(),
 		},
 	)
+	c.recordName()
+
+	c.initLogGroup(name)
+
+	keys := slices.Sorted(maps.Keys(c.tags))
+	for _, key := range keys {
+		awscdk.Tags_Of(c.stack).Add(jsii.String(key), jsii.String(c.tags[key]), nil)
+	}
+}
+
+// Configures stage (environment) of the deployment. The stage suffixes
+// the stack, log group and custom domain (e.g. api-dev.example.com), the
+// outputs are exported with stage-qualified names. The per-stage context
+// is read from cdk.json, it overrides account, region, bootstrap qualifier
+// and certificate:
+//
+//	"context": {
+//	  "stages": {
+//	    "dev": {"account": "...", "region": "...", "certificate": "arn:..."}
+//	  }
+//	}
+//
+// The stage must be configured before any other option of the builder.
+func (c *Gateway) Stage(name string) *Gateway {
+	if c.configured() {
+		panic("stage must be configured before other options")
+	}
+	if c.embedded {
+		panic("stage of embedded gateway is defined by the application")
+	}
+
+	c.app.Node().TryRemoveChild(c.stack.Node().Id())
+	c.stage = name
+	c.init()
 
-	c.loggroup = awslogs.NewLogGroup(c.stack, jsii.String("Logs"),
-		&awslogs.LogGroupProps{
-			LogGroupName:  jsii.String(fmt.Sprintf("/app/%s", name)),
-			RemovalPolicy: awscdk.RemovalPolicy_DESTROY,
-			Retention:     awslogs.RetentionDays_FIVE_DAYS,
+	return c
+}
+
+// Target is the environment of the deployment and roles assumed by CDK to
+// deploy into it, the account is bootstrapped with `cdk bootstrap`. Empty
+// fields default to the current environment and the default bootstrap.
+type Target struct {
+	// Account and region of the stack
+	Account string
+	Region  string
+
+	// Qualifier of the bootstrap resources (e.g. cdk bootstrap --qualifier)
+	Qualifier string
+
+	// Roles assumed to deploy the stack, e.g. created by bootstrap with
+	// --trust of the CI account
+	DeployRole       string
+	ExecutionRole    string
+	FilePublishRole  string
+	ImagePublishRole string
+	LookupRole       string
+}
+
+// synthesizer of the stack, nil uses the default one
+func (t Target) synthesizer() awscdk.IStackSynthesizer {
+	if t.Qualifier == "" && t.DeployRole == "" && t.ExecutionRole == "" &&
+		t.FilePublishRole == "" && t.ImagePublishRole == "" && t.LookupRole == "" {
+		return nil
+	}
+
+	optional := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return jsii.String(s)
+	}
+
+	return awscdk.NewDefaultStackSynthesizer(
+		&awscdk.DefaultStackSynthesizerProps{
+			Qualifier:                   optional(t.Qualifier),
+			DeployRoleArn:               optional(t.DeployRole),
+			CloudFormationExecutionRole: optional(t.ExecutionRole),
+			FileAssetPublishingRoleArn:  optional(t.FilePublishRole),
+			ImageAssetPublishingRoleArn: optional(t.ImagePublishRole),
+			LookupRoleArn:               optional(t.LookupRole),
 		},
 	)
+}
+
+// Configures the target account and region of the deployment, the bootstrap
+// qualifier and roles assumed by CDK, so that a single pipeline deploys into
+// multiple accounts without relying on CDK_DEFAULT_ACCOUNT. The per-stage
+// context (account, region, qualifier) overrides the target. The target must
+// be configured before any other option of the builder except the stage.
+//
+//	cloudmcp.New(server.HelloWorld).
+//		Target(cloudmcp.Target{
+//			Account:    "111111111111",
+//			Region:     "eu-west-1",
+//			DeployRole: "arn:aws:iam::111111111111:role/cdk-hnb659fds-deploy-role-111111111111-eu-west-1",
+//		})
+func (c *Gateway) Target(spec Target) *Gateway {
+	if c.configured() {
+		panic("target must be configured before other options")
+	}
+	if c.embedded {
+		panic("target of embedded gateway is defined by the application")
+	}
+
+	c.app.Node().TryRemoveChild(c.stack.Node().Id())
+	c.target = &spec
+	c.init()
+
+	return c
+}
+
+// Prefixes names of the stack and resources named after it (functions,
+// API, log group, tables, ...) to follow naming standards of the platform,
+// e.g. NamePrefix("acme-") names the stack acme-HelloWorld. The prefix must
+// be configured before any other option of the builder except the stage.
+// The embedded gateway prefixes names of resources only, the stack is named
+// by the application.
+func (c *Gateway) NamePrefix(prefix string) *Gateway {
+	if c.configured() {
+		panic("name prefix must be configured before other options")
+	}
+
+	c.prefix = prefix
+	if c.embedded {
+		c.stack.Node().TryRemoveChild(jsii.String("Logs"))
+		c.initLogGroup(c.name())
+		return c
+	}
+
+	c.app.Node().TryRemoveChild(c.stack.Node().Id())
+	c.init()
+
+	return c
+}
+
+// options of the builder are configured, the stack contains constructs other
+// than the log group.
+func (c *Gateway) configured() bool {
+	return len(*c.stack.Node().Children()) > c.base+1
+}
+
+// Tags every resource of the stack, e.g. with cost-allocation tags. The
+// tags are propagated to the stack itself, including other constructs of
+// the embedded gateway's stack.
+func (c *Gateway) Tags(tags map[string]string) *Gateway {
+	if c.tags == nil {
+		c.tags = map[string]string{}
+	}
+
+	keys := slices.Sorted(maps.Keys(tags))
+	for _, key := range keys {
+		c.tags[key] = tags[key]
+		awscdk.Tags_Of(c.stack).Add(jsii.String(key), jsii.String(tags[key]), nil)
+	}
+
+	return c
+}
+
+// Attaches permissions boundary to every IAM role created by the stack,
+// including roles of functions, authorizers and custom resources. The
+// managed policy is referenced by ARN or by name.
+func (c *Gateway) PermissionsBoundary(policy string) *Gateway {
+	var boundary awsiam.IManagedPolicy
+	if strings.HasPrefix(policy, "arn:") {
+		boundary = awsiam.ManagedPolicy_FromManagedPolicyArn(c.stack, jsii.String("PermissionsBoundary"), jsii.String(policy))
+	} else {
+		boundary = awsiam.ManagedPolicy_FromManagedPolicyName(c.stack, jsii.String("PermissionsBoundary"), jsii.String(policy))
+	}
+
+	awsiam.PermissionsBoundary_Of(c.stack).Apply(boundary)
+	return c
+}
+
+// Uses pre-created IAM role as execution role of functions (the server, its
+// versions, authorizers, hooks), instead of creating roles. The role must
+// trust lambda.amazonaws.com and allow writing logs, the permissions of the
+// server are attached to it as policies. The role must be configured before
+// access and other options that define functions.
+func (c *Gateway) ExecutionRole(arn string) *Gateway {
+	for _, node := range *c.stack.Node().FindAll(constructs.ConstructOrder_PREORDER) {
+		if _, ok := node.(awslambda.CfnFunction); ok {
+			panic("execution role must be configured before options defining functions")
+		}
+	}
+
+	c.role = awsiam.Role_FromRoleArn(c.stack, jsii.String("ExecutionRole"), jsii.String(arn),
+		&awsiam.FromRoleArnOptions{Mutable: jsii.Bool(true)},
+	)
 
 	return c
 }
 
+// name of the stack, qualified with prefix and stage
+func (c *Gateway) name() string {
+	if c.named != "" {
+		return c.prefix + c.staged(c.named)
+	}
+	return c.prefix + c.staged(servername(c.f))
+}
+
+// name qualified with stage
+func (c *Gateway) staged(name string) string {
+	if c.stage == "" {
+		return name
+	}
+
+	return name + "-" + c.stage
+}
+
+// domain qualified with stage, the first label is suffixed
+func (c *Gateway) stagedHost(host string) string {
+	if c.stage == "" {
+		return host
+	}
+
+	label, domain, _ := strings.Cut(host, ".")
+	if domain == "" {
+		return c.staged(label)
+	}
+
+	return c.staged(label) + "." + domain
+}
+
+// value of per-stage context from cdk.json
+func (c *Gateway) context(key string) string {
+	if c.stage == "" {
+		return ""
+	}
+
+	stages, ok := c.stack.Node().TryGetContext(jsii.String("stages")).(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	stage, ok := stages[c.stage].(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	value, _ := stage[key].(string)
+	return value
+}
+
+// stack output, exported with stage-qualified name
+func (c *Gateway) output(id string, value *string) {
+	props := &awscdk.CfnOutputProps{Value: value}
+	if c.stage != "" {
+		props.ExportName = jsii.String(*c.stack.StackName() + "-" + id)
+	}
+
+	awscdk.NewCfnOutput(c.stack, jsii.String(id), props)
+}
+
 // Configures gateway without custom domain, default API Gateway host will be used.
 func (c *Gateway) Hostless() *Gateway {
 	c.gateway = scud.NewGateway(c.stack, jsii.String("Gateway"),
@@ -75,9 +532,13 @@ func (c *Gateway) Hostless() *Gateway {
 
 // Configures gateway with custom domain and TLS certificate ARN.
 func (c *Gateway) Host(host, certificate string) *Gateway {
+	if v := c.context("certificate"); v != "" {
+		certificate = v
+	}
+
 	c.gateway = scud.NewGateway(c.stack, jsii.String("Gateway"),
 		&scud.GatewayProps{
-			Host:   jsii.String(host),
+			Host:   jsii.String(c.stagedHost(host)),
 			TlsArn: jsii.String(certificate),
 		},
 	)
@@ -85,14 +546,80 @@ func (c *Gateway) Host(host, certificate string) *Gateway {
 	return c
 }
 
+// Configures gateway with custom domain, the DNS-validated TLS certificate
+// and alias records (A and AAAA) are created within the given Route53 hosted
+// zone. The zone is the parent domain of the host (e.g. example.com for
+// api.example.com) or the host itself.
+func (c *Gateway) HostWithDNS(host, hostedZoneId string) *Gateway {
+	host = c.stagedHost(host)
+
+	zoneName := host
+	if labels := strings.Split(host, "."); len(labels) > 2 {
+		zoneName = strings.Join(labels[1:], ".")
+	}
+
+	zone := awsroute53.HostedZone_FromHostedZoneAttributes(c.stack, jsii.String("HostedZone"),
+		&awsroute53.HostedZoneAttributes{
+			HostedZoneId: jsii.String(hostedZoneId),
+			ZoneName:     jsii.String(zoneName),
+		},
+	)
+
+	domain := apigw2.NewDomainName(c.stack, jsii.String("DomainName"),
+		&apigw2.DomainNameProps{
+			EndpointType: apigw2.EndpointType_REGIONAL,
+			DomainName:   jsii.String(host),
+			Certificate: awscertificatemanager.NewCertificate(c.stack, jsii.String("Certificate"),
+				&awscertificatemanager.CertificateProps{
+					DomainName: jsii.String(host),
+					Validation: awscertificatemanager.CertificateValidation_FromDns(zone),
+				},
+			),
+		},
+	)
+
+	c.gateway = scud.NewGateway(c.stack, jsii.String("Gateway"),
+		&scud.GatewayProps{
+			HttpApiProps: &apigw2.HttpApiProps{
+				DefaultDomainMapping: &apigw2.DomainMappingOptions{DomainName: domain},
+			},
+		},
+	)
+
+	target := awsroute53.RecordTarget_FromAlias(
+		awsroute53targets.NewApiGatewayv2DomainProperties(domain.RegionalDomainName(), domain.RegionalHostedZoneId()),
+	)
+	awsroute53.NewARecord(c.stack, jsii.String("ARecord"),
+		&awsroute53.ARecordProps{RecordName: jsii.String(host), Target: target, Zone: zone},
+	)
+	awsroute53.NewAaaaRecord(c.stack, jsii.String("AaaaRecord"),
+		&awsroute53.AaaaRecordProps{RecordName: jsii.String(host), Target: target, Zone: zone},
+	)
+
+	return c
+}
+
 // Configures gateway with custom properties.
 func (c *Gateway) Gateway(props *scud.GatewayProps) *Gateway {
+	if props.Host != nil {
+		props.Host = jsii.String(c.stagedHost(*props.Host))
+	}
 	c.gateway = scud.NewGateway(c.stack, jsii.String("Gateway"), props)
 	return c
 }
 
 // Configures gateway with public access (no authentication).
 func (c *Gateway) AccessPublic() *Gateway {
+	// the private api is bounded by vpc endpoints
+	if c.private != nil {
+		return c
+	}
+
+	// the imported api does not authorize requests by default
+	if c.restapi != nil {
+		return c
+	}
+
 	c.authpub = c.gateway.NewAuthorizerPublic()
 	return c
 }
@@ -100,115 +627,2586 @@ func (c *Gateway) AccessPublic() *Gateway {
 // Configures gateway with API Key access, using basic digest
 // authentication with access and secret keys.
 func (c *Gateway) AccessApiKey(access, secret string) *Gateway {
+	if c.private != nil {
+		panic("private api supports only public and iam access")
+	}
+	if c.restapi != nil {
+		panic("imported api supports only public, iam, hashed api key and cognito access")
+	}
 	c.authkey = c.gateway.NewAuthorizerBasic(access, secret)
 	return c
 }
 
+// Configures gateway with API Key access, using basic digest authentication.
+// Only the access key and salted hash of the secret key are synthesized into
+// the stack, the secret is never exposed by CloudFormation templates.
+// Use `go run github.com/fogfish/cloudmcp/cmd/apikey` to generate keys.
+func (c *Gateway) AccessApiKeyHashed(access, hash string) *Gateway {
+	if c.private != nil {
+		panic("private api supports only public and iam access")
+	}
+	if c.restapi != nil {
+		c.restApiKeyHashed(access, hash)
+		return c
+	}
+	c.authhsh = newAuthorizerApiKeyHashed(c.gateway, access, hash, c.role)
+	return c
+}
+
+// Configures gateway with API keys managed at runtime, keys are kept in
+// DynamoDB table (only salted hash of the secret), each key has own scopes
+// passed to the server as "scope" claim (see AccessPolicy). Keys are
+// created, rotated and revoked via management API `/apikeys` of the gateway
+// without redeployment. The management API requires AWS IAM access, it is
+// granted to given principals (IAM role, user or account ARNs) or to the
+// account where the stack is deployed. The stack outputs `ApiKeysTable`.
+func (c *Gateway) AccessApiKeys(admins ...string) *Gateway {
+	if c.private != nil {
+		panic("private api supports only public and iam access")
+	}
+	if c.restapi != nil {
+		panic("imported api does not support managed api keys")
+	}
+
+	table := awsdynamodb.NewTable(c.stack, jsii.String("ApiKeys"),
+		&awsdynamodb.TableProps{
+			PartitionKey: &awsdynamodb.Attribute{
+				Name: jsii.String("access"),
+				Type: awsdynamodb.AttributeType_STRING,
+			},
+			BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+			PointInTimeRecoverySpecification: &awsdynamodb.PointInTimeRecoverySpecification{
+				PointInTimeRecoveryEnabled: jsii.Bool(true),
+			},
+			RemovalPolicy: awscdk.RemovalPolicy_RETAIN,
+		},
+	)
+
+	c.authhsh = newAuthorizerApiKeyManaged(c.gateway, table, c.role)
+
+	// HTTP API does not support resource policies, the function checks admins
+	adminsEnv := c.stack.Account()
+	if len(admins) > 0 {
+		adminsEnv = jsii.String(strings.Join(admins, ","))
+	}
+
+	f := scud.NewFunctionGo(c.stack, jsii.String("ApiKeysManagement"),
+		&scud.FunctionGoProps{
+			SourceCodeModule: "github.com/fogfish/cloudmcp",
+			SourceCodeLambda: "internal/cmd/apikeys",
+			FunctionProps: &awslambda.FunctionProps{
+				Role:     c.role,
+				LogGroup: c.loggroup,
+				Timeout:  awscdk.Duration_Seconds(jsii.Number(10)),
+				Environment: &map[string]*string{
+					"CONFIG_APIKEYS_TABLE":  table.TableName(),
+					"CONFIG_APIKEYS_ADMINS": adminsEnv,
+				},
+			},
+		},
+	)
+	table.GrantReadWriteData(f)
+
+	var grantee awsiam.IGrantable = awsiam.NewAccountRootPrincipal()
+	if len(admins) > 0 {
+		seq := make([]awsiam.IPrincipal, len(admins))
+		for i, arn := range admins {
+			seq[i] = awsiam.NewArnPrincipal(jsii.String(arn))
+		}
+		grantee = awsiam.NewCompositePrincipal(seq...)
+	}
+	c.gateway.NewAuthorizerIAM().AddResource("/apikeys", f, grantee)
+
+	c.output("ApiKeysTable", table.TableName())
+
+	return c
+}
+
 // Configures gateway with AWS Cognito access, using given user pool ARN
 // and optional list of app clients.
 func (c *Gateway) AccessAwsCognito(cognitoArn string, clients ...string) *Gateway {
-	c.authjwt = c.gateway.NewAuthorizerCognito(cognitoArn, clients...)
+	if c.private != nil {
+		panic("private api supports only public and iam access")
+	}
+	if c.restapi != nil {
+		c.restApiCognito(cognitoArn, clients...)
+	} else {
+		c.authjwt = c.gateway.NewAuthorizerCognito(cognitoArn, clients...)
+	}
+
+	// arn:aws:cognito-idp:{region}:{account}:userpool/{pool}
+	arn := strings.Split(cognitoArn, ":")
+	if len(arn) == 6 {
+		c.jwt = &jwtIssuer{
+			issuer:   "https://cognito-idp." + arn[3] + ".amazonaws.com/" + strings.TrimPrefix(arn[5], "userpool/"),
+			audience: clients,
+		}
+	}
+
 	return c
 }
 
 // Configures gateway with JWT access, using given issuer and optional
 // list of audiences.
 func (c *Gateway) AccessJWT(issuer string, audience ...string) *Gateway {
+	if c.private != nil {
+		panic("private api supports only public and iam access")
+	}
+	if c.restapi != nil {
+		panic("imported api supports only public, iam, hashed api key and cognito access")
+	}
 	c.authjwt = c.gateway.NewAuthorizerJwt(issuer, audience...)
+	c.jwt = &jwtIssuer{issuer: issuer, audience: audience}
 
 	c.authpub = c.gateway.NewAuthorizerPublic()
 
+	spec, err := json.Marshal(jwt.Issuer{Issuer: issuer, Audience: audience})
+	if err != nil {
+		panic(err)
+	}
+
 	f := scud.NewFunctionGo(c.stack, jsii.String("JWKS"),
 		&scud.FunctionGoProps{
 			SourceCodeModule: "github.com/fogfish/cloudmcp",
 			SourceCodeLambda: "/internal/oauth2",
 			FunctionProps: &awslambda.FunctionProps{
+				Role:     c.role,
 				LogGroup: c.loggroup,
 				Timeout:  awscdk.Duration_Minutes(jsii.Number(5)),
+				Environment: &map[string]*string{
+					"CONFIG_OAUTH2_ISSUER": jsii.String(string(spec)),
+				},
 			},
 		},
 	)
 
+	c.oauth2 = f
+
+	// authorization code flow of browser clients is proxied to the issuer
 	c.authpub.AddResource("/oauth2", f)
+	c.authpub.AddResource("/.well-known/oauth-protected-resource", f)
+	c.authpub.AddResource("/.well-known/oauth-authorization-server", f)
 
 	return c
 }
 
-func (c *Gateway) Build() {
-	if c.gateway == nil {
-		c.Hostless()
+// Configures signing keys of tokens issued by the gateway, it requires JWT
+// access. ECC P-256 keys are created in KMS and rotated with the given
+// period, the private key never leaves KMS. Retired keys are published in
+// JWKS (/.well-known/jwks.json) during the grace period, which must exceed
+// the lifetime of tokens, afterwards they are scheduled for deletion.
+func (c *Gateway) SigningKeys(rotation, grace time.Duration) *Gateway {
+	if c.oauth2 == nil {
+		panic("signing keys require jwt access")
+	}
+	if rotation < time.Hour || grace <= 0 {
+		panic("rotation of signing keys must be at least an hour, grace must be positive")
 	}
 
-	module, lambda := sourcecode(c.f)
-	server := NewServer(c.stack, jsii.String(filepath.Base(lambda)),
-		NewServerProps(c.f, &scud.FunctionGoProps{
-			SourceCodeModule: module,
-			SourceCodeLambda: lambda,
-			FunctionProps: &awslambda.FunctionProps{
-				LogGroup: c.loggroup,
-				Timeout:  awscdk.Duration_Minutes(jsii.Number(5)),
+	f := c.oauth2
+	param := "/cloudmcp/" + *c.stack.StackName() + "/signing-keys"
+	c.signing = grace
+
+	f.AddEnvironment(jsii.String("CONFIG_OAUTH2_KEYS"), jsii.String(param), nil)
+	f.AddEnvironment(jsii.String("CONFIG_OAUTH2_KEYS_GRACE"), jsii.String(grace.String()), nil)
+
+	f.AddToRolePolicy(
+		awsiam.NewPolicyStatement(
+			&awsiam.PolicyStatementProps{
+				Actions: jsii.Strings("ssm:GetParameter", "ssm:PutParameter"),
+				Resources: &[]*string{
+					c.stack.FormatArn(
+						&awscdk.ArnComponents{
+							Service:      jsii.String("ssm"),
+							Resource:     jsii.String("parameter"),
+							ResourceName: jsii.String(param[1:]),
+						},
+					),
+				},
 			},
-		}),
+		),
 	)
 
-	switch {
-	case c.authjwt != nil:
-		server.AllowAccessJWT(c.authjwt)
-	case c.authkey != nil:
-		server.AllowAccessApiKey(c.authkey)
-	case c.authpub != nil:
-		server.AllowAccessPublic(c.authpub)
-	default:
-		panic("no authorizer defined for server")
-	}
-
-	awscdk.NewCfnOutput(c.stack, jsii.String("Host"),
-		&awscdk.CfnOutputProps{Value: c.gateway.RestAPI.ApiEndpoint()},
+	// keys are created and used only with the tag of the keyring
+	f.AddToRolePolicy(
+		awsiam.NewPolicyStatement(
+			&awsiam.PolicyStatementProps{
+				Actions:   jsii.Strings("kms:CreateKey", "kms:TagResource"),
+				Resources: jsii.Strings("*"),
+				Conditions: &map[string]any{
+					"StringEquals": map[string]any{"aws:RequestTag/cloudmcp:keyring": param},
+				},
+			},
+		),
+	)
+	f.AddToRolePolicy(
+		awsiam.NewPolicyStatement(
+			&awsiam.PolicyStatementProps{
+				Actions:   jsii.Strings("kms:GetPublicKey", "kms:Sign", "kms:ScheduleKeyDeletion"),
+				Resources: jsii.Strings("*"),
+				Conditions: &map[string]any{
+					"StringEquals": map[string]any{"aws:ResourceTag/cloudmcp:keyring": param},
+				},
+			},
+		),
 	)
 
-	c.app.Synth(nil)
-}
+	rotate := map[string]any{"rotate": true}
 
-func servername(f any) string {
-	fptr := reflect.ValueOf(f).Pointer()
-	fobj := runtime.FuncForPC(fptr)
-	if fobj == nil {
-		panic(fmt.Errorf("failed to discover function metadata"))
-	}
+	awsevents.NewRule(c.stack, jsii.String("SigningKeysRotation"),
+		&awsevents.RuleProps{
+			Schedule: awsevents.Schedule_Rate(awscdk.Duration_Hours(jsii.Number(math.Floor(rotation.Hours())))),
+			Targets: &[]awsevents.IRuleTarget{
+				awseventstargets.NewLambdaFunction(f,
+					&awseventstargets.LambdaFunctionProps{
+						Event:         awsevents.RuleTargetInput_FromObject(rotate),
+						RetryAttempts: jsii.Number(2),
+					},
+				),
+			},
+		},
+	)
 
-	name := fobj.Name()
-	serv := filepath.Ext(name)[1:]
+	// the first key is created on deployment
+	customresources.NewAwsCustomResource(c.stack, jsii.String("SigningKeysInit"),
+		&customresources.AwsCustomResourceProps{
+			OnCreate: &customresources.AwsSdkCall{
+				Service: jsii.String("Lambda"),
+				Action:  jsii.String("invoke"),
+				Parameters: map[string]any{
+					"FunctionName": f.FunctionName(),
+					"Payload":      `{"rotate":true}`,
+				},
+				PhysicalResourceId: customresources.PhysicalResourceId_Of(jsii.String(param)),
+			},
+			// sdk call "invoke" is not mapped to the IAM action
+			Policy: customresources.AwsCustomResourcePolicy_FromStatements(
+				&[]awsiam.PolicyStatement{
+					awsiam.NewPolicyStatement(
+						&awsiam.PolicyStatementProps{
+							Actions:   jsii.Strings("lambda:InvokeFunction"),
+							Resources: &[]*string{f.FunctionArn()},
+						},
+					),
+				},
+			),
+			InstallLatestAwsSdk: jsii.Bool(false),
+			LogGroup:            c.loggroup,
+			Role:                c.role,
+		},
+	)
 
-	return serv
+	c.authpub.AddResource("/.well-known/jwks.json", f)
+
+	return c
 }
 
-func sourcecode(f any) (string, string) {
-	fptr := reflect.ValueOf(f).Pointer()
-	fobj := runtime.FuncForPC(fptr)
-	if fobj == nil {
-		panic(fmt.Errorf("failed to discover function metadata"))
+// Configures token exchange (RFC 8693) at /oauth2/token, it requires signing
+// keys. Access tokens of the identity provider are exchanged to tokens of
+// the gateway, which are valid for the given ttl and carry only requested
+// scopes out of the allowed ones. The gateway accepts tokens of both issuers,
+// the JWT authorizer is replaced with Lambda authorizer.
+func (c *Gateway) TokenExchange(ttl time.Duration, scopes ...string) *Gateway {
+	if c.signing == 0 {
+		panic("token exchange requires signing keys")
+	}
+	if c.edge {
+		panic("token exchange is not supported by validation of tokens at edge")
+	}
+	if ttl <= 0 || ttl > c.signing {
+		panic("ttl of exchanged tokens must be positive and not exceed grace period of signing keys")
 	}
 
-	name := fobj.Name()
-	path := strings.TrimSuffix(name, filepath.Ext(name))
+	issuer := *c.gateway.RestAPI.ApiEndpoint()
+	spec, err := json.Marshal(
+		map[string]any{"issuer": issuer, "ttl": ttl.String(), "scopes": scopes},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	c.oauth2.AddEnvironment(jsii.String("CONFIG_OAUTH2_EXCHANGE"), jsii.String(string(spec)), nil)
+	c.authpub.AddResource("/.well-known/openid-configuration", c.oauth2)
+
+	c.authjwts = newAuthorizerJwtIssuers(c.gateway,
+		[]JWTIssuer{
+			{Issuer: c.jwt.issuer, Audience: c.jwt.audience},
+			{Issuer: issuer, Audience: []string{issuer}},
+		},
+		c.role,
+	)
+	c.authjwt = nil
+
+	return c
+}
+
+// JWTIssuer is the issuer of tokens and audiences accepted from it, any
+// audience is accepted if the list is empty.
+type JWTIssuer struct {
+	Issuer   string
+	Audience []string
+}
+
+// Configures gateway with JWT access of multiple issuers (e.g. corporate
+// IdP and partner's IdP), each issuer has own list of audiences. Tokens are
+// validated by Lambda authorizer using keys of issuers fetched with OpenID
+// discovery, the rotation of keys is followed. Single issuer is configured
+// as AccessJWT does.
+func (c *Gateway) AccessJWTIssuers(issuers ...JWTIssuer) *Gateway {
+	if len(issuers) == 0 {
+		panic("at least one issuer is required")
+	}
+	if len(issuers) == 1 {
+		return c.AccessJWT(issuers[0].Issuer, issuers[0].Audience...)
+	}
+	if c.private != nil {
+		panic("private api supports only public and iam access")
+	}
+	if c.restapi != nil {
+		panic("imported api supports only public, iam, hashed api key and cognito access")
+	}
+
+	c.authjwts = newAuthorizerJwtIssuers(c.gateway, issuers, c.role)
+	return c
+}
+
+// Configures gateway with opaque token access, for identity providers that
+// issue opaque rather than JWT tokens. Tokens are validated by Lambda
+// authorizer using OAuth 2.0 Token Introspection (RFC 7662) with credentials
+// of the client, the secret is given as ARN of Secrets Manager secret (plain
+// text or JSON with "client_secret" key). Results of introspection are cached
+// by the authorizer until the token expires, at most for 5 minutes.
+func (c *Gateway) AccessIntrospection(introspectionUrl, clientId, clientSecretArn string) *Gateway {
+	if c.private != nil {
+		panic("private api supports only public and iam access")
+	}
+	if c.restapi != nil {
+		panic("imported api supports only public, iam, hashed api key and cognito access")
+	}
+
+	c.authint = newAuthorizerIntrospection(c.gateway, introspectionUrl, clientId, clientSecretArn, c.role)
+	return c
+}
+
+// issuer and audiences of JWT
+type jwtIssuer struct {
+	issuer   string
+	audience []string
+}
+
+// Configures gateway with AWS IAM access, requests must be signed using
+// AWS SigV4 (see pkg/auth). The access is granted to given principals
+// (IAM role, user or account ARNs). If no principals are given, the access
+// is granted to the account where the stack is deployed. HTTP API does not
+// support resource policies, the server rejects other principals.
+func (c *Gateway) AccessAwsIAM(principals ...string) *Gateway {
+	// the private and imported api authorize requests using its own methods
+	if c.private == nil && c.restapi == nil {
+		c.authiam = c.gateway.NewAuthorizerIAM()
+	}
+
+	if len(principals) == 0 {
+		c.env[envvar.Principals] = c.stack.Account()
+		c.grantee = awsiam.NewAccountRootPrincipal()
+		return c
+	}
+
+	c.env[envvar.Principals] = jsii.String(strings.Join(principals, ","))
+
+	seq := make([]awsiam.IPrincipal, len(principals))
+	for i, arn := range principals {
+		seq[i] = awsiam.NewArnPrincipal(jsii.String(arn))
+	}
+	c.grantee = awsiam.NewCompositePrincipal(seq...)
+
+	return c
+}
+
+// AccessGrant allows callers, whose claim contains the value, to call
+// listed tools. The claim "sub" is used if omitted, it is the access key
+// for API Key access, the subject for JWT access and ARN for IAM access.
+// The wildcard "*" matches any value or tool.
+type AccessGrant struct {
+	Claim string   `json:"claim,omitempty"`
+	Value string   `json:"value"`
+	Tools []string `json:"tools"`
+}
+
+// Configures per-tool authorization policy, the server rejects tools/call
+// requests unless the caller is granted to call the tool.
+//
+//	AccessPolicy(
+//		cloudmcp.AccessGrant{Claim: "scope", Value: "admin", Tools: []string{"*"}},
+//		cloudmcp.AccessGrant{Value: "*", Tools: []string{"sayer"}},
+//	)
+func (c *Gateway) AccessPolicy(grants ...AccessGrant) *Gateway {
+	policy, err := json.Marshal(grants)
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.Policy] = jsii.String(string(policy))
+	return c
+}
+
+// Configures per-tool authorization policy loaded from SSM Parameter Store.
+// The parameter holds JSON array of AccessGrant.
+func (c *Gateway) AccessPolicySSM(parameter string) *Gateway {
+	c.env[envvar.PolicySSM] = jsii.String(parameter)
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		f.GrantPrincipal().AddToPrincipalPolicy(
+			awsiam.NewPolicyStatement(
+				&awsiam.PolicyStatementProps{
+					Actions: jsii.Strings("ssm:GetParameter"),
+					Resources: jsii.Strings(
+						*c.stack.FormatArn(
+							&awscdk.ArnComponents{
+								Service:      jsii.String("ssm"),
+								Resource:     jsii.String("parameter"),
+								ResourceName: jsii.String(strings.TrimPrefix(parameter, "/")),
+							},
+						),
+					),
+				},
+			),
+		)
+	})
+
+	return c
+}
+
+// Capability exposes tools and resources to callers, whose claim contains
+// the value. The claim "scope" is used if omitted, space separated scopes
+// are matched individually. Tools and resources are names (URIs), "*" or
+// prefixes ending with "*".
+type Capability struct {
+	Claim     string   `json:"claim,omitempty"`
+	Value     string   `json:"value"`
+	Tools     []string `json:"tools,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+}
+
+// Configures mapping of token claims to MCP capabilities, a single
+// deployment serves differently-privileged clients. The server prunes
+// tools/list and resources/list responses to capabilities of the caller,
+// tools/call and resources/read outside of them are rejected.
+//
+//	Capabilities(
+//		cloudmcp.Capability{Value: "tools:billing", Tools: []string{"billing_*"}},
+//		cloudmcp.Capability{Value: "*", Tools: []string{"sayer"}},
+//	)
+func (c *Gateway) Capabilities(caps ...Capability) *Gateway {
+	data, err := json.Marshal(caps)
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.Capabilities] = jsii.String(string(data))
+	return c
+}
+
+// Grants the server read access to parameters of AWS SSM Parameter Store
+// under the prefix (e.g. /myapp). Tools load their configuration using
+// pkg/config, parameters are cached and reloaded once TTL is expired.
+func (c *Gateway) GrantConfig(prefix string) *Gateway {
+	path := strings.Trim(prefix, "/")
+
+	c.env[envvar.ConfigPrefix] = jsii.String("/" + path)
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		arn := func(name string) *string {
+			return c.stack.FormatArn(
+				&awscdk.ArnComponents{
+					Service:      jsii.String("ssm"),
+					Resource:     jsii.String("parameter"),
+					ResourceName: jsii.String(name),
+				},
+			)
+		}
+
+		f.GrantPrincipal().AddToPrincipalPolicy(
+			awsiam.NewPolicyStatement(
+				&awsiam.PolicyStatementProps{
+					Actions:   jsii.Strings("ssm:GetParametersByPath", "ssm:GetParameter", "ssm:GetParameters"),
+					Resources: &[]*string{arn(path), arn(path + "/*")},
+				},
+			),
+		)
+	})
+
+	return c
+}
+
+// Defines environment of the server, it is injected into the factory
+// (see FactoryWithConfig) as Config.Env, also when the server is
+// constructed at build time (e.g. CacheTools). Environment of lambda is
+// limited to 4KB.
+func (c *Gateway) Env(env map[string]string) *Gateway {
+	if c.runtime == nil {
+		c.runtime = map[string]string{}
+	}
+	maps.Copy(c.runtime, env)
+
+	data, err := json.Marshal(c.runtime)
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.Env] = jsii.String(string(data))
+	return c
+}
+
+// Defines secrets of the server, it maps names of secrets to names of SSM
+// parameters (SecureString) or Secrets Manager references, e.g.
+// "/aws/reference/secretsmanager/my-secret". Values are loaded at the init
+// phase of the lambda and injected into the factory (see FactoryWithConfig)
+// as Config.Secrets, they are never synthesized into the stack. Secrets are
+// not available when the server is constructed at build time.
+func (c *Gateway) Secrets(secrets map[string]string) *Gateway {
+	params := map[string]string{}
+	if spec, has := c.env[envvar.Secrets]; has {
+		if err := json.Unmarshal([]byte(*spec), &params); err != nil {
+			panic(err)
+		}
+	}
+	maps.Copy(params, secrets)
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		panic(err)
+	}
+	c.env[envvar.Secrets] = jsii.String(string(data))
+
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		for _, param := range secrets {
+			name := strings.Trim(param, "/")
+			f.GrantPrincipal().AddToPrincipalPolicy(
+				awsiam.NewPolicyStatement(
+					&awsiam.PolicyStatementProps{
+						Actions: jsii.Strings("ssm:GetParameters", "ssm:GetParameter"),
+						Resources: &[]*string{
+							c.stack.FormatArn(
+								&awscdk.ArnComponents{
+									Service:      jsii.String("ssm"),
+									Resource:     jsii.String("parameter"),
+									ResourceName: jsii.String(name),
+								},
+							),
+						},
+					},
+				),
+			)
+
+			secret, isRef := strings.CutPrefix(name, "aws/reference/secretsmanager/")
+			if !isRef {
+				continue
+			}
+			arn := jsii.String(secret)
+			if !strings.HasPrefix(secret, "arn:") {
+				// suffix of the secret's arn is generated by Secrets Manager
+				arn = c.stack.FormatArn(
+					&awscdk.ArnComponents{
+						Service:      jsii.String("secretsmanager"),
+						Resource:     jsii.String("secret"),
+						ResourceName: jsii.String(secret + "-??????"),
+						ArnFormat:    awscdk.ArnFormat_COLON_RESOURCE_NAME,
+					},
+				)
+			}
+			f.GrantPrincipal().AddToPrincipalPolicy(
+				awsiam.NewPolicyStatement(
+					&awsiam.PolicyStatementProps{
+						Actions:   jsii.Strings("secretsmanager:GetSecretValue"),
+						Resources: &[]*string{arn},
+					},
+				),
+			)
+		}
+	})
+
+	return c
+}
+
+// instance of the server at build time, the factory receives the stage and
+// environment of the server, secrets are not loaded.
+func (c *Gateway) newServer() (*mcp.Server, error) {
+	env := map[string]string{}
+	maps.Copy(env, c.runtime)
+
+	return newServer(context.Background(), c.f,
+		Config{Stage: c.stage, Env: env, Secrets: map[string]string{}},
+	)
+}
+
+// RateLimit caps the rate of tools/call per caller identity (or per MCP
+// session) using token bucket algorithm. The tool "*" matches any tool.
+// The Burst defaults to PerMinute if omitted.
+type RateLimit struct {
+	Tool       string `json:"tool"`
+	PerMinute  int    `json:"perMinute"`
+	Burst      int    `json:"burst,omitempty"`
+	PerSession bool   `json:"perSession,omitempty"`
+}
+
+// Configures rate limiting of tools/call. Token buckets are shared across
+// lambda instances using DynamoDB table, which is created by the builder.
+// Calls above the limit are rejected with HTTP 429 and Retry-After header.
+func (c *Gateway) RateLimit(limits ...RateLimit) *Gateway {
+	spec, err := json.Marshal(limits)
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := gateway.NewRateLimits(spec); err != nil {
+		panic(err)
+	}
+
+	table := awsdynamodb.NewTable(c.stack, jsii.String("RateLimit"),
+		&awsdynamodb.TableProps{
+			PartitionKey: &awsdynamodb.Attribute{
+				Name: jsii.String("key"),
+				Type: awsdynamodb.AttributeType_STRING,
+			},
+			BillingMode:         awsdynamodb.BillingMode_PAY_PER_REQUEST,
+			TimeToLiveAttribute: jsii.String("ttl"),
+			RemovalPolicy:       awscdk.RemovalPolicy_DESTROY,
+		},
+	)
+
+	c.env[envvar.RateLimit] = jsii.String(string(spec))
+	c.env[envvar.RateLimitTable] = table.TableName()
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		table.GrantReadWriteData(f)
+	})
+
+	return c
+}
+
+// RequestLimits bounds the size of incoming JSON-RPC requests. The zero
+// value of the limit disables it.
+type RequestLimits struct {
+	MaxBodySize int `json:"maxBodySize,omitempty"`
+	MaxDepth    int `json:"maxDepth,omitempty"`
+}
+
+// Configures limits of requests, protecting the server from hostile or
+// runaway payloads. The gateway checks requests before decoding them,
+// requests above MaxBodySize bytes are rejected with HTTP 413, requests
+// nested deeper than MaxDepth are rejected with HTTP 400. API Gateway caps
+// the body at 10 MB anyway. JSON-RPC batches are always rejected, MCP does
+// not support them.
+func (c *Gateway) RequestLimits(spec RequestLimits) *Gateway {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.Limits] = jsii.String(string(data))
+
+	return c
+}
+
+// Restricts callers of the server to source addresses (e.g. office network).
+// HTTP API does not support resource policies, the restriction is enforced
+// by the gateway, other callers are rejected with HTTP 403. Addresses are
+// seen by API Gateway, the restriction does not work behind CloudFront edge.
+func (c *Gateway) RestrictToCIDR(cidrs ...string) *Gateway {
+	for _, cidr := range cidrs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			panic(fmt.Errorf("invalid cidr %s: %w", cidr, err))
+		}
+	}
+
+	c.network.CIDR = append(c.network.CIDR, cidrs...)
+	return c.restrictNetwork()
+}
+
+// Restricts callers of the server to interface VPC endpoints. Endpoints are
+// identified by the header x-amzn-vpce-id, which API Gateway sets only for
+// requests received by private API through the endpoint. Clients of public
+// API control the header, the restriction requires Private configured
+// before it. The caller is allowed if it matches either the endpoint or the
+// address given by RestrictToCIDR.
+func (c *Gateway) RestrictToVpcEndpoint(vpceIds ...string) *Gateway {
+	if c.private == nil {
+		panic("vpc endpoint restriction requires private api")
+	}
+
+	c.network.VpcEndpoints = append(c.network.VpcEndpoints, vpceIds...)
+	return c.restrictNetwork()
+}
+
+func (c *Gateway) restrictNetwork() *Gateway {
+	c.network.Private = c.private != nil
+
+	data, err := json.Marshal(c.network)
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.Network] = jsii.String(string(data))
+
+	return c
+}
+
+// ResponseCache caches results of idempotent (read-only) tool per caller
+// identity for TTL seconds. The tool "*" matches any tool.
+type ResponseCache struct {
+	Tool string `json:"tool"`
+	TTL  int    `json:"ttl"`
+}
+
+// Configures caching of tools/call results, keyed by the tool name, hash of
+// arguments and caller identity. Results are kept in the sessions table
+// shared across lambda instances, cached results are served by the gateway
+// without invoking the tool. Tool execution errors are not cached.
+func (c *Gateway) CacheResponses(caches ...ResponseCache) *Gateway {
+	spec, err := json.Marshal(caches)
+	if err != nil {
+		panic(err)
+	}
+
+	c.sessionTable()
+	c.env[envvar.ResponseCache] = jsii.String(string(spec))
+
+	return c
+}
+
+// Tenancy resolves the tenant of the caller from the claim of JWT (e.g.
+// custom:tenant) or from the mapping of API access keys to tenants.
+type Tenancy struct {
+	Claim   string            `json:"claim,omitempty"`
+	ApiKeys map[string]string `json:"apiKeys,omitempty"`
+	Metrics bool              `json:"metrics,omitempty"`
+}
+
+// Configures multi-tenant isolation. The tenant is exposed to tool handlers
+// via context (see pkg/tenancy), calls without tenant are rejected. Sessions
+// and cached responses are partitioned by tenant. Metrics are emitted per
+// tenant for chargeback if Metrics is set.
+func (c *Gateway) Tenancy(spec Tenancy) *Gateway {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.Tenancy] = jsii.String(string(data))
+	return c
+}
+
+// Configures structured logs of the server, the format is either "json" or
+// "text" (default). Every log record of the gateway and of tool handlers
+// (logging with context, e.g. slog.InfoContext) carries AWS request ID, MCP
+// session ID, correlation ID, JSON-RPC id and tool name. The correlation ID
+// is taken from X-Correlation-Id header of the request or derived from the
+// request ID, it is forwarded to the server and returned to the client.
+func (c *Gateway) LogFormat(level slog.Level, format string) *Gateway {
+	c.env[envvar.LogLevel] = jsii.String(level.String())
+	c.env[envvar.LogFormat] = jsii.String(format)
+	return c
+}
+
+// Enables CloudWatch metrics per tool call using Embedded Metric Format.
+// The metrics Calls, Duration, RequestSize and ResponseSize are emitted into
+// the namespace (default "CloudMCP") with dimensions Server, Tool and Outcome.
+// Tools are known from the manifest (it is cached if it is not yet) and
+// the policy, calls of other tools are emitted as Tool "unknown".
+func (c *Gateway) Metrics(namespace ...string) *Gateway {
+	ns := "CloudMCP"
+	if len(namespace) > 0 {
+		ns = namespace[0]
+	}
+
+	c.CacheTools()
+
+	c.env[envvar.Metrics] = jsii.String(ns)
+	c.env[envvar.Server] = jsii.String(servername(c.f))
+	return c
+}
+
+// Publishes EventBridge event per tool call into the event bus, so that
+// analytics, billing and alerting consume usage of the server without log
+// scraping. Events have source "cloudmcp", detail type "Tool Call Succeeded",
+// "Tool Call Failed" or "Tool Call Rejected" and detail with server, tool,
+// outcome, duration and identity of the caller.
+func (c *Gateway) EmitEvents(busArn string) *Gateway {
+	c.env[envvar.Events] = jsii.String(busArn)
+	c.env[envvar.Server] = jsii.String(servername(c.f))
+	// the bus is shared by functions of all versions and partitions
+	bus := awsevents.EventBus_FromEventBusArn(c.stack, jsii.String("EventBus"), jsii.String(busArn))
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		bus.GrantPutEventsTo(f, nil)
+	})
+
+	return c
+}
+
+// Configures analytics of tools usage. Anonymized record of each tool call
+// (tool, outcome, duration, digest of principal, tenant) is streamed to
+// Firehose delivery stream, which converts records to Parquet and writes them
+// into S3 bucket partitioned by day. Glue table "tool_calls" defines the schema
+// of records, making them queryable by Athena without crawlers.
+func (c *Gateway) Analytics() *Gateway {
+	bucket := awss3.NewBucket(c.stack, jsii.String("Analytics"),
+		&awss3.BucketProps{
+			BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+			Encryption:        awss3.BucketEncryption_S3_MANAGED,
+			EnforceSSL:        jsii.Bool(true),
+			RemovalPolicy:     awscdk.RemovalPolicy_RETAIN,
+		},
+	)
+
+	db := awsglue.NewCfnDatabase(c.stack, jsii.String("AnalyticsCatalog"),
+		&awsglue.CfnDatabaseProps{
+			CatalogId:     c.stack.Account(),
+			DatabaseInput: &awsglue.CfnDatabase_DatabaseInputProperty{},
+		},
+	)
+
+	column := func(name, kind string) *awsglue.CfnTable_ColumnProperty {
+		return &awsglue.CfnTable_ColumnProperty{Name: jsii.String(name), Type: jsii.String(kind)}
+	}
+
+	location := "s3://" + *bucket.BucketName() + "/calls/"
+	table := awsglue.NewCfnTable(c.stack, jsii.String("AnalyticsTable"),
+		&awsglue.CfnTableProps{
+			CatalogId:    c.stack.Account(),
+			DatabaseName: db.Ref(),
+			TableInput: &awsglue.CfnTable_TableInputProperty{
+				Name:      jsii.String("tool_calls"),
+				TableType: jsii.String("EXTERNAL_TABLE"),
+				Parameters: map[string]string{
+					"classification":               "parquet",
+					"projection.enabled":           "true",
+					"projection.day.type":          "date",
+					"projection.day.format":        "yyyy-MM-dd",
+					"projection.day.range":         "2025-01-01,NOW",
+					"projection.day.interval":      "1",
+					"projection.day.interval.unit": "DAYS",
+					"storage.location.template":    location + "day=${day}/",
+				},
+				PartitionKeys: &[]*awsglue.CfnTable_ColumnProperty{
+					column("day", "string"),
+				},
+				StorageDescriptor: &awsglue.CfnTable_StorageDescriptorProperty{
+					Location:     jsii.String(location),
+					InputFormat:  jsii.String("org.apache.hadoop.hive.ql.io.parquet.MapredParquetInputFormat"),
+					OutputFormat: jsii.String("org.apache.hadoop.hive.ql.io.parquet.MapredParquetOutputFormat"),
+					SerdeInfo: &awsglue.CfnTable_SerdeInfoProperty{
+						SerializationLibrary: jsii.String("org.apache.hadoop.hive.ql.io.parquet.serde.ParquetHiveSerDe"),
+					},
+					Columns: &[]*awsglue.CfnTable_ColumnProperty{
+						column("timestamp", "timestamp"),
+						column("server", "string"),
+						column("tool", "string"),
+						column("outcome", "string"),
+						column("duration", "double"),
+						column("principal", "string"),
+						column("tenant", "string"),
+						column("session", "string"),
+						column("request_size", "int"),
+						column("response_size", "int"),
+					},
+				},
+			},
+		},
+	)
+
+	glue := func(resource string) *string {
+		return c.stack.FormatArn(
+			&awscdk.ArnComponents{
+				Service:  jsii.String("glue"),
+				Resource: jsii.String(resource),
+			},
+		)
+	}
+
+	role := awsiam.NewRole(c.stack, jsii.String("AnalyticsRole"),
+		&awsiam.RoleProps{
+			AssumedBy: awsiam.NewServicePrincipal(jsii.String("firehose.amazonaws.com"), nil),
+		},
+	)
+	bucket.GrantReadWrite(role, nil)
+	role.AddToPolicy(
+		awsiam.NewPolicyStatement(
+			&awsiam.PolicyStatementProps{
+				Actions: jsii.Strings("glue:GetTable", "glue:GetTableVersion", "glue:GetTableVersions"),
+				Resources: &[]*string{
+					glue("catalog"),
+					glue("database/" + *db.Ref()),
+					glue("table/" + *db.Ref() + "/" + *table.Ref()),
+				},
+			},
+		),
+	)
+
+	stream := awskinesisfirehose.NewCfnDeliveryStream(c.stack, jsii.String("AnalyticsStream"),
+		&awskinesisfirehose.CfnDeliveryStreamProps{
+			DeliveryStreamType: jsii.String("DirectPut"),
+			ExtendedS3DestinationConfiguration: &awskinesisfirehose.CfnDeliveryStream_ExtendedS3DestinationConfigurationProperty{
+				BucketArn:         bucket.BucketArn(),
+				RoleArn:           role.RoleArn(),
+				Prefix:            jsii.String("calls/day=!{timestamp:yyyy-MM-dd}/"),
+				ErrorOutputPrefix: jsii.String("errors/!{firehose:error-output-type}/day=!{timestamp:yyyy-MM-dd}/"),
+				// Parquet conversion requires buffer of 64 MiB at least
+				BufferingHints: &awskinesisfirehose.CfnDeliveryStream_BufferingHintsProperty{
+					IntervalInSeconds: jsii.Number(300),
+					SizeInMBs:         jsii.Number(64),
+				},
+				DataFormatConversionConfiguration: &awskinesisfirehose.CfnDeliveryStream_DataFormatConversionConfigurationProperty{
+					Enabled: jsii.Bool(true),
+					InputFormatConfiguration: &awskinesisfirehose.CfnDeliveryStream_InputFormatConfigurationProperty{
+						Deserializer: &awskinesisfirehose.CfnDeliveryStream_DeserializerProperty{
+							HiveJsonSerDe: &awskinesisfirehose.CfnDeliveryStream_HiveJsonSerDeProperty{
+								TimestampFormats: jsii.Strings("millis"),
+							},
+						},
+					},
+					OutputFormatConfiguration: &awskinesisfirehose.CfnDeliveryStream_OutputFormatConfigurationProperty{
+						Serializer: &awskinesisfirehose.CfnDeliveryStream_SerializerProperty{
+							ParquetSerDe: &awskinesisfirehose.CfnDeliveryStream_ParquetSerDeProperty{},
+						},
+					},
+					SchemaConfiguration: &awskinesisfirehose.CfnDeliveryStream_SchemaConfigurationProperty{
+						CatalogId:    c.stack.Account(),
+						DatabaseName: db.Ref(),
+						TableName:    table.Ref(),
+						Region:       c.stack.Region(),
+						RoleArn:      role.RoleArn(),
+						VersionId:    jsii.String("LATEST"),
+					},
+				},
+			},
+		},
+	)
+	// the stream validates access to the bucket and the table on creation
+	stream.Node().AddDependency(role)
+
+	c.output("AnalyticsDatabase", db.Ref())
+	c.output("AnalyticsBucket", bucket.BucketName())
+
+	c.env[envvar.Analytics] = stream.Ref()
+	c.env[envvar.Server] = jsii.String(servername(c.f))
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		f.GrantPrincipal().AddToPrincipalPolicy(
+			awsiam.NewPolicyStatement(
+				&awsiam.PolicyStatementProps{
+					Actions:   jsii.Strings("firehose:PutRecord", "firehose:PutRecordBatch"),
+					Resources: &[]*string{stream.AttrArn()},
+				},
+			),
+		)
+	})
+
+	return c
+}
+
+// Enables audit trail of side-effecting tool calls for security review of
+// agent actions. Each call (identity, digest of arguments, outcome and
+// timestamp) is appended to DynamoDB table as hash-chained record, see
+// pkg/audit. The function is granted to append records only, the table is
+// retained and protected from deletion, point-in-time recovery is enabled.
+// Tools are patterns of audited tools (e.g. "billing_*"), all tools are
+// audited if omitted, tools annotated as read-only are skipped if the tool
+// discovery cache is enabled. Chains are sharded per tenant and per day.
+// Records not appended are counted by the metric AuditFailures, the alarm
+// is raised on them. The stack outputs `AuditTable` and `AuditAlarm`.
+func (c *Gateway) Audit(tools ...string) *Gateway {
+	table := awsdynamodb.NewTable(c.stack, jsii.String("Audit"),
+		&awsdynamodb.TableProps{
+			PartitionKey: &awsdynamodb.Attribute{
+				Name: jsii.String("chain"),
+				Type: awsdynamodb.AttributeType_STRING,
+			},
+			SortKey: &awsdynamodb.Attribute{
+				Name: jsii.String("seq"),
+				Type: awsdynamodb.AttributeType_NUMBER,
+			},
+			BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+			PointInTimeRecoverySpecification: &awsdynamodb.PointInTimeRecoverySpecification{
+				PointInTimeRecoveryEnabled: jsii.Bool(true),
+			},
+			DeletionProtection: jsii.Bool(true),
+			RemovalPolicy:      awscdk.RemovalPolicy_RETAIN,
+		},
+	)
+
+	if len(tools) > 0 {
+		spec, err := json.Marshal(tools)
+		if err != nil {
+			panic(err)
+		}
+		c.env[envvar.Audit] = jsii.String(string(spec))
+	}
+
+	c.env[envvar.Server] = jsii.String(servername(c.f))
+	c.env[envvar.AuditTable] = table.TableName()
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		table.Grant(f, jsii.String("dynamodb:PutItem"), jsii.String("dynamodb:Query"))
+	})
+
+	c.output("AuditTable", table.TableName())
+
+	alarm := awscloudwatch.NewAlarm(c.stack, jsii.String("AuditFailuresAlarm"),
+		&awscloudwatch.AlarmProps{
+			Metric: awscloudwatch.NewMetric(
+				&awscloudwatch.MetricProps{
+					Namespace:     jsii.String(gateway.NamespaceAudit),
+					MetricName:    jsii.String(gateway.MetricAuditFailures),
+					DimensionsMap: &map[string]*string{"Server": jsii.String(servername(c.f))},
+					Statistic:     jsii.String("Sum"),
+					Period:        awscdk.Duration_Minutes(jsii.Number(5)),
+				},
+			),
+			Threshold:          jsii.Number(1),
+			EvaluationPeriods:  jsii.Number(1),
+			ComparisonOperator: awscloudwatch.ComparisonOperator_GREATER_THAN_OR_EQUAL_TO_THRESHOLD,
+			TreatMissingData:   awscloudwatch.TreatMissingData_NOT_BREACHING,
+		},
+	)
+	c.output("AuditAlarm", alarm.AlarmArn())
+
+	return c
+}
+
+// Enables OpenTelemetry tracing, spans are exported via OTLP/HTTP to the
+// collector endpoint. The collector is usually deployed as Lambda layer
+// (e.g. AWS Distro for OpenTelemetry), its ARN is passed as optional layers.
+// The endpoint defaults to the layer's collector http://localhost:4318.
+func (c *Gateway) OpenTelemetry(endpoint string, layers ...string) *Gateway {
+	if endpoint == "" {
+		endpoint = "http://localhost:4318"
+	}
+
+	c.env[envvar.Otel] = jsii.String(endpoint)
+	c.env[envvar.Server] = jsii.String(servername(c.f))
+	// layers are shared by functions of all versions
+	var refs []awslambda.ILayerVersion
+	c.hooks = append(c.hooks, func(f awslambda.Function) {
+		if c.dockerfile != "" && len(layers) > 0 {
+			panic("container image does not support layers, bundle the collector into the image")
+		}
+		if refs == nil {
+			for i, arn := range layers {
+				refs = append(refs,
+					awslambda.LayerVersion_FromLayerVersionArn(c.stack, jsii.Sprintf("Otel%d", i), jsii.String(arn)),
+				)
+			}
+		}
+		f.AddLayers(refs...)
+	})
+
+	return c
+}
+
+// Attaches the server to VPC, so that tools reach private resources (e.g.
+// RDS, ElastiCache, internal APIs). The VPC is looked up by id, the server
+// is placed into given subnets (private subnets with egress if omitted) and
+// security groups (a new one is created if omitted).
+func (c *Gateway) InVpc(vpcId string, subnets []string, securityGroups []string) *Gateway {
+	c.vpc = awsec2.Vpc_FromLookup(c.stack, jsii.String("Vpc"),
+		&awsec2.VpcLookupOptions{VpcId: jsii.String(vpcId)},
+	)
+
+	c.subnets = &awsec2.SubnetSelection{SubnetType: awsec2.SubnetType_PRIVATE_WITH_EGRESS}
+	if len(subnets) > 0 {
+		seq := make([]awsec2.ISubnet, len(subnets))
+		for i, id := range subnets {
+			seq[i] = awsec2.Subnet_FromSubnetId(c.stack, jsii.Sprintf("Subnet%d", i), jsii.String(id))
+		}
+		c.subnets = &awsec2.SubnetSelection{Subnets: &seq}
+	}
+
+	c.securityGroups = make([]awsec2.ISecurityGroup, len(securityGroups))
+	for i, id := range securityGroups {
+		c.securityGroups[i] = awsec2.SecurityGroup_FromSecurityGroupId(c.stack, jsii.Sprintf("SecurityGroup%d", i), jsii.String(id), nil)
+	}
+
+	return c
+}
+
+// Creates VPC endpoints for AWS services used by the server, so that the
+// traffic bypasses NAT. DynamoDB and S3 use gateway endpoints, other services
+// (e.g. secretsmanager, ssm, bedrock-runtime) use interface endpoints. The
+// server must be attached to VPC first.
+func (c *Gateway) VpcEndpoints(services ...string) *Gateway {
+	if c.vpc == nil {
+		panic("vpc endpoints require the server attached to vpc, use InVpc")
+	}
+
+	for _, service := range services {
+		id := jsii.String("Endpoint-" + service)
+
+		switch service {
+		case "dynamodb":
+			c.vpc.AddGatewayEndpoint(id,
+				&awsec2.GatewayVpcEndpointOptions{
+					Service: awsec2.GatewayVpcEndpointAwsService_DYNAMODB(),
+					Subnets: &[]*awsec2.SubnetSelection{c.subnets},
+				},
+			)
+		case "s3":
+			c.vpc.AddGatewayEndpoint(id,
+				&awsec2.GatewayVpcEndpointOptions{
+					Service: awsec2.GatewayVpcEndpointAwsService_S3(),
+					Subnets: &[]*awsec2.SubnetSelection{c.subnets},
+				},
+			)
+		default:
+			c.vpc.AddInterfaceEndpoint(id,
+				&awsec2.InterfaceVpcEndpointOptions{
+					Service: awsec2.NewInterfaceVpcEndpointAwsService(jsii.String(service), nil, nil, nil),
+					Subnets: c.subnets,
+				},
+			)
+		}
+	}
+
+	return c
+}
+
+// Creates Aurora Serverless v2 (PostgreSQL) cluster with the database for
+// tools. The cluster is accessed via RDS Data API, the server is granted
+// access, coordinates of the database are injected into the environment
+// (see pkg/store). The cluster is placed into VPC of the server, it scales
+// to zero when idle.
+func (c *Gateway) WithAurora(database string) *Gateway {
+	if c.vpc == nil {
+		panic("aurora cluster requires vpc, use InVpc")
+	}
+
+	cluster := awsrds.NewDatabaseCluster(c.stack, jsii.String("Database"),
+		&awsrds.DatabaseClusterProps{
+			Engine: awsrds.DatabaseClusterEngine_AuroraPostgres(
+				&awsrds.AuroraPostgresClusterEngineProps{Version: awsrds.AuroraPostgresEngineVersion_VER_16_8()},
+			),
+			Writer:                  awsrds.ClusterInstance_ServerlessV2(jsii.String("writer"), nil),
+			ServerlessV2MinCapacity: jsii.Number(0),
+			ServerlessV2MaxCapacity: jsii.Number(4),
+			DefaultDatabaseName:     jsii.String(database),
+			EnableDataApi:           jsii.Bool(true),
+			StorageEncrypted:        jsii.Bool(true),
+			Vpc:                     c.vpc,
+			VpcSubnets:              c.subnets,
+			RemovalPolicy:           awscdk.RemovalPolicy_SNAPSHOT,
+		},
+	)
+
+	c.env[envvar.DatabaseCluster] = cluster.ClusterArn()
+	c.env[envvar.DatabaseSecret] = cluster.Secret().SecretArn()
+	c.env[envvar.DatabaseName] = jsii.String(database)
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		cluster.GrantDataApiAccess(f)
+	})
+
+	return c
+}
+
+// Attaches existing Aurora cluster with enabled RDS Data API. The server is
+// granted access to the cluster and its secret (see pkg/store).
+func (c *Gateway) AttachAurora(clusterArn, secretArn, database string) *Gateway {
+	c.env[envvar.DatabaseCluster] = jsii.String(clusterArn)
+	c.env[envvar.DatabaseSecret] = jsii.String(secretArn)
+	c.env[envvar.DatabaseName] = jsii.String(database)
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		f.GrantPrincipal().AddToPrincipalPolicy(
+			awsiam.NewPolicyStatement(
+				&awsiam.PolicyStatementProps{
+					Actions: jsii.Strings(
+						"rds-data:ExecuteStatement",
+						"rds-data:BatchExecuteStatement",
+						"rds-data:BeginTransaction",
+						"rds-data:CommitTransaction",
+						"rds-data:RollbackTransaction",
+					),
+					Resources: jsii.Strings(clusterArn),
+				},
+			),
+		)
+		f.GrantPrincipal().AddToPrincipalPolicy(
+			awsiam.NewPolicyStatement(
+				&awsiam.PolicyStatementProps{
+					Actions:   jsii.Strings("secretsmanager:GetSecretValue", "secretsmanager:DescribeSecret"),
+					Resources: jsii.Strings(secretArn),
+				},
+			),
+		)
+	})
+
+	return c
+}
+
+// Creates DynamoDB table for tools, the server is granted read/write access.
+// The table is addressed by name from tools using pkg/store
+// (store.TableFromEnv[T](ctx, name)). Unless props defines it otherwise, the
+// table uses "id" as partition key and on-demand billing, it is retained on
+// stack removal.
+func (c *Gateway) WithTable(name string, props *awsdynamodb.TableProps) *Gateway {
+	if props == nil {
+		props = &awsdynamodb.TableProps{}
+	}
+	if props.PartitionKey == nil {
+		props.PartitionKey = &awsdynamodb.Attribute{
+			Name: jsii.String("id"),
+			Type: awsdynamodb.AttributeType_STRING,
+		}
+	}
+	if props.BillingMode == "" {
+		props.BillingMode = awsdynamodb.BillingMode_PAY_PER_REQUEST
+	}
+
+	keys := *props.PartitionKey.Name
+	if props.SortKey != nil {
+		keys += "," + *props.SortKey.Name
+	}
+
+	table := awsdynamodb.NewTable(c.stack, jsii.String("Table-"+name), props)
+
+	env := envvar.Table + envResourceName(name)
+	c.env[env] = table.TableName()
+	c.env[env+"_KEYS"] = jsii.String(keys)
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		table.GrantReadWriteData(f)
+	})
+
+	return c
+}
+
+// Creates S3 bucket for files exchanged by tools, the server is granted
+// read/write access. Tools address the bucket by name using pkg/store
+// (store.BucketFromEnv(ctx, name)) and issue presigned URLs for upload and
+// download. Unless props defines it otherwise, the bucket blocks public
+// access, enforces SSL and is retained on stack removal.
+func (c *Gateway) WithBucket(name string, props *awss3.BucketProps) *Gateway {
+	if props == nil {
+		props = &awss3.BucketProps{}
+	}
+	if props.BlockPublicAccess == nil {
+		props.BlockPublicAccess = awss3.BlockPublicAccess_BLOCK_ALL()
+	}
+	if props.EnforceSSL == nil {
+		props.EnforceSSL = jsii.Bool(true)
+	}
+
+	bucket := awss3.NewBucket(c.stack, jsii.String("Bucket-"+name), props)
+
+	c.env[envvar.Bucket+envResourceName(name)] = bucket.BucketName()
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		bucket.GrantReadWrite(f, nil)
+	})
+
+	return c
+}
+
+// Grants the server invocation of the lambda function, e.g. tool deployed by
+// cloudmcp.NewFunction. Composite tools call it by name using pkg/fanout,
+// bypassing API Gateway.
+func (c *Gateway) WithFunction(name string, f awslambda.IFunction) *Gateway {
+	c.env[envvar.Function+envResourceName(name)] = f.FunctionName()
+	c.grants = append(c.grants, func(g awsiam.IGrantable) {
+		f.GrantInvoke(g)
+	})
+
+	return c
+}
+
+// Propagates allowed fields of API Gateway request context into `_meta` of
+// MCP requests, tools read them using pkg/reqctx. Fields are "sourceIp",
+// "userAgent", "stage", "requestId", "domainName" and authorizer claims
+// "claims.{name}" or "claims.*" for all of them.
+//
+//	RequestContext("sourceIp", "userAgent", "claims.email")
+func (c *Gateway) RequestContext(fields ...string) *Gateway {
+	for _, field := range fields {
+		switch {
+		case field == "sourceIp", field == "userAgent", field == "stage",
+			field == "requestId", field == "domainName":
+		case strings.HasPrefix(field, "claims.") && len(field) > len("claims."):
+		default:
+			panic("unknown request context field " + field)
+		}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.RequestContext] = jsii.String(string(data))
+	return c
+}
+
+// BasePath normalizes paths of requests, see gateway.BasePath
+type BasePath struct {
+	Strip string `json:"strip,omitempty"`
+	Stage bool   `json:"stage,omitempty"`
+	Mount string `json:"mount,omitempty"`
+}
+
+// Normalizes paths of requests before routing. Custom domain base path
+// mappings (Strip) and non-default stages of HTTP API (Stage) prefix paths
+// of requests, the gateway strips them, optionally mounting the server at
+// the given path (Mount).
+//
+//	BasePath(cloudmcp.BasePath{Strip: "/v1", Stage: true})
+func (c *Gateway) BasePath(spec BasePath) *Gateway {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.BasePath] = jsii.String(string(data))
+	return c
+}
+
+// version of the server deployed as independent function
+type serverVersion struct {
+	name string
+	f    any
+}
+
+// Mounts the server at the path of the version, e.g. /v1/helloworld. Other
+// versions are deployed with WithVersion.
+func (c *Gateway) Version(name string) *Gateway {
+	c.version = versionName(name)
+	return c
+}
+
+// Deploys the version of the server constructed by the factory (Factory or
+// FactoryWithConfig), e.g. WithVersion("v2", v2.HelloWorld) is served at
+// /v2/helloworld. Versions are independent functions sharing the domain,
+// authorizers, configuration and permissions of the gateway, so that
+// breaking changes of tools roll out without breaking existing agents.
+// Canary deployment, keep warm, WebSocket and edge authentication apply
+// only to the primary version.
+func (c *Gateway) WithVersion(name string, f any) *Gateway {
+	switch f.(type) {
+	case Factory, FactoryWithConfig:
+	default:
+		panic(fmt.Errorf("version %s requires Factory or FactoryWithConfig, got %T", name, f))
+	}
+
+	name = versionName(name)
+	if name == c.version {
+		panic(fmt.Errorf("version %s is the primary version", name))
+	}
+	for _, v := range c.versions {
+		if v.name == name {
+			panic(fmt.Errorf("version %s is already defined", name))
+		}
+	}
+
+	c.versions = append(c.versions, serverVersion{name: name, f: f})
+	return c
+}
+
+// versions are path segments, e.g. v1, v2, 2025-10
+func versionName(name string) string {
+	if name == "" {
+		panic("version must not be empty")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			panic(fmt.Errorf("invalid version %s, use lower case letters, digits, '-' and '.'", name))
+		}
+	}
+	return name
+}
+
+// Cancels tool calls before the hard timeout, replying with JSON-RPC timeout
+// error (mcperr.CodeTimeout) carrying the last progress of the call instead
+// of 502/504 of API Gateway. The soft deadline is the deadline of the lambda
+// or the budget of the call (e.g. 29 seconds integration timeout of API
+// Gateway), whichever comes first, minus the margin reserved to reply.
+//
+//	Deadline(29*time.Second, time.Second)
+func (c *Gateway) Deadline(budget, margin time.Duration) *Gateway {
+	config := struct {
+		Budget float64 `json:"budget,omitempty"`
+		Margin float64 `json:"margin,omitempty"`
+	}{
+		Budget: budget.Seconds(),
+		Margin: margin.Seconds(),
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.Deadline] = jsii.String(string(data))
+	return c
+}
+
+// Samples the fraction of invocations (e.g. 0.01) for profiling. CPU profile
+// of sampled invocation is written to S3 bucket (output ProfilesBucket),
+// the split of the latency between the gateway and the handler is logged
+// and attached as metadata of the profile. Profiles are merged with
+// `go tool pprof` and deleted after a week.
+func (c *Gateway) Profiling(rate float64) *Gateway {
+	if rate <= 0 || rate > 1 {
+		panic("profiling rate must be within (0, 1]")
+	}
+
+	bucket := awss3.NewBucket(c.stack, jsii.String("Profiles"),
+		&awss3.BucketProps{
+			BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+			EnforceSSL:        jsii.Bool(true),
+			RemovalPolicy:     awscdk.RemovalPolicy_DESTROY,
+			AutoDeleteObjects: jsii.Bool(true),
+			LifecycleRules: &[]*awss3.LifecycleRule{
+				{
+					Expiration: awscdk.Duration_Days(jsii.Number(7)),
+				},
+			},
+		},
+	)
+
+	data, err := json.Marshal(map[string]float64{"rate": rate})
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.Profiling] = jsii.String(string(data))
+	c.env[envvar.ProfilingBucket] = bucket.BucketName()
+	c.env[envvar.Server] = jsii.String(servername(c.f))
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		bucket.GrantPut(f, nil)
+	})
+	c.output("ProfilesBucket", bucket.BucketName())
+
+	return c
+}
+
+// Enables upload of files for tools. Clients request presigned POST form
+// using `POST {endpoint}/uploads` with body {"name": "report.pdf",
+// "contentType": "application/pdf"}, upload the file directly to S3 and
+// pass the key of the object to the tool, which reads it using pkg/upload.
+// Uploads are bound to the caller and the session, files up to maxSize
+// bytes are accepted, the form expires after ttl. Uploaded files are
+// deleted after a day.
+func (c *Gateway) FileUploads(maxSize int64, ttl time.Duration) *Gateway {
+	bucket := awss3.NewBucket(c.stack, jsii.String("Uploads"),
+		&awss3.BucketProps{
+			BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+			EnforceSSL:        jsii.Bool(true),
+			RemovalPolicy:     awscdk.RemovalPolicy_DESTROY,
+			AutoDeleteObjects: jsii.Bool(true),
+			LifecycleRules: &[]*awss3.LifecycleRule{
+				{
+					Prefix:     jsii.String("uploads/"),
+					Expiration: awscdk.Duration_Days(jsii.Number(1)),
+				},
+			},
+		},
+	)
+
+	config := struct {
+		MaxSize int64 `json:"maxSize"`
+		TTL     int   `json:"ttl"`
+	}{
+		MaxSize: maxSize,
+		TTL:     int(ttl.Seconds()),
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.Uploads] = jsii.String(string(data))
+	c.env[envvar.UploadBucket] = bucket.BucketName()
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		bucket.GrantReadWrite(f, nil)
+	})
+	c.output("UploadBucket", bucket.BucketName())
+
+	return c
+}
+
+// name of the table or bucket in environment variables, e.g. "user-orders"
+// is USER_ORDERS
+func envResourceName(name string) string {
+	return strings.Map(
+		func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+				return r
+			default:
+				return '_'
+			}
+		},
+		name,
+	)
+}
+
+// Configures reserved concurrency of the server. It caps the number of
+// concurrent executions, protecting downstream systems of tools from
+// runaway fan-out. Calls above the limit are throttled by Lambda.
+func (c *Gateway) ReservedConcurrency(n int) *Gateway {
+	c.concurrency = n
+	return c
+}
+
+// DeadLetterQueue captures failed asynchronous invocations of the server
+// (e.g. scheduled warm-up or events), API Gateway invokes it synchronously.
+type DeadLetterQueue struct {
+	// Retention of failed invocations, defaults to 14 days
+	Retention time.Duration
+
+	// ARN of SNS topic notified when failed invocations are in the queue
+	AlarmTopic string
+
+	// Path to the package main of the redrive lambda within the module of
+	// the server (e.g. cmd/redrive), the lambda consumes the queue.
+	Handler string
+}
+
+// Configures SQS dead-letter queue of the server. The queue is optionally
+// watched by the alarm and consumed by the redrive lambda.
+func (c *Gateway) DeadLetterQueue(spec DeadLetterQueue) *Gateway {
+	if spec.Retention == 0 {
+		spec.Retention = 14 * 24 * time.Hour
+	}
+
+	queue := awssqs.NewQueue(c.stack, jsii.String("DeadLetters"),
+		&awssqs.QueueProps{
+			RetentionPeriod: awscdk.Duration_Seconds(jsii.Number(spec.Retention.Seconds())),
+			Encryption:      awssqs.QueueEncryption_SQS_MANAGED,
+			EnforceSSL:      jsii.Bool(true),
+		},
+	)
+	c.deadletters = queue
+
+	if spec.AlarmTopic != "" {
+		alarm := awscloudwatch.NewAlarm(c.stack, jsii.String("DeadLettersAlarm"),
+			&awscloudwatch.AlarmProps{
+				Metric: queue.MetricApproximateNumberOfMessagesVisible(
+					&awscloudwatch.MetricOptions{Period: awscdk.Duration_Minutes(jsii.Number(5))},
+				),
+				Threshold:          jsii.Number(1),
+				EvaluationPeriods:  jsii.Number(1),
+				ComparisonOperator: awscloudwatch.ComparisonOperator_GREATER_THAN_OR_EQUAL_TO_THRESHOLD,
+				TreatMissingData:   awscloudwatch.TreatMissingData_NOT_BREACHING,
+			},
+		)
+		alarm.AddAlarmAction(
+			awscloudwatchactions.NewSnsAction(
+				awssns.Topic_FromTopicArn(c.stack, jsii.String("DeadLettersTopic"), jsii.String(spec.AlarmTopic)),
+			),
+		)
+	}
+
+	if spec.Handler != "" {
+		module, _ := c.sourcecode(c.f)
+		f := scud.NewFunctionGo(c.stack, jsii.String("Redrive"),
+			&scud.FunctionGoProps{
+				SourceCodeModule: module,
+				SourceCodeLambda: spec.Handler,
+				FunctionProps: &awslambda.FunctionProps{
+					Role:     c.role,
+					LogGroup: c.loggroup,
+					Timeout:  awscdk.Duration_Minutes(jsii.Number(1)),
+				},
+			},
+		)
+		f.AddEventSource(
+			awslambdaeventsources.NewSqsEventSource(queue,
+				&awslambdaeventsources.SqsEventSourceProps{
+					BatchSize:               jsii.Number(10),
+					ReportBatchItemFailures: jsii.Bool(true),
+				},
+			),
+		)
+	}
+
+	return c
+}
+
+// Configures health endpoint `GET {endpoint}/healthz` for uptime monitors and
+// Route53 health checks. The endpoint is public, the gateway performs MCP
+// `initialize` round-trip against the server and checks reachability of the
+// sessions table. It responds 200 or 503 with the status of each check.
+func (c *Gateway) HealthCheck() *Gateway {
+	c.env[envvar.Health] = jsii.String("true")
+	c.health = true
+
+	return c
+}
+
+func (c *Gateway) buildHealthCheck(server *Server) {
+	// the private api routes any path of the server to the function
+	if c.private != nil {
+		return
+	}
+
+	if c.restapi != nil {
+		c.routeRestApiHealth(server)
+		return
+	}
+
+	c.gateway.RestAPI.AddRoutes(
+		&apigw2.AddRoutesOptions{
+			Path:    jsii.String(server.uri + "/healthz"),
+			Methods: &[]apigw2.HttpMethod{apigw2.HttpMethod_GET},
+			Integration: integrations.NewHttpLambdaIntegration(jsii.String("Health"), server.Function,
+				&integrations.HttpLambdaIntegrationProps{
+					PayloadFormatVersion: apigw2.PayloadFormatVersion_VERSION_1_0(),
+				},
+			),
+		},
+	)
+}
+
+// Configures warm-up of the server to avoid cold starts. EventBridge rule
+// invokes the server with JSON-RPC `ping` at the given rate (rounded to
+// minutes), keeping an execution environment warm. Optional hours [from, to)
+// in UTC limit warm-up to business hours on weekdays (e.g. 8, 18).
+func (c *Gateway) KeepWarm(rate time.Duration, hours ...int) *Gateway {
+	minutes := max(int(math.Round(rate.Minutes())), 1)
+
+	schedule := awsevents.Schedule_Rate(awscdk.Duration_Minutes(jsii.Number(minutes)))
+	if len(hours) == 2 {
+		schedule = awsevents.Schedule_Expression(
+			jsii.Sprintf("cron(0/%d %d-%d ? * MON-FRI *)", minutes, hours[0], hours[1]-1),
+		)
+	}
+
+	c.keepwarm = func(f awslambda.IFunction) {
+		ping := map[string]any{
+			"httpMethod": "POST",
+			"path":       "/",
+			"headers": map[string]string{
+				"Content-Type": "application/json",
+				"Accept":       "application/json, text/event-stream",
+			},
+			"body": `{"jsonrpc":"2.0","id":"keepwarm","method":"ping"}`,
+		}
+
+		rule := awsevents.NewRule(c.stack, jsii.String("KeepWarm"),
+			&awsevents.RuleProps{
+				Schedule: schedule,
+				Targets: &[]awsevents.IRuleTarget{
+					awseventstargets.NewLambdaFunction(f,
+						&awseventstargets.LambdaFunctionProps{
+							Event:         awsevents.RuleTargetInput_FromObject(ping),
+							RetryAttempts: jsii.Number(0),
+						},
+					),
+				},
+			},
+		)
+
+		// the alias of blue/green deployment is created by custom resource
+		rule.Node().AddDependency(f)
+	}
+
+	return c
+}
+
+// Configures canary deployment of the server. Traffic is shifted to a new
+// version of the server via Lambda alias, the given percent of traffic is
+// shifted first, the rest after the interval. Pre/post-traffic hooks check
+// that the server responds to MCP `initialize` request, the deployment is
+// rolled back automatically if the hooks fail or the errors alarm fires.
+func (c *Gateway) Canary(percent float64, interval time.Duration) *Gateway {
+	if c.deploy != nil {
+		panic("canary and blue/green deployments are exclusive")
+	}
+
+	c.deploy = func(f awslambda.Function) {
+		alias := awslambda.NewAlias(c.stack, jsii.String("Live"),
+			&awslambda.AliasProps{
+				AliasName: jsii.String("live"),
+				Version:   f.CurrentVersion(),
+			},
+		)
+
+		c.routeToAlias(f, alias)
+		c.alias = alias
+
+		alarm := awscloudwatch.NewAlarm(c.stack, jsii.String("CanaryErrors"),
+			&awscloudwatch.AlarmProps{
+				Metric:             alias.MetricErrors(&awscloudwatch.MetricOptions{Period: awscdk.Duration_Minutes(jsii.Number(1))}),
+				Threshold:          jsii.Number(1),
+				EvaluationPeriods:  jsii.Number(1),
+				ComparisonOperator: awscloudwatch.ComparisonOperator_GREATER_THAN_OR_EQUAL_TO_THRESHOLD,
+				TreatMissingData:   awscloudwatch.TreatMissingData_NOT_BREACHING,
+			},
+		)
+
+		config := awscodedeploy.NewLambdaDeploymentConfig(c.stack, jsii.String("CanaryConfig"),
+			&awscodedeploy.LambdaDeploymentConfigProps{
+				TrafficRouting: awscodedeploy.NewTimeBasedCanaryTrafficRouting(
+					&awscodedeploy.TimeBasedCanaryTrafficRoutingProps{
+						Percentage: jsii.Number(percent),
+						Interval:   awscdk.Duration_Minutes(jsii.Number(math.Ceil(interval.Minutes()))),
+					},
+				),
+			},
+		)
+
+		awscodedeploy.NewLambdaDeploymentGroup(c.stack, jsii.String("Canary"),
+			&awscodedeploy.LambdaDeploymentGroupProps{
+				Alias:            alias,
+				DeploymentConfig: config,
+				Alarms:           &[]awscloudwatch.IAlarm{alarm},
+				PreHook:          c.canaryHook("CanaryPreHook", f.CurrentVersion().FunctionArn()),
+				PostHook:         c.canaryHook("CanaryPostHook", alias.FunctionArn()),
+			},
+		)
+	}
+
+	return c
+}
+
+// Configures blue/green deployment of the server. The gateway routes the
+// traffic to alias "live" of the function, which is created once and not
+// updated by deployments. Each deployment publishes the green version of
+// the server (output GreenVersion), the traffic is shifted gradually by the
+// operator with `cloudmcp traffic` or pkg/bluegreen, the green version is
+// promoted to blue at 100 percent.
+func (c *Gateway) BlueGreen() *Gateway {
+	if c.deploy != nil {
+		panic("canary and blue/green deployments are exclusive")
+	}
+
+	c.deploy = func(f awslambda.Function) {
+		green := f.CurrentVersion()
+
+		// the alias is owned by the operator, CloudFormation does not reset
+		// its routing on deployment.
+		live := customresources.NewAwsCustomResource(c.stack, jsii.String("LiveAlias"),
+			&customresources.AwsCustomResourceProps{
+				OnCreate: &customresources.AwsSdkCall{
+					Service: jsii.String("Lambda"),
+					Action:  jsii.String("createAlias"),
+					Parameters: map[string]any{
+						"FunctionName":    f.FunctionName(),
+						"Name":            jsii.String(bluegreen.Alias),
+						"FunctionVersion": green.Version(),
+					},
+					PhysicalResourceId: customresources.PhysicalResourceId_Of(jsii.String(bluegreen.Alias)),
+				},
+				OnDelete: &customresources.AwsSdkCall{
+					Service: jsii.String("Lambda"),
+					Action:  jsii.String("deleteAlias"),
+					Parameters: map[string]any{
+						"FunctionName": f.FunctionName(),
+						"Name":         jsii.String(bluegreen.Alias),
+					},
+				},
+				Policy: customresources.AwsCustomResourcePolicy_FromSdkCalls(
+					&customresources.SdkCallsPolicyOptions{
+						Resources: &[]*string{f.FunctionArn(), jsii.String(*f.FunctionArn() + ":*")},
+					},
+				),
+				InstallLatestAwsSdk: jsii.Bool(false),
+				LogGroup:            c.loggroup,
+				Role:                c.role,
+			},
+		)
+
+		alias := awslambda.Function_FromFunctionAttributes(c.stack, jsii.String("Live"),
+			&awslambda.FunctionAttributes{
+				FunctionArn:     jsii.String(*f.FunctionArn() + ":" + bluegreen.Alias),
+				SameEnvironment: jsii.Bool(true),
+			},
+		)
+		alias.Node().AddDependency(live)
+
+		c.routeToAlias(f, alias)
+		c.alias = alias
+
+		c.output("ServerFunction", f.FunctionName())
+		c.output("GreenVersion", green.Version())
+	}
+
+	return c
+}
+
+// Lambda function checking the target before/after traffic shift
+func (c *Gateway) canaryHook(id string, target *string) awslambda.Function {
+	f := scud.NewFunctionGo(c.stack, jsii.String(id),
+		&scud.FunctionGoProps{
+			SourceCodeModule: "github.com/fogfish/cloudmcp",
+			SourceCodeLambda: "internal/cmd/canary",
+			FunctionProps: &awslambda.FunctionProps{
+				Role:     c.role,
+				LogGroup: c.loggroup,
+				Timeout:  awscdk.Duration_Minutes(jsii.Number(1)),
+				Environment: &map[string]*string{
+					envvar.CanaryTarget: target,
+				},
+			},
+		},
+	)
+
+	f.AddToRolePolicy(
+		awsiam.NewPolicyStatement(
+			&awsiam.PolicyStatementProps{
+				Actions:   jsii.Strings("lambda:InvokeFunction"),
+				Resources: &[]*string{target},
+			},
+		),
+	)
+
+	return f
+}
+
+// Authorizers integrate the function itself with API Gateway, the integration
+// is re-pointed to the alias so that traffic shifting is applied to requests.
+func (c *Gateway) routeToAlias(f awslambda.Function, alias awslambda.IFunction) {
+	arn := awscdk.Stack_Of(f).Resolve(f.FunctionArn())
+
+	for _, node := range *c.gateway.RestAPI.Node().FindAll(constructs.ConstructOrder_PREORDER) {
+		integration, ok := node.(apigw2.CfnIntegration)
+		if !ok || !reflect.DeepEqual(awscdk.Stack_Of(f).Resolve(integration.IntegrationUri()), arn) {
+			continue
+		}
+
+		integration.AddPropertyOverride(jsii.String("IntegrationUri"), alias.FunctionArn())
+	}
+
+	alias.AddPermission(jsii.String("Gateway"),
+		&awslambda.Permission{
+			Principal: awsiam.NewServicePrincipal(jsii.String("apigateway.amazonaws.com"), nil),
+			SourceArn: c.stack.FormatArn(
+				&awscdk.ArnComponents{
+					Service:      jsii.String("execute-api"),
+					Resource:     c.gateway.RestAPI.ApiId(),
+					ResourceName: jsii.String("*/*"),
+				},
+			),
+		},
+	)
+}
+
+// Attaches Amazon Bedrock Knowledge Base to the server. The server is granted
+// retrieval from the knowledge base, its id is injected into the environment
+// so that tool "retrieve" is registered without handler code (see
+// contrib/knowledgebase).
+func (c *Gateway) WithKnowledgeBase(id string) *Gateway {
+	c.env[envvar.KnowledgeBase] = jsii.String(id)
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		f.GrantPrincipal().AddToPrincipalPolicy(
+			awsiam.NewPolicyStatement(
+				&awsiam.PolicyStatementProps{
+					Actions: jsii.Strings("bedrock:Retrieve"),
+					Resources: jsii.Strings(
+						*c.stack.FormatArn(&awscdk.ArnComponents{
+							Service:      jsii.String("bedrock"),
+							Resource:     jsii.String("knowledge-base"),
+							ResourceName: jsii.String(id),
+							ArnFormat:    awscdk.ArnFormat_SLASH_RESOURCE_NAME,
+						}),
+					),
+				},
+			),
+		)
+	})
+
+	return c
+}
+
+// Configures server-side sampling using Amazon Bedrock. The stateless
+// transport cannot deliver `sampling/createMessage` requests to clients,
+// the gateway fulfills them using the model (model id or cross-region
+// inference profile, e.g. eu.anthropic.claude-sonnet-4-20250514-v1:0).
+func (c *Gateway) Sampling(model string) *Gateway {
+	c.env[envvar.Sampling] = jsii.String(model)
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		// inference profile routes requests to foundation model in other regions
+		resources := []*string{
+			c.stack.FormatArn(&awscdk.ArnComponents{
+				Service:      jsii.String("bedrock"),
+				Region:       jsii.String("*"),
+				Account:      jsii.String(""),
+				Resource:     jsii.String("foundation-model"),
+				ResourceName: jsii.String(foundationModel(model)),
+				ArnFormat:    awscdk.ArnFormat_SLASH_RESOURCE_NAME,
+			}),
+			c.stack.FormatArn(&awscdk.ArnComponents{
+				Service:      jsii.String("bedrock"),
+				Resource:     jsii.String("inference-profile"),
+				ResourceName: jsii.String(model),
+				ArnFormat:    awscdk.ArnFormat_SLASH_RESOURCE_NAME,
+			}),
+		}
+
+		f.GrantPrincipal().AddToPrincipalPolicy(
+			awsiam.NewPolicyStatement(
+				&awsiam.PolicyStatementProps{
+					Actions:   jsii.Strings("bedrock:InvokeModel"),
+					Resources: &resources,
+				},
+			),
+		)
+	})
+
+	return c
+}
+
+// foundation model of cross-region inference profile (e.g. eu.anthropic...)
+func foundationModel(model string) string {
+	prefix, base, _ := strings.Cut(model, ".")
+	switch prefix {
+	case "us", "us-gov", "eu", "apac", "jp", "au", "ca", "global":
+		return base
+	default:
+		return model
+	}
+}
+
+// Configures elicitation over the stateless transport. Pending elicitation
+// requests are persisted in the sessions table, the tool call fails with
+// the error mcperr.CodeElicitationPending carrying the request. Clients
+// that accept event stream receive the request as if it was sent by
+// the server. The client answers the request and retries the call, the
+// answer is replayed to the tool.
+func (c *Gateway) Elicitation() *Gateway {
+	c.sessionTable()
+	c.env[envvar.Elicitation] = jsii.String("true")
+	return c
+}
+
+// Configures progress notifications of long running tools. The response is
+// buffered by API Gateway, the progress sent by tools is persisted in the
+// sessions table instead. Clients poll it using the route
+// `GET {endpoint}/progress?token={progressToken}`.
+func (c *Gateway) Progress() *Gateway {
+	c.sessionTable()
+	c.env[envvar.Progress] = jsii.String("true")
+	return c
+}
+
+// DynamoDB table keeping state of MCP sessions, it is created once
+func (c *Gateway) sessionTable() awsdynamodb.Table {
+	if c.sessions != nil {
+		return c.sessions
+	}
+
+	c.sessions = awsdynamodb.NewTable(c.stack, jsii.String("Sessions"),
+		&awsdynamodb.TableProps{
+			PartitionKey: &awsdynamodb.Attribute{
+				Name: jsii.String("key"),
+				Type: awsdynamodb.AttributeType_STRING,
+			},
+			BillingMode:         awsdynamodb.BillingMode_PAY_PER_REQUEST,
+			TimeToLiveAttribute: jsii.String("ttl"),
+			RemovalPolicy:       awscdk.RemovalPolicy_DESTROY,
+		},
+	)
+
+	c.env[envvar.SessionTable] = c.sessions.TableName()
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		c.sessions.GrantReadWriteData(f)
+	})
+
+	return c.sessions
+}
+
+// Configures resumability of streams. Messages sent to clients that accept
+// event stream are recorded in DynamoDB table and identified by event id,
+// the client reconnects after network drop using Last-Event-ID header and
+// replays missed messages. Events are kept for an hour.
+func (c *Gateway) ResumableStreams() *Gateway {
+	table := awsdynamodb.NewTable(c.stack, jsii.String("Events"),
+		&awsdynamodb.TableProps{
+			PartitionKey: &awsdynamodb.Attribute{
+				Name: jsii.String("session"),
+				Type: awsdynamodb.AttributeType_STRING,
+			},
+			SortKey: &awsdynamodb.Attribute{
+				Name: jsii.String("event"),
+				Type: awsdynamodb.AttributeType_STRING,
+			},
+			BillingMode:         awsdynamodb.BillingMode_PAY_PER_REQUEST,
+			TimeToLiveAttribute: jsii.String("ttl"),
+			RemovalPolicy:       awscdk.RemovalPolicy_DESTROY,
+		},
+	)
+
+	c.env[envvar.EventTable] = table.TableName()
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		table.GrantReadWriteData(f)
+	})
+
+	return c
+}
+
+// Applies middlewares to every tool call inside the lambda runtime, e.g.
+// logging, validation or caching. Middlewares must be exported top-level
+// functions (see pkg/middleware), the generated binding code of the server
+// references them by name.
+func (c *Gateway) Use(mws ...middleware.Middleware) *Gateway {
+	c.middlewares = append(c.middlewares, mws...)
+	return c
+}
+
+// Runs the server in dry-run mode, letting teams trial agent workflows
+// safely (e.g. within dedicated stage). Mutating tools return simulated
+// results instead of executing, the results are marked with `_meta.dryRun`.
+// Tools are names (or prefixes ending with "*") of mutating tools, which
+// return the default result unless the simulator is registered with
+// pkg/dryrun. Tools with registered simulators are always simulated.
+func (c *Gateway) DryRun(tools ...string) *Gateway {
+	if tools == nil {
+		tools = []string{}
+	}
+
+	spec, err := json.Marshal(tools)
+	if err != nil {
+		panic(err)
+	}
+
+	c.env[envvar.DryRun] = jsii.String(string(spec))
+	return c
+}
+
+// Configures tool discovery cache. The manifest of the server (capabilities,
+// tools and schemas) is snapshotted at build time, the gateway serves
+// `initialize` and `tools/list` from the manifest without invoking the server.
+// The manifest is shipped as S3 asset of the stack, read at the init phase.
+func (c *Gateway) CacheTools() *Gateway {
+	if _, has := c.env[envvar.Manifest]; has {
+		return c
+	}
+
+	server, err := c.newServer()
+	if err != nil {
+		panic(fmt.Errorf("failed to create server %s: %w", servername(c.f), err))
+	}
+
+	manifest, err := gateway.Snapshot(context.Background(), server)
+	if err != nil {
+		panic(fmt.Errorf("failed to snapshot tools of %s: %w", servername(c.f), err))
+	}
+
+	data, err := gateway.EncodeManifest(manifest)
+	if err != nil {
+		panic(err)
+	}
+
+	dir, err := os.MkdirTemp("", "cloudmcp-manifest-")
+	if err != nil {
+		panic(err)
+	}
+	file := filepath.Join(dir, "manifest.b64")
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		panic(err)
+	}
+
+	asset := awss3assets.NewAsset(c.stack, jsii.String("Manifest"),
+		&awss3assets.AssetProps{Path: jsii.String(file)},
+	)
+
+	c.env[envvar.Manifest] = asset.S3ObjectUrl()
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		asset.GrantRead(f)
+	})
+
+	return c
+}
+
+// Validates arguments of tools/call requests against input schema of tools
+// at the gateway, before the call reaches the server. Malformed calls are
+// rejected with JSON-RPC invalid params error (-32602) explaining the
+// violation. Schemas are taken from the manifest of the server (see
+// CacheTools), the manifest is cached if it is not yet.
+func (c *Gateway) ValidateInputs() *Gateway {
+	c.CacheTools()
+
+	c.env[envvar.Validate] = jsii.String("true")
+	return c
+}
+
+// Grants IAM permissions declared by tools. The server is snapshotted at
+// build time, tools declare required actions and resources with metadata
+// "cloudmcp/iam" (see contrib/awsactions), each tool contributes exactly its
+// own statement to the role of the server.
+func (c *Gateway) GrantToolActions() *Gateway {
+	server, err := c.newServer()
+	if err != nil {
+		panic(fmt.Errorf("failed to create server %s: %w", servername(c.f), err))
+	}
+
+	manifest, err := gateway.Snapshot(context.Background(), server)
+	if err != nil {
+		panic(fmt.Errorf("failed to snapshot tools of %s: %w", servername(c.f), err))
+	}
+
+	for _, tool := range manifest.Tools.Tools {
+		spec, has := tool.Meta["cloudmcp/iam"]
+		if !has {
+			continue
+		}
+
+		var grant struct {
+			Actions   []string `json:"actions"`
+			Resources []string `json:"resources"`
+		}
+		if data, err := json.Marshal(spec); err != nil || json.Unmarshal(data, &grant) != nil || len(grant.Actions) == 0 {
+			panic(fmt.Errorf("invalid permissions of tool %s", tool.Name))
+		}
+		if len(grant.Resources) == 0 {
+			grant.Resources = []string{"*"}
+		}
+
+		c.grants = append(c.grants, func(f awsiam.IGrantable) {
+			f.GrantPrincipal().AddToPrincipalPolicy(
+				awsiam.NewPolicyStatement(
+					&awsiam.PolicyStatementProps{
+						Sid:       jsii.String(sid(tool.Name)),
+						Actions:   jsii.Strings(grant.Actions...),
+						Resources: jsii.Strings(grant.Resources...),
+					},
+				),
+			)
+		})
+	}
+
+	return c
+}
+
+// statement id of the tool, IAM allows alphanumeric characters only
+func sid(name string) string {
+	return "Tool" + strings.Map(
+		func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return -1
+		},
+		name,
+	)
+}
+
+// explicit module and package of the factory
+type sourceCode struct {
+	module string
+	lambda string
+}
+
+// Configures the Go module and the package of the factory relative to the
+// module (e.g. github.com/acme/mcp and /server), when they are not
+// discovered using go tooling from the current module or workspace.
+func (c *Gateway) SourceCode(module, lambda string) *Gateway {
+	c.source = &sourceCode{
+		module: module,
+		lambda: "/" + strings.Trim(strings.ReplaceAll(lambda, `\`, "/"), "/"),
+	}
+
+	if _, dir, err := goListModule(module + c.source.lambda); err == nil {
+		linkSourceCode(module, dir)
+	}
+
+	return c
+}
+
+// Configures container image packaging of the server, for servers that
+// require native dependencies. The image is built using the Dockerfile
+// (relative to the root of the module), see ServerProps.FromImage.
+func (c *Gateway) FromImage(dockerfile string) *Gateway {
+	c.dockerfile = dockerfile
+	return c
+}
+
+// Configures WebSocket API along with REST API. The WebSocket transport is
+// bidirectional, server-initiated messages (notifications, sampling and
+// elicitation requests) are pushed to clients using pkg/websocket.
+// Connections are kept in DynamoDB table. The WebSocket API is protected
+// with AWS IAM or hashed API Key authorizers, other authorizers are not
+// supported by WebSocket API Gateway.
+func (c *Gateway) WebSocket() *Gateway {
+	c.websocket = true
+	return c
+}
+
+func (c *Gateway) buildWebSocket(f awslambda.Function) {
+	var authorizer apigw2.IWebSocketRouteAuthorizer
+	switch {
+	case c.authiam != nil:
+		authorizer = authorizers.NewWebSocketIamAuthorizer()
+	case c.authhsh != nil:
+		authorizer = authorizers.NewWebSocketLambdaAuthorizer(jsii.String("WebSocketAuthorizer"), c.authhsh.Handler,
+			&authorizers.WebSocketLambdaAuthorizerProps{
+				IdentitySource: jsii.Strings("route.request.header.Authorization"),
+			},
+		)
+	case c.authjwts != nil:
+		authorizer = authorizers.NewWebSocketLambdaAuthorizer(jsii.String("WebSocketAuthorizer"), c.authjwts.Handler,
+			&authorizers.WebSocketLambdaAuthorizerProps{
+				IdentitySource: jsii.Strings("route.request.header.Authorization"),
+			},
+		)
+	case c.authint != nil:
+		authorizer = authorizers.NewWebSocketLambdaAuthorizer(jsii.String("WebSocketAuthorizer"), c.authint.Handler,
+			&authorizers.WebSocketLambdaAuthorizerProps{
+				IdentitySource: jsii.Strings("route.request.header.Authorization"),
+			},
+		)
+	case c.authpub != nil && c.authjwt == nil:
+		authorizer = nil
+	default:
+		panic("websocket api supports only public, iam, hashed api key, jwt issuers and introspection access")
+	}
+
+	table := awsdynamodb.NewTable(c.stack, jsii.String("Connections"),
+		&awsdynamodb.TableProps{
+			PartitionKey:        &awsdynamodb.Attribute{Name: jsii.String("connection"), Type: awsdynamodb.AttributeType_STRING},
+			TimeToLiveAttribute: jsii.String("ttl"),
+			BillingMode:         awsdynamodb.BillingMode_PAY_PER_REQUEST,
+			RemovalPolicy:       awscdk.RemovalPolicy_DESTROY,
+		},
+	)
+	table.GrantReadWriteData(f)
+
+	api := apigw2.NewWebSocketApi(c.stack, jsii.String("WebSocket"),
+		&apigw2.WebSocketApiProps{
+			ConnectRouteOptions: &apigw2.WebSocketRouteOptions{
+				Integration: integrations.NewWebSocketLambdaIntegration(jsii.String("Connect"), f, nil),
+				Authorizer:  authorizer,
+			},
+			DisconnectRouteOptions: &apigw2.WebSocketRouteOptions{
+				Integration: integrations.NewWebSocketLambdaIntegration(jsii.String("Disconnect"), f, nil),
+			},
+			DefaultRouteOptions: &apigw2.WebSocketRouteOptions{
+				Integration:    integrations.NewWebSocketLambdaIntegration(jsii.String("Message"), f, nil),
+				ReturnResponse: jsii.Bool(true),
+			},
+		},
+	)
+
+	stage := apigw2.NewWebSocketStage(c.stack, jsii.String("WebSocketStage"),
+		&apigw2.WebSocketStageProps{
+			WebSocketApi: api,
+			StageName:    jsii.String("mcp"),
+			AutoDeploy:   jsii.Bool(true),
+		},
+	)
+	stage.GrantManagementApiAccess(f)
+
+	f.AddEnvironment(jsii.String(envvar.WebSocketTable), table.TableName(), nil)
+	f.AddEnvironment(jsii.String(envvar.WebSocketEndpoint), stage.CallbackUrl(), nil)
+
+	c.output("HostWebSocket", stage.Url())
+}
+
+// Configures AppSync Events API for server notifications. Tools publish
+// notifications (resource updates, log messages) to channels of namespace
+// "mcp" using pkg/notify, clients subscribe to channels via real-time
+// endpoint of the API. Subscribers are authenticated with the issuer of JWT
+// or Cognito access, or AWS IAM otherwise, the access must be configured
+// before notifications.
+func (c *Gateway) Notifications() *Gateway {
+	iam := &awsappsync.AppSyncAuthProvider{AuthorizationType: awsappsync.AppSyncAuthorizationType_IAM}
+	auth := &awsappsync.EventApiAuthConfig{
+		AuthProviders:                 &[]*awsappsync.AppSyncAuthProvider{iam},
+		ConnectionAuthModeTypes:       &[]awsappsync.AppSyncAuthorizationType{awsappsync.AppSyncAuthorizationType_IAM},
+		DefaultPublishAuthModeTypes:   &[]awsappsync.AppSyncAuthorizationType{awsappsync.AppSyncAuthorizationType_IAM},
+		DefaultSubscribeAuthModeTypes: &[]awsappsync.AppSyncAuthorizationType{awsappsync.AppSyncAuthorizationType_IAM},
+	}
+
+	if c.jwt != nil {
+		oidc := &awsappsync.AppSyncAuthProvider{
+			AuthorizationType: awsappsync.AppSyncAuthorizationType_OIDC,
+			OpenIdConnectConfig: &awsappsync.AppSyncOpenIdConnectConfig{
+				OidcProvider: jsii.String(c.jwt.issuer),
+			},
+		}
+		if len(c.jwt.audience) > 0 {
+			oidc.OpenIdConnectConfig.ClientId = jsii.String(strings.Join(c.jwt.audience, "|"))
+		}
+
+		auth.AuthProviders = &[]*awsappsync.AppSyncAuthProvider{iam, oidc}
+		auth.ConnectionAuthModeTypes = &[]awsappsync.AppSyncAuthorizationType{awsappsync.AppSyncAuthorizationType_OIDC, awsappsync.AppSyncAuthorizationType_IAM}
+		auth.DefaultSubscribeAuthModeTypes = &[]awsappsync.AppSyncAuthorizationType{awsappsync.AppSyncAuthorizationType_OIDC, awsappsync.AppSyncAuthorizationType_IAM}
+	}
+
+	api := awsappsync.NewEventApi(c.stack, jsii.String("Notifications"),
+		&awsappsync.EventApiProps{
+			ApiName:             jsii.String(c.name()),
+			AuthorizationConfig: auth,
+		},
+	)
+	api.AddChannelNamespace(jsii.String("mcp"), nil)
+
+	c.env[envvar.NotificationsEndpoint] = api.HttpDns()
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		api.GrantPublish(f)
+	})
+
+	c.output("HostNotifications", jsii.String("wss://"+*api.RealtimeDns()+"/event/realtime"))
+
+	return c
+}
+
+func (c *Gateway) Build() {
+	if c.gateway == nil && c.private == nil && c.restapi == nil {
+		c.Hostless()
+	}
+
+	if c.private != nil && len(c.versions) > 0 {
+		panic("private api does not support versions")
+	}
+
+	if c.discovery {
+		c.env[envvar.Discovery] = jsii.String(c.discoveryDocument())
+	}
+
+	partitions := c.buildPartitions()
+	server := c.buildServer(c.f, "", c.version)
+	for _, partition := range partitions {
+		partition.GrantInvoke(server.Function)
+	}
+	// versions are not partitioned
+	delete(c.env, envvar.Partitions)
+
+	switch {
+	case c.private != nil:
+		c.buildPrivate(server.Function, server.uri)
+	case c.restapi != nil:
+		c.routeRestApi(server)
+	default:
+		c.allowAccess(server)
+	}
+
+	if c.discovery {
+		c.buildDiscovery(server)
+	}
+
+	if c.websocket {
+		c.buildWebSocket(server.Function)
+	}
+
+	if c.deploy != nil {
+		c.deploy(server.Function)
+	}
+
+	if c.keepwarm != nil {
+		var target awslambda.IFunction = server.Function
+		if c.alias != nil {
+			target = c.alias
+		}
+		c.keepwarm(target)
+	}
+
+	if c.gateway != nil {
+		c.output("Host", c.gateway.RestAPI.ApiEndpoint())
+		c.output("Endpoint", jsii.String(*c.gateway.RestAPI.ApiEndpoint()+server.uri))
+		c.manifestEndpoint(jsii.String(*c.gateway.RestAPI.ApiEndpoint() + server.uri))
+	}
+
+	if c.edge {
+		c.buildEdge(server.uri)
+	}
+
+	uris := []string{server.uri}
+	for _, v := range c.versions {
+		vserver := c.buildServer(v.f, v.name, v.name)
+		if c.restapi != nil {
+			c.routeRestApi(vserver)
+			continue
+		}
+		c.allowAccess(vserver)
+		c.output("Endpoint"+envResourceName(v.name), jsii.String(*c.gateway.RestAPI.ApiEndpoint()+vserver.uri))
+		uris = append(uris, vserver.uri)
+	}
+
+	if c.authiam != nil {
+		c.outputPolicyIAM(c.gateway.RestAPI, uris...)
+	}
+
+	if c.restapi != nil {
+		c.deployRestApi()
+	}
+
+	c.encrypt()
+
+	c.synth()
+}
+
+// synthesizes the stack and runs checks and reports of the synthesized
+// stack. The embedded stack is synthesized by the application, it does not
+// support them.
+func (c *Gateway) synth() {
+	if c.embedded {
+		if c.nag || c.cost != nil || c.terraform || c.listing != nil {
+			panic("embedded gateway does not support nag, cost report, terraform and manifest")
+		}
+		return
+	}
+
+	var paths map[string]string
+	if c.nag {
+		paths = c.suppressNag()
+	}
+	c.checkName()
+
+	assembly := c.app.Synth(nil)
+	if c.nag {
+		c.checkNag(assembly, paths)
+	}
+	if c.cost != nil {
+		c.reportCost(assembly)
+	}
+	if c.terraform {
+		c.writeTerraform(assembly)
+	}
+	if c.listing != nil {
+		c.writeManifest(assembly)
+	}
+}
+
+// defines the server function of the factory, mounted at the path of the
+// version. The function is configured with the environment and permissions
+// of the gateway.
+func (c *Gateway) buildServer(f any, id, version string) *Server {
+	props, lambda := c.serverProps(f, &c.env)
+
+	if id == "" {
+		id = filepath.Base(lambda)
+	}
+	server := NewServer(c.stack, jsii.String(id), props)
+
+	// versions are mounted at the path of the primary server
+	server.uri = "/" + strings.ToLower(servername(c.f))
+	if version != "" {
+		server.uri = "/" + version + server.uri
+	}
+
+	c.configureServer(server)
 
-	segs := strings.Split(path, "/")
-	for i := len(segs) - 1; i >= 0; i-- {
-		subpath := strings.Join(segs[:i], "/")
-		gomod := filepath.Join(subpath, "go.mod")
-		if _, err := os.Stat(rootSourceCode(gomod)); err == nil {
-			return subpath, strings.Join(segs[i:], "/")
+	if c.health {
+		c.buildHealthCheck(server)
+	}
+
+	return server
+}
+
+// properties of the server function of the factory, and its package
+func (c *Gateway) serverProps(f any, env *map[string]*string) (*ServerProps, string) {
+	module, lambda := c.sourcecode(f)
+	props := &ServerProps{Factory: f, FunctionGoProps: &scud.FunctionGoProps{
+		SourceCodeModule: module,
+		SourceCodeLambda: lambda,
+		FunctionProps: &awslambda.FunctionProps{
+			Role:            c.role,
+			LogGroup:        c.loggroup,
+			Timeout:         awscdk.Duration_Minutes(jsii.Number(5)),
+			Environment:     env,
+			DeadLetterQueue: c.deadletters,
+		},
+	}}
+	if c.concurrency > 0 {
+		props.FunctionProps.ReservedConcurrentExecutions = jsii.Number(c.concurrency)
+	}
+	if c.vpc != nil {
+		props.FunctionProps.Vpc = c.vpc
+		props.FunctionProps.VpcSubnets = c.subnets
+		if len(c.securityGroups) > 0 {
+			props.FunctionProps.SecurityGroups = &c.securityGroups
 		}
 	}
+	if c.kms != nil {
+		props.FunctionProps.EnvironmentEncryption = c.kms
+	}
+	if c.dockerfile != "" {
+		props.FromImage(c.dockerfile)
+	}
+	props.Use(c.middlewares...)
+
+	return props, lambda
+}
+
+// grants permissions of the gateway to the server function
+func (c *Gateway) configureServer(server *Server) {
+	for _, grant := range c.grants {
+		grant(server.Function)
+	}
+
+	for _, hook := range c.hooks {
+		hook(server.Function)
+	}
+}
+
+// routes the server via authorizer of the gateway
+func (c *Gateway) allowAccess(server *Server) {
+	switch {
+	case c.authjwt != nil:
+		server.AllowAccessJWT(c.authjwt)
+	case c.authjwts != nil:
+		server.AllowAccessJwtIssuers(c.authjwts)
+	case c.authint != nil:
+		server.AllowAccessIntrospection(c.authint)
+	case c.authkey != nil:
+		server.AllowAccessApiKey(c.authkey)
+	case c.authhsh != nil:
+		server.AllowAccessApiKeyHashed(c.authhsh)
+	case c.authiam != nil:
+		server.AllowAccessIAM(c.authiam, c.grantee)
+	case c.authpub != nil:
+		server.AllowAccessPublic(c.authpub)
+	default:
+		panic("no authorizer defined for server")
+	}
+}
+
+// api (HTTP or REST) invoked by IAM principals
+type executeApi interface {
+	ArnForExecuteApi(method *string, path *string, stage *string) *string
+}
+
+// The IAM authorizer does not create any policy for principals outside of
+// the stack. The policy statement required by clients is emitted as output,
+// it is scoped to routes of servers (e.g. excludes management of api keys).
+func (c *Gateway) outputPolicyIAM(api executeApi, uris ...string) {
+	arns := make([]*string, 0, 2*len(uris))
+	for _, uri := range uris {
+		arns = append(arns,
+			api.ArnForExecuteApi(nil, jsii.String(uri), nil),
+			api.ArnForExecuteApi(nil, jsii.String(uri+"/*"), nil),
+		)
+	}
+
+	policy := awsiam.NewPolicyDocument(
+		&awsiam.PolicyDocumentProps{
+			Statements: &[]awsiam.PolicyStatement{
+				awsiam.NewPolicyStatement(
+					&awsiam.PolicyStatementProps{
+						Effect:    awsiam.Effect_ALLOW,
+						Actions:   jsii.Strings("execute-api:Invoke"),
+						Resources: &arns,
+					},
+				),
+			},
+		},
+	)
+
+	c.output("PolicyIAM", c.stack.ToJsonString(policy.ToJSON(), nil))
+}
+
+func servername(f any) string {
+	fptr := reflect.ValueOf(f).Pointer()
+	fobj := runtime.FuncForPC(fptr)
+	if fobj == nil {
+		panic(fmt.Errorf("failed to discover function metadata"))
+	}
 
-	panic(fmt.Errorf("failed to go.mod for function %s", path))
+	name := fobj.Name()
+	serv := filepath.Ext(name)[1:]
+
+	return serv
 }
 
-func rootSourceCode(sourceCodeModule string) string {
-	sourceCode := os.Getenv("GITHUB_WORKSPACE")
-	if sourceCode == "" {
-		sourceCode = filepath.Join(os.Getenv("GOPATH"), "src", sourceCodeModule)
+// module and package of the factory, relative to the module, e.g.
+// github.com/fogfish/cloudmcp and /examples/helloworld/server
+func (c *Gateway) sourcecode(f any) (string, string) {
+	if c.source != nil && reflect.ValueOf(f).Pointer() == reflect.ValueOf(c.f).Pointer() {
+		return c.source.module, c.source.lambda
 	}
 
-	return sourceCode
+	return sourcecode(f)
 }
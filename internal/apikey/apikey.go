@@ -0,0 +1,129 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package apikey implements salted hashing of API key secrets, so that
+// only hash of the secret is synthesized into the authorizer configuration.
+// Secrets are always generated (128 bits of entropy), a single round of
+// salted SHA-256 resists brute force, key stretching would only slow down
+// the authorizer.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"strings"
+)
+
+const scheme = "sha256"
+
+var (
+	ErrForbidden     = errors.New("forbidden")
+	ErrMalformedHash = errors.New("malformed api key hash")
+)
+
+// Generate random access and secret keys
+func Generate() (access string, secret string) {
+	return "ak" + rand.Text()[:14], rand.Text()
+}
+
+// Hash the secret with random salt. The hash is "sha256:<salt>:<digest>".
+func Hash(secret string) string {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+
+	return encode(salt, secret)
+}
+
+// Verify the secret against the hash using constant time comparison
+func Verify(hash, secret string) (bool, error) {
+	seq := strings.Split(hash, ":")
+	if len(seq) != 3 || seq[0] != scheme {
+		return false, ErrMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(seq[1])
+	if err != nil {
+		return false, ErrMalformedHash
+	}
+
+	given := encode(salt, secret)
+	return subtle.ConstantTimeCompare([]byte(given), []byte(hash)) == 1, nil
+}
+
+func encode(salt []byte, secret string) string {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(secret))
+
+	return scheme + ":" +
+		base64.RawStdEncoding.EncodeToString(salt) + ":" +
+		base64.RawStdEncoding.EncodeToString(h.Sum(nil))
+}
+
+//------------------------------------------------------------------------------
+
+// Hashed Authorizer validates access/secret keys against salted hash of
+// the secret, the secret itself is never known to the authorizer.
+type Hashed struct {
+	access, hash string
+}
+
+func NewHashed(access, hash string) (*Hashed, error) {
+	if access == "" || hash == "" {
+		return nil, errors.New("hashed api key auth is not configured")
+	}
+
+	if _, err := Verify(hash, ""); err != nil {
+		return nil, err
+	}
+
+	return &Hashed{access: access, hash: hash}, nil
+}
+
+func (auth *Hashed) Validate(_ context.Context, apikey string) (string, map[string]any, error) {
+	access, secret, err := credentials(apikey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	gaccess := sha256.Sum256([]byte(access))
+	haccess := sha256.Sum256([]byte(auth.access))
+	if subtle.ConstantTimeCompare(gaccess[:], haccess[:]) != 1 {
+		slog.Error("apikey forbidden.")
+		return "", nil, ErrForbidden
+	}
+
+	if ok, _ := Verify(auth.hash, secret); !ok {
+		slog.Error("apikey forbidden.")
+		return "", nil, ErrForbidden
+	}
+
+	return access, map[string]any{"auth": "basic", "sub": access}, nil
+}
+
+// decodes access and secret keys of Basic credentials
+func credentials(apikey string) (string, string, error) {
+	c, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(apikey, "="))
+	if err != nil {
+		slog.Error("corrupted apikey.")
+		return "", "", ErrForbidden
+	}
+
+	access, secret, ok := strings.Cut(string(c), ":")
+	if !ok {
+		slog.Error("malformed apikey.")
+		return "", "", ErrForbidden
+	}
+
+	return access, secret, nil
+}
@@ -0,0 +1,245 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package apikey
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ErrNotFound = errors.New("api key not found")
+
+// Key is the API key managed at runtime, only salted hash of the secret is
+// stored. The secret is returned once, when the key is created or rotated.
+type Key struct {
+	Access  string   `json:"access" dynamodbav:"access"`
+	Secret  string   `json:"secret,omitempty" dynamodbav:"-"`
+	Name    string   `json:"name,omitempty" dynamodbav:"name,omitempty"`
+	Scopes  []string `json:"scopes,omitempty" dynamodbav:"scopes,stringset,omitempty"`
+	Created int64    `json:"created" dynamodbav:"created"`
+	Rotated int64    `json:"rotated,omitempty" dynamodbav:"rotated,omitempty"`
+
+	// hash of the secret and hash of the previous secret, which is valid
+	// until the grace period of rotation expires
+	Hash           string `json:"-" dynamodbav:"hash"`
+	Previous       string `json:"-" dynamodbav:"previous,omitempty"`
+	PreviousExpiry int64  `json:"-" dynamodbav:"previousExpiry,omitempty"`
+}
+
+// DynamoDB interface required by keys store
+type DynamoDB interface {
+	GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Scan(context.Context, *dynamodb.ScanInput, ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// Store of API keys in DynamoDB table, keys are created, rotated and
+// revoked without redeployment of the gateway.
+type Store struct {
+	db    DynamoDB
+	table string
+}
+
+func NewStore(db DynamoDB, table string) *Store {
+	return &Store{db: db, table: table}
+}
+
+// Create new key with given scopes
+func (s *Store) Create(ctx context.Context, name string, scopes []string) (*Key, error) {
+	access, secret := Generate()
+	key := Key{
+		Access:  access,
+		Secret:  secret,
+		Name:    name,
+		Scopes:  scopes,
+		Created: time.Now().Unix(),
+		Hash:    Hash(secret),
+	}
+
+	if err := s.put(ctx, &key, "attribute_not_exists(access)", nil); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// Rotate the secret of the key, the previous secret remains valid for the
+// grace period so that consumers switch without downtime.
+func (s *Store) Rotate(ctx context.Context, access string, grace time.Duration) (*Key, error) {
+	key, err := s.Lookup(ctx, access)
+	if err != nil {
+		return nil, err
+	}
+
+	_, secret := Generate()
+	now := time.Now()
+
+	// concurrent rotations are rejected
+	cond := map[string]types.AttributeValue{":hash": &types.AttributeValueMemberS{Value: key.Hash}}
+
+	key.Previous, key.PreviousExpiry = "", 0
+	if grace > 0 {
+		key.Previous, key.PreviousExpiry = key.Hash, now.Add(grace).Unix()
+	}
+	key.Secret = secret
+	key.Hash = Hash(secret)
+	key.Rotated = now.Unix()
+
+	if err := s.put(ctx, key, "attribute_exists(access) AND hash = :hash", cond); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Revoke the key, it is removed from the store
+func (s *Store) Revoke(ctx context.Context, access string) error {
+	_, err := s.db.DeleteItem(ctx,
+		&dynamodb.DeleteItemInput{
+			TableName:           aws.String(s.table),
+			Key:                 map[string]types.AttributeValue{"access": &types.AttributeValueMemberS{Value: access}},
+			ConditionExpression: aws.String("attribute_exists(access)"),
+		},
+	)
+	return notFound(err)
+}
+
+// Lookup the key
+func (s *Store) Lookup(ctx context.Context, access string) (*Key, error) {
+	val, err := s.db.GetItem(ctx,
+		&dynamodb.GetItemInput{
+			TableName:      aws.String(s.table),
+			Key:            map[string]types.AttributeValue{"access": &types.AttributeValueMemberS{Value: access}},
+			ConsistentRead: aws.Bool(true),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if val.Item == nil {
+		return nil, ErrNotFound
+	}
+
+	var key Key
+	if err := attributevalue.UnmarshalMap(val.Item, &key); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// List all keys, secrets are not included
+func (s *Store) List(ctx context.Context) ([]Key, error) {
+	keys := []Key{}
+	input := &dynamodb.ScanInput{TableName: aws.String(s.table)}
+
+	for {
+		val, err := s.db.Scan(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		var seq []Key
+		if err := attributevalue.UnmarshalListOfMaps(val.Items, &seq); err != nil {
+			return nil, err
+		}
+		keys = append(keys, seq...)
+
+		if len(val.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = val.LastEvaluatedKey
+	}
+
+	slices.SortFunc(keys, func(a, b Key) int { return strings.Compare(a.Access, b.Access) })
+	return keys, nil
+}
+
+func (s *Store) put(ctx context.Context, key *Key, cond string, values map[string]types.AttributeValue) error {
+	item, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.PutItem(ctx,
+		&dynamodb.PutItemInput{
+			TableName:                 aws.String(s.table),
+			Item:                      item,
+			ConditionExpression:       aws.String(cond),
+			ExpressionAttributeValues: values,
+		},
+	)
+	return notFound(err)
+}
+
+func notFound(err error) error {
+	var cond *types.ConditionalCheckFailedException
+	if errors.As(err, &cond) {
+		return ErrNotFound
+	}
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// Managed Authorizer validates access/secret keys against keys of the store,
+// scopes of the key are passed to the server as "scope" claim.
+type Managed struct {
+	store *Store
+}
+
+func NewManaged(store *Store) *Managed {
+	return &Managed{store: store}
+}
+
+func (auth *Managed) Validate(ctx context.Context, apikey string) (string, map[string]any, error) {
+	access, secret, err := credentials(apikey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key, err := auth.store.Lookup(ctx, access)
+	if err != nil {
+		if err != ErrNotFound {
+			slog.Error("apikey lookup failed.", "err", err)
+		}
+		return "", nil, ErrForbidden
+	}
+
+	match, _ := Verify(key.Hash, secret)
+	if !match && key.Previous != "" && time.Now().Unix() < key.PreviousExpiry {
+		match, _ = Verify(key.Previous, secret)
+	}
+
+	if !match {
+		slog.Error("apikey forbidden.")
+		return "", nil, ErrForbidden
+	}
+
+	claims := map[string]any{"auth": "basic", "sub": access}
+	if len(key.Scopes) > 0 {
+		claims["scope"] = strings.Join(key.Scopes, " ")
+	}
+	if key.Name != "" {
+		claims["name"] = key.Name
+	}
+
+	return access, claims, nil
+}
@@ -0,0 +1,106 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Lambda authorizer validating API key against salted hash of the secret,
+// either single key of the config or keys managed in DynamoDB table.
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/fogfish/cloudmcp/internal/apikey"
+)
+
+var (
+	None = events.APIGatewayCustomAuthorizerResponse{}
+)
+
+// validator of API keys, either single hashed key or keys managed in table
+type validator interface {
+	Validate(context.Context, string) (string, map[string]any, error)
+}
+
+func main() {
+	auth, err := newValidator(context.Background())
+	if err != nil {
+		slog.Warn("API Key auth disabled.", "err", err)
+		auth = nil
+	}
+
+	lambda.Start(
+		func(ctx context.Context, evt events.APIGatewayV2CustomAuthorizerV1Request) (events.APIGatewayCustomAuthorizerResponse, error) {
+			key := header(evt.Headers, "authorization")
+			if auth == nil || !strings.HasPrefix(key, "Basic ") {
+				return None, apikey.ErrForbidden
+			}
+
+			principal, context, err := auth.Validate(ctx, strings.TrimPrefix(key, "Basic "))
+			if err != nil {
+				return None, apikey.ErrForbidden
+			}
+
+			return events.APIGatewayCustomAuthorizerResponse{
+				PrincipalID: principal,
+				PolicyDocument: events.APIGatewayCustomAuthorizerPolicy{
+					Version: "2012-10-17",
+					Statement: []events.IAMPolicyStatement{
+						{
+							Action:   []string{"execute-api:*"},
+							Effect:   "Allow",
+							Resource: []string{evt.MethodArn},
+						},
+					},
+				},
+				Context: context,
+			}, nil
+		},
+	)
+}
+
+func newValidator(ctx context.Context) (validator, error) {
+	if table, has := os.LookupEnv("CONFIG_AUTHORIZER_TABLE"); has {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		store := apikey.NewStore(dynamodb.NewFromConfig(cfg), table)
+		return apikey.NewManaged(store), nil
+	}
+
+	access := os.Getenv("CONFIG_AUTHORIZER_ACCESS")
+	hash := os.Getenv("CONFIG_AUTHORIZER_SECRET_HASH")
+	auth, err := apikey.NewHashed(access, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth, nil
+}
+
+// HTTP API lowercases headers, REST API preserves them as sent by client
+func header(headers map[string]string, name string) string {
+	if val, has := headers[name]; has {
+		return val
+	}
+
+	for key, val := range headers {
+		if strings.EqualFold(key, name) {
+			return val
+		}
+	}
+
+	return ""
+}
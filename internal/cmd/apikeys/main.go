@@ -0,0 +1,186 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Lambda serving management API of API keys, the access is protected by
+// AWS IAM, callers are checked against admins (CONFIG_APIKEYS_ADMINS):
+//
+//	GET    /apikeys                 list keys
+//	POST   /apikeys                 create key {"name": "...", "scopes": [...]}
+//	GET    /apikeys/{access}        lookup key
+//	POST   /apikeys/{access}/rotate rotate secret {"grace": "24h"}
+//	DELETE /apikeys/{access}        revoke key
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/fogfish/cloudmcp/internal/apikey"
+	"github.com/fogfish/cloudmcp/internal/iam"
+)
+
+func main() {
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	api := &api{
+		store:  apikey.NewStore(dynamodb.NewFromConfig(cfg), os.Getenv("CONFIG_APIKEYS_TABLE")),
+		admins: iam.Parse(os.Getenv("CONFIG_APIKEYS_ADMINS")),
+	}
+
+	lambda.Start(api.serve)
+}
+
+type api struct {
+	store  *apikey.Store
+	admins iam.Principals
+}
+
+type createRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type rotateRequest struct {
+	Grace string `json:"grace"`
+}
+
+func (api *api) serve(ctx context.Context, req events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	// IAM authorizer of HTTP API accepts any identity allowed to invoke the api
+	if !api.admins.IsAllowed(req.RequestContext.Identity.AccountID, caller(&req)) {
+		slog.Warn("API keys management is denied.", "caller", caller(&req))
+		return failure(http.StatusForbidden, errors.New("forbidden"))
+	}
+
+	_, path, _ := strings.Cut(req.Path, "/apikeys")
+	seq := strings.Split(strings.Trim(path, "/"), "/")
+	if seq[0] == "" {
+		seq = nil
+	}
+
+	switch {
+	case len(seq) == 0 && req.HTTPMethod == http.MethodGet:
+		keys, err := api.store.List(ctx)
+		return reply(http.StatusOK, keys, err)
+
+	case len(seq) == 0 && req.HTTPMethod == http.MethodPost:
+		var spec createRequest
+		if err := decode(&req, &spec); err != nil {
+			return failure(http.StatusBadRequest, err)
+		}
+		key, err := api.store.Create(ctx, spec.Name, spec.Scopes)
+		if err == nil {
+			slog.Info("API key is created.", "access", key.Access, "caller", caller(&req))
+		}
+		return reply(http.StatusCreated, key, err)
+
+	case len(seq) == 1 && req.HTTPMethod == http.MethodGet:
+		key, err := api.store.Lookup(ctx, seq[0])
+		return reply(http.StatusOK, key, err)
+
+	case len(seq) == 2 && seq[1] == "rotate" && req.HTTPMethod == http.MethodPost:
+		var spec rotateRequest
+		if err := decode(&req, &spec); err != nil {
+			return failure(http.StatusBadRequest, err)
+		}
+		var grace time.Duration
+		if spec.Grace != "" {
+			g, err := time.ParseDuration(spec.Grace)
+			if err != nil || g < 0 {
+				return failure(http.StatusBadRequest, errors.New("invalid grace period"))
+			}
+			grace = g
+		}
+		key, err := api.store.Rotate(ctx, seq[0], grace)
+		if err == nil {
+			slog.Info("API key is rotated.", "access", key.Access, "grace", grace, "caller", caller(&req))
+		}
+		return reply(http.StatusOK, key, err)
+
+	case len(seq) == 1 && req.HTTPMethod == http.MethodDelete:
+		err := api.store.Revoke(ctx, seq[0])
+		if err == nil {
+			slog.Info("API key is revoked.", "access", seq[0], "caller", caller(&req))
+		}
+		return reply(http.StatusNoContent, nil, err)
+	}
+
+	return failure(http.StatusNotFound, errors.New("not found"))
+}
+
+// body is optional, empty body is decoded as zero value
+func decode(req *events.APIGatewayProxyRequest, v any) error {
+	body := []byte(req.Body)
+	if req.IsBase64Encoded {
+		data, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			return err
+		}
+		body = data
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+func caller(req *events.APIGatewayProxyRequest) string {
+	return req.RequestContext.Identity.UserArn
+}
+
+func reply(status int, val any, err error) (*events.APIGatewayProxyResponse, error) {
+	switch {
+	case errors.Is(err, apikey.ErrNotFound):
+		return failure(http.StatusNotFound, err)
+	case err != nil:
+		slog.Error("API keys management failed.", "err", err)
+		return failure(http.StatusInternalServerError, errors.New("internal error"))
+	case val == nil:
+		return &events.APIGatewayProxyResponse{StatusCode: status}, nil
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: status,
+		MultiValueHeaders: http.Header{
+			"Content-Type":  []string{"application/json"},
+			"Cache-Control": []string{"no-store"},
+		},
+		Body: string(data),
+	}, nil
+}
+
+func failure(status int, err error) (*events.APIGatewayProxyResponse, error) {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return &events.APIGatewayProxyResponse{
+		StatusCode: status,
+		MultiValueHeaders: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+		Body: string(data),
+	}, nil
+}
@@ -0,0 +1,109 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// CodeDeploy pre/post-traffic hook, which checks that MCP server responds
+// to `initialize` request before and after traffic is shifted.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/codedeploy"
+	"github.com/aws/aws-sdk-go-v2/service/codedeploy/types"
+	awslambda "github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+)
+
+// Lifecycle event of CodeDeploy hook
+type LifecycleEvent struct {
+	DeploymentId                  string `json:"DeploymentId"`
+	LifecycleEventHookExecutionId string `json:"LifecycleEventHookExecutionId"`
+}
+
+const initialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"cloudmcp-canary","version":"1.0.0"}}}`
+
+func main() {
+	target := os.Getenv(envvar.CanaryTarget)
+
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	deploy := codedeploy.NewFromConfig(cfg)
+	invoker := awslambda.NewFromConfig(cfg)
+
+	lambda.Start(
+		func(ctx context.Context, evt LifecycleEvent) error {
+			status := types.LifecycleEventStatusSucceeded
+			if err := check(ctx, invoker, target); err != nil {
+				slog.Error("canary check failed.", "target", target, "err", err)
+				status = types.LifecycleEventStatusFailed
+			}
+
+			_, err := deploy.PutLifecycleEventHookExecutionStatus(ctx,
+				&codedeploy.PutLifecycleEventHookExecutionStatusInput{
+					DeploymentId:                  aws.String(evt.DeploymentId),
+					LifecycleEventHookExecutionId: aws.String(evt.LifecycleEventHookExecutionId),
+					Status:                        status,
+				},
+			)
+			return err
+		},
+	)
+}
+
+func check(ctx context.Context, api *awslambda.Client, target string) error {
+	req, err := json.Marshal(
+		events.APIGatewayProxyRequest{
+			HTTPMethod: "POST",
+			Path:       "/",
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"Accept":       "application/json, text/event-stream",
+			},
+			Body: initialize,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	out, err := api.Invoke(ctx,
+		&awslambda.InvokeInput{
+			FunctionName: aws.String(target),
+			Payload:      req,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if out.FunctionError != nil {
+		return fmt.Errorf("function error %s: %s", aws.ToString(out.FunctionError), out.Payload)
+	}
+
+	var rsp events.APIGatewayProxyResponse
+	if err := json.Unmarshal(out.Payload, &rsp); err != nil {
+		return err
+	}
+
+	if rsp.StatusCode != 200 {
+		return fmt.Errorf("unexpected status %d: %s", rsp.StatusCode, rsp.Body)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Lambda authorizer validating opaque tokens using token introspection.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/fogfish/cloudmcp/internal/introspect"
+	"github.com/fogfish/cloudmcp/internal/jwt"
+)
+
+var (
+	None = events.APIGatewayCustomAuthorizerResponse{}
+)
+
+func main() {
+	auth, err := newIntrospector(context.Background())
+	if err != nil {
+		slog.Warn("Token introspection disabled.", "err", err)
+		auth = nil
+	}
+
+	lambda.Start(
+		func(ctx context.Context, evt events.APIGatewayV2CustomAuthorizerV1Request) (events.APIGatewayCustomAuthorizerResponse, error) {
+			token := header(evt.Headers, "authorization")
+			if auth == nil || len(token) < 7 || !strings.EqualFold(token[:7], "Bearer ") {
+				return None, introspect.ErrForbidden
+			}
+
+			claims, err := auth.Introspect(ctx, token[7:])
+			if err != nil {
+				if err != introspect.ErrForbidden {
+					slog.Warn("Token introspection failed.", "err", err)
+				}
+				return None, introspect.ErrForbidden
+			}
+
+			// tokens of client credentials grant do not have subject
+			principal := ""
+			for _, key := range []string{"sub", "username", "client_id"} {
+				if id, ok := claims[key].(string); ok && id != "" {
+					principal = id
+					break
+				}
+			}
+			if principal == "" {
+				return None, introspect.ErrForbidden
+			}
+
+			context := jwt.AuthorizerContext("opaque", claims)
+			context["sub"] = principal
+
+			return events.APIGatewayCustomAuthorizerResponse{
+				PrincipalID: principal,
+				PolicyDocument: events.APIGatewayCustomAuthorizerPolicy{
+					Version: "2012-10-17",
+					Statement: []events.IAMPolicyStatement{
+						{
+							Action:   []string{"execute-api:*"},
+							Effect:   "Allow",
+							Resource: []string{evt.MethodArn},
+						},
+					},
+				},
+				Context: context,
+			}, nil
+		},
+	)
+}
+
+// the secret of the client is loaded from Secrets Manager via SSM reference
+func newIntrospector(ctx context.Context) (*introspect.Introspector, error) {
+	spec, err := introspect.NewConfig([]byte(os.Getenv("CONFIG_AUTHORIZER_INTROSPECTION")))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := ssm.NewFromConfig(cfg).GetParameter(ctx,
+		&ssm.GetParameterInput{
+			Name:           aws.String("/aws/reference/secretsmanager/" + spec.ClientSecret),
+			WithDecryption: aws.Bool(true),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return introspect.New(spec.Url, spec.ClientID, clientSecret(aws.ToString(val.Parameter.Value)), nil), nil
+}
+
+// the secret is either plain text or JSON object with client_secret key
+func clientSecret(val string) string {
+	var secret struct {
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.Unmarshal([]byte(val), &secret); err == nil && secret.ClientSecret != "" {
+		return secret.ClientSecret
+	}
+
+	return strings.TrimSpace(val)
+}
+
+// HTTP API lowercases headers, REST API preserves them as sent by client
+func header(headers map[string]string, name string) string {
+	if val, has := headers[name]; has {
+		return val
+	}
+
+	for key, val := range headers {
+		if strings.EqualFold(key, name) {
+			return val
+		}
+	}
+
+	return ""
+}
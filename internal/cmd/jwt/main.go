@@ -0,0 +1,79 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Lambda authorizer validating JWT of multiple issuers.
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/fogfish/cloudmcp/internal/jwt"
+)
+
+var (
+	None = events.APIGatewayCustomAuthorizerResponse{}
+)
+
+func main() {
+	issuers, err := jwt.NewIssuers([]byte(os.Getenv("CONFIG_AUTHORIZER_ISSUERS")))
+	if err != nil {
+		slog.Warn("JWT auth disabled.", "err", err)
+	}
+	verifier := jwt.NewVerifier(issuers, nil)
+
+	lambda.Start(
+		func(ctx context.Context, evt events.APIGatewayV2CustomAuthorizerV1Request) (events.APIGatewayCustomAuthorizerResponse, error) {
+			token := header(evt.Headers, "authorization")
+			if len(token) < 7 || !strings.EqualFold(token[:7], "Bearer ") {
+				return None, jwt.ErrForbidden
+			}
+
+			claims, err := verifier.Verify(ctx, token[7:])
+			if err != nil {
+				slog.Debug("JWT is rejected.", "err", err)
+				return None, jwt.ErrForbidden
+			}
+
+			sub, _ := claims["sub"].(string)
+			return events.APIGatewayCustomAuthorizerResponse{
+				PrincipalID: sub,
+				PolicyDocument: events.APIGatewayCustomAuthorizerPolicy{
+					Version: "2012-10-17",
+					Statement: []events.IAMPolicyStatement{
+						{
+							Action:   []string{"execute-api:*"},
+							Effect:   "Allow",
+							Resource: []string{evt.MethodArn},
+						},
+					},
+				},
+				Context: jwt.AuthorizerContext("jwt", claims),
+			}, nil
+		},
+	)
+}
+
+// HTTP API lowercases headers, REST API preserves them as sent by client
+func header(headers map[string]string, name string) string {
+	if val, has := headers[name]; has {
+		return val
+	}
+
+	for key, val := range headers {
+		if strings.EqualFold(key, name) {
+			return val
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,226 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package codegen generates typed client stubs for tools of MCP server
+// (TypeScript, Python) and configuration snippets of MCP clients (Claude
+// Desktop, Cursor) from the manifest of the server (tools/list).
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Server is the manifest of MCP server and its connection, either the
+// endpoint of the deployed server or the command of the local one.
+type Server struct {
+	Name     string
+	Endpoint string
+	Headers  http.Header
+	Command  []string
+	Tools    []*mcp.Tool
+}
+
+// Generator writes the artifact of the server
+type Generator func(io.Writer, *Server) error
+
+// Generators of supported artifacts
+var Generators = map[string]Generator{
+	"ts":     TypeScript,
+	"python": Python,
+	"claude": ClaudeDesktop,
+	"cursor": Cursor,
+}
+
+// tool with decoded schemas, tools are ordered by name
+type tool struct {
+	*mcp.Tool
+	input  *jsonschema.Schema
+	output *jsonschema.Schema
+}
+
+func toolsOf(s *Server) ([]tool, error) {
+	seq := make([]tool, 0, len(s.Tools))
+	for _, t := range s.Tools {
+		input, err := schemaOf(t.InputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input schema of tool %s: %w", t.Name, err)
+		}
+		if input == nil {
+			input = &jsonschema.Schema{Type: "object"}
+		}
+
+		output, err := schemaOf(t.OutputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output schema of tool %s: %w", t.Name, err)
+		}
+
+		seq = append(seq, tool{Tool: t, input: input, output: output})
+	}
+
+	sort.Slice(seq, func(i, j int) bool { return seq[i].Name < seq[j].Name })
+	return seq, nil
+}
+
+// schema is either *jsonschema.Schema (server side) or decoded JSON (client side)
+func schemaOf(v any) (*jsonschema.Schema, error) {
+	switch s := v.(type) {
+	case nil:
+		return nil, nil
+	case *jsonschema.Schema:
+		return s, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return nil, nil
+	}
+
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// resolves local references (#/$defs/... or #/definitions/...) of the schema
+func deref(root *jsonschema.Schema, ref string) *jsonschema.Schema {
+	if name, has := strings.CutPrefix(ref, "#/$defs/"); has {
+		return root.Defs[name]
+	}
+	if name, has := strings.CutPrefix(ref, "#/definitions/"); has {
+		return root.Definitions[name]
+	}
+	if ref == "#" {
+		return root
+	}
+	return nil
+}
+
+// types of the schema, the schema without type is either object or any
+func typesOf(s *jsonschema.Schema) []string {
+	switch {
+	case s.Type != "":
+		return []string{s.Type}
+	case len(s.Types) > 0:
+		return s.Types
+	case s.Properties != nil:
+		return []string{"object"}
+	default:
+		return nil
+	}
+}
+
+func isRequired(s *jsonschema.Schema, key string) bool {
+	for _, r := range s.Required {
+		if r == key {
+			return true
+		}
+	}
+	return false
+}
+
+func keysOf(s *jsonschema.Schema) []string {
+	keys := make([]string, 0, len(s.Properties))
+	for key := range s.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// words of the name, e.g. getUserById, get_user_by_id, get-user.by-id
+func words(name string) []string {
+	seq := []string{}
+	word := []rune{}
+	flush := func() {
+		if len(word) > 0 {
+			seq = append(seq, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	return seq
+}
+
+func pascal(name string) string {
+	sb := strings.Builder{}
+	for _, w := range words(name) {
+		r := []rune(w)
+		sb.WriteString(strings.ToUpper(string(r[0])) + string(r[1:]))
+	}
+
+	id := sb.String()
+	if id == "" || unicode.IsDigit([]rune(id)[0]) {
+		id = "T" + id
+	}
+	return id
+}
+
+func camel(name string) string {
+	id := pascal(name)
+	r := []rune(id)
+	return strings.ToLower(string(r[0])) + string(r[1:])
+}
+
+func snake(name string) string {
+	id := strings.Join(words(name), "_")
+	if id == "" || unicode.IsDigit([]rune(id)[0]) {
+		id = "_" + id
+	}
+	return id
+}
+
+// unique names of generated types
+type names map[string]bool
+
+func (n names) unique(name string) string {
+	id := name
+	for i := 2; n[id]; i++ {
+		id = fmt.Sprintf("%s%d", name, i)
+	}
+	n[id] = true
+	return id
+}
+
+func header(source string) string {
+	return "Code generated by cloudmcp codegen from " + source + ". DO NOT EDIT."
+}
+
+func sourceOf(s *Server) string {
+	if s.Endpoint != "" {
+		return s.Endpoint
+	}
+	return strings.Join(s.Command, " ")
+}
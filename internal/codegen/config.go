@@ -0,0 +1,92 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package codegen
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// configuration of the server within mcpServers of the client
+type clientServer struct {
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ClaudeDesktop generates the snippet of claude_desktop_config.json. Claude
+// Desktop runs local servers only, the deployed server is bridged with
+// mcp-remote, headers are passed via environment variables.
+func ClaudeDesktop(w io.Writer, s *Server) error {
+	if len(s.Command) > 0 {
+		return writeClientConfig(w, s.Name, clientServer{Command: s.Command[0], Args: s.Command[1:]})
+	}
+	if s.Endpoint == "" {
+		return errors.New("endpoint or command of the server is required")
+	}
+
+	server := clientServer{Command: "npx", Args: []string{"-y", "mcp-remote", s.Endpoint}}
+	for _, key := range headerKeys(s) {
+		// spaces within arguments are not escaped on Windows, values are
+		// substituted by mcp-remote from the environment
+		env := "MCP_HEADER_" + strings.ToUpper(snake(key))
+		if server.Env == nil {
+			server.Env = map[string]string{}
+		}
+		server.Env[env] = strings.Join(s.Headers.Values(key), ", ")
+		server.Args = append(server.Args, "--header", key+":${"+env+"}")
+	}
+
+	return writeClientConfig(w, s.Name, server)
+}
+
+// Cursor generates the snippet of mcp.json (.cursor/mcp.json)
+func Cursor(w io.Writer, s *Server) error {
+	if len(s.Command) > 0 {
+		return writeClientConfig(w, s.Name, clientServer{Command: s.Command[0], Args: s.Command[1:]})
+	}
+	if s.Endpoint == "" {
+		return errors.New("endpoint or command of the server is required")
+	}
+
+	server := clientServer{URL: s.Endpoint}
+	for _, key := range headerKeys(s) {
+		if server.Headers == nil {
+			server.Headers = map[string]string{}
+		}
+		server.Headers[key] = strings.Join(s.Headers.Values(key), ", ")
+	}
+
+	return writeClientConfig(w, s.Name, server)
+}
+
+func headerKeys(s *Server) []string {
+	keys := make([]string, 0, len(s.Headers))
+	for key := range s.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeClientConfig(w io.Writer, name string, server clientServer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(
+		map[string]any{
+			"mcpServers": map[string]clientServer{name: server},
+		},
+	)
+}
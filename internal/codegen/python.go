@@ -0,0 +1,267 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// Python generates the client of tools for mcp package (Python 3.11+), each
+// tool is the method with arguments typed by TypedDict. The method returns
+// the structured content if the tool declares output schema, it raises
+// ToolError if the tool fails.
+func Python(w io.Writer, s *Server) error {
+	tools, err := toolsOf(s)
+	if err != nil {
+		return err
+	}
+
+	g := &pygen{
+		ids:  names{"ToolError": true},
+		defs: &strings.Builder{},
+	}
+
+	type method struct {
+		tool          tool
+		input, output string
+		optionalInput bool
+	}
+	methods := []method{}
+
+	for _, t := range tools {
+		m := method{tool: t, output: "CallToolResult"}
+
+		g.root, g.seen = t.input, map[*jsonschema.Schema]bool{}
+		m.input = g.typedDict(pascal(t.Name)+"Input", t.input)
+		m.optionalInput = len(t.input.Required) == 0
+
+		if t.output != nil {
+			g.root, g.seen = t.output, map[*jsonschema.Schema]bool{}
+			m.output = g.typeOf(t.output, pascal(t.Name)+"Output")
+		}
+
+		methods = append(methods, m)
+	}
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "# %s\n\n", header(sourceOf(s)))
+	sb.WriteString("from typing import Any, Literal, NotRequired, TypedDict, cast\n\n")
+	sb.WriteString("from mcp import ClientSession\n")
+	sb.WriteString("from mcp.types import CallToolResult, TextContent\n\n")
+	sb.WriteString(g.defs.String())
+	sb.WriteString(pyToolError)
+
+	fmt.Fprintf(sb, "\nclass %s:\n", pascal(s.Name)+"Client")
+	fmt.Fprintf(sb, "    %s\n\n", pyDoc("Client of tools of the MCP server "+s.Name, "    "))
+	sb.WriteString("    def __init__(self, session: ClientSession) -> None:\n")
+	sb.WriteString("        self.session = session\n")
+
+	for _, m := range methods {
+		arg := "args: " + m.input
+		if m.optionalInput {
+			arg += " | None = None"
+		}
+
+		fmt.Fprintf(sb, "\n    async def %s(self, %s) -> %s:\n", pyIdent(snake(m.tool.Name)), arg, m.output)
+		if doc := strings.TrimSpace(m.tool.Description); doc != "" {
+			fmt.Fprintf(sb, "        %s\n", pyDoc(doc, "        "))
+		}
+		fmt.Fprintf(sb, "        result = await self.session.call_tool(%s, arguments=dict(args or {}))\n", pyLiteral(m.tool.Name))
+		sb.WriteString("        if result.isError:\n")
+		sb.WriteString("            raise ToolError(result)\n")
+		if m.tool.output != nil {
+			fmt.Fprintf(sb, "        return cast(%s, result.structuredContent)\n", pyLiteral(m.output))
+		} else {
+			sb.WriteString("        return result\n")
+		}
+	}
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+const pyToolError = `
+class ToolError(Exception):
+    """Error of the tool, it carries the result of the call"""
+
+    def __init__(self, result: CallToolResult) -> None:
+        text = "\n".join(c.text for c in result.content if isinstance(c, TextContent))
+        super().__init__(text or "tool failed")
+        self.result = result
+
+`
+
+// nested objects are hoisted into TypedDict, named after the parent and
+// the property, definitions are written in order of dependencies.
+type pygen struct {
+	root *jsonschema.Schema
+	seen map[*jsonschema.Schema]bool
+	ids  names
+	defs *strings.Builder
+}
+
+func (g *pygen) typeOf(s *jsonschema.Schema, name string) string {
+	if s == nil {
+		return "Any"
+	}
+
+	if s.Ref != "" {
+		ref := deref(g.root, s.Ref)
+		if ref == nil || g.seen[ref] {
+			return "Any"
+		}
+		g.seen[ref] = true
+		defer delete(g.seen, ref)
+		return g.typeOf(ref, name)
+	}
+
+	if s.Const != nil {
+		return "Literal[" + pyLiteral(*s.Const) + "]"
+	}
+
+	if len(s.Enum) > 0 {
+		seq := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			seq[i] = pyLiteral(v)
+		}
+		return "Literal[" + strings.Join(seq, ", ") + "]"
+	}
+
+	if alt := slices.Concat(s.AnyOf, s.OneOf); len(alt) > 0 {
+		seq := []string{}
+		for i, a := range alt {
+			seq = append(seq, g.typeOf(a, fmt.Sprintf("%s%d", name, i+1)))
+		}
+		return strings.Join(seq, " | ")
+	}
+
+	types := typesOf(s)
+	if len(types) == 0 {
+		return "Any"
+	}
+
+	seq := make([]string, len(types))
+	for i, t := range types {
+		switch t {
+		case "string":
+			seq[i] = "str"
+		case "integer":
+			seq[i] = "int"
+		case "number":
+			seq[i] = "float"
+		case "boolean":
+			seq[i] = "bool"
+		case "null":
+			seq[i] = "None"
+		case "array":
+			seq[i] = "list[" + g.typeOf(s.Items, name+"Item") + "]"
+		case "object":
+			if len(s.Properties) == 0 {
+				seq[i] = "dict[str, " + g.typeOf(s.AdditionalProperties, name+"Value") + "]"
+			} else {
+				seq[i] = g.typedDict(name, s)
+			}
+		default:
+			seq[i] = "Any"
+		}
+	}
+	return strings.Join(seq, " | ")
+}
+
+// TypedDict uses functional syntax, properties are not required to be
+// identifiers of Python.
+func (g *pygen) typedDict(name string, s *jsonschema.Schema) string {
+	id := g.ids.unique(name)
+
+	fields := &strings.Builder{}
+	for _, key := range keysOf(s) {
+		prop := s.Properties[key]
+		if doc := strings.TrimSpace(prop.Description); doc != "" {
+			for _, line := range strings.Split(doc, "\n") {
+				fmt.Fprintf(fields, "        %s\n", strings.TrimRight("# "+line, " \t"))
+			}
+		}
+
+		t := g.typeOf(prop, id+pascal(key))
+		if !isRequired(s, key) {
+			t = "NotRequired[" + t + "]"
+		}
+		fmt.Fprintf(fields, "        %s: %s,\n", pyLiteral(key), t)
+	}
+
+	if fields.Len() == 0 {
+		fmt.Fprintf(g.defs, "%s = TypedDict(%s, {})\n", id, pyLiteral(id))
+	} else {
+		fmt.Fprintf(g.defs, "%s = TypedDict(\n    %s,\n    {\n%s    },\n)\n", id, pyLiteral(id), fields)
+	}
+	if doc := strings.TrimSpace(s.Description); doc != "" {
+		fmt.Fprintf(g.defs, "%s.__doc__ = %s\n", id, pyLiteral(doc))
+	}
+	g.defs.WriteString("\n")
+
+	return id
+}
+
+var pyKeywords = []string{
+	"False", "None", "True", "and", "as", "assert", "async", "await",
+	"break", "class", "continue", "def", "del", "elif", "else", "except",
+	"finally", "for", "from", "global", "if", "import", "in", "is", "lambda",
+	"nonlocal", "not", "or", "pass", "raise", "return", "try", "while",
+	"with", "yield",
+}
+
+func pyIdent(id string) string {
+	if slices.Contains(pyKeywords, id) {
+		return id + "_"
+	}
+	return id
+}
+
+func pyLiteral(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "None"
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "None"
+	}
+	return string(data)
+}
+
+func pyDoc(doc, indent string) string {
+	doc = strings.ReplaceAll(doc, `\`, `\\`)
+	doc = strings.ReplaceAll(doc, `"""`, `\"\"\"`)
+
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		return `"""` + doc + `"""`
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if line := strings.TrimRight(lines[i], " \t"); line != "" {
+			lines[i] = indent + line
+		} else {
+			lines[i] = ""
+		}
+	}
+	return `"""` + strings.Join(lines, "\n") + "\n" + indent + `"""`
+}
@@ -0,0 +1,250 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// TypeScript generates the client of tools for @modelcontextprotocol/sdk,
+// each tool is the method with typed arguments. The method returns the
+// structured content if the tool declares output schema, it throws ToolError
+// if the tool fails.
+func TypeScript(w io.Writer, s *Server) error {
+	tools, err := toolsOf(s)
+	if err != nil {
+		return err
+	}
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "// %s\n\n", header(sourceOf(s)))
+	sb.WriteString("import type { Client } from \"@modelcontextprotocol/sdk/client/index.js\";\n")
+	sb.WriteString("import type { CallToolResult } from \"@modelcontextprotocol/sdk/types.js\";\n\n")
+
+	ids := names{"ToolError": true}
+	type method struct {
+		tool           tool
+		input, output  string
+		optionalInput  bool
+		structuredData bool
+	}
+	methods := []method{}
+
+	for _, t := range tools {
+		m := method{tool: t, input: ids.unique(pascal(t.Name) + "Input"), output: "CallToolResult"}
+
+		g := &tsgen{root: t.input, seen: map[*jsonschema.Schema]bool{}}
+		tsDoc(sb, "", "Arguments of the tool "+t.Name)
+		fmt.Fprintf(sb, "export type %s = %s;\n\n", m.input, g.typeOf(t.input, ""))
+		m.optionalInput = len(t.input.Required) == 0
+
+		if t.output != nil {
+			m.output = ids.unique(pascal(t.Name) + "Output")
+			m.structuredData = true
+
+			g := &tsgen{root: t.output, seen: map[*jsonschema.Schema]bool{}}
+			tsDoc(sb, "", "Structured content of the tool "+t.Name)
+			fmt.Fprintf(sb, "export type %s = %s;\n\n", m.output, g.typeOf(t.output, ""))
+		}
+
+		methods = append(methods, m)
+	}
+
+	sb.WriteString(tsToolError)
+
+	tsDoc(sb, "", "Client of tools of the MCP server "+s.Name)
+	fmt.Fprintf(sb, "export class %s {\n", pascal(s.Name)+"Client")
+	sb.WriteString("  constructor(readonly client: Client) {}\n")
+
+	for _, m := range methods {
+		sb.WriteString("\n")
+		tsDoc(sb, "  ", m.tool.Description)
+
+		arg := "input: " + m.input
+		if m.optionalInput {
+			arg += " = {}"
+		}
+		fmt.Fprintf(sb, "  async %s(%s): Promise<%s> {\n", camel(m.tool.Name), arg, m.output)
+		fmt.Fprintf(sb, "    const result = (await this.client.callTool({ name: %s, arguments: input })) as CallToolResult;\n", tsLiteral(m.tool.Name))
+		sb.WriteString("    if (result.isError) {\n")
+		sb.WriteString("      throw new ToolError(result);\n")
+		sb.WriteString("    }\n")
+		if m.structuredData {
+			fmt.Fprintf(sb, "    return result.structuredContent as %s;\n", m.output)
+		} else {
+			sb.WriteString("    return result;\n")
+		}
+		sb.WriteString("  }\n")
+	}
+	sb.WriteString("}\n")
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+const tsToolError = `/** Error of the tool, it carries the result of the call */
+export class ToolError extends Error {
+  constructor(readonly result: CallToolResult) {
+    super(
+      result.content
+        .map((c) => (c.type === "text" ? c.text : ""))
+        .join("\n") || "tool failed",
+    );
+    this.name = "ToolError";
+  }
+}
+
+`
+
+type tsgen struct {
+	root *jsonschema.Schema
+	seen map[*jsonschema.Schema]bool
+}
+
+func (g *tsgen) typeOf(s *jsonschema.Schema, indent string) string {
+	if s == nil {
+		return "unknown"
+	}
+
+	if s.Ref != "" {
+		ref := deref(g.root, s.Ref)
+		if ref == nil || g.seen[ref] {
+			return "unknown"
+		}
+		g.seen[ref] = true
+		defer delete(g.seen, ref)
+		return g.typeOf(ref, indent)
+	}
+
+	if s.Const != nil {
+		return tsLiteral(*s.Const)
+	}
+
+	if len(s.Enum) > 0 {
+		seq := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			seq[i] = tsLiteral(v)
+		}
+		return strings.Join(seq, " | ")
+	}
+
+	if alt := slices.Concat(s.AnyOf, s.OneOf); len(alt) > 0 {
+		return g.union(alt, indent)
+	}
+
+	types := typesOf(s)
+	if len(types) == 0 {
+		return "unknown"
+	}
+
+	seq := make([]string, len(types))
+	for i, t := range types {
+		switch t {
+		case "string":
+			seq[i] = "string"
+		case "integer", "number":
+			seq[i] = "number"
+		case "boolean":
+			seq[i] = "boolean"
+		case "null":
+			seq[i] = "null"
+		case "array":
+			seq[i] = "Array<" + g.typeOf(s.Items, indent) + ">"
+		case "object":
+			seq[i] = g.object(s, indent)
+		default:
+			seq[i] = "unknown"
+		}
+	}
+	return strings.Join(seq, " | ")
+}
+
+func (g *tsgen) union(alt []*jsonschema.Schema, indent string) string {
+	seq := []string{}
+	for _, s := range alt {
+		t := g.typeOf(s, indent)
+		if strings.Contains(t, " | ") || strings.HasPrefix(t, "{") {
+			t = "(" + t + ")"
+		}
+		seq = append(seq, t)
+	}
+	return strings.Join(seq, " | ")
+}
+
+func (g *tsgen) object(s *jsonschema.Schema, indent string) string {
+	if len(s.Properties) == 0 {
+		switch {
+		case s.AdditionalProperties == nil:
+			return "Record<string, unknown>"
+		case s.AdditionalProperties.Not != nil:
+			return "Record<string, never>"
+		default:
+			return "Record<string, " + g.typeOf(s.AdditionalProperties, indent) + ">"
+		}
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString("{\n")
+	for _, key := range keysOf(s) {
+		prop := s.Properties[key]
+		tsDoc(sb, indent+"  ", prop.Description)
+
+		opt := "?"
+		if isRequired(s, key) {
+			opt = ""
+		}
+		fmt.Fprintf(sb, "%s  %s%s: %s;\n", indent, tsKey(key), opt, g.typeOf(prop, indent+"  "))
+	}
+	sb.WriteString(indent + "}")
+
+	return sb.String()
+}
+
+var tsIdent = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func tsKey(key string) string {
+	if tsIdent.MatchString(key) {
+		return key
+	}
+	return tsLiteral(key)
+}
+
+func tsLiteral(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "unknown"
+	}
+	return string(data)
+}
+
+func tsDoc(sb *strings.Builder, indent, doc string) {
+	doc = strings.TrimSpace(strings.ReplaceAll(doc, "*/", "*\\/"))
+	if doc == "" {
+		return
+	}
+
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(sb, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+
+	fmt.Fprintf(sb, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(sb, "%s %s\n", indent, strings.TrimRight("* "+line, " \t"))
+	}
+	fmt.Fprintf(sb, "%s */\n", indent)
+}
@@ -0,0 +1,171 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package envvar declares environment variables used to configure servers
+// at runtime. The builder injects them into lambda functions, the runtime
+// packages read them. The package has no dependencies, so that the builder
+// does not depend on the runtime.
+package envvar
+
+// per-tool authorization policy
+const (
+	Policy    = "CONFIG_CLOUDMCP_POLICY"
+	PolicySSM = "CONFIG_CLOUDMCP_POLICY_SSM"
+)
+
+// principals of AWS IAM access
+const Principals = "CONFIG_CLOUDMCP_PRINCIPALS"
+
+// rate limits of tools/call
+const (
+	RateLimit      = "CONFIG_CLOUDMCP_RATELIMIT"
+	RateLimitTable = "CONFIG_CLOUDMCP_RATELIMIT_TABLE"
+)
+
+// metrics, the server name is shared by observability features
+const (
+	Metrics = "CONFIG_CLOUDMCP_METRICS"
+	Server  = "CONFIG_CLOUDMCP_SERVER"
+)
+
+// OpenTelemetry tracing
+const Otel = "CONFIG_CLOUDMCP_OTEL"
+
+// endpoint checked by the pre-traffic hook of canary
+const CanaryTarget = "CONFIG_CLOUDMCP_CANARY_TARGET"
+
+// build-time manifest, location of S3 object
+const Manifest = "CONFIG_CLOUDMCP_MANIFEST"
+
+// WebSocket transport
+const (
+	WebSocketTable    = "CONFIG_CLOUDMCP_WEBSOCKET_TABLE"
+	WebSocketEndpoint = "CONFIG_CLOUDMCP_WEBSOCKET_ENDPOINT"
+)
+
+// sampling model
+const Sampling = "CONFIG_CLOUDMCP_SAMPLING"
+
+// elicitation, the session table is shared by stateful features
+const (
+	SessionTable = "CONFIG_CLOUDMCP_SESSION_TABLE"
+	Elicitation  = "CONFIG_CLOUDMCP_ELICITATION"
+)
+
+// resumable streams
+const EventTable = "CONFIG_CLOUDMCP_EVENT_TABLE"
+
+// progress polling
+const Progress = "CONFIG_CLOUDMCP_PROGRESS"
+
+// response cache
+const ResponseCache = "CONFIG_CLOUDMCP_RESPONSE_CACHE"
+
+// multi-tenant isolation
+const Tenancy = "CONFIG_CLOUDMCP_TENANCY"
+
+// prefix of SSM parameters
+const ConfigPrefix = "CONFIG_CLOUDMCP_CONFIG_PREFIX"
+
+// EventBridge events
+const Events = "CONFIG_CLOUDMCP_EVENTS"
+
+// analytics stream
+const Analytics = "CONFIG_CLOUDMCP_ANALYTICS"
+
+// health check
+const Health = "CONFIG_CLOUDMCP_HEALTH"
+
+// Aurora Data API
+const (
+	DatabaseCluster = "CONFIG_CLOUDMCP_DATABASE_CLUSTER"
+	DatabaseSecret  = "CONFIG_CLOUDMCP_DATABASE_SECRET"
+	DatabaseName    = "CONFIG_CLOUDMCP_DATABASE_NAME"
+)
+
+// prefix of table names, suffixed by the name of the table
+const Table = "CONFIG_CLOUDMCP_TABLE_"
+
+// prefix of bucket names, suffixed by the name of the bucket
+const Bucket = "CONFIG_CLOUDMCP_BUCKET_"
+
+// Bedrock knowledge base
+const KnowledgeBase = "CONFIG_CLOUDMCP_KNOWLEDGE_BASE"
+
+// AppSync Events notifications
+const NotificationsEndpoint = "CONFIG_CLOUDMCP_NOTIFICATIONS_ENDPOINT"
+
+// validation of tool arguments
+const Validate = "CONFIG_CLOUDMCP_VALIDATE"
+
+// request limits
+const Limits = "CONFIG_CLOUDMCP_LIMITS"
+
+// structured logging
+const (
+	LogLevel  = "CONFIG_CLOUDMCP_LOG_LEVEL"
+	LogFormat = "CONFIG_CLOUDMCP_LOG_FORMAT"
+)
+
+// network restrictions
+const Network = "CONFIG_CLOUDMCP_NETWORK"
+
+// capability filter
+const Capabilities = "CONFIG_CLOUDMCP_CAPABILITIES"
+
+// audit trail
+const (
+	Audit      = "CONFIG_CLOUDMCP_AUDIT"
+	AuditTable = "CONFIG_CLOUDMCP_AUDIT_TABLE"
+)
+
+// human-in-the-loop approvals
+const Approvals = "CONFIG_CLOUDMCP_APPROVALS"
+
+// dry-run mode
+const DryRun = "CONFIG_CLOUDMCP_DRYRUN"
+
+// uploads
+const (
+	Uploads      = "CONFIG_CLOUDMCP_UPLOADS"
+	UploadBucket = "CONFIG_CLOUDMCP_UPLOAD_BUCKET"
+)
+
+// propagation of request context
+const RequestContext = "CONFIG_CLOUDMCP_REQUEST_CONTEXT"
+
+// base path
+const BasePath = "CONFIG_CLOUDMCP_BASE_PATH"
+
+// soft deadline
+const Deadline = "CONFIG_CLOUDMCP_DEADLINE"
+
+// sampled profiling
+const (
+	Profiling       = "CONFIG_CLOUDMCP_PROFILING"
+	ProfilingBucket = "CONFIG_CLOUDMCP_PROFILING_BUCKET"
+)
+
+// prefix of function names of fan-out, suffixed by the name of the function
+const Function = "CONFIG_CLOUDMCP_FUNCTION_"
+
+// runtime configuration
+const (
+	Stage   = "CONFIG_CLOUDMCP_STAGE"
+	Env     = "CONFIG_CLOUDMCP_ENV"
+	Secrets = "CONFIG_CLOUDMCP_SECRETS"
+)
+
+// partitioning of tools
+const (
+	Partitions = "CONFIG_CLOUDMCP_PARTITIONS"
+	Partition  = "CONFIG_CLOUDMCP_PARTITION"
+)
+
+// discovery document
+const Discovery = "CONFIG_CLOUDMCP_DISCOVERY"
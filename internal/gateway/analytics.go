@@ -0,0 +1,91 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/firehose/types"
+)
+
+// Firehose interface required by the analytics
+type Firehose interface {
+	PutRecord(context.Context, *firehose.PutRecordInput, ...func(*firehose.Options)) (*firehose.PutRecordOutput, error)
+}
+
+// Record of tool call delivered to the analytics stream. The record is
+// anonymized: the principal and the session are replaced with digests, which
+// keeps the record queryable "by whom" without disclosing the identity.
+// The schema matches the Glue table defined by the builder.
+type ToolCallRecord struct {
+	Timestamp    int64   `json:"timestamp"`
+	Server       string  `json:"server"`
+	Tool         string  `json:"tool"`
+	Outcome      string  `json:"outcome"`
+	Duration     float64 `json:"duration"`
+	Principal    string  `json:"principal,omitempty"`
+	Tenant       string  `json:"tenant,omitempty"`
+	Session      string  `json:"session,omitempty"`
+	RequestSize  int     `json:"request_size"`
+	ResponseSize int     `json:"response_size"`
+}
+
+// analytics delivers record per tool call to the Firehose delivery stream
+type analytics struct {
+	api    Firehose
+	stream string
+	server string
+}
+
+// record is deferred by the gateway, it delivers the record once response is
+// ready. Failures are logged, they do not impact the tool call.
+func (a *analytics) record(ctx context.Context, tool string, principal *Principal, session string, size int, t time.Time, rsp **events.APIGatewayProxyResponse) {
+	record := ToolCallRecord{
+		Timestamp:   t.UnixMilli(),
+		Server:      a.server,
+		Tool:        tool,
+		Outcome:     OutcomeError,
+		Duration:    float64(time.Since(t).Microseconds()) / 1000.0,
+		RequestSize: size,
+	}
+	if *rsp != nil {
+		record.Outcome = outcomeOf(*rsp)
+		record.ResponseSize = len((*rsp).Body)
+	}
+	if principal != nil {
+		record.Principal = digest(principal.ID)
+		record.Tenant = principal.Tenant
+	}
+	if session != "" {
+		record.Session = digest(session)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode analytics record", "err", err)
+		return
+	}
+
+	// records are newline delimited, as expected by the format conversion
+	_, err = a.api.PutRecord(ctx,
+		&firehose.PutRecordInput{
+			DeliveryStreamName: aws.String(a.stream),
+			Record:             &types.Record{Data: append(data, '\n')},
+		},
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to deliver analytics record", "err", err)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -26,13 +27,30 @@ func NewHttpRequest(ctx context.Context, r *events.APIGatewayProxyRequest) (*htt
 		return nil, err
 	}
 
+	// API Gateway passes both single and multi-value headers (REST API and
+	// HTTP API payload 1.0), the single-value one is the last of values.
+	// Multi-value headers preserve duplicates (e.g. Cookie).
+	for header, values := range r.MultiValueHeaders {
+		for _, value := range values {
+			req.Header.Add(header, value)
+		}
+	}
 	for header, value := range r.Headers {
-		req.Header.Set(header, value)
+		if _, has := req.Header[http.CanonicalHeaderKey(header)]; !has {
+			req.Header.Set(header, value)
+		}
 	}
 
 	q := req.URL.Query()
+	for key, vals := range r.MultiValueQueryStringParameters {
+		for _, val := range vals {
+			q.Add(key, val)
+		}
+	}
 	for key, val := range r.QueryStringParameters {
-		q.Add(key, val)
+		if !q.Has(key) {
+			q.Add(key, val)
+		}
 	}
 	req.URL.RawQuery = q.Encode()
 
@@ -60,13 +78,23 @@ type ResponseWriter interface {
 func NewHttpResponse() ResponseWriter {
 	return &writer{
 		head: http.Header{},
+		wbuf: buffers.Get().(*bytes.Buffer),
 	}
 }
 
+// buffers of responses are reused across invocations, tool results might be
+// multi-megabyte, growing the buffer from scratch for each one is expensive.
+var buffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// buffers larger than the lambda response limit are not reused
+const maxPooledBuffer = 6 << 20
+
 type writer struct {
 	code int
 	head http.Header
-	wbuf bytes.Buffer
+	wbuf *bytes.Buffer
 }
 
 func (w *writer) WriteHeader(statusCode int) {
@@ -79,15 +107,23 @@ func (w *writer) Write(b []byte) (int, error) {
 
 func (w *writer) Header() http.Header { return w.head }
 
+// Value of the response, the writer is not usable afterwards.
 func (w *writer) Value() *events.APIGatewayProxyResponse {
 	code := 200
 	if w.code != 0 {
 		code = w.code
 	}
 
+	body := w.wbuf.String()
+	if w.wbuf.Cap() <= maxPooledBuffer {
+		w.wbuf.Reset()
+		buffers.Put(w.wbuf)
+	}
+	w.wbuf = nil
+
 	return &events.APIGatewayProxyResponse{
 		StatusCode:        code,
 		MultiValueHeaders: w.head,
-		Body:              w.wbuf.String(),
+		Body:              body,
 	}
 }
@@ -0,0 +1,347 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/fogfish/cloudmcp/pkg/mcperr"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// path of approval endpoint, relative to the server endpoint
+const pathApprovals = "/approvals"
+
+// pending approval waits for the decision no longer than
+const approvalTTL = 24 * time.Hour
+
+// clients are advised to retry pending calls after
+const approvalRetryAfter = 30 * time.Second
+
+// Detail type of EventBridge event notifying approvers
+const approvalDetailType = "Tool Call Approval Requested"
+
+// Status of approval
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+// Approvals configures human-in-the-loop gate for dangerous tools. Calls of
+// listed tools are held until the approver decides on them.
+type Approvals struct {
+	// Patterns of tool names requiring approval
+	Tools []string `json:"tools"`
+
+	// Callers allowed to decide on approvals of tools
+	Approvers Policy `json:"approvers"`
+
+	// Slack compatible webhook notified about pending approvals
+	Webhook string `json:"webhook,omitempty"`
+
+	// EventBridge bus notified about pending approvals
+	Bus string `json:"bus,omitempty"`
+}
+
+// NewApprovals decodes approvals config from JSON
+func NewApprovals(data []byte) (*Approvals, error) {
+	var spec Approvals
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+
+	return &spec, nil
+}
+
+// Approval of the tool call as seen by the client and the approver
+type Approval struct {
+	ID        string          `json:"id"`
+	Status    string          `json:"status"`
+	Server    string          `json:"server,omitempty"`
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Principal string          `json:"principal"`
+	Tenant    string          `json:"tenant,omitempty"`
+	Approver  string          `json:"approver,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	DecidedAt *time.Time      `json:"decidedAt,omitempty"`
+}
+
+func approvalKey(id string) string { return "approval#" + id }
+
+// approvals holds calls of tools in the sessions store until the decision
+type approvals struct {
+	spec     *Approvals
+	sessions Sessions
+	events   EventBridge
+	server   string
+	http     *http.Client
+}
+
+// gate is applied to tools/call before the server is invoked. The call is
+// identified by the caller, the tool and the arguments, the client retries
+// the same call until the decision. It returns nil if the call is approved,
+// the approval is consumed by the call with conditional delete, so that
+// concurrent retries do not execute the call twice.
+func (a *approvals) gate(ctx context.Context, call *jsonrpc.Request, tool string, principal *Principal) (*events.APIGatewayProxyResponse, error) {
+	if !matchesAny(a.spec.Tools, tool) {
+		return nil, nil
+	}
+
+	var params mcp.CallToolParamsRaw
+	json.Unmarshal(call.Params, &params)
+
+	id := digest(principal.Tenant, principal.ID, tool, string(params.Arguments))
+	state, raw, err := a.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case state == nil:
+		state = &Approval{
+			ID:        id,
+			Status:    ApprovalPending,
+			Server:    a.server,
+			Tool:      tool,
+			Arguments: params.Arguments,
+			Principal: principal.ID,
+			Tenant:    principal.Tenant,
+			CreatedAt: time.Now().UTC(),
+		}
+		created, err := a.swap(ctx, nil, state)
+		if err != nil {
+			return nil, err
+		}
+
+		// approval is requested by the concurrent call
+		if created {
+			slog.InfoContext(ctx, "tool call is pending approval", "tool", tool, "approval", id)
+			a.notify(ctx, state)
+		}
+		return newApprovalResponse(call.ID, state), nil
+
+	case state.Status == ApprovalPending:
+		return newApprovalResponse(call.ID, state), nil
+	}
+
+	// the decision is applicable once, concurrent calls race for it
+	consumed, err := a.sessions.RemoveIf(ctx, approvalKey(id), raw)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		slog.WarnContext(ctx, "approval is consumed by concurrent call", "tool", tool, "approval", id)
+		return NewErrorResponse(http.StatusForbidden, call.ID, mcperr.CodeUnauthorized, "approval is consumed by concurrent call"), nil
+	}
+
+	if state.Status != ApprovalApproved {
+		slog.WarnContext(ctx, "tool call is rejected by approver", "tool", tool, "approver", state.Approver)
+		return NewErrorResponse(http.StatusForbidden, call.ID, mcperr.CodeUnauthorized, "tool call is rejected by approver"), nil
+	}
+
+	slog.InfoContext(ctx, "tool call is approved", "tool", tool, "approver", state.Approver)
+	return nil, nil
+}
+
+// approval and its encoded value, the value conditions updates
+func (a *approvals) get(ctx context.Context, id string) (*Approval, []byte, error) {
+	data, err := a.sessions.Get(ctx, approvalKey(id))
+	if err != nil || data == nil {
+		return nil, nil, err
+	}
+
+	var state Approval
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, nil, err
+	}
+
+	return &state, data, nil
+}
+
+// writes the approval unless it is changed since it was read as old value
+func (a *approvals) swap(ctx context.Context, old []byte, state *Approval) (bool, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return false, err
+	}
+
+	return a.sessions.Swap(ctx, approvalKey(state.ID), old, data, approvalTTL)
+}
+
+// notifies approvers, failures are logged, approvers might poll pending
+// approvals by other means
+func (a *approvals) notify(ctx context.Context, state *Approval) {
+	if a.spec.Webhook != "" {
+		text := fmt.Sprintf("Approval of tool call is requested.\nServer: %s\nTool: %s\nCaller: %s\nArguments: %s\nApproval: %s",
+			state.Server, state.Tool, state.Principal, string(state.Arguments), state.ID)
+
+		body, _ := json.Marshal(map[string]string{"text": text})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.spec.Webhook, bytes.NewReader(body))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to notify approvers", "err", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		rsp, err := a.http.Do(req)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to notify approvers", "err", err)
+		} else {
+			rsp.Body.Close()
+			if rsp.StatusCode >= 300 {
+				slog.ErrorContext(ctx, "webhook rejected notification of approvers", "status", rsp.StatusCode)
+			}
+		}
+	}
+
+	if a.spec.Bus != "" && a.events != nil {
+		detail, _ := json.Marshal(state)
+		_, err := a.events.PutEvents(ctx,
+			&eventbridge.PutEventsInput{
+				Entries: []types.PutEventsRequestEntry{
+					{
+						EventBusName: aws.String(a.spec.Bus),
+						Source:       aws.String(EventSource),
+						DetailType:   aws.String(approvalDetailType),
+						Detail:       aws.String(string(detail)),
+					},
+				},
+			},
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to notify approvers", "err", err)
+		}
+	}
+}
+
+// serves the approval API:
+//
+//	GET  {endpoint}/approvals?id={id}  reads the approval
+//	POST {endpoint}/approvals?id={id}  decides on the approval {"status": "approved"}
+func (gw *Gateway) serveApproval(ctx context.Context, req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	id := req.QueryStringParameters["id"]
+	if id == "" {
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusBadRequest}, nil
+	}
+
+	state, raw, err := gw.approvals.get(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "sessions store failed", "err", err)
+		return nil, err
+	}
+	if state == nil {
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusNotFound}, nil
+	}
+
+	principal := gw.principal(req)
+	if principal == nil {
+		principal = newAnonymous(req)
+	}
+
+	switch req.HTTPMethod {
+	case http.MethodGet:
+		if principal.ID != state.Principal && !gw.approvals.spec.Approvers.IsAllowed(principal, state.Tool) {
+			return &events.APIGatewayProxyResponse{StatusCode: http.StatusForbidden}, nil
+		}
+		return newApprovalStatus(http.StatusOK, state), nil
+
+	case http.MethodPost:
+		var decision struct {
+			Status string `json:"status"`
+		}
+		if err := json.Unmarshal([]byte(req.Body), &decision); err != nil ||
+			(decision.Status != ApprovalApproved && decision.Status != ApprovalRejected) {
+			return &events.APIGatewayProxyResponse{StatusCode: http.StatusBadRequest}, nil
+		}
+
+		// approvers are explicitly granted, the caller does not approve itself
+		if len(gw.approvals.spec.Approvers) == 0 || principal.ID == state.Principal ||
+			!gw.approvals.spec.Approvers.IsAllowed(principal, state.Tool) {
+			slog.WarnContext(ctx, "approval is decided by other caller", "principal", principal.ID)
+			return &events.APIGatewayProxyResponse{StatusCode: http.StatusForbidden}, nil
+		}
+
+		if state.Status != ApprovalPending {
+			return newApprovalStatus(http.StatusConflict, state), nil
+		}
+
+		// the decision is written only over the pending approval
+		now := time.Now().UTC()
+		state.Status, state.Approver, state.DecidedAt = decision.Status, principal.ID, &now
+		decided, err := gw.approvals.swap(ctx, raw, state)
+		if err != nil {
+			slog.ErrorContext(ctx, "sessions store failed", "err", err)
+			return nil, err
+		}
+		if !decided {
+			slog.WarnContext(ctx, "approval is decided by concurrent approver", "approval", id)
+			return &events.APIGatewayProxyResponse{StatusCode: http.StatusConflict}, nil
+		}
+
+		slog.InfoContext(ctx, "approval is decided", "approval", id, "status", state.Status, "tool", state.Tool)
+		return newApprovalStatus(http.StatusOK, state), nil
+	}
+
+	return &events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+}
+
+func newApprovalStatus(status int, state *Approval) *events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(state)
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: status,
+		MultiValueHeaders: http.Header{
+			"Content-Type":  []string{"application/json"},
+			"Cache-Control": []string{"no-cache"},
+		},
+		Body: string(body),
+	}
+}
+
+// JSON-RPC error mcperr.CodeApprovalPending, the client retries the call.
+// The status is 200, clients fail the session on other statuses.
+func newApprovalResponse(id jsonrpc.ID, state *Approval) *events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(wireResponse{
+		Version: "2.0",
+		ID:      id.Raw(),
+		Error: &wireError{
+			Code:    mcperr.CodeApprovalPending,
+			Message: "approval pending, retry the call once approved",
+			Data:    mcperr.Data{RetryAfter: int(approvalRetryAfter.Seconds()), Details: state},
+		},
+	})
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		MultiValueHeaders: http.Header{
+			"Content-Type": []string{"application/json"},
+			"Retry-After":  []string{strconv.Itoa(int(approvalRetryAfter.Seconds()))},
+		},
+		Body: string(body),
+	}
+}
+
+func isApprovalsPath(path string) bool {
+	return strings.HasSuffix(path, pathApprovals)
+}
@@ -0,0 +1,130 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/fogfish/cloudmcp/pkg/audit"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Failures of the audit trail are emitted as the metric of the namespace
+// with dimension Server, the builder alarms on it.
+const (
+	NamespaceAudit      = "CloudMCP"
+	MetricAuditFailures = "AuditFailures"
+)
+
+// time reserved for the reply after the audit record
+const auditLeeway = 500 * time.Millisecond
+
+// auditor appends record per side-effecting tool call to the audit trail
+type auditor struct {
+	trail  *audit.Trail
+	server string
+	tools  []string
+	w      io.Writer
+}
+
+type emfAuditRecord struct {
+	AWS           emfMetadata `json:"_aws"`
+	Server        string      `json:"Server"`
+	AuditFailures int         `json:"AuditFailures"`
+}
+
+// checks if the tool is audited, tools annotated as read-only in
+// the manifest are skipped.
+func (a *auditor) audits(tool string, cache *Manifest) bool {
+	if !matchesAny(a.tools, tool) {
+		return false
+	}
+
+	if cache != nil && cache.Tools != nil {
+		for _, t := range cache.Tools.Tools {
+			if t.Name == tool && t.Annotations != nil && t.Annotations.ReadOnlyHint {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// record is deferred by the gateway, it appends the record once response is
+// ready. Failures are logged and emitted as metric, the tool call has
+// already taken effect.
+func (a *auditor) record(ctx context.Context, call *jsonrpc.Request, tool string, principal *Principal, session string, t time.Time, rsp **events.APIGatewayProxyResponse) {
+	var params mcp.CallToolParamsRaw
+	json.Unmarshal(call.Params, &params)
+
+	record := audit.Record{
+		Timestamp: t.UnixMilli(),
+		Tool:      tool,
+		Session:   session,
+		Arguments: audit.Arguments(params.Arguments),
+		Outcome:   OutcomeError,
+	}
+	if *rsp != nil {
+		record.Outcome = outcomeOf(*rsp)
+	}
+	if principal != nil {
+		record.Principal = principal.ID
+		record.Tenant = principal.Tenant
+	}
+
+	record.Chain = audit.Chain(a.server, record.Tenant, t)
+
+	// retries of the append leave time to reply before deadline of the lambda
+	if deadline, has := ctx.Deadline(); has {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-auditLeeway))
+		defer cancel()
+	}
+
+	if _, err := a.trail.Append(ctx, record); err != nil {
+		slog.ErrorContext(ctx, "failed to append audit record", "chain", record.Chain, "err", err)
+		a.failure(ctx)
+	}
+}
+
+// emits EMF record of the failure
+func (a *auditor) failure(ctx context.Context) {
+	data, err := json.Marshal(
+		emfAuditRecord{
+			AWS: emfMetadata{
+				Timestamp: time.Now().UnixMilli(),
+				CloudWatchMetrics: []emfDirective{
+					{
+						Namespace:  NamespaceAudit,
+						Dimensions: [][]string{{"Server"}},
+						Metrics:    []emfMetric{{Name: MetricAuditFailures, Unit: "Count"}},
+					},
+				},
+			},
+			Server:        a.server,
+			AuditFailures: 1,
+		},
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode EMF record", "err", err)
+		return
+	}
+
+	data = append(data, '\n')
+	if _, err := a.w.Write(data); err != nil {
+		slog.ErrorContext(ctx, "failed to emit EMF record", "err", err)
+	}
+}
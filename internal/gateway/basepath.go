@@ -0,0 +1,78 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// BasePath normalizes paths of requests. Custom domain base path mappings
+// and non-default stages of HTTP API prefix paths of requests, the gateway
+// strips them, so that the controller sees paths it expects.
+type BasePath struct {
+	// Prefix stripped from paths (e.g. base path mapping "/v1")
+	Strip string `json:"strip,omitempty"`
+
+	// Strip the stage prefix (e.g. "/dev") from paths
+	Stage bool `json:"stage,omitempty"`
+
+	// Prefix added to normalized paths, the path the controller is mounted at
+	Mount string `json:"mount,omitempty"`
+}
+
+// NewBasePath decodes base path from JSON
+func NewBasePath(data []byte) (*BasePath, error) {
+	var bp BasePath
+	if err := json.Unmarshal(data, &bp); err != nil {
+		return nil, err
+	}
+
+	return &bp, nil
+}
+
+// normalizes path of the request
+func (bp *BasePath) rewrite(r *events.APIGatewayProxyRequest) {
+	p := r.Path
+
+	if bp.Stage && r.RequestContext.Stage != "" && r.RequestContext.Stage != "$default" {
+		p = stripPrefix(p, "/"+r.RequestContext.Stage)
+	}
+
+	if bp.Strip != "" {
+		p = stripPrefix(p, "/"+strings.Trim(bp.Strip, "/"))
+	}
+
+	if bp.Mount != "" {
+		p = path.Join("/"+strings.Trim(bp.Mount, "/"), p)
+	}
+
+	r.Path = p
+}
+
+// strips prefix at the segment boundary, "/v1" is stripped from "/v1/mcp"
+// but not from "/v12/mcp"
+func stripPrefix(p, prefix string) string {
+	if prefix == "/" {
+		return p
+	}
+
+	rest, ok := strings.CutPrefix(p, prefix)
+	if !ok || (rest != "" && rest[0] != '/') {
+		return p
+	}
+
+	if rest == "" {
+		return "/"
+	}
+	return rest
+}
@@ -0,0 +1,111 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ResponseCache caches results of idempotent tool per caller identity.
+type ResponseCache struct {
+	// Name of the tool, "*" matches any tool
+	Tool string `json:"tool"`
+
+	// Time-to-live of cached result in seconds
+	TTL int `json:"ttl"`
+}
+
+// NewResponseCaches decodes response caches from JSON
+func NewResponseCaches(data []byte) ([]ResponseCache, error) {
+	var caches []ResponseCache
+	if err := json.Unmarshal(data, &caches); err != nil {
+		return nil, err
+	}
+
+	return caches, nil
+}
+
+// results of tools/call kept in the sessions store
+type responses struct {
+	caches []ResponseCache
+	store  Sessions
+}
+
+// time-to-live of results of the tool, the exact match wins over "*"
+func (r *responses) ttl(tool string) time.Duration {
+	if r == nil {
+		return 0
+	}
+
+	ttl := 0
+	for _, cache := range r.caches {
+		switch cache.Tool {
+		case tool:
+			return time.Duration(cache.TTL) * time.Second
+		case "*":
+			ttl = cache.TTL
+		}
+	}
+
+	return time.Duration(ttl) * time.Second
+}
+
+// the result is cached per tenant, caller identity, tool and arguments
+func responseKey(principal *Principal, req *jsonrpc.Request) string {
+	var params mcp.CallToolParamsRaw
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return ""
+	}
+
+	return "response#" + digest(principal.Tenant, principal.ID, params.Name, string(params.Arguments))
+}
+
+func (r *responses) get(ctx context.Context, key string) json.RawMessage {
+	data, err := r.store.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "response cache failed", "err", err)
+		return nil
+	}
+
+	return data
+}
+
+// only successful results are cached, tool execution errors are not
+func (r *responses) put(ctx context.Context, key string, rsp *events.APIGatewayProxyResponse, ttl time.Duration) {
+	if rsp.StatusCode != http.StatusOK {
+		return
+	}
+
+	var wire struct {
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(rsp.Body), &wire); err != nil || wire.Error != nil || wire.Result == nil {
+		return
+	}
+
+	var result struct {
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(wire.Result, &result); err != nil || result.IsError {
+		return
+	}
+
+	if err := r.store.Put(ctx, key, wire.Result, ttl); err != nil {
+		slog.WarnContext(ctx, "response cache failed", "err", err)
+	}
+}
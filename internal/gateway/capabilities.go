@@ -0,0 +1,174 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	methodResourcesList          = "resources/list"
+	methodResourcesTemplatesList = "resources/templates/list"
+	methodResourcesRead          = "resources/read"
+)
+
+// Capability exposes tools and resources to callers, whose claim contains
+// the value. Patterns are exact names (URIs), "*" or prefixes ending with "*"
+// (e.g. "billing_*", "file:///reports/*").
+type Capability struct {
+	// Claim to match, "scope" is used if empty
+	Claim string `json:"claim,omitempty"`
+
+	// Value of the claim to match, "*" matches any caller
+	Value string `json:"value"`
+
+	// Patterns of tool names
+	Tools []string `json:"tools,omitempty"`
+
+	// Patterns of resource URIs (or URI templates)
+	Resources []string `json:"resources,omitempty"`
+}
+
+// Capabilities of callers, the listing of tools and resources is pruned to
+// capabilities of the caller, calls outside of them are rejected.
+type Capabilities []Capability
+
+// NewCapabilities decodes capabilities from JSON
+func NewCapabilities(data []byte) (Capabilities, error) {
+	var caps Capabilities
+	if err := json.Unmarshal(data, &caps); err != nil {
+		return nil, err
+	}
+
+	return caps, nil
+}
+
+// IsAllowedTool checks if the tool is exposed to the principal
+func (caps Capabilities) IsAllowedTool(p *Principal, tool string) bool {
+	return caps.isAllowed(p, tool, func(c Capability) []string { return c.Tools })
+}
+
+// IsAllowedResource checks if the resource is exposed to the principal
+func (caps Capabilities) IsAllowedResource(p *Principal, uri string) bool {
+	return caps.isAllowed(p, uri, func(c Capability) []string { return c.Resources })
+}
+
+func (caps Capabilities) isAllowed(p *Principal, name string, patterns func(Capability) []string) bool {
+	if len(caps) == 0 {
+		return true
+	}
+
+	for _, c := range caps {
+		if !matchesAny(patterns(c), name) {
+			continue
+		}
+
+		if c.Value == "*" {
+			return true
+		}
+
+		claim := c.Claim
+		if claim == "" {
+			claim = "scope"
+		}
+
+		if p.Has(claim, c.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		switch {
+		case pattern == "*" || pattern == name:
+			return true
+		case strings.HasSuffix(pattern, "*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// prunes the listing of tools or resources in the response to capabilities
+// of the principal, other responses are not changed.
+func (caps Capabilities) prune(p *Principal, method string, rsp *events.APIGatewayProxyResponse) {
+	var field, key string
+	var allowed func(*Principal, string) bool
+
+	switch method {
+	case methodToolsList:
+		field, key, allowed = "tools", "name", caps.IsAllowedTool
+	case methodResourcesList:
+		field, key, allowed = "resources", "uri", caps.IsAllowedResource
+	case methodResourcesTemplatesList:
+		field, key, allowed = "resourceTemplates", "uriTemplate", caps.IsAllowedResource
+	default:
+		return
+	}
+
+	if rsp == nil || rsp.StatusCode != 200 {
+		return
+	}
+
+	var reply struct {
+		Version string                     `json:"jsonrpc"`
+		ID      json.RawMessage            `json:"id"`
+		Result  map[string]json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(rsp.Body), &reply); err != nil || reply.Result == nil {
+		return
+	}
+
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(reply.Result[field], &items); err != nil {
+		return
+	}
+
+	exposed := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		var name string
+		if err := json.Unmarshal(item[key], &name); err == nil && allowed(p, name) {
+			exposed = append(exposed, item)
+		}
+	}
+
+	list, err := json.Marshal(exposed)
+	if err != nil {
+		return
+	}
+	reply.Result[field] = list
+
+	body, err := json.Marshal(reply)
+	if err != nil {
+		return
+	}
+	rsp.Body = string(body)
+}
+
+// uri of resources/read request, empty string for other methods
+func resourceURI(req *jsonrpc.Request) string {
+	if req.Method != methodResourcesRead {
+		return ""
+	}
+
+	var params mcp.ReadResourceParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return ""
+	}
+
+	return params.URI
+}
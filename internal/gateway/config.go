@@ -0,0 +1,728 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+	"github.com/fogfish/cloudmcp/internal/iam"
+	"github.com/fogfish/cloudmcp/pkg/audit"
+	"github.com/fogfish/cloudmcp/pkg/chunk"
+	"github.com/fogfish/cloudmcp/pkg/dryrun"
+	"github.com/fogfish/cloudmcp/pkg/identity"
+	"github.com/fogfish/cloudmcp/pkg/reqctx"
+	"github.com/fogfish/cloudmcp/pkg/tenancy"
+	"github.com/fogfish/cloudmcp/pkg/upload"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Environment variables used to configure the gateway at runtime.
+// The builder injects them into the lambda function, the names shared with
+// the builder are declared by internal/envvar.
+const (
+	EnvPolicy    = envvar.Policy
+	EnvPolicySSM = envvar.PolicySSM
+
+	EnvRateLimit      = envvar.RateLimit
+	EnvRateLimitTable = envvar.RateLimitTable
+
+	EnvLimits     = envvar.Limits
+	EnvNetwork    = envvar.Network
+	EnvPrincipals = envvar.Principals
+
+	EnvCapabilities = envvar.Capabilities
+
+	EnvLogLevel  = envvar.LogLevel
+	EnvLogFormat = envvar.LogFormat
+
+	EnvServer  = envvar.Server
+	EnvMetrics = envvar.Metrics
+	EnvOtel    = envvar.Otel
+
+	EnvManifest = envvar.Manifest
+	EnvValidate = envvar.Validate
+
+	EnvWebSocketTable = envvar.WebSocketTable
+
+	EnvSampling = envvar.Sampling
+
+	EnvSessionTable = envvar.SessionTable
+	EnvElicitation  = envvar.Elicitation
+	EnvProgress     = envvar.Progress
+
+	EnvEventTable = envvar.EventTable
+
+	EnvResponseCache = envvar.ResponseCache
+
+	EnvTenancy = envvar.Tenancy
+
+	EnvEvents = envvar.Events
+
+	EnvAnalytics = envvar.Analytics
+
+	EnvAudit      = envvar.Audit
+	EnvAuditTable = envvar.AuditTable
+
+	EnvHealth    = envvar.Health
+	EnvDiscovery = envvar.Discovery
+
+	EnvApprovals = envvar.Approvals
+
+	EnvDryRun = envvar.DryRun
+
+	EnvRequestContext = envvar.RequestContext
+
+	EnvBasePath = envvar.BasePath
+
+	EnvDeadline = envvar.Deadline
+
+	EnvProfiling       = envvar.Profiling
+	EnvProfilingBucket = envvar.ProfilingBucket
+
+	EnvUploads      = envvar.Uploads
+	EnvUploadBucket = upload.EnvBucket
+
+	EnvPartitions = envvar.Partitions
+	EnvPartition  = envvar.Partition
+)
+
+// Option configures the gateway
+type Option func(*Gateway)
+
+// WithPolicy enables per-tool authorization
+func WithPolicy(policy Policy) Option {
+	return func(gw *Gateway) {
+		gw.policy = policy
+	}
+}
+
+// WithRateLimit enables rate limiting of tools/call using given backend
+func WithRateLimit(backend Limiter, limits ...RateLimit) Option {
+	return func(gw *Gateway) {
+		gw.limiter = &limiter{limits: limits, backend: backend}
+	}
+}
+
+// WithLimits enables rejection of requests exceeding the limits, before
+// they are decoded
+func WithLimits(limits *Limits) Option {
+	return func(gw *Gateway) {
+		gw.limits = limits
+	}
+}
+
+// WithNetwork enables restriction of callers to source addresses or VPC endpoints
+func WithNetwork(network *Network) Option {
+	return func(gw *Gateway) {
+		gw.network = network
+	}
+}
+
+// WithPrincipals restricts callers authenticated by AWS IAM to principals
+func WithPrincipals(principals iam.Principals) Option {
+	return func(gw *Gateway) {
+		gw.principals = principals
+	}
+}
+
+// WithCapabilities enables pruning of tools and resources listed to
+// the caller by claims of its token
+func WithCapabilities(caps Capabilities) Option {
+	return func(gw *Gateway) {
+		gw.capabilities = caps
+	}
+}
+
+// WithMetrics enables emission of CloudWatch EMF records per tool call
+// into stdout, lambda runtime forwards them to CloudWatch Logs.
+func WithMetrics(namespace, server string) Option {
+	return func(gw *Gateway) {
+		gw.metrics = &metrics{namespace: namespace, server: server, w: os.Stdout}
+	}
+}
+
+// WithManifest enables serving of `initialize` and `tools/list` from
+// the manifest snapshot, without invoking the server.
+func WithManifest(m *Manifest) Option {
+	return func(gw *Gateway) {
+		gw.cache = m
+	}
+}
+
+// WithValidation enables validation of tools/call arguments against input
+// schema of tools declared by the manifest
+func WithValidation(m *Manifest) Option {
+	return func(gw *Gateway) {
+		gw.validator = newValidator(m)
+	}
+}
+
+// WithConnections enables persistence of WebSocket connections
+func WithConnections(connections Connections) Option {
+	return func(gw *Gateway) {
+		gw.connections = connections
+	}
+}
+
+// WithElicitation enables elicitation over the stateless transport, the
+// gateway accepts answers of clients into the sessions store.
+func WithElicitation(sessions Sessions) Option {
+	return func(gw *Gateway) {
+		gw.elicitation = sessions
+	}
+}
+
+// WithProgress enables polling of tools progress from the sessions store
+func WithProgress(sessions Sessions) Option {
+	return func(gw *Gateway) {
+		gw.progress = sessions
+	}
+}
+
+// WithResponseCache enables caching of tools/call results in the store
+func WithResponseCache(store Sessions, caches ...ResponseCache) Option {
+	return func(gw *Gateway) {
+		gw.responses = &responses{caches: caches, store: store}
+	}
+}
+
+// WithApprovals enables human-in-the-loop approval of tools, pending calls
+// are kept in the sessions store, approvers are notified via webhook or
+// EventBridge bus (api might be nil if the bus is not configured).
+func WithApprovals(spec *Approvals, sessions Sessions, api EventBridge, server string) Option {
+	return func(gw *Gateway) {
+		gw.approvals = &approvals{
+			spec:     spec,
+			sessions: sessions,
+			events:   api,
+			server:   server,
+			http:     &http.Client{Timeout: 5 * time.Second},
+		}
+	}
+}
+
+// WithRequestContext propagates allowed fields of API Gateway request context
+// into `_meta` of MCP requests
+func WithRequestContext(fields ...string) Option {
+	return func(gw *Gateway) {
+		gw.reqctx = &requestContext{fields: fields}
+	}
+}
+
+// WithBasePath normalizes paths of requests before routing
+func WithBasePath(bp *BasePath) Option {
+	return func(gw *Gateway) {
+		gw.basePath = bp
+	}
+}
+
+// WithDeadline cancels tool calls before the hard timeout of the lambda
+func WithDeadline(spec *Deadline) Option {
+	return func(gw *Gateway) {
+		gw.deadline = spec
+	}
+}
+
+// WithProfiling samples invocations, CPU profiles are written to the bucket
+func WithProfiling(spec *Profiling, api S3, bucket, server string) Option {
+	return func(gw *Gateway) {
+		gw.profiler = &profiler{spec: spec, api: api, bucket: bucket, server: server}
+	}
+}
+
+// WithUploads enables presigned POST uploads of files for tools into the bucket
+func WithUploads(spec *Uploads, api PostPresigner, bucket string) Option {
+	return func(gw *Gateway) {
+		gw.uploads = &uploads{spec: spec, api: api, bucket: bucket}
+	}
+}
+
+// WithTenancy enables isolation of callers by tenant
+func WithTenancy(tenancy *Tenancy) Option {
+	return func(gw *Gateway) {
+		gw.tenancy = tenancy
+	}
+}
+
+// WithEventStore enables resumability of streams, messages sent to the client
+// are recorded so that the client replays them using Last-Event-ID.
+func WithEventStore(store mcp.EventStore) Option {
+	return func(gw *Gateway) {
+		gw.events = store
+	}
+}
+
+// WithEvents enables publishing of EventBridge event per tool call
+func WithEvents(api EventBridge, bus, server string) Option {
+	return func(gw *Gateway) {
+		gw.emitter = &emitter{api: api, bus: bus, server: server}
+	}
+}
+
+// WithAnalytics enables delivery of anonymized record per tool call into
+// the Firehose delivery stream
+func WithAnalytics(api Firehose, stream, server string) Option {
+	return func(gw *Gateway) {
+		gw.analytics = &analytics{api: api, stream: stream, server: server}
+	}
+}
+
+// WithAudit enables appending of record per side-effecting tool call to
+// the audit trail, chains are named after the server and sharded per tenant
+// and per day. Tools are patterns of audited tools, all tools are audited
+// if omitted.
+func WithAudit(trail *audit.Trail, server string, tools ...string) Option {
+	if len(tools) == 0 {
+		tools = []string{"*"}
+	}
+
+	return func(gw *Gateway) {
+		gw.auditor = &auditor{trail: trail, server: server, tools: tools, w: os.Stdout}
+	}
+}
+
+// WithHealth enables health endpoint `GET {endpoint}/healthz`
+func WithHealth() Option {
+	return func(gw *Gateway) {
+		gw.health = true
+	}
+}
+
+// withTracing enables OpenTelemetry tracing with given tracer
+func withTracing(t *tracing) Option {
+	return func(gw *Gateway) {
+		gw.tracing = t
+	}
+}
+
+// FromEnv builds gateway options from environment variables.
+func FromEnv(ctx context.Context) ([]Option, error) {
+	loggerFromEnv()
+
+	opts := []Option{}
+
+	if data, has := os.LookupEnv(EnvPolicy); has {
+		policy, err := NewPolicy([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithPolicy(policy))
+	}
+
+	if param, has := os.LookupEnv(EnvPolicySSM); has {
+		data, err := ssmParameter(ctx, param)
+		if err != nil {
+			return nil, err
+		}
+
+		policy, err := NewPolicy([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithPolicy(policy))
+	}
+
+	if data, has := os.LookupEnv(EnvRateLimit); has {
+		limits, err := NewRateLimits([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+
+		var backend Limiter = NewLimiterMemory()
+		if table, has := os.LookupEnv(EnvRateLimitTable); has {
+			cfg, err := awsConfig(ctx)
+			if err != nil {
+				return nil, err
+			}
+			backend = NewLimiterDynamoDB(dynamodb.NewFromConfig(cfg), table)
+		}
+
+		opts = append(opts, WithRateLimit(backend, limits...))
+	}
+
+	if data, has := os.LookupEnv(EnvLimits); has {
+		limits, err := NewLimits([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithLimits(limits))
+	}
+
+	if data, has := os.LookupEnv(EnvNetwork); has {
+		network, err := NewNetwork([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithNetwork(network))
+	}
+
+	if data, has := os.LookupEnv(EnvPrincipals); has {
+		opts = append(opts, WithPrincipals(iam.Parse(data)))
+	}
+
+	if data, has := os.LookupEnv(EnvCapabilities); has {
+		caps, err := NewCapabilities([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithCapabilities(caps))
+	}
+
+	if namespace, has := os.LookupEnv(EnvMetrics); has {
+		opts = append(opts, WithMetrics(namespace, os.Getenv(EnvServer)))
+	}
+
+	if bus, has := os.LookupEnv(EnvEvents); has {
+		cfg, err := awsConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithEvents(eventbridge.NewFromConfig(cfg), bus, os.Getenv(EnvServer)))
+	}
+
+	if stream, has := os.LookupEnv(EnvAnalytics); has {
+		cfg, err := awsConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithAnalytics(firehose.NewFromConfig(cfg), stream, os.Getenv(EnvServer)))
+	}
+
+	if table, has := os.LookupEnv(EnvAuditTable); has {
+		var tools []string
+		if data, has := os.LookupEnv(EnvAudit); has {
+			if err := json.Unmarshal([]byte(data), &tools); err != nil {
+				return nil, err
+			}
+		}
+
+		cfg, err := awsConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithAudit(audit.NewTrail(dynamodb.NewFromConfig(cfg), table), os.Getenv(EnvServer), tools...))
+	}
+
+	if endpoint, has := os.LookupEnv(EnvOtel); has {
+		t, err := newTracing(ctx, endpoint, os.Getenv(EnvServer))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, withTracing(t))
+	}
+
+	if uri, has := os.LookupEnv(EnvManifest); has {
+		cfg, err := awsConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := FetchManifest(ctx, s3.NewFromConfig(cfg), uri)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithManifest(m))
+
+		if _, has := os.LookupEnv(EnvValidate); has {
+			opts = append(opts, WithValidation(m))
+		}
+	}
+
+	if table, has := os.LookupEnv(EnvWebSocketTable); has {
+		cfg, err := awsConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithConnections(NewConnectionsDynamoDB(dynamodb.NewFromConfig(cfg), table)))
+	}
+
+	if _, has := os.LookupEnv(EnvElicitation); has {
+		sessions, err := sessionsFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithElicitation(sessions))
+	}
+
+	if _, has := os.LookupEnv(EnvProgress); has {
+		sessions, err := sessionsFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithProgress(sessions))
+	}
+
+	if data, has := os.LookupEnv(EnvTenancy); has {
+		spec, err := NewTenancy([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithTenancy(spec))
+	}
+
+	if data, has := os.LookupEnv(EnvResponseCache); has {
+		caches, err := NewResponseCaches([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+
+		store, err := sessionsFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithResponseCache(store, caches...))
+	}
+
+	if data, has := os.LookupEnv(EnvApprovals); has {
+		spec, err := NewApprovals([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+
+		sessions, err := sessionsFromEnv(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var api EventBridge
+		if spec.Bus != "" {
+			cfg, err := awsConfig(ctx)
+			if err != nil {
+				return nil, err
+			}
+			api = eventbridge.NewFromConfig(cfg)
+		}
+
+		opts = append(opts, WithApprovals(spec, sessions, api, os.Getenv(EnvServer)))
+	}
+
+	if data, has := os.LookupEnv(EnvBasePath); has {
+		bp, err := NewBasePath([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithBasePath(bp))
+	}
+
+	if data, has := os.LookupEnv(EnvDeadline); has {
+		spec, err := NewDeadline([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithDeadline(spec))
+	}
+
+	if data, has := os.LookupEnv(EnvProfiling); has {
+		spec, err := NewProfiling([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+
+		cfg, err := awsConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		api := s3.NewFromConfig(cfg)
+		opts = append(opts, WithProfiling(spec, api, os.Getenv(EnvProfilingBucket), os.Getenv(EnvServer)))
+	}
+
+	if data, has := os.LookupEnv(EnvRequestContext); has {
+		fields, err := NewRequestContextFields([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithRequestContext(fields...))
+	}
+
+	if data, has := os.LookupEnv(EnvUploads); has {
+		spec, err := NewUploads([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+
+		cfg, err := awsConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		api := s3.NewPresignClient(s3.NewFromConfig(cfg))
+		opts = append(opts, WithUploads(spec, api, os.Getenv(EnvUploadBucket)))
+	}
+
+	if _, has := os.LookupEnv(EnvHealth); has {
+		opts = append(opts, WithHealth())
+	}
+
+	if data, has := os.LookupEnv(EnvDiscovery); has {
+		doc, err := NewDiscovery([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithDiscovery(doc))
+	}
+
+	if table, has := os.LookupEnv(EnvEventTable); has {
+		cfg, err := awsConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithEventStore(NewEventStoreDynamoDB(dynamodb.NewFromConfig(cfg), table)))
+	}
+
+	if data, has := os.LookupEnv(EnvPartitions); has {
+		routes, err := NewPartitions([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+
+		cfg, err := awsConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithPartitions(lambda.NewFromConfig(cfg), routes))
+	}
+
+	if _, has := os.LookupEnv(EnvPartition); has {
+		opts = append(opts, WithPartition())
+	}
+
+	return opts, nil
+}
+
+// AWS config is loaded once per execution environment, it is shared by all
+// clients constructed during the init phase.
+var (
+	awsConfigOnce sync.Once
+	awsConfigVal  aws.Config
+	awsConfigErr  error
+)
+
+func awsConfig(ctx context.Context) (aws.Config, error) {
+	awsConfigOnce.Do(func() {
+		awsConfigVal, awsConfigErr = config.LoadDefaultConfig(ctx)
+	})
+	return awsConfigVal, awsConfigErr
+}
+
+// sessions store is shared by the gateway and the server within the lambda,
+// the memory store is used if the table is not configured.
+var sessionsStore Sessions
+
+func sessionsFromEnv(ctx context.Context) (Sessions, error) {
+	if sessionsStore != nil {
+		return sessionsStore, nil
+	}
+
+	table, has := os.LookupEnv(EnvSessionTable)
+	if !has {
+		sessionsStore = NewSessionsMemory()
+		return sessionsStore, nil
+	}
+
+	cfg, err := awsConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionsStore = NewSessionsDynamoDB(dynamodb.NewFromConfig(cfg), table)
+	return sessionsStore, nil
+}
+
+func ssmParameter(ctx context.Context, name string) (string, error) {
+	cfg, err := awsConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	val, err := ssm.NewFromConfig(cfg).GetParameter(ctx,
+		&ssm.GetParameterInput{
+			Name:           aws.String(name),
+			WithDecryption: aws.Bool(true),
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(val.Parameter.Value), nil
+}
+
+// ServerFromEnv installs middlewares of MCP server configured by environment
+// variables, e.g. server-side sampling, elicitation or progress.
+func ServerFromEnv(ctx context.Context, server *mcp.Server) error {
+	loggerFromEnv()
+
+	server.AddReceivingMiddleware(chunk.Middleware(), identity.Middleware())
+
+	if model, has := os.LookupEnv(EnvSampling); has {
+		cfg, err := awsConfig(ctx)
+		if err != nil {
+			return err
+		}
+
+		sampler := NewSamplerBedrock(bedrockruntime.NewFromConfig(cfg), model)
+		server.AddSendingMiddleware(SamplingMiddleware(sampler))
+	}
+
+	if _, has := os.LookupEnv(EnvElicitation); has {
+		sessions, err := sessionsFromEnv(ctx)
+		if err != nil {
+			return err
+		}
+		InstallElicitation(server, sessions)
+	}
+
+	if _, has := os.LookupEnv(EnvTenancy); has {
+		server.AddReceivingMiddleware(tenancy.Middleware())
+	}
+
+	if _, has := os.LookupEnv(EnvRequestContext); has {
+		server.AddReceivingMiddleware(reqctx.Middleware())
+	}
+
+	if _, has := os.LookupEnv(EnvDeadline); has {
+		server.AddReceivingMiddleware(DeadlineMiddleware())
+	}
+
+	if data, has := os.LookupEnv(EnvDryRun); has {
+		var tools []string
+		if err := json.Unmarshal([]byte(data), &tools); err != nil {
+			return err
+		}
+		slog.WarnContext(ctx, "server runs in dry-run mode", "tools", tools)
+		server.AddReceivingMiddleware(dryrun.Middleware(tools...))
+	}
+
+	if _, has := os.LookupEnv(EnvProgress); has {
+		sessions, err := sessionsFromEnv(ctx)
+		if err != nil {
+			return err
+		}
+		InstallProgress(server, sessions)
+	}
+
+	return nil
+}
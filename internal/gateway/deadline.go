@@ -0,0 +1,191 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/fogfish/cloudmcp/pkg/mcperr"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// HeaderDeadline carries the soft deadline of the tool call (unix time in
+// milliseconds) to the server, the runtime cancels the context of the tool
+// handler at the deadline. The value supplied by clients is discarded.
+const HeaderDeadline = "Mcp-Deadline"
+
+// Deadline of tool calls. API Gateway replies 502/504 if the lambda does not
+// complete in time, the gateway cancels tool handlers slightly before the
+// hard timeout and replies with JSON-RPC timeout error instead.
+type Deadline struct {
+	// Time budget of the call in seconds, the integration timeout of API
+	// Gateway (29 seconds) is usually shorter than the timeout of the lambda.
+	Budget float64 `json:"budget,omitempty"`
+
+	// Margin in seconds reserved before the hard deadline to reply
+	Margin float64 `json:"margin,omitempty"`
+}
+
+// NewDeadline decodes deadline from JSON
+func NewDeadline(data []byte) (*Deadline, error) {
+	var spec Deadline
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+
+	if spec.Margin == 0 {
+		spec.Margin = 1
+	}
+
+	return &spec, nil
+}
+
+// soft deadline of the call, derived from deadline of the lambda context
+func (d *Deadline) soft(ctx context.Context) (time.Time, bool) {
+	deadline, has := ctx.Deadline()
+	if d.Budget > 0 {
+		budget := time.Now().Add(seconds(d.Budget))
+		if !has || budget.Before(deadline) {
+			deadline, has = budget, true
+		}
+	}
+	if !has {
+		return time.Time{}, false
+	}
+
+	return deadline.Add(-seconds(d.Margin)), true
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// serves tools/call by controller, the handler is cancelled at the soft
+// deadline (see DeadlineMiddleware). The handler that does not respect the
+// context still runs after the reply, it is resumed on the next invocation
+// of the lambda.
+func (gw *Gateway) serveCtrlWithin(ctx context.Context, req *events.APIGatewayProxyRequest, call *jsonrpc.Request, principal *Principal) (*events.APIGatewayProxyResponse, error) {
+	deadline, has := gw.deadline.soft(ctx)
+	if !has {
+		setRequestHeader(req, HeaderDeadline, "")
+		return gw.serveCtrl(ctx, req)
+	}
+
+	setRequestHeader(req, HeaderDeadline, strconv.FormatInt(deadline.UnixMilli(), 10))
+
+	tctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	type reply struct {
+		rsp *events.APIGatewayProxyResponse
+		err error
+	}
+	ch := make(chan reply, 1)
+	go func() {
+		rsp, err := gw.serveCtrl(tctx, req)
+		ch <- reply{rsp, err}
+	}()
+
+	// the handler cancelled at the deadline replies concurrently with the timer
+	var r reply
+	select {
+	case r = <-ch:
+	case <-tctx.Done():
+	}
+	if tctx.Err() == nil {
+		return r.rsp, r.err
+	}
+
+	slog.WarnContext(ctx, "tool call is timed out", "tool", toolName(call))
+	return gw.timeout(ctx, req, call, principal), nil
+}
+
+// DeadlineMiddleware cancels the context of tool handlers at the soft
+// deadline set by the gateway, it is installed by the lambda runtime.
+func DeadlineMiddleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			extra := req.GetExtra()
+			if method != methodToolsCall || extra == nil || extra.Header == nil {
+				return next(ctx, method, req)
+			}
+
+			ms, err := strconv.ParseInt(extra.Header.Get(HeaderDeadline), 10, 64)
+			if err != nil {
+				return next(ctx, method, req)
+			}
+
+			ctx, cancel := context.WithDeadline(ctx, time.UnixMilli(ms))
+			defer cancel()
+
+			return next(ctx, method, req)
+		}
+	}
+}
+
+// JSON-RPC timeout error, carrying the last progress of the call if known
+func (gw *Gateway) timeout(ctx context.Context, req *events.APIGatewayProxyRequest, call *jsonrpc.Request, principal *Principal) *events.APIGatewayProxyResponse {
+	var data any
+	if gw.progress != nil {
+		if token := progressToken(call); token != nil {
+			// the lambda context is about to expire
+			pctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 200*time.Millisecond)
+			defer cancel()
+
+			// the request is still being served, its headers are not updated
+			scope := digest(principal.Tenant, principal.ID, sessionID(req))
+			val, err := gw.progress.Get(pctx, progressKey(scope, token))
+			if err != nil {
+				slog.WarnContext(ctx, "failed to read progress", "err", err)
+			}
+			if val != nil {
+				data = mcperr.Data{Details: json.RawMessage(val)}
+			}
+		}
+	}
+
+	body, _ := json.Marshal(wireResponse{
+		Version: "2.0",
+		ID:      call.ID.Raw(),
+		Error: &wireError{
+			Code:    mcperr.CodeTimeout,
+			Message: "tool " + toolName(call) + " has not completed in time",
+			Data:    data,
+		},
+	})
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: mcperr.StatusCode(mcperr.CodeTimeout),
+		MultiValueHeaders: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+		Body: string(body),
+	}
+}
+
+// progress token of the request, nil if the client does not track progress
+func progressToken(call *jsonrpc.Request) any {
+	var params struct {
+		Meta struct {
+			ProgressToken any `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(call.Params, &params); err != nil {
+		return nil
+	}
+
+	return params.Meta.ProgressToken
+}
@@ -0,0 +1,113 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// path of discovery endpoint, relative to the host
+const pathDiscovery = "/.well-known/mcp.json"
+
+// Discovery is the document describing connection details of the server,
+// clients and registries discover the server from it. It is generated by
+// builder, endpoints are paths relative to the host serving the document.
+type Discovery struct {
+	Name            string                  `json:"name"`
+	Title           string                  `json:"title,omitempty"`
+	Version         string                  `json:"version"`
+	ProtocolVersion string                  `json:"protocolVersion"`
+	Transport       string                  `json:"transport"`
+	Endpoint        string                  `json:"endpoint"`
+	Versions        map[string]string       `json:"versions,omitempty"`
+	Auth            []DiscoveryAuth         `json:"auth"`
+	Capabilities    *mcp.ServerCapabilities `json:"capabilities,omitempty"`
+}
+
+// DiscoveryAuth is the authentication mode accepted by the server
+type DiscoveryAuth struct {
+	// public, apikey, jwt, opaque or iam
+	Type string `json:"type"`
+
+	// scheme of Authorization header (e.g. Basic, Bearer, AWS4-HMAC-SHA256)
+	Scheme string `json:"scheme,omitempty"`
+
+	// issuer and audiences of JWT
+	Issuer   string   `json:"issuer,omitempty"`
+	Audience []string `json:"audience,omitempty"`
+
+	// service of SigV4 signature
+	Service string `json:"service,omitempty"`
+}
+
+// NewDiscovery parses the discovery document
+func NewDiscovery(data []byte) (*Discovery, error) {
+	var doc Discovery
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+// WithDiscovery enables discovery endpoint `GET /.well-known/mcp.json`
+func WithDiscovery(doc *Discovery) Option {
+	return func(gw *Gateway) {
+		gw.discovery = doc
+	}
+}
+
+// serves the discovery document, endpoints are resolved against the host
+// of the request.
+func (gw *Gateway) serveDiscovery(req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	host := req.RequestContext.DomainName
+	if host == "" {
+		host = requestHeader(req, "Host")
+	}
+
+	doc := *gw.discovery
+	doc.Endpoint = discoveryURL(host, doc.Endpoint)
+	if len(doc.Versions) > 0 {
+		doc.Versions = make(map[string]string, len(gw.discovery.Versions))
+		for version, endpoint := range gw.discovery.Versions {
+			doc.Versions[version] = discoveryURL(host, endpoint)
+		}
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		MultiValueHeaders: http.Header{
+			"Content-Type":                []string{"application/json"},
+			"Cache-Control":               []string{"public, max-age=300"},
+			"Access-Control-Allow-Origin": []string{"*"},
+		},
+		Body: string(data),
+	}, nil
+}
+
+func discoveryURL(host, path string) string {
+	if host == "" {
+		return path
+	}
+	return "https://" + host + path
+}
+
+func isDiscoveryPath(path string) bool {
+	return strings.HasSuffix(path, pathDiscovery)
+}
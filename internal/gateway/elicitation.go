@@ -0,0 +1,254 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/fogfish/cloudmcp/pkg/mcperr"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const methodElicit = "elicitation/create"
+
+// pending elicitation waits for the answer no longer than
+const elicitationTTL = time.Hour
+
+// Elicitation over the stateless transport. The request of the tool is
+// persisted in the sessions store and the tool call fails with the error
+// mcperr.CodeElicitationPending, which carries the elicitation request.
+// The client answers the request as usual (JSON-RPC response) and retries
+// the call. The tool is executed again, the answer is replayed to it.
+//
+// The tool has to be deterministic before elicitation, the call is
+// identified by the caller, the tool name and the arguments.
+func InstallElicitation(server *mcp.Server, sessions Sessions) {
+	server.AddReceivingMiddleware(elicitationCallMiddleware(sessions))
+	server.AddSendingMiddleware(ElicitationMiddleware(sessions))
+}
+
+// pending elicitation request persisted in the sessions store
+type elicitation struct {
+	Scope  string            `json:"scope"`
+	Params *mcp.ElicitParams `json:"params"`
+	Result *mcp.ElicitResult `json:"result,omitempty"`
+}
+
+// JSON-RPC request of elicitation delivered to the client
+type elicitationRequest struct {
+	Version string            `json:"jsonrpc"`
+	ID      string            `json:"id"`
+	Method  string            `json:"method"`
+	Params  *mcp.ElicitParams `json:"params"`
+}
+
+func elicitationKey(id string) string { return "elicitation#" + id }
+
+// state of tools/call, elicitation requests are numbered in order they are made
+type elicitationCall struct {
+	key   string
+	scope string
+	seq   atomic.Int64
+}
+
+type elicitationCallKey struct{}
+
+func (call *elicitationCall) id(seq int64) string {
+	return digest(call.key, strconv.FormatInt(seq, 10))
+}
+
+// receiving middleware identifies tools/call, the identity is used by
+// elicitation requests made by the tool
+func elicitationCallMiddleware(sessions Sessions) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != methodToolsCall {
+				return next(ctx, method, req)
+			}
+
+			tool, ok := req.(*mcp.CallToolRequest)
+			if !ok {
+				return next(ctx, method, req)
+			}
+
+			scope := scopeOf(tool.GetExtra())
+			call := &elicitationCall{
+				key:   digest(scope, tool.Params.Name, string(tool.Params.Arguments)),
+				scope: scope,
+			}
+			res, err := next(context.WithValue(ctx, elicitationCallKey{}, call), method, req)
+
+			// answers are not needed once the call is completed
+			if result, ok := res.(*mcp.CallToolResult); ok && err == nil && !result.IsError {
+				for seq := range call.seq.Load() {
+					if err := sessions.Remove(ctx, elicitationKey(call.id(seq+1))); err != nil {
+						slog.WarnContext(ctx, "failed to remove elicitation", "err", err)
+					}
+				}
+			}
+
+			return res, err
+		}
+	}
+}
+
+// ElicitationMiddleware intercepts `elicitation/create` requests sent by
+// MCP server to the client. It replays the answer if it is known, otherwise
+// persists the request and fails with mcperr.ErrElicitationPending.
+func ElicitationMiddleware(sessions Sessions) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != methodElicit {
+				return next(ctx, method, req)
+			}
+
+			call, ok := ctx.Value(elicitationCallKey{}).(*elicitationCall)
+			if !ok {
+				return next(ctx, method, req)
+			}
+
+			params, ok := req.GetParams().(*mcp.ElicitParams)
+			if !ok {
+				return next(ctx, method, req)
+			}
+
+			id := call.id(call.seq.Add(1))
+			data, err := sessions.Get(ctx, elicitationKey(id))
+			if err != nil {
+				return nil, err
+			}
+
+			var state elicitation
+			if data != nil {
+				if err := json.Unmarshal(data, &state); err != nil {
+					return nil, err
+				}
+				if state.Result != nil {
+					return state.Result, nil
+				}
+			}
+
+			if data == nil {
+				data, err := json.Marshal(elicitation{Scope: call.scope, Params: params})
+				if err != nil {
+					return nil, err
+				}
+
+				if err := sessions.Put(ctx, elicitationKey(id), data, elicitationTTL); err != nil {
+					return nil, err
+				}
+			}
+
+			return nil, mcperr.ElicitationPending(
+				elicitationRequest{Version: "2.0", ID: id, Method: methodElicit, Params: params},
+			)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// serves the answer of the client to pending elicitation request, the
+// response is passed to the server if it does not belong to elicitation.
+func (gw *Gateway) serveElicitResult(ctx context.Context, req *events.APIGatewayProxyRequest, msg *jsonrpc.Response) (*events.APIGatewayProxyResponse, error) {
+	id, ok := msg.ID.Raw().(string)
+	if !ok {
+		return gw.serveCtrl(ctx, req)
+	}
+
+	data, err := gw.elicitation.Get(ctx, elicitationKey(id))
+	if err != nil {
+		slog.ErrorContext(ctx, "sessions store failed", "err", err)
+		return nil, err
+	}
+	if data == nil {
+		return gw.serveCtrl(ctx, req)
+	}
+
+	var state elicitation
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	principal := gw.principal(req)
+	if principal == nil {
+		principal = newAnonymous(req)
+	}
+
+	if state.Scope != sessionScope(req, principal) {
+		slog.WarnContext(ctx, "elicitation is answered by other caller", "principal", principal.ID)
+		return NewErrorResponse(http.StatusForbidden, msg.ID, mcperr.CodeUnauthorized, "not allowed to answer elicitation"), nil
+	}
+
+	// JSON-RPC error implies that user has not answered
+	state.Result = &mcp.ElicitResult{Action: "cancel"}
+	if msg.Error == nil {
+		if err := json.Unmarshal(msg.Result, state.Result); err != nil {
+			return NewErrorResponse(http.StatusBadRequest, msg.ID, CodeInvalidParams, "invalid elicitation result"), nil
+		}
+	}
+
+	data, err = json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gw.elicitation.Put(ctx, elicitationKey(id), data, elicitationTTL); err != nil {
+		slog.ErrorContext(ctx, "sessions store failed", "err", err)
+		return nil, err
+	}
+
+	return &events.APIGatewayProxyResponse{StatusCode: http.StatusAccepted}, nil
+}
+
+// Surfaces pending elicitation request to clients that accept event stream.
+// The tools/call response is rewritten into the stream of elicitation request
+// followed by the error, as if the server has sent the request over the stream.
+func surfaceElicitation(r *events.APIGatewayProxyRequest, rsp *events.APIGatewayProxyResponse) {
+	if !strings.Contains(requestHeader(r, "Accept"), "text/event-stream") {
+		return
+	}
+
+	if !isErrorResponse(rsp.Body) {
+		return
+	}
+
+	var wire struct {
+		Error *struct {
+			Code int64 `json:"code"`
+			Data struct {
+				Details json.RawMessage `json:"details"`
+			} `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(rsp.Body), &wire); err != nil || wire.Error == nil {
+		return
+	}
+
+	if wire.Error.Code != mcperr.CodeElicitationPending || len(wire.Error.Data.Details) == 0 {
+		return
+	}
+
+	rsp.Body = "event: message\ndata: " + string(wire.Error.Data.Details) + "\n\n" +
+		"event: message\ndata: " + rsp.Body + "\n\n"
+
+	if rsp.MultiValueHeaders == nil {
+		rsp.MultiValueHeaders = map[string][]string{}
+	}
+	http.Header(rsp.MultiValueHeaders).Set("Content-Type", "text/event-stream")
+}
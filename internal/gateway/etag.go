@@ -0,0 +1,71 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// tags listings of tools and resources with ETag, the digest of the result
+// as seen by the caller (e.g. after pruning to capabilities). Clients caching
+// listings revalidate them with If-None-Match, the gateway replies 304 Not
+// Modified without the body if the listing is not changed.
+func tagResponse(req *events.APIGatewayProxyRequest, msg jsonrpc.Message, rsp *events.APIGatewayProxyResponse) *events.APIGatewayProxyResponse {
+	call, ok := msg.(*jsonrpc.Request)
+	if !ok || rsp == nil || rsp.StatusCode != http.StatusOK {
+		return rsp
+	}
+
+	switch call.Method {
+	case methodToolsList, methodResourcesList, methodResourcesTemplatesList:
+	default:
+		return rsp
+	}
+
+	var wire struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(rsp.Body), &wire); err != nil || wire.Result == nil {
+		return rsp
+	}
+
+	etag := `"` + digest(call.Method, string(wire.Result)) + `"`
+
+	if match := requestHeader(req, "If-None-Match"); match != "" && hasETag(match, etag) {
+		return &events.APIGatewayProxyResponse{
+			StatusCode: http.StatusNotModified,
+			MultiValueHeaders: http.Header{
+				"Etag": []string{etag},
+			},
+		}
+	}
+
+	if rsp.MultiValueHeaders == nil {
+		rsp.MultiValueHeaders = map[string][]string{}
+	}
+	http.Header(rsp.MultiValueHeaders).Set("Etag", etag)
+
+	return rsp
+}
+
+// weak comparison of If-None-Match (RFC 9110), listings are equivalent
+func hasETag(match, etag string) bool {
+	for tag := range strings.SplitSeq(match, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
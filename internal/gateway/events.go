@@ -0,0 +1,103 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// Source of EventBridge events emitted by the gateway
+const EventSource = "cloudmcp"
+
+// Detail types of EventBridge events, one event is emitted per tool call
+var eventDetailType = map[string]string{
+	OutcomeSuccess:  "Tool Call Succeeded",
+	OutcomeError:    "Tool Call Failed",
+	OutcomeRejected: "Tool Call Rejected",
+}
+
+// EventBridge interface required by the emitter
+type EventBridge interface {
+	PutEvents(context.Context, *eventbridge.PutEventsInput, ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// Detail of EventBridge event
+type ToolCallEvent struct {
+	Server    string  `json:"server"`
+	Tool      string  `json:"tool"`
+	Outcome   string  `json:"outcome"`
+	Duration  float64 `json:"duration"`
+	Principal string  `json:"principal,omitempty"`
+	Tenant    string  `json:"tenant,omitempty"`
+	Session   string  `json:"session,omitempty"`
+}
+
+// emitter publishes EventBridge event per tool call
+type emitter struct {
+	api    EventBridge
+	bus    string
+	server string
+}
+
+// emit is deferred by the gateway, it publishes the event once response is
+// ready. Failures are logged, they do not impact the tool call.
+func (e *emitter) emit(ctx context.Context, tool string, principal *Principal, session string, t time.Time, rsp **events.APIGatewayProxyResponse) {
+	outcome := OutcomeError
+	if *rsp != nil {
+		outcome = outcomeOf(*rsp)
+	}
+
+	detail := ToolCallEvent{
+		Server:   e.server,
+		Tool:     tool,
+		Outcome:  outcome,
+		Duration: float64(time.Since(t).Microseconds()) / 1000.0,
+		Session:  session,
+	}
+	if principal != nil {
+		detail.Principal = principal.ID
+		detail.Tenant = principal.Tenant
+	}
+
+	data, err := json.Marshal(detail)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode event", "err", err)
+		return
+	}
+
+	out, err := e.api.PutEvents(ctx,
+		&eventbridge.PutEventsInput{
+			Entries: []types.PutEventsRequestEntry{
+				{
+					EventBusName: aws.String(e.bus),
+					Source:       aws.String(EventSource),
+					DetailType:   aws.String(eventDetailType[outcome]),
+					Detail:       aws.String(string(data)),
+					Time:         aws.Time(time.Now()),
+				},
+			},
+		},
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to emit event", "err", err)
+		return
+	}
+
+	if out.FailedEntryCount > 0 {
+		slog.ErrorContext(ctx, "event is rejected by EventBridge", "code", aws.ToString(out.Entries[0].ErrorCode))
+	}
+}
@@ -0,0 +1,358 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// events of streams are replayed no longer than
+const eventTTL = time.Hour
+
+// DynamoDB interface required by event store
+type DynamoDBEvents interface {
+	GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// Event store of Streamable HTTP transport in DynamoDB table, outbound events
+// are shared across concurrent lambda instances. The table uses "session"
+// as partition key, "event" as sort key and "ttl" as time-to-live attribute.
+// The event is addressed by stream and zero padded index ("stream#0000000001"),
+// the head of stream ("stream#") keeps the number of events.
+type EventStoreDynamoDB struct {
+	db    DynamoDBEvents
+	table string
+}
+
+var _ mcp.EventStore = (*EventStoreDynamoDB)(nil)
+
+func NewEventStoreDynamoDB(db DynamoDBEvents, table string) *EventStoreDynamoDB {
+	return &EventStoreDynamoDB{db: db, table: table}
+}
+
+func eventKey(streamID string, index int) string {
+	return fmt.Sprintf("%s#%010d", streamID, index)
+}
+
+func eventHead(streamID string) string { return streamID + "#" }
+
+func (s *EventStoreDynamoDB) key(sessionID, event string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"session": &types.AttributeValueMemberS{Value: sessionID},
+		"event":   &types.AttributeValueMemberS{Value: event},
+	}
+}
+
+func (s *EventStoreDynamoDB) ttl() types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(eventTTL).Unix(), 10)}
+}
+
+func (s *EventStoreDynamoDB) Open(ctx context.Context, sessionID, streamID string) error {
+	item := s.key(sessionID, eventHead(streamID))
+	item["seq"] = &types.AttributeValueMemberN{Value: "0"}
+	item["ttl"] = s.ttl()
+
+	_, err := s.db.PutItem(ctx,
+		&dynamodb.PutItemInput{
+			TableName:                aws.String(s.table),
+			Item:                     item,
+			ConditionExpression:      aws.String("attribute_not_exists(#event)"),
+			ExpressionAttributeNames: map[string]string{"#event": "event"},
+		},
+	)
+
+	// the stream is opened already
+	var conflict *types.ConditionalCheckFailedException
+	if errors.As(err, &conflict) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *EventStoreDynamoDB) Append(ctx context.Context, sessionID, streamID string, data []byte) error {
+	head, err := s.db.UpdateItem(ctx,
+		&dynamodb.UpdateItemInput{
+			TableName:                aws.String(s.table),
+			Key:                      s.key(sessionID, eventHead(streamID)),
+			UpdateExpression:         aws.String("ADD #seq :one SET #ttl = :ttl"),
+			ConditionExpression:      aws.String("attribute_exists(#seq)"),
+			ExpressionAttributeNames: map[string]string{"#seq": "seq", "#ttl": "ttl"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":one": &types.AttributeValueMemberN{Value: "1"},
+				":ttl": s.ttl(),
+			},
+			ReturnValues: types.ReturnValueUpdatedNew,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append event to stream %s: %w", streamID, err)
+	}
+
+	seq, err := attributeInt(head.Attributes["seq"])
+	if err != nil {
+		return err
+	}
+
+	item := s.key(sessionID, eventKey(streamID, seq-1))
+	item["data"] = &types.AttributeValueMemberB{Value: data}
+	item["ttl"] = s.ttl()
+
+	_, err = s.db.PutItem(ctx,
+		&dynamodb.PutItemInput{
+			TableName: aws.String(s.table),
+			Item:      item,
+		},
+	)
+	return err
+}
+
+func (s *EventStoreDynamoDB) After(ctx context.Context, sessionID, streamID string, index int) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		head, err := s.db.GetItem(ctx,
+			&dynamodb.GetItemInput{
+				TableName:      aws.String(s.table),
+				Key:            s.key(sessionID, eventHead(streamID)),
+				ConsistentRead: aws.Bool(true),
+			},
+		)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if head.Item == nil {
+			yield(nil, fmt.Errorf("stream %s is not found", streamID))
+			return
+		}
+
+		seq, err := attributeInt(head.Item["seq"])
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		var cursor map[string]types.AttributeValue
+		expected := index + 1
+		for expected < seq {
+			val, err := s.db.Query(ctx,
+				&dynamodb.QueryInput{
+					TableName:              aws.String(s.table),
+					KeyConditionExpression: aws.String("#session = :session AND #event BETWEEN :from AND :to"),
+					ExpressionAttributeNames: map[string]string{
+						"#session": "session",
+						"#event":   "event",
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":session": &types.AttributeValueMemberS{Value: sessionID},
+						":from":    &types.AttributeValueMemberS{Value: eventKey(streamID, expected)},
+						":to":      &types.AttributeValueMemberS{Value: eventKey(streamID, math.MaxInt32)},
+					},
+					ConsistentRead:    aws.Bool(true),
+					ExclusiveStartKey: cursor,
+				},
+			)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, item := range val.Items {
+				event, _ := item["event"].(*types.AttributeValueMemberS)
+				data, _ := item["data"].(*types.AttributeValueMemberB)
+				if event == nil || data == nil || event.Value != eventKey(streamID, expected) {
+					yield(nil, fmt.Errorf("events of stream %s after %d are dropped", streamID, index))
+					return
+				}
+
+				if !yield(data.Value, nil) {
+					return
+				}
+				expected++
+			}
+
+			cursor = val.LastEvaluatedKey
+			if cursor == nil {
+				break
+			}
+		}
+
+		if expected < seq {
+			yield(nil, fmt.Errorf("events of stream %s after %d are dropped", streamID, index))
+		}
+	}
+}
+
+func (s *EventStoreDynamoDB) SessionClosed(ctx context.Context, sessionID string) error {
+	var cursor map[string]types.AttributeValue
+	for {
+		val, err := s.db.Query(ctx,
+			&dynamodb.QueryInput{
+				TableName:                aws.String(s.table),
+				KeyConditionExpression:   aws.String("#session = :session"),
+				ExpressionAttributeNames: map[string]string{"#session": "session", "#event": "event"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":session": &types.AttributeValueMemberS{Value: sessionID},
+				},
+				ProjectionExpression: aws.String("#session, #event"),
+				ExclusiveStartKey:    cursor,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range val.Items {
+			_, err := s.db.DeleteItem(ctx,
+				&dynamodb.DeleteItemInput{
+					TableName: aws.String(s.table),
+					Key:       item,
+				},
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		cursor = val.LastEvaluatedKey
+		if cursor == nil {
+			return nil
+		}
+	}
+}
+
+func attributeInt(val types.AttributeValue) (int, error) {
+	n, ok := val.(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, errors.New("invalid sequence number of stream")
+	}
+
+	return strconv.Atoi(n.Value)
+}
+
+//------------------------------------------------------------------------------
+
+// Records messages of the response into new stream of the session, the
+// response is rewritten into the event stream with ids of events, so that
+// the client reconnects using Last-Event-ID.
+func (gw *Gateway) recordEvents(ctx context.Context, r *events.APIGatewayProxyRequest, rsp *events.APIGatewayProxyResponse) {
+	session := sessionID(r)
+	if session == "" || rsp.StatusCode != http.StatusOK || len(rsp.Body) == 0 {
+		return
+	}
+
+	if !strings.Contains(requestHeader(r, "Accept"), "text/event-stream") {
+		return
+	}
+
+	messages := []string{rsp.Body}
+	if !strings.HasPrefix(rsp.Body, "{") {
+		messages = eventData(rsp.Body)
+	}
+
+	stream := rand.Text()
+	if err := gw.events.Open(ctx, session, stream); err != nil {
+		slog.WarnContext(ctx, "failed to open stream", "err", err)
+		return
+	}
+
+	body := strings.Builder{}
+	for idx, msg := range messages {
+		if err := gw.events.Append(ctx, session, stream, []byte(msg)); err != nil {
+			slog.WarnContext(ctx, "failed to append event", "err", err)
+			return
+		}
+		writeEvent(&body, stream, idx, msg)
+	}
+
+	rsp.Body = body.String()
+	if rsp.MultiValueHeaders == nil {
+		rsp.MultiValueHeaders = map[string][]string{}
+	}
+	http.Header(rsp.MultiValueHeaders).Set("Content-Type", "text/event-stream")
+}
+
+// Replays events of the stream after Last-Event-ID, the response is buffered
+// by API Gateway, therefore it contains only events recorded so far.
+func (gw *Gateway) serveReplay(ctx context.Context, r *events.APIGatewayProxyRequest, lastEventID string) (*events.APIGatewayProxyResponse, error) {
+	session := sessionID(r)
+	stream, index, ok := parseEventID(lastEventID)
+	if session == "" || !ok {
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusBadRequest}, nil
+	}
+
+	body := strings.Builder{}
+	for data, err := range gw.events.After(ctx, session, stream, index) {
+		if err != nil {
+			slog.WarnContext(ctx, "failed to replay events", "stream", stream, "err", err)
+			return &events.APIGatewayProxyResponse{StatusCode: http.StatusNotFound}, nil
+		}
+		index++
+		writeEvent(&body, stream, index, string(data))
+	}
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		MultiValueHeaders: http.Header{
+			"Content-Type": []string{"text/event-stream"},
+		},
+		Body: body.String(),
+	}, nil
+}
+
+// event id is "stream_index", as defined by MCP SDK
+func writeEvent(w *strings.Builder, stream string, index int, data string) {
+	fmt.Fprintf(w, "event: message\nid: %s_%d\ndata: %s\n\n", stream, index, data)
+}
+
+func parseEventID(id string) (string, int, bool) {
+	stream, seq, ok := strings.Cut(id, "_")
+	if !ok || stream == "" {
+		return "", 0, false
+	}
+
+	index, err := strconv.Atoi(seq)
+	if err != nil || index < 0 {
+		return "", 0, false
+	}
+
+	return stream, index, true
+}
+
+// data of events in the stream
+func eventData(stream string) []string {
+	seq := []string{}
+	for event := range strings.SplitSeq(stream, "\n\n") {
+		for line := range strings.SplitSeq(event, "\n") {
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				seq = append(seq, data)
+			}
+		}
+	}
+	return seq
+}
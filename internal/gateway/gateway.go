@@ -11,10 +11,19 @@ package gateway
 import (
 	"context"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/fogfish/cloudmcp/internal/iam"
+	"github.com/fogfish/cloudmcp/pkg/identity"
+	"github.com/fogfish/cloudmcp/pkg/mcperr"
+	"github.com/fogfish/cloudmcp/pkg/tenancy"
+	"github.com/fogfish/cloudmcp/pkg/upload"
 	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // Control plane of JSON-RPC Gateway
@@ -26,55 +35,356 @@ type Controller interface {
 // HTTP requests understood by MCP JSON-RPC server and routes them to different
 // lambda functions.
 type Gateway struct {
-	ctrl Controller
+	ctrl    Controller
+	policy  Policy
+	limiter *limiter
+	metrics *metrics
+	tracing *tracing
+	cache   *Manifest
+
+	validator  *validator
+	limits     *Limits
+	network    *Network
+	principals iam.Principals
+
+	capabilities Capabilities
+
+	connections Connections
+	elicitation Sessions
+	events      mcp.EventStore
+	progress    Sessions
+	responses   *responses
+	tenancy     *Tenancy
+	emitter     *emitter
+	analytics   *analytics
+	auditor     *auditor
+	approvals   *approvals
+	uploads     *uploads
+	reqctx      *requestContext
+	basePath    *BasePath
+	deadline    *Deadline
+	profiler    *profiler
+	health      bool
+	discovery   *Discovery
+	partitions  *partitions
+	partition   bool
 }
 
 // Create new JSON-RPC Serverless Gateway
-func New(ctrl Controller) *Gateway {
-	return &Gateway{ctrl: ctrl}
+func New(ctrl Controller, opts ...Option) *Gateway {
+	gw := &Gateway{ctrl: ctrl}
+	for _, opt := range opts {
+		opt(gw)
+	}
+
+	return gw
 }
 
 // Serve handles incoming API Gateway requests and routes them to MCP JSON-RPC server.
 func (gw *Gateway) Serve(ctx context.Context, req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	// the request is routed by the gateway, which has enforced policies
+	if gw.partition {
+		ctx, scope := withLogScope(ctx, req)
+		rsp, err := gw.serveCtrl(ctx, req)
+		scope.reply(rsp)
+		return rsp, err
+	}
+
+	if gw.basePath != nil {
+		gw.basePath.rewrite(req)
+	}
+
+	ctx, scope := withLogScope(ctx, req)
+
+	if gw.profiler != nil {
+		var done func()
+		ctx, done = gw.profiler.start(ctx)
+		defer done()
+	}
+
+	rsp, err := gw.serve(ctx, req)
+	scope.reply(rsp)
+
+	return rsp, err
+}
+
+func (gw *Gateway) serve(ctx context.Context, req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	if !gw.network.IsAllowed(req) {
+		slog.WarnContext(ctx, "caller network is not allowed", "sourceIp", req.RequestContext.Identity.SourceIP)
+		return NewErrorResponse(http.StatusForbidden, jsonrpc.ID{}, mcperr.CodeUnauthorized, "caller network is not allowed"), nil
+	}
+
+	// IAM authorizer accepts any identity permitted to invoke the api
+	if id := req.RequestContext.Identity; id.UserArn != "" && gw.principals != nil && !gw.principals.IsAllowed(id.AccountID, id.UserArn) {
+		slog.WarnContext(ctx, "caller principal is not allowed", "principal", id.UserArn)
+		return NewErrorResponse(http.StatusForbidden, jsonrpc.ID{}, mcperr.CodeUnauthorized, "caller principal is not allowed"), nil
+	}
+
+	if gw.approvals != nil && isApprovalsPath(req.Path) {
+		return gw.serveApproval(ctx, req)
+	}
+
+	if gw.uploads != nil && isUploadsPath(req.Path) {
+		return gw.serveUpload(ctx, req)
+	}
+
 	// In the context of MCP protocol, GET implies a setup of a streaming connection,
-	// which is not supported in lambda proxy.
+	// which is not supported in lambda proxy. Only replay of recorded events is.
 	if req.HTTPMethod == "GET" {
+		if gw.health && isHealthPath(req.Path) {
+			return gw.serveHealth(ctx)
+		}
+
+		if gw.discovery != nil && isDiscoveryPath(req.Path) {
+			return gw.serveDiscovery(req)
+		}
+
+		if gw.progress != nil && isProgressPath(req.Path) {
+			return gw.serveProgress(ctx, req)
+		}
+
+		if lastEventID := requestHeader(req, "Last-Event-Id"); lastEventID != "" && gw.events != nil {
+			return gw.serveReplay(ctx, req, lastEventID)
+		}
+
 		return &events.APIGatewayProxyResponse{
 			StatusCode: 405,
 		}, nil
 	}
 
+	setRequestHeader(req, identity.Header, gw.principal(req).caller())
+
 	if len(req.Body) == 0 {
 		return gw.serveCtrl(ctx, req)
 	}
 
-	msg, err := jsonrpc.DecodeMessage([]byte(req.Body))
+	if gw.limits != nil {
+		if rsp := gw.limits.check(ctx, req.Body); rsp != nil {
+			return rsp, nil
+		}
+	}
+
+	if isBatch(req.Body) {
+		slog.WarnContext(ctx, "json-rpc batch is not supported")
+		return NewErrorResponse(http.StatusBadRequest, jsonrpc.ID{}, CodeInvalidRequest, "json-rpc batch is not supported"), nil
+	}
+
+	msg, err := decodeMessage(req.Body)
 	if err != nil {
-		slog.Error("bad json-rpc message", "err", err)
+		slog.ErrorContext(ctx, "bad json-rpc message", "err", err)
 		return nil, err
 	}
-	slog.Debug("received json-rpc message", "msg", msg)
+	annotateLogScope(ctx, msg)
+	slog.DebugContext(ctx, "received json-rpc message", "msg", msg)
+
+	if call, ok := msg.(*jsonrpc.Request); ok && gw.reqctx != nil {
+		if err := gw.reqctx.inject(req, call, gw.principal(req)); err != nil {
+			slog.WarnContext(ctx, "bad json-rpc params", "err", err)
+			return NewErrorResponse(http.StatusBadRequest, call.ID, CodeInvalidParams, "invalid params"), nil
+		}
+	}
+
+	if gw.tracing != nil {
+		ctx, span := gw.tracing.start(ctx, req, msg)
+		rsp, err := gw.serveMsg(ctx, req, msg)
+		gw.tracing.end(ctx, span, rsp, err)
+		return rsp, err
+	}
+
+	return gw.serveMsg(ctx, req, msg)
+}
+
+func (gw *Gateway) serveMsg(ctx context.Context, req *events.APIGatewayProxyRequest, msg jsonrpc.Message) (*events.APIGatewayProxyResponse, error) {
+	rsp, err := gw.routeMsg(ctx, req, msg)
+	if err == nil && gw.events != nil {
+		gw.recordEvents(ctx, req, rsp)
+	}
+
+	if err == nil {
+		rsp = tagResponse(req, msg, rsp)
+	}
 
-	// TODO: handle json-rpc switching message
-	// switch v := msg.(type) {
-	// case *jsonrpc.Request:
-	// 	if v.Method == "tools/call" {
-	// 		// TODO: return
-	// 	}
-	// }
+	return rsp, err
+}
+
+func (gw *Gateway) routeMsg(ctx context.Context, req *events.APIGatewayProxyRequest, msg jsonrpc.Message) (*events.APIGatewayProxyResponse, error) {
+	if call, ok := msg.(*jsonrpc.Request); ok && call.Method == methodToolsCall {
+		return gw.serveTool(ctx, req, call)
+	}
+
+	if reply, ok := msg.(*jsonrpc.Response); ok && gw.elicitation != nil {
+		return gw.serveElicitResult(ctx, req, reply)
+	}
+
+	if call, ok := msg.(*jsonrpc.Request); ok && gw.capabilities != nil {
+		return gw.serveCapabilities(ctx, req, call)
+	}
+
+	if call, ok := msg.(*jsonrpc.Request); ok && gw.cache != nil {
+		if rsp := gw.cache.serve(call); rsp != nil {
+			return rsp, nil
+		}
+	}
 
 	return gw.serveCtrl(ctx, req)
 }
 
+// serves request, pruning tools and resources to capabilities of the caller
+func (gw *Gateway) serveCapabilities(ctx context.Context, req *events.APIGatewayProxyRequest, call *jsonrpc.Request) (*events.APIGatewayProxyResponse, error) {
+	principal := gw.principal(req)
+
+	if uri := resourceURI(call); uri != "" && !gw.capabilities.IsAllowedResource(principal, uri) {
+		slog.WarnContext(ctx, "resource is not exposed", "uri", uri)
+		return NewErrorResponse(http.StatusForbidden, call.ID, mcperr.CodeUnauthorized, "not allowed to read resource "+uri), nil
+	}
+
+	var rsp *events.APIGatewayProxyResponse
+	if gw.cache != nil {
+		rsp = gw.cache.serve(call)
+	}
+
+	if rsp == nil {
+		var err error
+		rsp, err = gw.serveCtrl(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gw.capabilities.prune(principal, call.Method, rsp)
+	return rsp, nil
+}
+
+// serves tools/call request, enforcing the policy and rate limits
+func (gw *Gateway) serveTool(ctx context.Context, req *events.APIGatewayProxyRequest, call *jsonrpc.Request) (rsp *events.APIGatewayProxyResponse, err error) {
+	tool := toolName(call)
+	principal := gw.principal(req)
+
+	if gw.metrics != nil {
+		defer gw.metrics.measure(ctx, toolDimension(tool, gw.cache, gw.policy), gw.tenantOf(principal), len(req.Body), time.Now(), &rsp)
+	}
+
+	if gw.emitter != nil {
+		t := time.Now()
+		defer func() { gw.emitter.emit(ctx, tool, principal, sessionID(req), t, &rsp) }()
+	}
+
+	if gw.analytics != nil {
+		t := time.Now()
+		defer func() { gw.analytics.record(ctx, tool, principal, sessionID(req), len(req.Body), t, &rsp) }()
+	}
+
+	if gw.auditor != nil && gw.auditor.audits(tool, gw.cache) {
+		t := time.Now()
+		defer func() { gw.auditor.record(ctx, call, tool, principal, sessionID(req), t, &rsp) }()
+	}
+
+	if !gw.policy.IsAllowed(principal, tool) {
+		slog.WarnContext(ctx, "tool call is not allowed", "tool", tool)
+		return NewErrorResponse(http.StatusForbidden, call.ID, mcperr.CodeUnauthorized, "not allowed to call tool "+tool), nil
+	}
+
+	if !gw.capabilities.IsAllowedTool(principal, tool) {
+		slog.WarnContext(ctx, "tool is not exposed", "tool", tool)
+		return NewErrorResponse(http.StatusForbidden, call.ID, mcperr.CodeUnauthorized, "not allowed to call tool "+tool), nil
+	}
+
+	if gw.validator != nil {
+		if err := gw.validator.validate(ctx, call.Params); err != nil {
+			slog.WarnContext(ctx, "tool call is invalid", "tool", tool, "err", err)
+			return NewErrorResponse(http.StatusBadRequest, call.ID, CodeInvalidParams, err.Error()), nil
+		}
+	}
+
+	if gw.tenancy != nil {
+		if principal == nil || principal.Tenant == "" {
+			slog.WarnContext(ctx, "tool call without tenant", "tool", tool)
+			return NewErrorResponse(http.StatusForbidden, call.ID, mcperr.CodeUnauthorized, "tenant is required to call tool "+tool), nil
+		}
+		setRequestHeader(req, tenancy.Header, principal.Tenant)
+	}
+
+	if principal == nil {
+		principal = newAnonymous(req)
+	}
+
+	if gw.uploads != nil {
+		setRequestHeader(req, upload.Header, gw.uploads.prefix(req, principal))
+	}
+
+	wait, err := gw.limiter.Take(ctx, principal, sessionID(req), tool)
+	if err != nil {
+		slog.ErrorContext(ctx, "rate limiter failed", "err", err)
+		return nil, err
+	}
+	if wait > 0 {
+		slog.WarnContext(ctx, "tool call is rate limited", "tool", tool, "principal", principal.ID)
+		rsp := NewErrorResponse(http.StatusTooManyRequests, call.ID, mcperr.CodeRateLimited, "too many calls of tool "+tool)
+		http.Header(rsp.MultiValueHeaders).Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		return rsp, nil
+	}
+
+	if gw.approvals != nil {
+		rsp, err := gw.approvals.gate(ctx, call, tool, principal)
+		if err != nil {
+			slog.ErrorContext(ctx, "approvals failed", "err", err)
+			return nil, err
+		}
+		if rsp != nil {
+			return rsp, nil
+		}
+	}
+
+	// state of elicitation and progress is scoped to the caller
+	if gw.elicitation != nil || gw.progress != nil {
+		sessionScope(req, principal)
+	}
+
+	ttl := gw.responses.ttl(tool)
+	key := ""
+	if ttl > 0 {
+		key = responseKey(principal, call)
+		if result := gw.responses.get(ctx, key); result != nil {
+			return NewResultResponse(call.ID, result), nil
+		}
+	}
+
+	if fn, has := gw.partitions.function(tool); has {
+		rsp, err = gw.servePartition(ctx, req, fn)
+	} else if gw.deadline != nil {
+		rsp, err = gw.serveCtrlWithin(ctx, req, call, principal)
+	} else {
+		rsp, err = gw.serveCtrl(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl > 0 {
+		gw.responses.put(ctx, key, rsp, ttl)
+	}
+
+	if gw.elicitation != nil {
+		surfaceElicitation(req, rsp)
+	}
+
+	return rsp, nil
+}
+
 func (gw *Gateway) serveCtrl(ctx context.Context, req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	input, err := NewHttpRequest(ctx, req)
 	if err != nil {
-		slog.Error("bad http request", "err", err)
+		slog.ErrorContext(ctx, "bad http request", "err", err)
 		return nil, err
 	}
 
 	reply := NewHttpResponse()
+	t := time.Now()
 	gw.ctrl.ServeHTTP(reply, input)
+	timeHandler(ctx, t)
+
+	rsp := reply.Value()
+	mapErrorResponse(rsp)
 
-	return reply.Value(), nil
+	return rsp, nil
 }
@@ -0,0 +1,147 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// path of health endpoint, relative to the server endpoint
+const pathHealth = "/healthz"
+
+// Status of health checks
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+// Health of the server as seen by uptime monitors
+type Health struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+// HealthCheck is status of the component
+type HealthCheck struct {
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+	Error    string  `json:"error,omitempty"`
+}
+
+const healthInitialize = `{"jsonrpc":"2.0","id":"healthz","method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"healthz","version":"v1.0.0"}}}`
+
+// serves health of the server, it performs initialize round-trip against the
+// server and checks reachability of the sessions store. The status is 503
+// if any check fails.
+func (gw *Gateway) serveHealth(ctx context.Context) (*events.APIGatewayProxyResponse, error) {
+	health := Health{Status: HealthOK, Checks: map[string]HealthCheck{}}
+	check := func(name string, f func() error) {
+		t := time.Now()
+		err := f()
+
+		status := HealthCheck{Status: HealthOK, Duration: float64(time.Since(t).Microseconds()) / 1000.0}
+		if err != nil {
+			slog.WarnContext(ctx, "health check failed", "check", name, "err", err)
+			status.Status, status.Error = HealthFail, err.Error()
+			health.Status = HealthFail
+		}
+		health.Checks[name] = status
+	}
+
+	check("server", func() error { return gw.checkServer(ctx) })
+
+	sessions := gw.elicitation
+	if sessions == nil {
+		sessions = gw.progress
+	}
+
+	if sessions != nil {
+		check("sessions", func() error {
+			_, err := sessions.Get(ctx, "health#")
+			return err
+		})
+	}
+
+	if gw.responses != nil && gw.responses.store != sessions {
+		check("responses", func() error {
+			_, err := gw.responses.store.Get(ctx, "health#")
+			return err
+		})
+	}
+
+	data, err := json.Marshal(health)
+	if err != nil {
+		return nil, err
+	}
+
+	code := http.StatusOK
+	if health.Status != HealthOK {
+		code = http.StatusServiceUnavailable
+	}
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: code,
+		MultiValueHeaders: http.Header{
+			"Content-Type":  []string{"application/json"},
+			"Cache-Control": []string{"no-cache"},
+		},
+		Body: string(data),
+	}, nil
+}
+
+// initialize round-trip, bypassing the manifest
+func (gw *Gateway) checkServer(ctx context.Context) error {
+	rsp, err := gw.serveCtrl(ctx,
+		&events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodPost,
+			Path:       "/",
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"Accept":       "application/json, text/event-stream",
+			},
+			Body: healthInitialize,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("initialize failed with status %d", rsp.StatusCode)
+	}
+
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(rsp.Body), &reply); err != nil {
+		return fmt.Errorf("invalid initialize response: %w", err)
+	}
+	if reply.Error != nil {
+		return fmt.Errorf("initialize failed: %s", reply.Error.Message)
+	}
+	if reply.Result == nil {
+		return fmt.Errorf("initialize result is missing")
+	}
+
+	return nil
+}
+
+func isHealthPath(path string) bool {
+	return strings.HasSuffix(path, pathHealth)
+}
@@ -0,0 +1,157 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/fogfish/cloudmcp/pkg/identity"
+)
+
+// Principal is the caller identity as authenticated by API Gateway.
+type Principal struct {
+	// Unique identity of the caller: JWT subject, API access key or IAM ARN
+	ID string
+
+	// Claims of the caller (JWT claims or Lambda authorizer context)
+	Claims map[string]any
+
+	// Tenant of the caller, if tenancy is configured
+	Tenant string
+
+	// Kind of the authentication
+	Kind identity.Kind
+}
+
+// NewPrincipal extracts the caller identity from API Gateway request context.
+// It supports JWT, Lambda (API Key) and IAM authorizers. It returns nil for
+// public access.
+func NewPrincipal(r *events.APIGatewayProxyRequest) *Principal {
+	auth := r.RequestContext.Authorizer
+
+	// JWT authorizer, payload format 1.0 places claims under "claims",
+	// payload format 2.0 under "jwt.claims".
+	if claims, ok := auth["claims"].(map[string]any); ok {
+		return newPrincipalFromClaims(claims)
+	}
+	if jwt, ok := auth["jwt"].(map[string]any); ok {
+		if claims, ok := jwt["claims"].(map[string]any); ok {
+			return newPrincipalFromClaims(claims)
+		}
+	}
+
+	// Lambda authorizer (API Key, JWT issuers or introspection) passes its context as is.
+	if len(auth) > 0 {
+		claims := map[string]any{}
+		for key, val := range auth {
+			claims[key] = val
+		}
+		if lambda, ok := auth["lambda"].(map[string]any); ok {
+			for key, val := range lambda {
+				claims[key] = val
+			}
+		}
+
+		id, _ := claims["sub"].(string)
+		if id == "" {
+			id, _ = claims["principalId"].(string)
+		}
+		if id == "" {
+			return nil
+		}
+		claims["sub"] = id
+
+		// Lambda authorizer of multiple JWT issuers or token introspection
+		switch claims["auth"] {
+		case "jwt":
+			return &Principal{ID: id, Claims: claims, Kind: identity.JWT}
+		case "opaque":
+			return &Principal{ID: id, Claims: claims, Kind: identity.Opaque}
+		}
+
+		return &Principal{ID: id, Claims: claims, Kind: identity.APIKey}
+	}
+
+	// IAM authorizer
+	if arn := r.RequestContext.Identity.UserArn; arn != "" {
+		return &Principal{
+			ID:   arn,
+			Kind: identity.IAM,
+			Claims: map[string]any{
+				"sub":     arn,
+				"account": r.RequestContext.Identity.AccountID,
+				"caller":  r.RequestContext.Identity.Caller,
+			},
+		}
+	}
+
+	return nil
+}
+
+// Anonymous principal identified by the source IP, used for public access.
+func newAnonymous(r *events.APIGatewayProxyRequest) *Principal {
+	return &Principal{
+		ID:     "anonymous@" + r.RequestContext.Identity.SourceIP,
+		Claims: map[string]any{},
+	}
+}
+
+func newPrincipalFromClaims(claims map[string]any) *Principal {
+	id, _ := claims["sub"].(string)
+	return &Principal{ID: id, Claims: claims, Kind: identity.JWT}
+}
+
+// caller encoded for the header, empty for public access
+func (p *Principal) caller() string {
+	if p == nil || p.ID == "" {
+		return ""
+	}
+
+	return identity.Encode(&identity.Principal{ID: p.ID, Kind: p.Kind, Claims: p.Claims, Tenant: p.Tenant})
+}
+
+// Has checks if the claim of principal contains the value. Claims might be
+// strings, space/comma delimited strings (e.g. scope) or arrays.
+func (p *Principal) Has(claim, value string) bool {
+	if p == nil {
+		return false
+	}
+
+	switch v := p.Claims[claim].(type) {
+	case string:
+		if v == value {
+			return true
+		}
+		for _, x := range strings.FieldsFunc(v, isClaimSeparator) {
+			if x == value {
+				return true
+			}
+		}
+	case []any:
+		for _, x := range v {
+			if s, ok := x.(string); ok && s == value {
+				return true
+			}
+		}
+	case []string:
+		for _, x := range v {
+			if x == value {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// API Gateway serializes arrays claims (e.g. cognito:groups) as "[a b]".
+func isClaimSeparator(r rune) bool {
+	return r == ' ' || r == ',' || r == '[' || r == ']'
+}
@@ -0,0 +1,229 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/fogfish/cloudmcp/pkg/mcperr"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// JSON-RPC error codes used by the gateway
+const (
+	CodeInvalidRequest = -32600
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+const methodToolsCall = "tools/call"
+
+type wireError struct {
+	Code    int64  `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type wireResponse struct {
+	Version string     `json:"jsonrpc"`
+	ID      any        `json:"id"`
+	Error   *wireError `json:"error"`
+}
+
+// NewErrorResponse builds API Gateway response carrying JSON-RPC error.
+func NewErrorResponse(status int, id jsonrpc.ID, code int64, message string) *events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(wireResponse{
+		Version: "2.0",
+		ID:      id.Raw(),
+		Error:   &wireError{Code: code, Message: message},
+	})
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: status,
+		MultiValueHeaders: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+		Body: string(body),
+	}
+}
+
+type wireResult struct {
+	Version string `json:"jsonrpc"`
+	ID      any    `json:"id"`
+	Result  any    `json:"result"`
+}
+
+// NewResultResponse builds API Gateway response carrying JSON-RPC result.
+func NewResultResponse(id jsonrpc.ID, result any) *events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(wireResult{
+		Version: "2.0",
+		ID:      id.Raw(),
+		Result:  result,
+	})
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		MultiValueHeaders: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+		Body: string(body),
+	}
+}
+
+// Maps JSON-RPC error of the taxonomy (see pkg/mcperr) to HTTP status and
+// Retry-After header. Other responses are passed as is.
+func mapErrorResponse(rsp *events.APIGatewayProxyResponse) {
+	if !isErrorResponse(rsp.Body) {
+		return
+	}
+
+	var wire struct {
+		Error *struct {
+			Code int64       `json:"code"`
+			Data mcperr.Data `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(rsp.Body), &wire); err != nil || wire.Error == nil {
+		return
+	}
+
+	status := mcperr.StatusCode(wire.Error.Code)
+	if status == 0 {
+		return
+	}
+
+	rsp.StatusCode = status
+	if wire.Error.Data.RetryAfter > 0 {
+		if rsp.MultiValueHeaders == nil {
+			rsp.MultiValueHeaders = map[string][]string{}
+		}
+		http.Header(rsp.MultiValueHeaders).Set("Retry-After", strconv.Itoa(wire.Error.Data.RetryAfter))
+	}
+}
+
+// Checks if the body is JSON-RPC error response. Results might be
+// multi-megabyte and mention "error" within content, the body is streamed
+// until the first of top-level "result" or "error" members instead of
+// decoding it as a whole.
+func isErrorResponse(body string) bool {
+	if !strings.HasPrefix(body, "{") || !strings.Contains(body, `"error"`) {
+		return false
+	}
+
+	dec := json.NewDecoder(strings.NewReader(body))
+	if _, err := dec.Token(); err != nil {
+		return false
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return false
+		}
+
+		switch key {
+		case "error":
+			return true
+		case "result":
+			return false
+		}
+
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return false
+		}
+	}
+
+	return false
+}
+
+// Decodes JSON-RPC message of the request body. The body is viewed as bytes
+// without copying, it might be multi-megabyte. The view is read-only, the
+// decoder copies values (e.g. params) rather than referencing the input.
+func decodeMessage(body string) (jsonrpc.Message, error) {
+	return jsonrpc.DecodeMessage(unsafe.Slice(unsafe.StringData(body), len(body)))
+}
+
+// JSON-RPC batch (top-level array), batches are removed from MCP and
+// the decoder never accepts them.
+func isBatch(body string) bool {
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// MCP session id of the request, empty string for stateless clients
+func sessionID(r *events.APIGatewayProxyRequest) string {
+	return requestHeader(r, "Mcp-Session-Id")
+}
+
+// header of the request, API Gateway does not canonicalize header names
+func requestHeader(r *events.APIGatewayProxyRequest, name string) string {
+	for header, value := range r.Headers {
+		if http.CanonicalHeaderKey(header) == name {
+			return value
+		}
+	}
+	for header, values := range r.MultiValueHeaders {
+		if http.CanonicalHeaderKey(header) == name && len(values) > 0 {
+			return values[len(values)-1]
+		}
+	}
+	return ""
+}
+
+// tool name of tools/call request, empty string for other methods
+func toolName(req *jsonrpc.Request) string {
+	if req.Method != methodToolsCall {
+		return ""
+	}
+
+	var params mcp.CallToolParamsRaw
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return ""
+	}
+
+	return params.Name
+}
+
+// sets header of the request, overriding any value supplied by the client
+func setRequestHeader(r *events.APIGatewayProxyRequest, name, value string) {
+	for header := range r.Headers {
+		if http.CanonicalHeaderKey(header) == name {
+			delete(r.Headers, header)
+		}
+	}
+	if r.Headers == nil {
+		r.Headers = map[string]string{}
+	}
+	r.Headers[name] = value
+
+	for header := range r.MultiValueHeaders {
+		if http.CanonicalHeaderKey(header) == name {
+			delete(r.MultiValueHeaders, header)
+		}
+	}
+	if r.MultiValueHeaders != nil {
+		r.MultiValueHeaders[name] = []string{value}
+	}
+}
@@ -0,0 +1,97 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// Limits of requests, enforced before the message is decoded. Zero value
+// of the limit disables it.
+type Limits struct {
+	// Size of the body in bytes, larger requests are rejected with 413
+	MaxBodySize int `json:"maxBodySize,omitempty"`
+
+	// Nesting of JSON objects and arrays, deeper requests are rejected with 400
+	MaxDepth int `json:"maxDepth,omitempty"`
+}
+
+// NewLimits decodes limits from JSON
+func NewLimits(data []byte) (*Limits, error) {
+	var limits Limits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, err
+	}
+
+	return &limits, nil
+}
+
+// checks the body against limits, the rejection is returned if the body
+// violates any of them.
+func (l *Limits) check(ctx context.Context, body string) *events.APIGatewayProxyResponse {
+	if l.MaxBodySize > 0 && len(body) > l.MaxBodySize {
+		slog.WarnContext(ctx, "request is too large", "size", len(body), "limit", l.MaxBodySize)
+		return NewErrorResponse(http.StatusRequestEntityTooLarge, jsonrpc.ID{}, CodeInvalidRequest,
+			fmt.Sprintf("request exceeds %d bytes", l.MaxBodySize))
+	}
+
+	if l.MaxDepth > 0 && depth(body, l.MaxDepth) > l.MaxDepth {
+		slog.WarnContext(ctx, "request is too deep", "limit", l.MaxDepth)
+		return NewErrorResponse(http.StatusBadRequest, jsonrpc.ID{}, CodeInvalidRequest,
+			fmt.Sprintf("request exceeds nesting depth %d", l.MaxDepth))
+	}
+
+	return nil
+}
+
+// scans JSON text without decoding it, it returns the maximum nesting depth
+// (up to the limit + 1). Malformed JSON is left to the decoder.
+func depth(body string, limit int) int {
+	level, depth, quoted, escaped := 0, 0, false, false
+
+	for i := 0; i < len(body); i++ {
+		ch := body[i]
+
+		if quoted {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == '"':
+				quoted = false
+			}
+			continue
+		}
+
+		switch ch {
+		case '"':
+			quoted = true
+		case '{', '[':
+			level++
+			if level > depth {
+				depth = level
+				if depth > limit {
+					return depth
+				}
+			}
+		case '}', ']':
+			level--
+		}
+	}
+
+	return depth
+}
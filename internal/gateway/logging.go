@@ -0,0 +1,165 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// Header carrying correlation ID of the request. The gateway accepts the
+// value supplied by the client or derives one from the request ID, it is
+// forwarded to the server and returned to the client.
+const HeaderCorrelationID = "X-Correlation-Id"
+
+// NewLogger creates logger, which annotates every record with attributes of
+// the request being served (AWS request ID, MCP session ID, correlation ID,
+// JSON-RPC id and tool name). The format is either "json" or "text".
+func NewLogger(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		h = slog.NewTextHandler(w, opts)
+	}
+
+	return slog.New(&logHandler{Handler: h})
+}
+
+var setupLogger sync.Once
+
+// installs the default logger, configured from environment
+func loggerFromEnv() {
+	setupLogger.Do(func() {
+		var level slog.Level
+		if val, has := os.LookupEnv(EnvLogLevel); has {
+			if err := level.UnmarshalText([]byte(val)); err != nil {
+				slog.Warn("invalid log level", "level", val)
+			}
+		}
+
+		slog.SetDefault(NewLogger(os.Stdout, level, os.Getenv(EnvLogFormat)))
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// attributes of the request being served, they are enriched as the request
+// is decoded and routed.
+type logScope struct {
+	requestID     string
+	sessionID     string
+	correlationID string
+	rpcID         jsonrpc.ID
+	tool          string
+}
+
+type logScopeKey struct{}
+
+// annotates context with the scope of request, the correlation ID is set
+// to the request so that it is forwarded to the server.
+func withLogScope(ctx context.Context, req *events.APIGatewayProxyRequest) (context.Context, *logScope) {
+	scope := &logScope{
+		requestID:     req.RequestContext.RequestID,
+		sessionID:     sessionID(req),
+		correlationID: requestHeader(req, HeaderCorrelationID),
+	}
+
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		scope.requestID = lc.AwsRequestID
+	}
+
+	if scope.correlationID == "" {
+		scope.correlationID = req.RequestContext.RequestID
+		if scope.correlationID == "" {
+			scope.correlationID = scope.requestID
+		}
+	}
+
+	if scope.correlationID != "" {
+		setRequestHeader(req, HeaderCorrelationID, scope.correlationID)
+	}
+
+	return context.WithValue(ctx, logScopeKey{}, scope), scope
+}
+
+// annotates scope of the context with JSON-RPC message
+func annotateLogScope(ctx context.Context, msg jsonrpc.Message) {
+	scope, ok := ctx.Value(logScopeKey{}).(*logScope)
+	if !ok {
+		return
+	}
+
+	if call, ok := msg.(*jsonrpc.Request); ok {
+		scope.rpcID = call.ID
+		scope.tool = toolName(call)
+	}
+}
+
+// returns correlation ID to the client
+func (s *logScope) reply(rsp *events.APIGatewayProxyResponse) {
+	if rsp == nil || s.correlationID == "" {
+		return
+	}
+
+	if rsp.MultiValueHeaders == nil {
+		rsp.MultiValueHeaders = map[string][]string{}
+	}
+	rsp.MultiValueHeaders[HeaderCorrelationID] = []string{s.correlationID}
+}
+
+func (s *logScope) attrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, 5)
+	if s.requestID != "" {
+		attrs = append(attrs, slog.String("requestId", s.requestID))
+	}
+	if s.sessionID != "" {
+		attrs = append(attrs, slog.String("sessionId", s.sessionID))
+	}
+	if s.correlationID != "" {
+		attrs = append(attrs, slog.String("correlationId", s.correlationID))
+	}
+	if s.rpcID.IsValid() {
+		attrs = append(attrs, slog.Any("rpcId", s.rpcID.Raw()))
+	}
+	if s.tool != "" {
+		attrs = append(attrs, slog.String("tool", s.tool))
+	}
+	return attrs
+}
+
+// handler injecting attributes of the scope into records
+type logHandler struct{ slog.Handler }
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if scope, ok := ctx.Value(logScopeKey{}).(*logScope); ok {
+		r.AddAttrs(scope.attrs()...)
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithGroup(name)}
+}
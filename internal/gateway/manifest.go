@@ -0,0 +1,171 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	methodInitialize = "initialize"
+	methodToolsList  = "tools/list"
+)
+
+// Manifest is a snapshot of MCP server capabilities and tools, taken at
+// build time. The gateway serves `initialize` and `tools/list` from the
+// manifest without invoking the server.
+type Manifest struct {
+	Initialize *mcp.InitializeResult `json:"initialize"`
+	Tools      *mcp.ListToolsResult  `json:"tools"`
+}
+
+// Snapshot takes the manifest of the server using in-memory client.
+func Snapshot(ctx context.Context, server *mcp.Server) (*Manifest, error) {
+	ct, st := mcp.NewInMemoryTransports()
+	ss, err := server.Connect(ctx, st, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.Close()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "cloudmcp", Version: "snapshot"}, nil)
+	cs, err := client.Connect(ctx, ct, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cs.Close()
+
+	tools := &mcp.ListToolsResult{Tools: []*mcp.Tool{}}
+	for tool, err := range cs.Tools(ctx, nil) {
+		if err != nil {
+			return nil, err
+		}
+		tools.Tools = append(tools.Tools, tool)
+	}
+
+	return &Manifest{Initialize: cs.InitializeResult(), Tools: tools}, nil
+}
+
+// EncodeManifest encodes the manifest as compressed string.
+func EncodeManifest(m *Manifest) (string, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	w, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeManifest decodes the manifest encoded by EncodeManifest.
+func DecodeManifest(s string) (*Manifest, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// S3 interface required to fetch the manifest
+type S3Manifest interface {
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// FetchManifest reads the manifest shipped as S3 object s3://{bucket}/{key}
+// (asset of the stack), it is not bounded by limits of lambda environment.
+func FetchManifest(ctx context.Context, api S3Manifest, uri string) (*Manifest, error) {
+	obj, err := url.Parse(uri)
+	if err != nil || obj.Scheme != "s3" || obj.Host == "" {
+		return nil, fmt.Errorf("invalid manifest location %s", uri)
+	}
+
+	val, err := api.GetObject(ctx,
+		&s3.GetObjectInput{
+			Bucket: aws.String(obj.Host),
+			Key:    aws.String(strings.TrimPrefix(obj.Path, "/")),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer val.Body.Close()
+
+	data, err := io.ReadAll(val.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeManifest(string(data))
+}
+
+// serves the request from the manifest, nil is returned if the manifest
+// is not applicable (e.g. other protocol version or paginated listing).
+func (m *Manifest) serve(req *jsonrpc.Request) *events.APIGatewayProxyResponse {
+	switch req.Method {
+	case methodInitialize:
+		var params mcp.InitializeParams
+		if m.Initialize == nil || json.Unmarshal(req.Params, &params) != nil {
+			return nil
+		}
+		if params.ProtocolVersion != m.Initialize.ProtocolVersion {
+			return nil
+		}
+		return NewResultResponse(req.ID, m.Initialize)
+
+	case methodToolsList:
+		var params mcp.ListToolsParams
+		if m.Tools == nil || (len(req.Params) > 0 && json.Unmarshal(req.Params, &params) != nil) {
+			return nil
+		}
+		if params.Cursor != "" {
+			return nil
+		}
+		return NewResultResponse(req.ID, m.Tools)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Outcome of the tool call
+const (
+	OutcomeSuccess  = "success"
+	OutcomeError    = "error"
+	OutcomeRejected = "rejected"
+)
+
+// metrics emits CloudWatch Embedded Metric Format (EMF) records per tool call.
+// See https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+type metrics struct {
+	namespace string
+	server    string
+	w         io.Writer
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+type emfRecord struct {
+	AWS          emfMetadata `json:"_aws"`
+	Server       string      `json:"Server"`
+	Tool         string      `json:"Tool"`
+	Tenant       string      `json:"Tenant,omitempty"`
+	Outcome      string      `json:"Outcome"`
+	Calls        int         `json:"Calls"`
+	Duration     float64     `json:"Duration"`
+	RequestSize  int         `json:"RequestSize"`
+	ResponseSize int         `json:"ResponseSize"`
+}
+
+// Tool dimension of calls to tools unknown to the gateway
+const toolUnknown = "unknown"
+
+var emfMetrics = []emfMetric{
+	{Name: "Calls", Unit: "Count"},
+	{Name: "Duration", Unit: "Milliseconds"},
+	{Name: "RequestSize", Unit: "Bytes"},
+	{Name: "ResponseSize", Unit: "Bytes"},
+}
+
+// measure is deferred by the gateway, it emits the record once response is ready.
+// Metrics are also emitted per tenant, if the tenant is defined.
+func (m *metrics) measure(ctx context.Context, tool, tenant string, size int, t time.Time, rsp **events.APIGatewayProxyResponse) {
+	outcome := OutcomeError
+	bytes := 0
+	if *rsp != nil {
+		outcome = outcomeOf(*rsp)
+		bytes = len((*rsp).Body)
+	}
+
+	dimensions := [][]string{{"Server", "Tool", "Outcome"}}
+	if tenant != "" {
+		dimensions = append(dimensions, []string{"Server", "Tenant", "Outcome"})
+	}
+
+	now := time.Now()
+	record := emfRecord{
+		AWS: emfMetadata{
+			Timestamp: now.UnixMilli(),
+			CloudWatchMetrics: []emfDirective{
+				{
+					Namespace:  m.namespace,
+					Dimensions: dimensions,
+					Metrics:    emfMetrics,
+				},
+			},
+		},
+		Server:       m.server,
+		Tool:         tool,
+		Tenant:       tenant,
+		Outcome:      outcome,
+		Calls:        1,
+		Duration:     float64(now.Sub(t).Microseconds()) / 1000.0,
+		RequestSize:  size,
+		ResponseSize: bytes,
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode EMF record", "err", err)
+		return
+	}
+
+	data = append(data, '\n')
+	if _, err := m.w.Write(data); err != nil {
+		slog.ErrorContext(ctx, "failed to emit EMF record", "err", err)
+	}
+}
+
+// tool as the metric dimension. The name is supplied by the client, it is
+// emitted only for tools known from the manifest or the policy, so that
+// clients do not blow up cardinality of metrics.
+func toolDimension(tool string, cache *Manifest, policy Policy) string {
+	if cache != nil && cache.Tools != nil {
+		for _, t := range cache.Tools.Tools {
+			if t.Name == tool {
+				return tool
+			}
+		}
+	}
+
+	for _, grant := range policy {
+		if tool != "*" && slices.Contains(grant.Tools, tool) {
+			return tool
+		}
+	}
+
+	return toolUnknown
+}
+
+func outcomeOf(rsp *events.APIGatewayProxyResponse) string {
+	switch {
+	case rsp.StatusCode == 403 || rsp.StatusCode == 429:
+		return OutcomeRejected
+	case rsp.StatusCode >= 300:
+		return OutcomeError
+	case strings.Contains(rsp.Body, `"error":`) || strings.Contains(rsp.Body, `"isError":true`):
+		return OutcomeError
+	default:
+		return OutcomeSuccess
+	}
+}
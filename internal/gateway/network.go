@@ -0,0 +1,77 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Header set by API Gateway for requests received through interface VPC endpoint
+const HeaderVpcEndpoint = "X-Amzn-Vpce-Id"
+
+// Network restricts callers of the gateway to source addresses or interface
+// VPC endpoints. The caller is allowed if it matches any of them. Endpoints
+// are trusted only behind private API, clients of public API control the
+// header.
+type Network struct {
+	CIDR         []string `json:"cidr,omitempty"`
+	VpcEndpoints []string `json:"vpce,omitempty"`
+	Private      bool     `json:"private,omitempty"`
+
+	prefixes []netip.Prefix
+}
+
+// NewNetwork decodes network restrictions from JSON
+func NewNetwork(data []byte) (*Network, error) {
+	var network Network
+	if err := json.Unmarshal(data, &network); err != nil {
+		return nil, err
+	}
+
+	for _, cidr := range network.CIDR {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %s: %w", cidr, err)
+		}
+		network.prefixes = append(network.prefixes, prefix)
+	}
+
+	return &network, nil
+}
+
+// IsAllowed checks the source of the request
+func (n *Network) IsAllowed(r *events.APIGatewayProxyRequest) bool {
+	if n == nil {
+		return true
+	}
+
+	if n.Private {
+		if vpce := requestHeader(r, HeaderVpcEndpoint); vpce != "" && slices.Contains(n.VpcEndpoints, vpce) {
+			return true
+		}
+	}
+
+	addr, err := netip.ParseAddr(r.RequestContext.Identity.SourceIP)
+	if err != nil {
+		return false
+	}
+
+	for _, prefix := range n.prefixes {
+		if prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+
+	return false
+}
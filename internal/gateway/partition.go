@@ -0,0 +1,103 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// Invoker interface required to call partitions
+type Invoker interface {
+	Invoke(context.Context, *lambda.InvokeInput, ...func(*lambda.Options)) (*lambda.InvokeOutput, error)
+}
+
+// Tools of the server are partitioned across lambda functions. The gateway
+// routes tools/call to the function of the partition, once the call passes
+// policies and limits of the gateway. Other requests and tools outside of
+// partitions are served by the gateway's own server.
+type partitions struct {
+	api    Invoker
+	routes map[string]string
+}
+
+// NewPartitions parses routes of tools to functions of partitions
+func NewPartitions(data []byte) (map[string]string, error) {
+	var routes map[string]string
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// WithPartitions enables routing of tools/call to functions of partitions
+func WithPartitions(api Invoker, routes map[string]string) Option {
+	return func(gw *Gateway) {
+		gw.partitions = &partitions{api: api, routes: routes}
+	}
+}
+
+// WithPartition serves requests routed by the gateway as is, the function is
+// the partition of tools, it is not exposed to clients.
+func WithPartition() Option {
+	return func(gw *Gateway) {
+		gw.partition = true
+	}
+}
+
+// function of the partition serving the tool
+func (p *partitions) function(tool string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+
+	fn, has := p.routes[tool]
+	return fn, has
+}
+
+// calls the function of the partition with the request, the request carries
+// headers injected by the gateway (e.g. identity, tenant).
+func (gw *Gateway) servePartition(ctx context.Context, req *events.APIGatewayProxyRequest, fn string) (*events.APIGatewayProxyResponse, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t := time.Now()
+	out, err := gw.partitions.api.Invoke(ctx,
+		&lambda.InvokeInput{
+			FunctionName: aws.String(fn),
+			Payload:      payload,
+		},
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "partition invocation failed", "function", fn, "err", err)
+		return nil, err
+	}
+	timeHandler(ctx, t)
+
+	if out.FunctionError != nil {
+		return nil, fmt.Errorf("partition %s failed: %s: %s", fn, aws.ToString(out.FunctionError), out.Payload)
+	}
+
+	var rsp events.APIGatewayProxyResponse
+	if err := json.Unmarshal(out.Payload, &rsp); err != nil {
+		return nil, err
+	}
+
+	return &rsp, nil
+}
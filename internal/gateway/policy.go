@@ -0,0 +1,69 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Grant allows callers, whose claim contains the value, to call listed tools.
+// The wildcard "*" matches any value or tool.
+type Grant struct {
+	// Claim to match, "sub" is used if empty. API Key identity is "sub" claim.
+	Claim string `json:"claim,omitempty"`
+
+	// Value of the claim to match
+	Value string `json:"value"`
+
+	// Allowlist of tool names
+	Tools []string `json:"tools"`
+}
+
+// Policy is a set of grants mapping caller identity to allowed tools.
+// Empty policy allows everything.
+type Policy []Grant
+
+// NewPolicy decodes policy from JSON
+func NewPolicy(data []byte) (Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// IsAllowed checks if the principal is allowed to call the tool.
+func (policy Policy) IsAllowed(p *Principal, tool string) bool {
+	if len(policy) == 0 {
+		return true
+	}
+
+	for _, grant := range policy {
+		if !slices.Contains(grant.Tools, "*") && !slices.Contains(grant.Tools, tool) {
+			continue
+		}
+
+		if grant.Value == "*" {
+			return true
+		}
+
+		claim := grant.Claim
+		if claim == "" {
+			claim = "sub"
+		}
+
+		if p.Has(claim, grant.Value) {
+			return true
+		}
+	}
+
+	return false
+}
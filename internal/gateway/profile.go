@@ -0,0 +1,122 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Profiling samples invocations of the lambda, CPU profile of sampled
+// invocation is written to S3 bucket along with the split of the latency
+// between the gateway and the handler (the MCP server).
+type Profiling struct {
+	// Fraction of invocations being profiled, e.g. 0.01
+	Rate float64 `json:"rate"`
+}
+
+// NewProfiling decodes profiling from JSON
+func NewProfiling(data []byte) (*Profiling, error) {
+	var spec Profiling
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+
+	return &spec, nil
+}
+
+// S3 interface required by profiling
+type S3 interface {
+	PutObject(context.Context, *s3.PutObjectInput, ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+type profiler struct {
+	spec   *Profiling
+	api    S3
+	bucket string
+	server string
+}
+
+// timing of the invocation being profiled, the handler might outlive the
+// invocation if it is timed out (see Deadline)
+type timing struct {
+	handler atomic.Int64
+}
+
+type timingKey struct{}
+
+// accounts time spent by the handler since t
+func timeHandler(ctx context.Context, t time.Time) {
+	if tm, ok := ctx.Value(timingKey{}).(*timing); ok {
+		tm.handler.Add(int64(time.Since(t)))
+	}
+}
+
+// starts profiling of the invocation if it is sampled, the returned function
+// completes the profile and writes it to the bucket.
+func (p *profiler) start(ctx context.Context) (context.Context, func()) {
+	if p.spec.Rate <= 0 || rand.Float64() >= p.spec.Rate {
+		return ctx, func() {}
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		slog.WarnContext(ctx, "failed to start profiling", "err", err)
+		return ctx, func() {}
+	}
+
+	tm := &timing{}
+	ctx = context.WithValue(ctx, timingKey{}, tm)
+	t := time.Now()
+
+	return ctx, func() {
+		pprof.StopCPUProfile()
+
+		total := time.Since(t)
+		handler := time.Duration(tm.handler.Load())
+		gateway := total - handler
+		key := fmt.Sprintf("profiles/%s/%s.pprof", p.server, t.UTC().Format("2006-01-02T15:04:05.000000000Z"))
+
+		_, err := p.api.PutObject(context.WithoutCancel(ctx),
+			&s3.PutObjectInput{
+				Bucket:      aws.String(p.bucket),
+				Key:         aws.String(key),
+				Body:        bytes.NewReader(buf.Bytes()),
+				ContentType: aws.String("application/octet-stream"),
+				Metadata: map[string]string{
+					"total-us":   strconv.FormatInt(total.Microseconds(), 10),
+					"gateway-us": strconv.FormatInt(gateway.Microseconds(), 10),
+					"handler-us": strconv.FormatInt(handler.Microseconds(), 10),
+				},
+			},
+		)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to write profile", "err", err)
+			return
+		}
+
+		slog.InfoContext(ctx, "invocation is profiled",
+			"profile", key,
+			"totalMs", float64(total.Microseconds())/1000,
+			"gatewayMs", float64(gateway.Microseconds())/1000,
+			"handlerMs", float64(handler.Microseconds())/1000,
+		)
+	}
+}
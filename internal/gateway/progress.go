@@ -0,0 +1,147 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const methodNotifyProgress = "notifications/progress"
+
+// path of polling endpoint, relative to the server endpoint
+const pathProgress = "/progress"
+
+// progress of the tool is available for polling no longer than
+const progressTTL = time.Hour
+
+// Progress of long running tool call as seen by polling clients
+type Progress struct {
+	ProgressToken any       `json:"progressToken"`
+	Progress      float64   `json:"progress"`
+	Total         float64   `json:"total,omitempty"`
+	Percent       float64   `json:"percent,omitempty"`
+	Message       string    `json:"message,omitempty"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func progressKey(scope string, token any) string {
+	return "progress#" + digest(scope, fmt.Sprint(token))
+}
+
+type progressScopeKey struct{}
+
+// Progress notifications over the stateless transport. The response is
+// buffered by API Gateway, notifications `notifications/progress` sent by
+// tools are persisted in the sessions store instead. Clients poll them using
+// `GET {endpoint}/progress?token={progressToken}`.
+func InstallProgress(server *mcp.Server, sessions Sessions) {
+	server.AddReceivingMiddleware(progressScopeMiddleware())
+	server.AddSendingMiddleware(ProgressMiddleware(sessions))
+}
+
+// receiving middleware passes the scope of the caller to the tool
+func progressScopeMiddleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != methodToolsCall {
+				return next(ctx, method, req)
+			}
+
+			return next(context.WithValue(ctx, progressScopeKey{}, scopeOf(req.GetExtra())), method, req)
+		}
+	}
+}
+
+// ProgressMiddleware intercepts `notifications/progress` sent by MCP server
+// to the client and persists them in the sessions store.
+func ProgressMiddleware(sessions Sessions) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != methodNotifyProgress {
+				return next(ctx, method, req)
+			}
+
+			scope, ok := ctx.Value(progressScopeKey{}).(string)
+			if !ok {
+				return next(ctx, method, req)
+			}
+
+			params, ok := req.GetParams().(*mcp.ProgressNotificationParams)
+			if !ok || params.ProgressToken == nil {
+				return next(ctx, method, req)
+			}
+
+			progress := Progress{
+				ProgressToken: params.ProgressToken,
+				Progress:      params.Progress,
+				Total:         params.Total,
+				Message:       params.Message,
+				UpdatedAt:     time.Now().UTC(),
+			}
+			if params.Total > 0 {
+				progress.Percent = 100 * params.Progress / params.Total
+			}
+
+			data, err := json.Marshal(progress)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := sessions.Put(ctx, progressKey(scope, params.ProgressToken), data, progressTTL); err != nil {
+				slog.WarnContext(ctx, "failed to persist progress", "err", err)
+			}
+
+			return next(ctx, method, req)
+		}
+	}
+}
+
+// serves polling of progress, the progress is visible to the caller only
+func (gw *Gateway) serveProgress(ctx context.Context, req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	token := req.QueryStringParameters["token"]
+	if token == "" {
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusBadRequest}, nil
+	}
+
+	principal := gw.principal(req)
+	if principal == nil {
+		principal = newAnonymous(req)
+	}
+
+	data, err := gw.progress.Get(ctx, progressKey(sessionScope(req, principal), token))
+	if err != nil {
+		slog.ErrorContext(ctx, "sessions store failed", "err", err)
+		return nil, err
+	}
+	if data == nil {
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusNotFound}, nil
+	}
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		MultiValueHeaders: http.Header{
+			"Content-Type":  []string{"application/json"},
+			"Cache-Control": []string{"no-cache"},
+		},
+		Body: string(data),
+	}, nil
+}
+
+func isProgressPath(path string) bool {
+	return strings.HasSuffix(path, pathProgress)
+}
@@ -0,0 +1,149 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit caps the rate of tools/call per caller using token bucket.
+type RateLimit struct {
+	// Name of the tool, "*" matches any tool
+	Tool string `json:"tool"`
+
+	// Number of calls permitted per minute
+	PerMinute int `json:"perMinute"`
+
+	// Burst of calls permitted above the rate, PerMinute is used if zero
+	Burst int `json:"burst,omitempty"`
+
+	// Limit per MCP session instead of per caller identity
+	PerSession bool `json:"perSession,omitempty"`
+}
+
+// NewRateLimits decodes rate limits from JSON
+func NewRateLimits(data []byte) ([]RateLimit, error) {
+	var limits []RateLimit
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, err
+	}
+
+	for _, limit := range limits {
+		if err := limit.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return limits, nil
+}
+
+// zero rate never refills the bucket, the wait overflows the duration
+func (limit RateLimit) validate() error {
+	if limit.PerMinute <= 0 {
+		return fmt.Errorf("rate limit of tool %s: perMinute must be positive", limit.Tool)
+	}
+
+	if limit.Burst < 0 {
+		return fmt.Errorf("rate limit of tool %s: burst must not be negative", limit.Tool)
+	}
+
+	return nil
+}
+
+// Bucket is a state of the token bucket
+type Bucket struct {
+	Tokens float64
+	Time   time.Time
+}
+
+// Take the token from the bucket refilled with given rate limit.
+// It returns the duration to wait if the bucket is exhausted.
+func (b Bucket) Take(limit RateLimit, now time.Time) (Bucket, time.Duration) {
+	burst := float64(limit.Burst)
+	if burst == 0 {
+		burst = float64(limit.PerMinute)
+	}
+	rate := float64(limit.PerMinute) / 60.0
+
+	tokens := burst
+	if !b.Time.IsZero() {
+		tokens = math.Min(burst, b.Tokens+now.Sub(b.Time).Seconds()*rate)
+	}
+
+	if tokens < 1 {
+		wait := time.Duration((1 - tokens) / rate * float64(time.Second))
+		return Bucket{Tokens: tokens, Time: now}, wait
+	}
+
+	return Bucket{Tokens: tokens - 1, Time: now}, 0
+}
+
+// Limiter is a pluggable storage of token buckets. It returns the duration
+// to wait before retry if the call is not permitted.
+type Limiter interface {
+	Take(ctx context.Context, key string, limit RateLimit) (time.Duration, error)
+}
+
+// Limiter that keeps token buckets in memory of the lambda function.
+// The limit is not shared across concurrent lambda instances.
+type LimiterMemory struct {
+	sync.Mutex
+	buckets map[string]Bucket
+}
+
+func NewLimiterMemory() *LimiterMemory {
+	return &LimiterMemory{buckets: map[string]Bucket{}}
+}
+
+func (l *LimiterMemory) Take(ctx context.Context, key string, limit RateLimit) (time.Duration, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	bucket, wait := l.buckets[key].Take(limit, time.Now())
+	l.buckets[key] = bucket
+
+	return wait, nil
+}
+
+// rate limiter configured at the gateway
+type limiter struct {
+	limits  []RateLimit
+	backend Limiter
+}
+
+// Take tokens from each bucket matching the tool.
+func (l *limiter) Take(ctx context.Context, principal *Principal, session, tool string) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+
+	for _, limit := range l.limits {
+		if limit.Tool != "*" && limit.Tool != tool {
+			continue
+		}
+
+		// session id is chosen by the client, it is scoped to the caller
+		key := "identity#" + principal.ID
+		if limit.PerSession && session != "" {
+			key = "session#" + principal.ID + "#" + session
+		}
+
+		wait, err := l.backend.Take(ctx, key+"#"+limit.Tool, limit)
+		if err != nil || wait > 0 {
+			return wait, err
+		}
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,112 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDB interface required by the limiter
+type DynamoDB interface {
+	GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+// Limiter that keeps token buckets in DynamoDB table, the limit is shared
+// across concurrent lambda instances. The table uses "key" as partition key
+// and "ttl" as time-to-live attribute.
+type LimiterDynamoDB struct {
+	db    DynamoDB
+	table string
+}
+
+func NewLimiterDynamoDB(db DynamoDB, table string) *LimiterDynamoDB {
+	return &LimiterDynamoDB{db: db, table: table}
+}
+
+func (l *LimiterDynamoDB) Take(ctx context.Context, key string, limit RateLimit) (time.Duration, error) {
+	// optimistic concurrency, bucket is updated only if nobody else did it
+	for range 3 {
+		val, err := l.db.GetItem(ctx,
+			&dynamodb.GetItemInput{
+				TableName:      aws.String(l.table),
+				Key:            map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: key}},
+				ConsistentRead: aws.Bool(true),
+			},
+		)
+		if err != nil {
+			return 0, err
+		}
+
+		bucket, version := decodeBucket(val.Item)
+		bucket, wait := bucket.Take(limit, time.Now())
+		if wait > 0 {
+			return wait, nil
+		}
+
+		input := &dynamodb.PutItemInput{
+			TableName: aws.String(l.table),
+			Item: map[string]types.AttributeValue{
+				"key":    &types.AttributeValueMemberS{Value: key},
+				"tokens": &types.AttributeValueMemberN{Value: strconv.FormatFloat(bucket.Tokens, 'f', -1, 64)},
+				"time":   &types.AttributeValueMemberN{Value: strconv.FormatInt(bucket.Time.UnixMicro(), 10)},
+				"ttl":    &types.AttributeValueMemberN{Value: strconv.FormatInt(bucket.Time.Add(time.Hour).Unix(), 10)},
+			},
+			ConditionExpression:      aws.String("attribute_not_exists(#key)"),
+			ExpressionAttributeNames: map[string]string{"#key": "key"},
+		}
+
+		if version != "" {
+			input.ConditionExpression = aws.String("#time = :version")
+			input.ExpressionAttributeNames = map[string]string{"#time": "time"}
+			input.ExpressionAttributeValues = map[string]types.AttributeValue{
+				":version": &types.AttributeValueMemberN{Value: version},
+			}
+		}
+
+		_, err = l.db.PutItem(ctx, input)
+
+		var conflict *types.ConditionalCheckFailedException
+		switch {
+		case err == nil:
+			return 0, nil
+		case errors.As(err, &conflict):
+			continue
+		default:
+			return 0, err
+		}
+	}
+
+	// bucket is highly contended, it is safer to reject the call
+	return time.Second, nil
+}
+
+func decodeBucket(item map[string]types.AttributeValue) (Bucket, string) {
+	tokens, ok := item["tokens"].(*types.AttributeValueMemberN)
+	if !ok {
+		return Bucket{}, ""
+	}
+
+	at, ok := item["time"].(*types.AttributeValueMemberN)
+	if !ok {
+		return Bucket{}, ""
+	}
+
+	n, _ := strconv.ParseFloat(tokens.Value, 64)
+	t, _ := strconv.ParseInt(at.Value, 10, 64)
+
+	return Bucket{Tokens: n, Time: time.UnixMicro(t)}, at.Value
+}
@@ -0,0 +1,125 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/fogfish/cloudmcp/pkg/reqctx"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// NewRequestContextFields decodes allowlist of request context fields from JSON
+func NewRequestContextFields(data []byte) ([]string, error) {
+	var fields []string
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		switch {
+		case field == reqctx.SourceIP, field == reqctx.UserAgent, field == reqctx.Stage,
+			field == reqctx.RequestID, field == reqctx.DomainName:
+		case strings.HasPrefix(field, reqctx.Claims) && len(field) > len(reqctx.Claims):
+		default:
+			return nil, fmt.Errorf("unknown request context field %s", field)
+		}
+	}
+
+	return fields, nil
+}
+
+type requestContext struct {
+	fields []string
+}
+
+// allowed subset of API Gateway request context
+func (rc *requestContext) of(req *events.APIGatewayProxyRequest, principal *Principal) *reqctx.RequestContext {
+	val := &reqctx.RequestContext{}
+
+	for _, field := range rc.fields {
+		switch field {
+		case reqctx.SourceIP:
+			val.SourceIP = req.RequestContext.Identity.SourceIP
+		case reqctx.UserAgent:
+			val.UserAgent = req.RequestContext.Identity.UserAgent
+			if val.UserAgent == "" {
+				val.UserAgent = requestHeader(req, "User-Agent")
+			}
+		case reqctx.Stage:
+			val.Stage = req.RequestContext.Stage
+		case reqctx.RequestID:
+			val.RequestID = req.RequestContext.RequestID
+		case reqctx.DomainName:
+			val.DomainName = req.RequestContext.DomainName
+		default:
+			if principal == nil {
+				continue
+			}
+
+			claim := strings.TrimPrefix(field, reqctx.Claims)
+			for key, v := range principal.Claims {
+				if claim == "*" || claim == key {
+					if val.Claims == nil {
+						val.Claims = map[string]any{}
+					}
+					val.Claims[key] = v
+				}
+			}
+		}
+	}
+
+	return val
+}
+
+// injects the request context into `_meta` of the request, the value
+// supplied by the client is overridden. The body of the request is updated.
+func (rc *requestContext) inject(req *events.APIGatewayProxyRequest, call *jsonrpc.Request, principal *Principal) error {
+	params := map[string]json.RawMessage{}
+	if len(call.Params) > 0 && string(call.Params) != "null" {
+		if err := json.Unmarshal(call.Params, &params); err != nil {
+			return err
+		}
+	}
+
+	meta := map[string]json.RawMessage{}
+	if raw, has := params["_meta"]; has && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return err
+		}
+	}
+
+	val, err := json.Marshal(rc.of(req, principal))
+	if err != nil {
+		return err
+	}
+	meta[reqctx.MetaKey] = val
+
+	params["_meta"], err = json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	call.Params, err = json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	body, err := jsonrpc.EncodeMessage(call)
+	if err != nil {
+		return err
+	}
+
+	req.Body = string(body)
+	req.IsBase64Encoded = false
+	return nil
+}
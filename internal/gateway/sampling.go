@@ -0,0 +1,156 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const methodCreateMessage = "sampling/createMessage"
+
+// Sampler fulfills sampling requests of MCP server at server-side, the
+// stateless transport cannot deliver them to the client.
+type Sampler interface {
+	CreateMessage(ctx context.Context, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error)
+}
+
+// SamplingMiddleware intercepts `sampling/createMessage` requests sent by
+// MCP server to the client and fulfills them using the sampler.
+func SamplingMiddleware(sampler Sampler) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != methodCreateMessage {
+				return next(ctx, method, req)
+			}
+
+			params, ok := req.GetParams().(*mcp.CreateMessageParams)
+			if !ok {
+				return next(ctx, method, req)
+			}
+
+			return sampler.CreateMessage(ctx, params)
+		}
+	}
+}
+
+// Bedrock interface required by the sampler
+type Bedrock interface {
+	Converse(context.Context, *bedrockruntime.ConverseInput, ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error)
+}
+
+// Sampler that uses Amazon Bedrock Converse API with the configured model.
+// Model preferences of the server are ignored.
+type SamplerBedrock struct {
+	api   Bedrock
+	model string
+}
+
+func NewSamplerBedrock(api Bedrock, model string) *SamplerBedrock {
+	return &SamplerBedrock{api: api, model: model}
+}
+
+func (s *SamplerBedrock) CreateMessage(ctx context.Context, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error) {
+	input := &bedrockruntime.ConverseInput{
+		ModelId:         aws.String(s.model),
+		Messages:        make([]types.Message, 0, len(params.Messages)),
+		InferenceConfig: &types.InferenceConfiguration{},
+	}
+
+	if params.SystemPrompt != "" {
+		input.System = []types.SystemContentBlock{
+			&types.SystemContentBlockMemberText{Value: params.SystemPrompt},
+		}
+	}
+
+	if params.MaxTokens > 0 {
+		input.InferenceConfig.MaxTokens = aws.Int32(int32(params.MaxTokens))
+	}
+	if params.Temperature > 0 {
+		input.InferenceConfig.Temperature = aws.Float32(float32(params.Temperature))
+	}
+	if len(params.StopSequences) > 0 {
+		input.InferenceConfig.StopSequences = params.StopSequences
+	}
+
+	for _, msg := range params.Messages {
+		block, err := contentBlock(msg.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		input.Messages = append(input.Messages,
+			types.Message{
+				Role:    types.ConversationRole(msg.Role),
+				Content: []types.ContentBlock{block},
+			},
+		)
+	}
+
+	out, err := s.api.Converse(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, ok := out.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, fmt.Errorf("bedrock %s returned no message", s.model)
+	}
+
+	text := strings.Builder{}
+	for _, block := range reply.Value.Content {
+		if t, ok := block.(*types.ContentBlockMemberText); ok {
+			text.WriteString(t.Value)
+		}
+	}
+
+	return &mcp.CreateMessageResult{
+		Content:    &mcp.TextContent{Text: text.String()},
+		Model:      s.model,
+		Role:       "assistant",
+		StopReason: stopReason(out.StopReason),
+	}, nil
+}
+
+func contentBlock(content mcp.Content) (types.ContentBlock, error) {
+	switch c := content.(type) {
+	case *mcp.TextContent:
+		return &types.ContentBlockMemberText{Value: c.Text}, nil
+	case *mcp.ImageContent:
+		_, format, _ := strings.Cut(c.MIMEType, "/")
+		return &types.ContentBlockMemberImage{
+			Value: types.ImageBlock{
+				Format: types.ImageFormat(format),
+				Source: &types.ImageSourceMemberBytes{Value: c.Data},
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("sampling content %T is not supported", content)
+	}
+}
+
+// maps Bedrock stop reason to MCP
+func stopReason(reason types.StopReason) string {
+	switch reason {
+	case types.StopReasonEndTurn:
+		return "endTurn"
+	case types.StopReasonStopSequence:
+		return "stopSequence"
+	case types.StopReasonMaxTokens:
+		return "maxTokens"
+	default:
+		return string(reason)
+	}
+}
@@ -0,0 +1,282 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// HeaderSessionScope binds state kept in the sessions store (e.g. pending
+// elicitation) to the caller: principal and session. The gateway sets it for
+// every tools/call, the value supplied by clients is discarded.
+const HeaderSessionScope = "Mcp-Session-Scope"
+
+// Sessions keeps state of MCP sessions across invocations of the stateless
+// transport (e.g. pending elicitation requests or progress of tools). Get
+// returns nil if the key is not found or expired. Swap and RemoveIf are
+// conditional on the current value (nil for the missing key), they return
+// false if the value is changed by concurrent writer.
+type Sessions interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, val []byte, ttl time.Duration) error
+	Remove(ctx context.Context, key string) error
+	Swap(ctx context.Context, key string, old, val []byte, ttl time.Duration) (bool, error)
+	RemoveIf(ctx context.Context, key string, old []byte) (bool, error)
+}
+
+//------------------------------------------------------------------------------
+
+// Sessions store in memory of lambda instance, useful for local development.
+type SessionsMemory struct {
+	sync.Mutex
+	items map[string]sessionItem
+}
+
+type sessionItem struct {
+	val     []byte
+	expires time.Time
+}
+
+func NewSessionsMemory() *SessionsMemory {
+	return &SessionsMemory{items: map[string]sessionItem{}}
+}
+
+func (s *SessionsMemory) Get(ctx context.Context, key string) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	item, has := s.items[key]
+	if !has || time.Now().After(item.expires) {
+		delete(s.items, key)
+		return nil, nil
+	}
+
+	return item.val, nil
+}
+
+func (s *SessionsMemory) Put(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.items[key] = sessionItem{val: val, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *SessionsMemory) Remove(ctx context.Context, key string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.items, key)
+	return nil
+}
+
+func (s *SessionsMemory) Swap(ctx context.Context, key string, old, val []byte, ttl time.Duration) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if !bytes.Equal(s.current(key), old) {
+		return false, nil
+	}
+
+	s.items[key] = sessionItem{val: val, expires: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (s *SessionsMemory) RemoveIf(ctx context.Context, key string, old []byte) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if !bytes.Equal(s.current(key), old) {
+		return false, nil
+	}
+
+	delete(s.items, key)
+	return true, nil
+}
+
+func (s *SessionsMemory) current(key string) []byte {
+	item, has := s.items[key]
+	if !has || time.Now().After(item.expires) {
+		return nil
+	}
+	return item.val
+}
+
+//------------------------------------------------------------------------------
+
+// DynamoDB interface required by sessions store
+type DynamoDBSessions interface {
+	GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// Sessions store in DynamoDB table, the state is shared across concurrent
+// lambda instances. The table uses "key" as partition key and "ttl" as
+// time-to-live attribute.
+type SessionsDynamoDB struct {
+	db    DynamoDBSessions
+	table string
+}
+
+func NewSessionsDynamoDB(db DynamoDBSessions, table string) *SessionsDynamoDB {
+	return &SessionsDynamoDB{db: db, table: table}
+}
+
+func (s *SessionsDynamoDB) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := s.db.GetItem(ctx,
+		&dynamodb.GetItemInput{
+			TableName:      aws.String(s.table),
+			Key:            map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: key}},
+			ConsistentRead: aws.Bool(true),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// DynamoDB deletes expired items lazily
+	if ttl, ok := val.Item["ttl"].(*types.AttributeValueMemberN); ok {
+		if t, err := strconv.ParseInt(ttl.Value, 10, 64); err == nil && time.Now().Unix() > t {
+			return nil, nil
+		}
+	}
+
+	data, ok := val.Item["value"].(*types.AttributeValueMemberB)
+	if !ok {
+		return nil, nil
+	}
+
+	return data.Value, nil
+}
+
+func (s *SessionsDynamoDB) Put(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	_, err := s.db.PutItem(ctx,
+		&dynamodb.PutItemInput{
+			TableName: aws.String(s.table),
+			Item: map[string]types.AttributeValue{
+				"key":   &types.AttributeValueMemberS{Value: key},
+				"value": &types.AttributeValueMemberB{Value: val},
+				"ttl":   &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)},
+			},
+		},
+	)
+	return err
+}
+
+func (s *SessionsDynamoDB) Remove(ctx context.Context, key string) error {
+	_, err := s.db.DeleteItem(ctx,
+		&dynamodb.DeleteItemInput{
+			TableName: aws.String(s.table),
+			Key:       map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: key}},
+		},
+	)
+	return err
+}
+
+func (s *SessionsDynamoDB) Swap(ctx context.Context, key string, old, val []byte, ttl time.Duration) (bool, error) {
+	cond, names, values := s.condition(old)
+
+	_, err := s.db.PutItem(ctx,
+		&dynamodb.PutItemInput{
+			TableName: aws.String(s.table),
+			Item: map[string]types.AttributeValue{
+				"key":   &types.AttributeValueMemberS{Value: key},
+				"value": &types.AttributeValueMemberB{Value: val},
+				"ttl":   &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)},
+			},
+			ConditionExpression:       aws.String(cond),
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		},
+	)
+
+	return conditional(err)
+}
+
+func (s *SessionsDynamoDB) RemoveIf(ctx context.Context, key string, old []byte) (bool, error) {
+	cond, names, values := s.condition(old)
+
+	_, err := s.db.DeleteItem(ctx,
+		&dynamodb.DeleteItemInput{
+			TableName:                 aws.String(s.table),
+			Key:                       map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: key}},
+			ConditionExpression:       aws.String(cond),
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		},
+	)
+
+	return conditional(err)
+}
+
+// condition on the current value, expired items are seen as missing
+func (s *SessionsDynamoDB) condition(old []byte) (string, map[string]string, map[string]types.AttributeValue) {
+	now := &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)}
+
+	if old == nil {
+		return "attribute_not_exists(#key) OR #ttl < :now",
+			map[string]string{"#key": "key", "#ttl": "ttl"},
+			map[string]types.AttributeValue{":now": now}
+	}
+
+	return "#value = :old AND #ttl >= :now",
+		map[string]string{"#value": "value", "#ttl": "ttl"},
+		map[string]types.AttributeValue{":old": &types.AttributeValueMemberB{Value: old}, ":now": now}
+}
+
+func conditional(err error) (bool, error) {
+	var conflict *types.ConditionalCheckFailedException
+	if errors.As(err, &conflict) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+//------------------------------------------------------------------------------
+
+// scope of the state made by the caller, it is passed to the server via header.
+// The state is partitioned by tenant, principal and session.
+func sessionScope(r *events.APIGatewayProxyRequest, principal *Principal) string {
+	scope := digest(principal.Tenant, principal.ID, sessionID(r))
+	setRequestHeader(r, HeaderSessionScope, scope)
+
+	return scope
+}
+
+// scope of the state as seen by the server
+func scopeOf(extra *mcp.RequestExtra) string {
+	if extra == nil || extra.Header == nil {
+		return ""
+	}
+	return extra.Header.Get(HeaderSessionScope)
+}
+
+func digest(values ...string) string {
+	h := sha256.New()
+	for _, v := range values {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
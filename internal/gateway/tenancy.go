@@ -0,0 +1,72 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Tenancy resolves the tenant of the caller. The state kept by the gateway
+// (sessions, cached responses) is partitioned by tenant.
+type Tenancy struct {
+	// Claim of the principal carrying the tenant (e.g. custom:tenant)
+	Claim string `json:"claim,omitempty"`
+
+	// Mapping of the principal (e.g. API access key) to the tenant
+	ApiKeys map[string]string `json:"apiKeys,omitempty"`
+
+	// Emit metrics per tenant for chargeback
+	Metrics bool `json:"metrics,omitempty"`
+}
+
+// NewTenancy decodes tenancy from JSON
+func NewTenancy(data []byte) (*Tenancy, error) {
+	var tenancy Tenancy
+	if err := json.Unmarshal(data, &tenancy); err != nil {
+		return nil, err
+	}
+
+	return &tenancy, nil
+}
+
+// tenant of the principal, the mapping wins over the claim
+func (t *Tenancy) tenant(principal *Principal) string {
+	if tenant, has := t.ApiKeys[principal.ID]; has {
+		return tenant
+	}
+
+	if t.Claim != "" {
+		if tenant, ok := principal.Claims[t.Claim].(string); ok {
+			return tenant
+		}
+	}
+
+	return ""
+}
+
+// caller identity, the tenant is resolved if tenancy is configured
+func (gw *Gateway) principal(r *events.APIGatewayProxyRequest) *Principal {
+	principal := NewPrincipal(r)
+	if principal != nil && gw.tenancy != nil {
+		principal.Tenant = gw.tenancy.tenant(principal)
+	}
+
+	return principal
+}
+
+// tenant dimension of metrics
+func (gw *Gateway) tenantOf(principal *Principal) string {
+	if principal == nil || gw.tenancy == nil || !gw.tenancy.Metrics {
+		return ""
+	}
+
+	return principal.Tenant
+}
@@ -0,0 +1,135 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracing instruments the gateway with OpenTelemetry spans. The trace context
+// is extracted from MCP `_meta` field of the request, falling back to
+// W3C Trace Context HTTP headers.
+type tracing struct {
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// newTracing creates tracer exporting spans via OTLP/HTTP to the collector
+// (e.g. ADOT Lambda layer listening at localhost:4318).
+func newTracing(ctx context.Context, endpoint, server string) (*tracing, error) {
+	exporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(endpoint),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		// Lambda is frozen between invocations, spans are flushed explicitly
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithResource(
+			resource.NewSchemaless(
+				semconv.ServiceName(server),
+				semconv.CloudProviderAWS,
+				semconv.CloudPlatformAWSLambda,
+			),
+		),
+	)
+
+	return &tracing{
+		provider:   provider,
+		tracer:     provider.Tracer("github.com/fogfish/cloudmcp"),
+		propagator: propagation.TraceContext{},
+	}, nil
+}
+
+// start span for JSON-RPC message
+func (t *tracing) start(ctx context.Context, req *events.APIGatewayProxyRequest, msg jsonrpc.Message) (context.Context, trace.Span) {
+	head := http.Header{}
+	for key, val := range req.Headers {
+		head.Set(key, val)
+	}
+	ctx = t.propagator.Extract(ctx, propagation.HeaderCarrier(head))
+
+	name := "mcp"
+	attrs := []attribute.KeyValue{
+		attribute.String("faas.invocation_id", req.RequestContext.RequestID),
+	}
+
+	if call, ok := msg.(*jsonrpc.Request); ok {
+		name = "mcp " + call.Method
+		attrs = append(attrs,
+			semconv.RPCSystemKey.String("jsonrpc"),
+			semconv.RPCMethod(call.Method),
+		)
+		if tool := toolName(call); tool != "" {
+			attrs = append(attrs, attribute.String("mcp.tool", tool))
+		}
+		if meta := metaOf(call); meta != nil {
+			ctx = t.propagator.Extract(ctx, meta)
+		}
+	}
+
+	return t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// end span with status of the response
+func (t *tracing) end(ctx context.Context, span trace.Span, rsp *events.APIGatewayProxyResponse, err error) {
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case rsp != nil:
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rsp.StatusCode))
+		if rsp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, http.StatusText(rsp.StatusCode))
+		}
+	}
+	span.End()
+
+	if err := t.provider.ForceFlush(ctx); err != nil {
+		span.RecordError(err)
+	}
+}
+
+// MCP `_meta` field of request as propagation carrier
+func metaOf(call *jsonrpc.Request) propagation.MapCarrier {
+	var params struct {
+		Meta map[string]any `json:"_meta"`
+	}
+	if err := json.Unmarshal(call.Params, &params); err != nil || len(params.Meta) == 0 {
+		return nil
+	}
+
+	carrier := propagation.MapCarrier{}
+	for key, val := range params.Meta {
+		if s, ok := val.(string); ok {
+			carrier[key] = s
+		}
+	}
+
+	return carrier
+}
@@ -0,0 +1,157 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// path of upload endpoint, relative to the server endpoint
+const pathUploads = "/uploads"
+
+// PostPresigner interface required by uploads
+type PostPresigner interface {
+	PresignPostObject(context.Context, *s3.PutObjectInput, ...func(*s3.PresignPostOptions)) (*s3.PresignedPostRequest, error)
+}
+
+// Uploads configures presigned POST uploads of files for tools
+type Uploads struct {
+	// Max size of the file in bytes
+	MaxSize int64 `json:"maxSize"`
+
+	// Time-to-live of the presigned form in seconds
+	TTL int `json:"ttl"`
+}
+
+// NewUploads decodes uploads config from JSON
+func NewUploads(data []byte) (*Uploads, error) {
+	var spec Uploads
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+
+	if spec.MaxSize == 0 {
+		spec.MaxSize = 100 << 20
+	}
+	if spec.TTL == 0 {
+		spec.TTL = 900
+	}
+
+	return &spec, nil
+}
+
+// Upload form issued to the client, the file is sent as multipart/form-data
+// to the url along with fields, the file is the last field of the form.
+type Upload struct {
+	URL       string            `json:"url"`
+	Fields    map[string]string `json:"fields"`
+	Key       string            `json:"key"`
+	MaxSize   int64             `json:"maxSize"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+type uploads struct {
+	spec   *Uploads
+	api    PostPresigner
+	bucket string
+}
+
+// key prefix of objects uploaded by the caller within the session
+func (u *uploads) prefix(req *events.APIGatewayProxyRequest, principal *Principal) string {
+	return "uploads/" + digest(principal.Tenant, principal.ID, sessionID(req)) + "/"
+}
+
+// serves the upload API:
+//
+//	POST {endpoint}/uploads  {"name": "report.pdf", "contentType": "application/pdf"}
+func (gw *Gateway) serveUpload(ctx context.Context, req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	if req.HTTPMethod != http.MethodPost {
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+	}
+
+	var file struct {
+		Name        string `json:"name"`
+		ContentType string `json:"contentType"`
+	}
+	if err := json.Unmarshal([]byte(req.Body), &file); err != nil {
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusBadRequest}, nil
+	}
+
+	name := path.Base(strings.ReplaceAll(file.Name, "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		name = "file"
+	}
+
+	principal := gw.principal(req)
+	if principal == nil {
+		principal = newAnonymous(req)
+	}
+
+	key := gw.uploads.prefix(req, principal) + rand.Text() + "/" + name
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(gw.uploads.bucket),
+		Key:    aws.String(key),
+	}
+	conditions := []any{
+		[]any{"content-length-range", 0, gw.uploads.spec.MaxSize},
+	}
+	if file.ContentType != "" {
+		input.ContentType = aws.String(file.ContentType)
+		conditions = append(conditions, map[string]string{"Content-Type": file.ContentType})
+	}
+
+	ttl := time.Duration(gw.uploads.spec.TTL) * time.Second
+	form, err := gw.uploads.api.PresignPostObject(ctx, input,
+		func(opts *s3.PresignPostOptions) {
+			opts.Expires = ttl
+			opts.Conditions = conditions
+		},
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to presign upload", "err", err)
+		return nil, err
+	}
+
+	if file.ContentType != "" {
+		form.Values["Content-Type"] = file.ContentType
+	}
+
+	body, _ := json.Marshal(Upload{
+		URL:       form.URL,
+		Fields:    form.Values,
+		Key:       key,
+		MaxSize:   gw.uploads.spec.MaxSize,
+		ExpiresAt: time.Now().Add(ttl).UTC(),
+	})
+
+	slog.InfoContext(ctx, "upload is issued", "key", key)
+	return &events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		MultiValueHeaders: http.Header{
+			"Content-Type":  []string{"application/json"},
+			"Cache-Control": []string{"no-store"},
+		},
+		Body: string(body),
+	}, nil
+}
+
+func isUploadsPath(path string) bool {
+	return strings.HasSuffix(path, pathUploads)
+}
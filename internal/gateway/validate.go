@@ -0,0 +1,108 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// validator of tools/call arguments against input schema of tools, as
+// declared by the manifest. Schemas are resolved once per lambda instance,
+// tools with schema not supported by the validator are not validated.
+type validator struct {
+	manifest *Manifest
+
+	mu      sync.Mutex
+	schemas map[string]*jsonschema.Resolved
+}
+
+func newValidator(m *Manifest) *validator {
+	return &validator{manifest: m, schemas: map[string]*jsonschema.Resolved{}}
+}
+
+// validates arguments of the call, the error explains the violation
+func (v *validator) validate(ctx context.Context, params json.RawMessage) error {
+	var call mcp.CallToolParamsRaw
+	if err := json.Unmarshal(params, &call); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+
+	schema, err := v.schema(ctx, call.Name)
+	if err != nil {
+		return err
+	}
+	if schema == nil {
+		return nil
+	}
+
+	var args any = map[string]any{}
+	if len(call.Arguments) > 0 {
+		if err := json.Unmarshal(call.Arguments, &args); err != nil {
+			return fmt.Errorf("invalid arguments of tool %s: %w", call.Name, err)
+		}
+	}
+
+	if err := schema.Validate(args); err != nil {
+		return fmt.Errorf("invalid arguments of tool %s: %w", call.Name, err)
+	}
+
+	return nil
+}
+
+func (v *validator) schema(ctx context.Context, tool string) (*jsonschema.Resolved, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if rs, has := v.schemas[tool]; has {
+		return rs, nil
+	}
+
+	var spec *mcp.Tool
+	if v.manifest.Tools != nil {
+		for _, t := range v.manifest.Tools.Tools {
+			if t.Name == tool {
+				spec = t
+				break
+			}
+		}
+	}
+	if spec == nil {
+		return nil, fmt.Errorf("unknown tool %s", tool)
+	}
+
+	// the manifest keeps schema as generic JSON value
+	data, err := json.Marshal(spec.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		slog.WarnContext(ctx, "input schema is not supported", "tool", tool, "err", err)
+		v.schemas[tool] = nil
+		return nil, nil
+	}
+
+	rs, err := schema.Resolve(nil)
+	if err != nil {
+		slog.WarnContext(ctx, "input schema is not supported", "tool", tool, "err", err)
+		v.schemas[tool] = nil
+		return nil, nil
+	}
+
+	v.schemas[tool] = rs
+	return rs, nil
+}
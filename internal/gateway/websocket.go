@@ -0,0 +1,182 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// HeaderConnectionID carries WebSocket connection id of the client to MCP
+// server, tool handlers use it to push server-initiated messages.
+const HeaderConnectionID = "Mcp-Connection-Id"
+
+// WebSocket API Gateway limits connection duration to 2 hours
+const connectionTTL = 2 * time.Hour
+
+// Connections keeps state of WebSocket connections
+type Connections interface {
+	Connect(ctx context.Context, connection string, principal *Principal) error
+	Disconnect(ctx context.Context, connection string) error
+}
+
+// DynamoDB interface required by connections store
+type DynamoDBConnections interface {
+	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// Connections store in DynamoDB table. The table uses "connection" as
+// partition key and "ttl" as time-to-live attribute.
+type ConnectionsDynamoDB struct {
+	db    DynamoDBConnections
+	table string
+}
+
+func NewConnectionsDynamoDB(db DynamoDBConnections, table string) *ConnectionsDynamoDB {
+	return &ConnectionsDynamoDB{db: db, table: table}
+}
+
+func (c *ConnectionsDynamoDB) Connect(ctx context.Context, connection string, principal *Principal) error {
+	now := time.Now()
+	item := map[string]types.AttributeValue{
+		"connection":  &types.AttributeValueMemberS{Value: connection},
+		"connectedAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		"ttl":         &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(connectionTTL).Unix(), 10)},
+	}
+	if principal != nil {
+		item["principal"] = &types.AttributeValueMemberS{Value: principal.ID}
+	}
+
+	_, err := c.db.PutItem(ctx,
+		&dynamodb.PutItemInput{
+			TableName: aws.String(c.table),
+			Item:      item,
+		},
+	)
+	return err
+}
+
+func (c *ConnectionsDynamoDB) Disconnect(ctx context.Context, connection string) error {
+	_, err := c.db.DeleteItem(ctx,
+		&dynamodb.DeleteItemInput{
+			TableName: aws.String(c.table),
+			Key:       map[string]types.AttributeValue{"connection": &types.AttributeValueMemberS{Value: connection}},
+		},
+	)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// Handle is the lambda entry point, it dispatches WebSocket events and
+// REST API requests to corresponding handlers.
+func (gw *Gateway) Handle(ctx context.Context, raw json.RawMessage) (*events.APIGatewayProxyResponse, error) {
+	// REST API events are not probed, the event might be multi-megabyte.
+	// Quotes within strings (e.g. body) are escaped, the key is not matched.
+	if !bytes.Contains(raw, []byte(`"connectionId"`)) {
+		return gw.serveEvent(ctx, raw)
+	}
+
+	var probe struct {
+		RequestContext struct {
+			ConnectionID string `json:"connectionId"`
+		} `json:"requestContext"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, err
+	}
+
+	if probe.RequestContext.ConnectionID != "" {
+		var evt events.APIGatewayWebsocketProxyRequest
+		if err := json.Unmarshal(raw, &evt); err != nil {
+			return nil, err
+		}
+		return gw.ServeWebSocket(ctx, &evt)
+	}
+
+	return gw.serveEvent(ctx, raw)
+}
+
+func (gw *Gateway) serveEvent(ctx context.Context, raw json.RawMessage) (*events.APIGatewayProxyResponse, error) {
+	var req events.APIGatewayProxyRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, err
+	}
+	return gw.Serve(ctx, &req)
+}
+
+// ServeWebSocket handles WebSocket API Gateway events. Messages are JSON-RPC
+// messages, they are served as REST API requests, the response is sent back
+// to the client via the route response.
+func (gw *Gateway) ServeWebSocket(ctx context.Context, evt *events.APIGatewayWebsocketProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	req := websocketRequest(evt)
+	connection := evt.RequestContext.ConnectionID
+
+	switch evt.RequestContext.EventType {
+	case "CONNECT":
+		if gw.connections != nil {
+			if err := gw.connections.Connect(ctx, connection, NewPrincipal(req)); err != nil {
+				slog.ErrorContext(ctx, "failed to persist connection", "connection", connection, "err", err)
+				return nil, err
+			}
+		}
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+
+	case "DISCONNECT":
+		if gw.connections != nil {
+			if err := gw.connections.Disconnect(ctx, connection); err != nil {
+				slog.ErrorContext(ctx, "failed to remove connection", "connection", connection, "err", err)
+			}
+		}
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	}
+
+	return gw.Serve(ctx, req)
+}
+
+// WebSocket message as REST API request
+func websocketRequest(evt *events.APIGatewayWebsocketProxyRequest) *events.APIGatewayProxyRequest {
+	headers := map[string]string{}
+	for key, val := range evt.Headers {
+		headers[key] = val
+	}
+	headers["Content-Type"] = "application/json"
+	headers["Accept"] = "application/json, text/event-stream"
+	headers[HeaderConnectionID] = evt.RequestContext.ConnectionID
+
+	auth, _ := evt.RequestContext.Authorizer.(map[string]any)
+
+	return &events.APIGatewayProxyRequest{
+		HTTPMethod:      http.MethodPost,
+		Path:            "/",
+		Headers:         headers,
+		Body:            evt.Body,
+		IsBase64Encoded: evt.IsBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:  evt.RequestContext.AccountID,
+			Stage:      evt.RequestContext.Stage,
+			RequestID:  evt.RequestContext.RequestID,
+			Identity:   evt.RequestContext.Identity,
+			Authorizer: auth,
+			APIID:      evt.RequestContext.APIID,
+			DomainName: evt.RequestContext.DomainName,
+		},
+	}
+}
@@ -0,0 +1,77 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package iam matches callers authenticated with AWS SigV4 against the
+// principals granted by the stack. HTTP API does not support resource
+// policies, any identity of any account allowed to call execute-api:Invoke
+// passes the authorizer, the lambda enforces principals instead.
+package iam
+
+import (
+	"strings"
+)
+
+// Principals allowed to call the api: account id, account root, IAM role
+// or IAM user ARNs.
+type Principals []string
+
+// Parse comma separated list of principals
+func Parse(s string) Principals {
+	var seq Principals
+	for principal := range strings.SplitSeq(s, ",") {
+		if principal = strings.TrimSpace(principal); principal != "" {
+			seq = append(seq, principal)
+		}
+	}
+	return seq
+}
+
+// IsAllowed checks the caller, identified by the account and the ARN
+// as reported by API Gateway (e.g. assumed role session).
+func (p Principals) IsAllowed(account, caller string) bool {
+	if account == "" || caller == "" {
+		return false
+	}
+
+	for _, principal := range p {
+		if match(principal, account, caller) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func match(principal, account, caller string) bool {
+	if principal == caller {
+		return true
+	}
+
+	// account id or arn:aws:iam::{account}:root
+	if principal == account {
+		return true
+	}
+
+	seq := strings.SplitN(principal, ":", 6)
+	if len(seq) != 6 || seq[0] != "arn" || seq[2] != "iam" || seq[4] != account {
+		return false
+	}
+
+	switch {
+	case seq[5] == "root":
+		return true
+	case strings.HasPrefix(seq[5], "role/"):
+		// arn:aws:iam::{account}:role/{path}/{name} is seen as
+		// arn:aws:sts::{account}:assumed-role/{name}/{session}
+		name := seq[5][strings.LastIndex(seq[5], "/")+1:]
+		prefix := "arn:" + seq[1] + ":sts::" + account + ":assumed-role/" + name + "/"
+		return strings.HasPrefix(caller, prefix)
+	}
+
+	return false
+}
@@ -0,0 +1,184 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package introspect validates opaque tokens using OAuth 2.0 Token
+// Introspection (RFC 7662). Results are cached until the token expires but
+// not longer than TTL, the identity provider is not called for every request.
+package introspect
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// Config of the introspection endpoint, the secret of the client is the ARN
+// of Secrets Manager secret, the value is loaded at runtime.
+type Config struct {
+	Url          string `json:"url"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// NewConfig decodes config from JSON
+func NewConfig(data []byte) (*Config, error) {
+	var spec Config
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+
+	if spec.Url == "" || spec.ClientID == "" {
+		return nil, errors.New("missing introspection url or client id")
+	}
+
+	return &spec, nil
+}
+
+// caching of introspection results, inactive tokens are cached briefly to
+// shield identity provider from replay of invalid tokens.
+const (
+	cacheTTL      = 5 * time.Minute
+	cacheInactive = 30 * time.Second
+	cacheSize     = 10000
+)
+
+// Introspector of tokens
+type Introspector struct {
+	sync.Mutex
+	url    string
+	client string
+	secret string
+	http   *http.Client
+	cache  map[[sha256.Size]byte]entry
+}
+
+type entry struct {
+	claims  map[string]any
+	expires time.Time
+}
+
+// New creates introspector of tokens using client credentials
+func New(introspectionUrl, clientID, clientSecret string, client *http.Client) *Introspector {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	return &Introspector{
+		url:    introspectionUrl,
+		client: clientID,
+		secret: clientSecret,
+		http:   client,
+		cache:  map[[sha256.Size]byte]entry{},
+	}
+}
+
+// Introspect the token, it returns claims of active token.
+func (in *Introspector) Introspect(ctx context.Context, token string) (map[string]any, error) {
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+
+	in.Lock()
+	if e, has := in.cache[key]; has && now.Before(e.expires) {
+		in.Unlock()
+		if e.claims == nil {
+			return nil, ErrForbidden
+		}
+		return e.claims, nil
+	}
+	in.Unlock()
+
+	claims, err := in.introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	e := entry{claims: claims, expires: now.Add(cacheInactive)}
+	if claims != nil {
+		e.expires = now.Add(cacheTTL)
+		if exp, ok := claims["exp"].(float64); ok {
+			if t := time.Unix(int64(exp), 0); t.Before(e.expires) {
+				e.expires = t
+			}
+		}
+	}
+
+	in.Lock()
+	if len(in.cache) >= cacheSize {
+		in.evict(now)
+	}
+	in.cache[key] = e
+	in.Unlock()
+
+	if claims == nil {
+		return nil, ErrForbidden
+	}
+	return claims, nil
+}
+
+// evicts expired entries, the cache is reset if all entries are alive
+func (in *Introspector) evict(now time.Time) {
+	for key, e := range in.cache {
+		if !now.Before(e.expires) {
+			delete(in.cache, key)
+		}
+	}
+
+	if len(in.cache) >= cacheSize {
+		clear(in.cache)
+	}
+}
+
+// calls introspection endpoint, nil claims stands for inactive token
+func (in *Introspector) introspect(ctx context.Context, token string) (map[string]any, error) {
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, in.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(in.client), url.QueryEscape(in.secret))
+
+	rsp, err := in.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection failed: %s", rsp.Status)
+	}
+
+	var claims map[string]any
+	if err := json.NewDecoder(rsp.Body).Decode(&claims); err != nil {
+		return nil, err
+	}
+
+	if active, _ := claims["active"].(bool); !active {
+		return nil, nil
+	}
+
+	if exp, ok := claims["exp"].(float64); ok && time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, nil
+	}
+
+	return claims, nil
+}
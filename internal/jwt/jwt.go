@@ -0,0 +1,380 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package jwt validates JSON Web Tokens of multiple issuers, each issuer has
+// own list of audiences. Keys of issuers are fetched using OpenID discovery,
+// the key set is refreshed if the token is signed by unknown key (rotation).
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// Issuer of tokens and audiences accepted from it, any audience is accepted
+// if the list is empty.
+type Issuer struct {
+	Issuer   string   `json:"issuer"`
+	Audience []string `json:"audience,omitempty"`
+}
+
+// NewIssuers decodes issuers from JSON
+func NewIssuers(data []byte) ([]Issuer, error) {
+	var issuers []Issuer
+	if err := json.Unmarshal(data, &issuers); err != nil {
+		return nil, err
+	}
+
+	return issuers, nil
+}
+
+// clock skew tolerated by validation of time claims
+const leeway = 60 * time.Second
+
+// key sets are cached, unknown keys trigger refresh at most once per interval
+const (
+	keysTTL     = 1 * time.Hour
+	keysRefresh = 1 * time.Minute
+)
+
+// Verifier of tokens
+type Verifier struct {
+	sync.Mutex
+	issuers map[string]Issuer
+	client  *http.Client
+	keys    map[string]*keySet
+}
+
+type keySet struct {
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+// NewVerifier creates verifier of tokens of given issuers
+func NewVerifier(issuers []Issuer, client *http.Client) *Verifier {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	v := &Verifier{
+		issuers: map[string]Issuer{},
+		client:  client,
+		keys:    map[string]*keySet{},
+	}
+	for _, iss := range issuers {
+		v.issuers[strings.TrimSuffix(iss.Issuer, "/")] = iss
+	}
+
+	return v
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify signature, issuer, audience and time claims of the token, it
+// returns claims of the token.
+func (v *Verifier) Verify(ctx context.Context, token string) (map[string]any, error) {
+	seq := strings.Split(token, ".")
+	if len(seq) != 3 {
+		return nil, ErrForbidden
+	}
+
+	var head header
+	if err := decode(seq[0], &head); err != nil {
+		return nil, ErrForbidden
+	}
+
+	var claims map[string]any
+	if err := decode(seq[1], &claims); err != nil {
+		return nil, ErrForbidden
+	}
+
+	iss, _ := claims["iss"].(string)
+	issuer, has := v.issuers[strings.TrimSuffix(iss, "/")]
+	if !has {
+		return nil, ErrForbidden
+	}
+
+	key, err := v.key(ctx, issuer, head.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(seq[2])
+	if err != nil {
+		return nil, ErrForbidden
+	}
+
+	if err := verify(head.Alg, key, []byte(seq[0]+"."+seq[1]), signature); err != nil {
+		return nil, ErrForbidden
+	}
+
+	if err := validate(issuer, claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func decode(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func validate(issuer Issuer, claims map[string]any, now time.Time) error {
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(leeway)) {
+		return ErrForbidden
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+		return ErrForbidden
+	}
+
+	if len(issuer.Audience) == 0 {
+		return nil
+	}
+
+	// access tokens of some issuers (e.g. Cognito) carry client_id instead of aud
+	audience := []string{}
+	switch aud := claims["aud"].(type) {
+	case string:
+		audience = append(audience, aud)
+	case []any:
+		for _, x := range aud {
+			if s, ok := x.(string); ok {
+				audience = append(audience, s)
+			}
+		}
+	}
+	if client, ok := claims["client_id"].(string); ok {
+		audience = append(audience, client)
+	}
+
+	for _, aud := range audience {
+		if slices.Contains(issuer.Audience, aud) {
+			return nil
+		}
+	}
+
+	return ErrForbidden
+}
+
+func verify(alg string, key crypto.PublicKey, data, signature []byte) error {
+	if len(alg) != 5 {
+		return ErrForbidden
+	}
+
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return ErrForbidden
+	}
+
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+		case "PS":
+			return rsa.VerifyPSS(pub, hash, digest, signature, nil)
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" || len(signature) != 2*size {
+			return ErrForbidden
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if ecdsa.Verify(pub, digest, r, s) {
+			return nil
+		}
+	}
+
+	return ErrForbidden
+}
+
+// key of the issuer, the key set is refreshed if the key is not known
+func (v *Verifier) key(ctx context.Context, issuer Issuer, kid string) (crypto.PublicKey, error) {
+	v.Lock()
+	defer v.Unlock()
+
+	id := strings.TrimSuffix(issuer.Issuer, "/")
+	set := v.keys[id]
+
+	stale := set == nil || time.Since(set.fetched) > keysTTL
+	if !stale {
+		if key, has := set.keys[kid]; has {
+			return key, nil
+		}
+		stale = time.Since(set.fetched) > keysRefresh
+	}
+
+	if stale {
+		keys, err := v.fetch(ctx, id)
+		if err != nil {
+			// previous keys are used while the issuer is not reachable
+			if set == nil {
+				return nil, err
+			}
+		} else {
+			set = &keySet{keys: keys, fetched: time.Now()}
+			v.keys[id] = set
+		}
+	}
+
+	if key, has := set.keys[kid]; has {
+		return key, nil
+	}
+
+	return nil, ErrForbidden
+}
+
+// fetches keys of the issuer using OpenID discovery
+func (v *Verifier) fetch(ctx context.Context, issuer string) (map[string]crypto.PublicKey, error) {
+	var conf struct {
+		JwksURI string `json:"jwks_uri"`
+	}
+	if err := v.get(ctx, issuer+"/.well-known/openid-configuration", &conf); err != nil {
+		return nil, err
+	}
+	if conf.JwksURI == "" {
+		return nil, fmt.Errorf("issuer %s does not define jwks_uri", issuer)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := v.get(ctx, conf.JwksURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+
+	return keys, nil
+}
+
+func (v *Verifier) get(ctx context.Context, url string, reply any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed: %s", url, rsp.Status)
+	}
+
+	return json.NewDecoder(rsp.Body).Decode(reply)
+}
+
+// AuthorizerContext flattens claims into context of Lambda authorizer, the
+// context supports only scalar values, lists are space delimited (e.g.
+// groups) and objects are encoded as JSON. The kind of authentication is
+// passed as "auth".
+func AuthorizerContext(auth string, claims map[string]any) map[string]any {
+	ctx := map[string]any{}
+	for key, val := range claims {
+		switch v := val.(type) {
+		case string, float64, bool:
+			ctx[key] = v
+		case []any:
+			seq := make([]string, 0, len(v))
+			for _, x := range v {
+				if s, ok := x.(string); ok {
+					seq = append(seq, s)
+				}
+			}
+			ctx[key] = strings.Join(seq, " ")
+		case nil:
+		default:
+			if data, err := json.Marshal(v); err == nil {
+				ctx[key] = string(data)
+			}
+		}
+	}
+	ctx["auth"] = auth
+	return ctx
+}
@@ -0,0 +1,167 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/fogfish/cloudmcp/internal/jwt"
+)
+
+// OAuth 2.0 Token Exchange (RFC 8693)
+const (
+	grantTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeAccess    = "urn:ietf:params:oauth:token-type:access_token"
+	tokenTypeJWT       = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+const pathOpenIDConfiguration = "/.well-known/openid-configuration"
+
+// configuration of token exchange, as defined by the builder
+type exchangeConfig struct {
+	Issuer string   `json:"issuer"`
+	TTL    string   `json:"ttl"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// exchange of access tokens issued by the identity provider to tokens of the
+// gateway. Tokens of the gateway are short-lived, scoped to the gateway
+// (issuer and audience) and carry only the granted scopes.
+type exchange struct {
+	issuer   string
+	ttl      time.Duration
+	scopes   []string
+	verifier *jwt.Verifier
+}
+
+func newExchange(data []byte, upstream jwt.Issuer, client *http.Client) (*exchange, error) {
+	var conf exchangeConfig
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return nil, err
+	}
+
+	ttl, err := time.ParseDuration(conf.TTL)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Issuer == "" || ttl <= 0 {
+		return nil, fmt.Errorf("invalid token exchange config %s", data)
+	}
+
+	return &exchange{
+		issuer:   strings.TrimSuffix(conf.Issuer, "/"),
+		ttl:      ttl,
+		scopes:   conf.Scopes,
+		verifier: jwt.NewVerifier([]jwt.Issuer{upstream}, client),
+	}, nil
+}
+
+// scopes granted to the exchanged token, requested scopes must be subset of
+// scopes allowed by the gateway, all allowed scopes are granted by default.
+func (e *exchange) grant(requested string) ([]string, error) {
+	if requested == "" {
+		return e.scopes, nil
+	}
+
+	scopes := strings.Fields(requested)
+	for _, scope := range scopes {
+		if !slices.Contains(e.scopes, scope) {
+			return nil, fmt.Errorf("scope %s is not allowed", scope)
+		}
+	}
+
+	return scopes, nil
+}
+
+// exchanges access token of the identity provider (subject token) to the
+// token of the gateway signed with the current signing key.
+func (p *proxy) exchangeToken(ctx context.Context, req *events.APIGatewayProxyRequest, form url.Values) *events.APIGatewayProxyResponse {
+	if p.exchange == nil || p.keys == nil {
+		return failure(http.StatusBadRequest, "unsupported_grant_type", "token exchange is not configured")
+	}
+
+	switch {
+	case form.Get("subject_token") == "":
+		return failure(http.StatusBadRequest, "invalid_request", "subject_token is required")
+	case form.Get("subject_token_type") != tokenTypeAccess && form.Get("subject_token_type") != tokenTypeJWT:
+		return failure(http.StatusBadRequest, "invalid_request", "subject_token_type must be access_token or jwt")
+	case form.Get("actor_token") != "":
+		return failure(http.StatusBadRequest, "invalid_request", "delegation with actor_token is not supported")
+	case form.Get("requested_token_type") != "" && form.Get("requested_token_type") != tokenTypeAccess:
+		return failure(http.StatusBadRequest, "invalid_request", "only access_token can be requested")
+	}
+
+	if err := p.resource(req, form); err != nil {
+		return failure(http.StatusBadRequest, "invalid_target", err.Error())
+	}
+
+	claims, err := p.exchange.verifier.Verify(ctx, form.Get("subject_token"))
+	if err != nil {
+		slog.Debug("Subject token is rejected.", "err", err)
+		return failure(http.StatusBadRequest, "invalid_grant", "subject token is not valid")
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return failure(http.StatusBadRequest, "invalid_grant", "subject token has no subject")
+	}
+
+	scopes, err := p.exchange.grant(form.Get("scope"))
+	if err != nil {
+		return failure(http.StatusBadRequest, "invalid_scope", err.Error())
+	}
+
+	now := time.Now()
+	token, err := p.keys.sign(ctx,
+		map[string]any{
+			"iss":   p.exchange.issuer,
+			"aud":   p.exchange.issuer,
+			"sub":   sub,
+			"iat":   now.Unix(),
+			"exp":   now.Add(p.exchange.ttl).Unix(),
+			"jti":   rand.Text(),
+			"scope": strings.Join(scopes, " "),
+		},
+	)
+	if err != nil {
+		slog.Error("Token is not signed.", "err", err)
+		return failure(http.StatusServiceUnavailable, "temporarily_unavailable", "signing keys are not available")
+	}
+
+	data, _ := json.Marshal(map[string]any{
+		"access_token":      token,
+		"issued_token_type": tokenTypeAccess,
+		"token_type":        "Bearer",
+		"expires_in":        int(p.exchange.ttl.Seconds()),
+		"scope":             strings.Join(scopes, " "),
+	})
+	return reply(http.StatusOK, data)
+}
+
+// OpenID discovery of the gateway as the issuer of exchanged tokens, it is
+// used by authorizers to fetch signing keys.
+func (p *proxy) openidConfiguration() *events.APIGatewayProxyResponse {
+	data, _ := json.Marshal(map[string]any{
+		"issuer":                p.exchange.issuer,
+		"jwks_uri":              p.exchange.issuer + pathJWKS,
+		"token_endpoint":        p.exchange.issuer + "/oauth2/token",
+		"grant_types_supported": []string{grantTokenExchange},
+	})
+	return reply(http.StatusOK, data)
+}
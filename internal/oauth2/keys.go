@@ -0,0 +1,332 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Tag of KMS keys managed by the keyring, the value is the name of parameter
+const tagKeyring = "cloudmcp:keyring"
+
+// keyring is re-read periodically, so that rotation is followed by
+// concurrent instances of the lambda
+const keyringTTL = 1 * time.Minute
+
+// minimal waiting period of KMS key deletion
+const deletionWindow = 7
+
+var errNoSigningKey = errors.New("signing key is not provisioned")
+
+// KMS interface required by the keyring
+type KMS interface {
+	CreateKey(context.Context, *kms.CreateKeyInput, ...func(*kms.Options)) (*kms.CreateKeyOutput, error)
+	GetPublicKey(context.Context, *kms.GetPublicKeyInput, ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error)
+	Sign(context.Context, *kms.SignInput, ...func(*kms.Options)) (*kms.SignOutput, error)
+	ScheduleKeyDeletion(context.Context, *kms.ScheduleKeyDeletionInput, ...func(*kms.Options)) (*kms.ScheduleKeyDeletionOutput, error)
+}
+
+// SSM interface required by the keyring
+type SSM interface {
+	GetParameter(context.Context, *ssm.GetParameterInput, ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	PutParameter(context.Context, *ssm.PutParameterInput, ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+}
+
+// signing key, the private key never leaves KMS
+type signingKey struct {
+	Kid     string    `json:"kid"`
+	Arn     string    `json:"arn"`
+	JWK     jwk       `json:"jwk"`
+	Created time.Time `json:"created"`
+	Retired time.Time `json:"retired,omitzero"`
+}
+
+// public key of the signing key (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// state of the keyring, kept in SSM parameter
+type keyring struct {
+	Current  *signingKey  `json:"current,omitempty"`
+	Previous []signingKey `json:"previous,omitempty"`
+}
+
+// keys signing tokens issued by the gateway. ECC P-256 keys are created in
+// KMS and rotated by schedule, the current key signs tokens. Retired keys
+// are served by JWKS during the grace period, so that tokens signed by them
+// remain valid until expiry, afterwards keys are scheduled for deletion.
+type keys struct {
+	sync.Mutex
+	kms     KMS
+	ssm     SSM
+	param   string
+	grace   time.Duration
+	ring    *keyring
+	fetched time.Time
+}
+
+func newKeys(kms KMS, ssm SSM, param string, grace time.Duration) *keys {
+	return &keys{kms: kms, ssm: ssm, param: param, grace: grace}
+}
+
+func (k *keys) load(ctx context.Context) (*keyring, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	if k.ring != nil && time.Since(k.fetched) < keyringTTL {
+		return k.ring, nil
+	}
+
+	ring, err := k.read(ctx)
+	if err != nil {
+		// previous keys are used while parameter store is not available
+		if k.ring != nil {
+			slog.Warn("Keyring is not refreshed.", "err", err)
+			return k.ring, nil
+		}
+		return nil, err
+	}
+
+	k.ring, k.fetched = ring, time.Now()
+	return ring, nil
+}
+
+func (k *keys) read(ctx context.Context) (*keyring, error) {
+	val, err := k.ssm.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(k.param)})
+	if err != nil {
+		var notFound *ssmtypes.ParameterNotFound
+		if errors.As(err, &notFound) {
+			return &keyring{}, nil
+		}
+		return nil, err
+	}
+
+	var ring keyring
+	if err := json.Unmarshal([]byte(aws.ToString(val.Parameter.Value)), &ring); err != nil {
+		return nil, err
+	}
+
+	return &ring, nil
+}
+
+// JWKS of the current and previous keys
+func (k *keys) jwks(ctx context.Context) ([]jwk, error) {
+	ring, err := k.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seq := []jwk{}
+	if ring.Current != nil {
+		seq = append(seq, ring.Current.JWK)
+	}
+	for _, key := range ring.Previous {
+		seq = append(seq, key.JWK)
+	}
+
+	return seq, nil
+}
+
+// sign claims with the current key (ES256)
+func (k *keys) sign(ctx context.Context, claims map[string]any) (string, error) {
+	ring, err := k.load(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ring.Current == nil {
+		return "", errNoSigningKey
+	}
+
+	head, err := json.Marshal(map[string]string{"alg": "ES256", "typ": "at+jwt", "kid": ring.Current.Kid})
+	if err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	data := base64.RawURLEncoding.EncodeToString(head) + "." + base64.RawURLEncoding.EncodeToString(body)
+	digest := sha256.Sum256([]byte(data))
+
+	out, err := k.kms.Sign(ctx,
+		&kms.SignInput{
+			KeyId:            aws.String(ring.Current.Arn),
+			Message:          digest[:],
+			MessageType:      kmstypes.MessageTypeDigest,
+			SigningAlgorithm: kmstypes.SigningAlgorithmSpecEcdsaSha256,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := rawSignature(out.Signature, 32)
+	if err != nil {
+		return "", err
+	}
+
+	return data + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// KMS returns DER encoded ECDSA signature, JWS requires r || s (RFC 7518)
+func rawSignature(der []byte, size int) ([]byte, error) {
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 2*size)
+	sig.R.FillBytes(raw[:size])
+	sig.S.FillBytes(raw[size:])
+	return raw, nil
+}
+
+// rotate creates new current key, the current key is retired. Keys retired
+// longer than the grace period are scheduled for deletion.
+func (k *keys) rotate(ctx context.Context) error {
+	ring, err := k.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := k.create(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	next := keyring{Current: key}
+
+	if ring.Current != nil {
+		retired := *ring.Current
+		retired.Retired = now
+		next.Previous = append(next.Previous, retired)
+	}
+
+	for _, prev := range ring.Previous {
+		if now.Sub(prev.Retired) < k.grace {
+			next.Previous = append(next.Previous, prev)
+			continue
+		}
+
+		_, err := k.kms.ScheduleKeyDeletion(ctx,
+			&kms.ScheduleKeyDeletionInput{
+				KeyId:               aws.String(prev.Arn),
+				PendingWindowInDays: aws.Int32(deletionWindow),
+			},
+		)
+		if err != nil {
+			// deletion is retried by the next rotation
+			slog.Warn("Signing key is not deleted.", "kid", prev.Kid, "err", err)
+			next.Previous = append(next.Previous, prev)
+		}
+	}
+
+	data, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+
+	_, err = k.ssm.PutParameter(ctx,
+		&ssm.PutParameterInput{
+			Name:      aws.String(k.param),
+			Value:     aws.String(string(data)),
+			Type:      ssmtypes.ParameterTypeString,
+			Overwrite: aws.Bool(true),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	k.Lock()
+	k.ring, k.fetched = &next, now
+	k.Unlock()
+
+	slog.Info("Signing key is rotated.", "kid", key.Kid, "previous", len(next.Previous))
+	return nil
+}
+
+func (k *keys) create(ctx context.Context) (*signingKey, error) {
+	key, err := k.kms.CreateKey(ctx,
+		&kms.CreateKeyInput{
+			KeySpec:     kmstypes.KeySpecEccNistP256,
+			KeyUsage:    kmstypes.KeyUsageTypeSignVerify,
+			Description: aws.String("signing key of tokens issued by the gateway"),
+			Tags:        []kmstypes.Tag{{TagKey: aws.String(tagKeyring), TagValue: aws.String(k.param)}},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	arn := aws.ToString(key.KeyMetadata.Arn)
+	pub, err := k.kms.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(arn)})
+	if err != nil {
+		return nil, err
+	}
+
+	spki, err := x509.ParsePKIXPublicKey(pub.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	ec, ok := spki.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not ECDSA key", arn)
+	}
+
+	point, err := ec.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	// point is encoded as 0x04 || x || y
+	kid := aws.ToString(key.KeyMetadata.KeyId)
+	return &signingKey{
+		Kid: kid,
+		Arn: arn,
+		JWK: jwk{
+			Kty: "EC",
+			Kid: kid,
+			Use: "sig",
+			Alg: "ES256",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(point[1:33]),
+			Y:   base64.RawURLEncoding.EncodeToString(point[33:]),
+		},
+		Created: time.Now(),
+	}, nil
+}
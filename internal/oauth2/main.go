@@ -0,0 +1,162 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Lambda serving OAuth 2.0 endpoints of the gateway, it proxies authorization
+// code flow to the upstream identity provider (issuer of JWT access) so that
+// browser-based MCP clients work out of the box:
+//
+//	GET  /.well-known/oauth-protected-resource/{path}  (RFC 9728)
+//	GET  /.well-known/oauth-authorization-server       (RFC 8414)
+//	GET  /oauth2/authorize
+//	POST /oauth2/token
+//	GET  /.well-known/jwks.json                        (keys of the gateway)
+//	GET  /.well-known/openid-configuration             (gateway as issuer)
+//
+// Signing keys of the gateway are managed in KMS, the scheduled event
+// {"rotate": true} rotates them. Access tokens of the identity provider are
+// exchanged (RFC 8693) to short-lived tokens of the gateway at /oauth2/token.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/fogfish/cloudmcp/internal/jwt"
+)
+
+func main() {
+	var issuer jwt.Issuer
+	if err := json.Unmarshal([]byte(os.Getenv("CONFIG_OAUTH2_ISSUER")), &issuer); err != nil {
+		slog.Warn("OAuth2 proxy disabled.", "err", err)
+	}
+
+	proxy := newProxy(issuer, nil)
+
+	if param, has := os.LookupEnv("CONFIG_OAUTH2_KEYS"); has {
+		grace, err := time.ParseDuration(os.Getenv("CONFIG_OAUTH2_KEYS_GRACE"))
+		if err != nil {
+			panic(err)
+		}
+
+		cfg, err := config.LoadDefaultConfig(context.Background())
+		if err != nil {
+			panic(err)
+		}
+
+		proxy.keys = newKeys(kms.NewFromConfig(cfg), ssm.NewFromConfig(cfg), param, grace)
+	}
+
+	if spec, has := os.LookupEnv("CONFIG_OAUTH2_EXCHANGE"); has {
+		exchange, err := newExchange([]byte(spec), issuer, proxy.client)
+		if err != nil {
+			panic(err)
+		}
+		proxy.exchange = exchange
+	}
+
+	lambda.Start(
+		func(ctx context.Context, evt json.RawMessage) (*events.APIGatewayProxyResponse, error) {
+			var cmd struct {
+				Rotate bool `json:"rotate"`
+			}
+			if err := json.Unmarshal(evt, &cmd); err == nil && cmd.Rotate {
+				if proxy.keys == nil {
+					return nil, errNoSigningKey
+				}
+				return nil, proxy.keys.rotate(ctx)
+			}
+
+			var req events.APIGatewayProxyRequest
+			if err := json.Unmarshal(evt, &req); err != nil {
+				return nil, err
+			}
+
+			if proxy.keys != nil && strings.HasSuffix(req.Path, pathJWKS) && req.HTTPMethod == http.MethodGet {
+				return proxy.jwks(ctx), nil
+			}
+
+			if proxy.exchange != nil && strings.HasSuffix(req.Path, pathOpenIDConfiguration) && req.HTTPMethod == http.MethodGet {
+				return proxy.openidConfiguration(), nil
+			}
+
+			if proxy.issuer == "" {
+				return failure(http.StatusNotFound, "not_found", "oauth2 is not configured"), nil
+			}
+
+			switch {
+			case req.HTTPMethod == http.MethodOptions:
+				return preflight(), nil
+			case strings.Contains(req.Path, pathProtectedResource):
+				return proxy.protectedResource(&req), nil
+			case strings.HasSuffix(req.Path, pathAuthorizationServer):
+				return proxy.authorizationServer(&req), nil
+			case strings.HasSuffix(req.Path, "/oauth2/authorize") && req.HTTPMethod == http.MethodGet:
+				return proxy.authorize(ctx, &req), nil
+			case strings.HasSuffix(req.Path, "/oauth2/token") && req.HTTPMethod == http.MethodPost:
+				return proxy.token(ctx, &req), nil
+			default:
+				return failure(http.StatusNotFound, "not_found", req.HTTPMethod+" "+req.Path), nil
+			}
+		},
+	)
+}
+
+func preflight() *events.APIGatewayProxyResponse {
+	return &events.APIGatewayProxyResponse{
+		StatusCode: http.StatusNoContent,
+		MultiValueHeaders: http.Header{
+			"Access-Control-Allow-Origin":  []string{"*"},
+			"Access-Control-Allow-Methods": []string{"GET, POST, OPTIONS"},
+			"Access-Control-Allow-Headers": []string{"Authorization, Content-Type, Mcp-Protocol-Version"},
+			"Access-Control-Max-Age":       []string{"3600"},
+		},
+	}
+}
+
+// error response of OAuth 2.0 (RFC 6749)
+func failure(status int, code, description string) *events.APIGatewayProxyResponse {
+	data, _ := json.Marshal(map[string]string{"error": code, "error_description": description})
+	return reply(status, data)
+}
+
+func reply(status int, body []byte) *events.APIGatewayProxyResponse {
+	return &events.APIGatewayProxyResponse{
+		StatusCode: status,
+		MultiValueHeaders: http.Header{
+			"Content-Type":                []string{"application/json"},
+			"Cache-Control":               []string{"no-store"},
+			"Access-Control-Allow-Origin": []string{"*"},
+		},
+		Body: string(body),
+	}
+}
+
+// HTTP API lowercases headers, REST API preserves them as sent by client
+func header(headers map[string]string, name string) string {
+	if val, has := headers[name]; has {
+		return val
+	}
+
+	for key, val := range headers {
+		if strings.EqualFold(key, name) {
+			return val
+		}
+	}
+
+	return ""
+}
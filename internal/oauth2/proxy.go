@@ -0,0 +1,306 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/fogfish/cloudmcp/internal/jwt"
+)
+
+const (
+	pathProtectedResource   = "/.well-known/oauth-protected-resource"
+	pathAuthorizationServer = "/.well-known/oauth-authorization-server"
+	pathJWKS                = "/.well-known/jwks.json"
+)
+
+// proxy of authorization code flow, it enforces expectations of MCP auth
+// spec (PKCE with S256, resource indicators) in front of identity providers
+// that do not implement them (e.g. Cognito ignores resource indicators).
+type proxy struct {
+	sync.Mutex
+	issuer   string
+	audience []string
+	client   *http.Client
+	upstream *upstream
+	keys     *keys
+	exchange *exchange
+}
+
+// endpoints of upstream identity provider
+type upstream struct {
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	ScopesSupported       []string `json:"scopes_supported,omitempty"`
+}
+
+func newProxy(issuer jwt.Issuer, client *http.Client) *proxy {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &proxy{
+		issuer:   strings.TrimSuffix(issuer.Issuer, "/"),
+		audience: issuer.Audience,
+		client:   client,
+	}
+}
+
+// endpoints of the issuer are discovered once, failures are retried
+func (p *proxy) discover(ctx context.Context) (*upstream, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.upstream != nil {
+		return p.upstream, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery of %s failed: %s", p.issuer, rsp.Status)
+	}
+
+	var conf upstream
+	if err := json.NewDecoder(rsp.Body).Decode(&conf); err != nil {
+		return nil, err
+	}
+	if conf.AuthorizationEndpoint == "" || conf.TokenEndpoint == "" {
+		return nil, fmt.Errorf("issuer %s does not support authorization code flow", p.issuer)
+	}
+
+	p.upstream = &conf
+	return p.upstream, nil
+}
+
+// base URL of the gateway, resolved from the host of the request
+func base(req *events.APIGatewayProxyRequest) string {
+	host := req.RequestContext.DomainName
+	if host == "" {
+		host = header(req.Headers, "Host")
+	}
+	return "https://" + host
+}
+
+// Protected Resource Metadata (RFC 9728), the path of the resource follows
+// the well-known suffix (e.g. /.well-known/oauth-protected-resource/helloworld).
+func (p *proxy) protectedResource(req *events.APIGatewayProxyRequest) *events.APIGatewayProxyResponse {
+	_, resource, _ := strings.Cut(req.Path, pathProtectedResource)
+
+	data, _ := json.Marshal(map[string]any{
+		"resource":                 base(req) + resource,
+		"authorization_servers":    []string{base(req)},
+		"bearer_methods_supported": []string{"header"},
+	})
+	return reply(http.StatusOK, data)
+}
+
+// Authorization Server Metadata (RFC 8414) of the proxy
+func (p *proxy) authorizationServer(req *events.APIGatewayProxyRequest) *events.APIGatewayProxyResponse {
+	meta := map[string]any{
+		"issuer":                                base(req),
+		"authorization_endpoint":                base(req) + "/oauth2/authorize",
+		"token_endpoint":                        base(req) + "/oauth2/token",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"none", "client_secret_basic", "client_secret_post"},
+	}
+
+	if conf, err := p.discover(context.Background()); err == nil && len(conf.ScopesSupported) > 0 {
+		meta["scopes_supported"] = conf.ScopesSupported
+	}
+
+	if p.keys != nil {
+		meta["jwks_uri"] = base(req) + pathJWKS
+	}
+
+	if p.exchange != nil {
+		meta["grant_types_supported"] = []string{"authorization_code", "refresh_token", grantTokenExchange}
+	}
+
+	data, _ := json.Marshal(meta)
+	return reply(http.StatusOK, data)
+}
+
+// redirects the user agent to the upstream authorization endpoint, PKCE is
+// required and resource indicator is validated against the gateway.
+func (p *proxy) authorize(ctx context.Context, req *events.APIGatewayProxyRequest) *events.APIGatewayProxyResponse {
+	query := url.Values{}
+	for key, val := range req.MultiValueQueryStringParameters {
+		query[key] = val
+	}
+	for key, val := range req.QueryStringParameters {
+		if _, has := query[key]; !has {
+			query.Set(key, val)
+		}
+	}
+
+	switch {
+	case query.Get("response_type") != "code":
+		return failure(http.StatusBadRequest, "unsupported_response_type", "only authorization code flow is supported")
+	case query.Get("client_id") == "" || query.Get("redirect_uri") == "":
+		return failure(http.StatusBadRequest, "invalid_request", "client_id and redirect_uri are required")
+	case query.Get("code_challenge") == "":
+		return failure(http.StatusBadRequest, "invalid_request", "PKCE code_challenge is required")
+	case query.Get("code_challenge_method") != "S256":
+		return failure(http.StatusBadRequest, "invalid_request", "PKCE code_challenge_method must be S256")
+	}
+
+	if err := p.resource(req, query); err != nil {
+		return failure(http.StatusBadRequest, "invalid_target", err.Error())
+	}
+
+	// audience of access token is requested explicitly (e.g. Auth0)
+	if len(p.audience) > 0 && query.Get("audience") == "" {
+		query.Set("audience", p.audience[0])
+	}
+
+	conf, err := p.discover(ctx)
+	if err != nil {
+		slog.Error("Identity provider is not available.", "err", err)
+		return failure(http.StatusBadGateway, "temporarily_unavailable", "identity provider is not available")
+	}
+
+	target, err := url.Parse(conf.AuthorizationEndpoint)
+	if err != nil {
+		return failure(http.StatusBadGateway, "server_error", "invalid authorization endpoint")
+	}
+	params := target.Query()
+	for key, val := range query {
+		params[key] = val
+	}
+	target.RawQuery = params.Encode()
+
+	return &events.APIGatewayProxyResponse{
+		StatusCode: http.StatusFound,
+		MultiValueHeaders: http.Header{
+			"Location":      []string{target.String()},
+			"Cache-Control": []string{"no-store"},
+		},
+	}
+}
+
+// forwards token request to the upstream token endpoint, authorization code
+// grant requires PKCE code verifier. Token exchange is served by the gateway.
+func (p *proxy) token(ctx context.Context, req *events.APIGatewayProxyRequest) *events.APIGatewayProxyResponse {
+	body := req.Body
+	if req.IsBase64Encoded {
+		data, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return failure(http.StatusBadRequest, "invalid_request", "malformed body")
+		}
+		body = string(data)
+	}
+
+	form, err := url.ParseQuery(body)
+	if err != nil {
+		return failure(http.StatusBadRequest, "invalid_request", "malformed body")
+	}
+
+	switch form.Get("grant_type") {
+	case "authorization_code":
+		if form.Get("code_verifier") == "" {
+			return failure(http.StatusBadRequest, "invalid_request", "PKCE code_verifier is required")
+		}
+	case "refresh_token":
+	case grantTokenExchange:
+		return p.exchangeToken(ctx, req, form)
+	default:
+		return failure(http.StatusBadRequest, "unsupported_grant_type", "only authorization_code, refresh_token and token exchange grants are supported")
+	}
+
+	if err := p.resource(req, form); err != nil {
+		return failure(http.StatusBadRequest, "invalid_target", err.Error())
+	}
+
+	conf, err := p.discover(ctx)
+	if err != nil {
+		slog.Error("Identity provider is not available.", "err", err)
+		return failure(http.StatusBadGateway, "temporarily_unavailable", "identity provider is not available")
+	}
+
+	call, err := http.NewRequestWithContext(ctx, http.MethodPost, conf.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return failure(http.StatusBadGateway, "server_error", "invalid token endpoint")
+	}
+	call.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	call.Header.Set("Accept", "application/json")
+	if auth := header(req.Headers, "Authorization"); auth != "" {
+		call.Header.Set("Authorization", auth)
+	}
+
+	rsp, err := p.client.Do(call)
+	if err != nil {
+		slog.Error("Identity provider is not available.", "err", err)
+		return failure(http.StatusBadGateway, "temporarily_unavailable", "identity provider is not available")
+	}
+	defer rsp.Body.Close()
+
+	data, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return failure(http.StatusBadGateway, "temporarily_unavailable", "identity provider is not available")
+	}
+
+	return reply(rsp.StatusCode, data)
+}
+
+// JSON Web Key Set of signing keys of the gateway, retired keys are listed
+// until the grace period is over.
+func (p *proxy) jwks(ctx context.Context) *events.APIGatewayProxyResponse {
+	keys, err := p.keys.jwks(ctx)
+	if err != nil {
+		slog.Error("Signing keys are not available.", "err", err)
+		return failure(http.StatusServiceUnavailable, "temporarily_unavailable", "signing keys are not available")
+	}
+
+	data, _ := json.Marshal(map[string]any{"keys": keys})
+	rsp := reply(http.StatusOK, data)
+	http.Header(rsp.MultiValueHeaders).Set("Cache-Control", "public, max-age=300")
+	return rsp
+}
+
+// validates resource indicator (RFC 8707) against the host of the gateway,
+// the indicator is dropped, upstream identity providers either ignore or
+// reject resource indicators of the gateway.
+func (p *proxy) resource(req *events.APIGatewayProxyRequest, params url.Values) error {
+	host := base(req)
+	for _, resource := range params["resource"] {
+		uri, err := url.Parse(resource)
+		if err != nil || uri.Fragment != "" {
+			return fmt.Errorf("resource %s is not valid", resource)
+		}
+		if resource != host && !strings.HasPrefix(resource, host+"/") {
+			return fmt.Errorf("resource %s is not served by the gateway", resource)
+		}
+	}
+	params.Del("resource")
+
+	return nil
+}
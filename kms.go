@@ -0,0 +1,134 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awskms"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Configures encryption of the stack using customer managed KMS key, the key
+// is created (with rotation enabled) unless ARN of existing key is given. The
+// key encrypts DynamoDB tables (sessions, rate limits, events, connections and
+// tables declared by WithTable), buckets declared by WithBucket, environment
+// variables of the server function and the log group. The server is granted
+// to use the key. Existing key must allow CloudWatch Logs service principal
+// of the region to use it, the policy of created key does it.
+func (c *Gateway) WithKms(keyArn ...string) *Gateway {
+	if c.kms != nil {
+		panic("kms key is already configured")
+	}
+
+	if len(keyArn) > 0 {
+		c.kms = awskms.Key_FromKeyArn(c.stack, jsii.String("Key"), jsii.String(keyArn[0]))
+	} else {
+		key := awskms.NewKey(c.stack, jsii.String("Key"),
+			&awskms.KeyProps{
+				Alias:             jsii.String("alias/" + c.name()),
+				Description:       jsii.String("encryption of " + c.name()),
+				EnableKeyRotation: jsii.Bool(true),
+				RemovalPolicy:     awscdk.RemovalPolicy_RETAIN,
+			},
+		)
+
+		key.AddToResourcePolicy(
+			awsiam.NewPolicyStatement(
+				&awsiam.PolicyStatementProps{
+					Effect: awsiam.Effect_ALLOW,
+					Principals: &[]awsiam.IPrincipal{
+						awsiam.NewServicePrincipal(jsii.String("logs."+*c.stack.Region()+".amazonaws.com"), nil),
+					},
+					Actions: jsii.Strings(
+						"kms:Encrypt*",
+						"kms:Decrypt*",
+						"kms:ReEncrypt*",
+						"kms:GenerateDataKey*",
+						"kms:Describe*",
+					),
+					Resources: jsii.Strings("*"),
+					Conditions: &map[string]any{
+						"ArnLike": map[string]any{
+							"kms:EncryptionContext:aws:logs:arn": "arn:" + *c.stack.Partition() + ":logs:" + *c.stack.Region() + ":" + *c.stack.Account() + ":log-group:*",
+						},
+					},
+				},
+			),
+			nil,
+		)
+
+		c.kms = key
+	}
+
+	c.grants = append(c.grants, func(f awsiam.IGrantable) {
+		c.kms.GrantEncryptDecrypt(f)
+	})
+
+	return c
+}
+
+// applies the key to resources of the stack, it is called once all options
+// are configured so that order of options does not matter.
+func (c *Gateway) encrypt() {
+	if c.kms == nil {
+		return
+	}
+
+	for _, node := range *c.stack.Node().FindAll(constructs.ConstructOrder_PREORDER) {
+		switch r := node.(type) {
+		case awsdynamodb.Table:
+			if r.EncryptionKey() != nil {
+				continue
+			}
+
+			cfn := r.Node().DefaultChild().(awsdynamodb.CfnTable)
+			cfn.SetSseSpecification(
+				&awsdynamodb.CfnTable_SSESpecificationProperty{
+					SseEnabled:     jsii.Bool(true),
+					SseType:        jsii.String("KMS"),
+					KmsMasterKeyId: c.kms.KeyArn(),
+				},
+			)
+		case awss3.Bucket:
+			// only buckets of the server are encrypted, other buckets of the
+			// stack are accessed by services (e.g. Firehose) not granted to the key
+			if !strings.HasPrefix(*r.Node().Id(), "Bucket-") || r.EncryptionKey() != nil {
+				continue
+			}
+
+			cfn := r.Node().DefaultChild().(awss3.CfnBucket)
+			cfn.SetBucketEncryption(
+				&awss3.CfnBucket_BucketEncryptionProperty{
+					ServerSideEncryptionConfiguration: &[]any{
+						&awss3.CfnBucket_ServerSideEncryptionRuleProperty{
+							BucketKeyEnabled: jsii.Bool(true),
+							ServerSideEncryptionByDefault: &awss3.CfnBucket_ServerSideEncryptionByDefaultProperty{
+								SseAlgorithm:   jsii.String("aws:kms"),
+								KmsMasterKeyId: c.kms.KeyArn(),
+							},
+						},
+					},
+				},
+			)
+		}
+	}
+
+	// existing log group or log group encrypted by the key of Logging are kept
+	if c.logging == nil || (c.logging.LogGroup == "" && c.logging.KmsKey == "") {
+		cfn := c.loggroup.Node().DefaultChild().(awslogs.CfnLogGroup)
+		cfn.SetKmsKeyId(c.kms.KeyArn())
+	}
+}
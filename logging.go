@@ -0,0 +1,155 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awskinesis"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awskinesisfirehose"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awskms"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogsdestinations"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Logging configures the log group of the server
+type Logging struct {
+	// Retention of logs, defaults to 5 days
+	Retention awslogs.RetentionDays
+
+	// ARN of KMS key encrypting logs, the key policy must allow CloudWatch
+	// Logs service principal of the region to use the key.
+	KmsKey string
+
+	// Name or ARN of existing log group used instead of /app/{ServerName}.
+	// Retention and encryption of the group are managed by its owner.
+	LogGroup string
+
+	// Subscription filters streaming logs to other destinations
+	Subscriptions []LogSubscription
+}
+
+// LogSubscription streams log events matching the pattern to destination
+type LogSubscription struct {
+	// ARN of Kinesis stream, Firehose delivery stream or Lambda function
+	// (e.g. forwarding to OpenSearch)
+	Destination string
+
+	// Filter pattern of events, all events are streamed if omitted
+	Pattern string
+}
+
+// Configures the log group of the server, all functions and containers of
+// the stack log into it. By default, the group /app/{ServerName} is created
+// with 5 days retention. The logging must be configured before other options,
+// only Stage precedes it.
+func (c *Gateway) Logging(spec Logging) *Gateway {
+	if c.configured() {
+		panic("logging must be configured before other options")
+	}
+
+	for _, sub := range spec.Subscriptions {
+		if _, err := logDestinationOf(sub.Destination); err != nil {
+			panic(err)
+		}
+	}
+
+	c.stack.Node().TryRemoveChild(jsii.String("Logs"))
+	c.logging = &spec
+	c.initLogGroup(c.name())
+
+	return c
+}
+
+// creates (or imports) the log group of the stack
+func (c *Gateway) initLogGroup(name string) {
+	spec := c.logging
+	if spec == nil {
+		spec = &Logging{}
+	}
+
+	switch {
+	case strings.HasPrefix(spec.LogGroup, "arn:"):
+		c.loggroup = awslogs.LogGroup_FromLogGroupArn(c.stack, jsii.String("Logs"), jsii.String(spec.LogGroup))
+	case spec.LogGroup != "":
+		c.loggroup = awslogs.LogGroup_FromLogGroupName(c.stack, jsii.String("Logs"), jsii.String(spec.LogGroup))
+	default:
+		props := &awslogs.LogGroupProps{
+			LogGroupName:  jsii.String(fmt.Sprintf("/app/%s", name)),
+			RemovalPolicy: awscdk.RemovalPolicy_DESTROY,
+			Retention:     awslogs.RetentionDays_FIVE_DAYS,
+		}
+		if spec.Retention != "" {
+			props.Retention = spec.Retention
+		}
+		if spec.KmsKey != "" {
+			props.EncryptionKey = awskms.Key_FromKeyArn(c.stack, jsii.String("LogsKey"), jsii.String(spec.KmsKey))
+		}
+		c.loggroup = awslogs.NewLogGroup(c.stack, jsii.String("Logs"), props)
+	}
+
+	for i, sub := range spec.Subscriptions {
+		kind, _ := logDestinationOf(sub.Destination)
+		id := fmt.Sprintf("LogsSubscription%d", i)
+
+		var destination awslogs.ILogSubscriptionDestination
+		switch kind {
+		case "kinesis":
+			destination = awslogsdestinations.NewKinesisDestination(
+				awskinesis.Stream_FromStreamArn(c.stack, jsii.String(id+"Stream"), jsii.String(sub.Destination)),
+				nil,
+			)
+		case "firehose":
+			destination = awslogsdestinations.NewFirehoseDestination(
+				awskinesisfirehose.DeliveryStream_FromDeliveryStreamArn(c.stack, jsii.String(id+"Stream"), jsii.String(sub.Destination)),
+				nil,
+			)
+		case "lambda":
+			destination = awslogsdestinations.NewLambdaDestination(
+				awslambda.Function_FromFunctionArn(c.stack, jsii.String(id+"Function"), jsii.String(sub.Destination)),
+				nil,
+			)
+		}
+
+		pattern := awslogs.FilterPattern_AllEvents()
+		if sub.Pattern != "" {
+			pattern = awslogs.FilterPattern_Literal(jsii.String(sub.Pattern))
+		}
+
+		c.loggroup.AddSubscriptionFilter(jsii.String(id),
+			&awslogs.SubscriptionFilterOptions{
+				Destination:   destination,
+				FilterPattern: pattern,
+			},
+		)
+	}
+}
+
+// kind of subscription destination, the service of ARN
+func logDestinationOf(arn string) (string, error) {
+	seq := strings.SplitN(arn, ":", 4)
+	if len(seq) != 4 || seq[0] != "arn" {
+		return "", fmt.Errorf("invalid log destination %s", arn)
+	}
+
+	switch seq[2] {
+	case "kinesis":
+		return "kinesis", nil
+	case "firehose":
+		return "firehose", nil
+	case "lambda":
+		return "lambda", nil
+	default:
+		return "", fmt.Errorf("log destination %s is not supported, use kinesis, firehose or lambda", arn)
+	}
+}
@@ -0,0 +1,225 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/cxapi"
+	"github.com/fogfish/cloudmcp/internal/gateway"
+)
+
+// Listing of the server at MCP registry
+type Listing struct {
+	// Name of the server in reverse-DNS format (e.g. io.github.fogfish/helloworld),
+	// the namespace is verified by the registry.
+	Name string
+
+	// Description of the server, required by the registry
+	Description string
+
+	// Title of the server, the title of the factory's server if omitted
+	Title string
+
+	// Version of the server, the version of the factory's server if omitted
+	Version string
+
+	// Home page of the server
+	WebsiteURL string
+
+	// URL of the source code repository (e.g. https://github.com/fogfish/cloudmcp)
+	Repository string
+}
+
+// Placeholder of the endpoint within the manifest, the endpoint of the
+// API Gateway is known once the stack is deployed (see `cloudmcp manifest`).
+const ManifestEndpoint = "${Endpoint}"
+
+// Schema of the server manifest (server.json) of MCP registry
+const manifestSchema = "https://static.modelcontextprotocol.io/schemas/2025-09-29/server.schema.json"
+
+// Configures the manifest of the server for publishing at MCP registries.
+// The manifest (server.json) is written into the cloud assembly as
+// `{stack}.server.json`. It declares the deployed endpoint as the remote
+// (streamable-http) with headers required by the access policy of the
+// gateway and lists tools of the factory as publisher-provided metadata.
+// The endpoint of API Gateway is resolved at deployment, the manifest
+// refers to it with ManifestEndpoint, use `cloudmcp manifest` to inject
+// the endpoint of the deployed stack.
+//
+//	cloudmcp.New(server.HelloWorld).
+//		Manifest(cloudmcp.Listing{
+//			Name:        "io.github.fogfish/helloworld",
+//			Description: "Greets the world",
+//		})
+func (c *Gateway) Manifest(listing Listing) *Gateway {
+	if !strings.Contains(listing.Name, "/") {
+		panic(fmt.Errorf("name of the listing must be namespace/name, got %q", listing.Name))
+	}
+	if listing.Description == "" {
+		panic("description of the listing is required")
+	}
+
+	c.listing = &listing
+	return c
+}
+
+// server.json of MCP registry
+type manifestServer struct {
+	Schema      string              `json:"$schema"`
+	Name        string              `json:"name"`
+	Title       string              `json:"title,omitempty"`
+	Description string              `json:"description"`
+	Version     string              `json:"version"`
+	WebsiteURL  string              `json:"websiteUrl,omitempty"`
+	Repository  *manifestRepository `json:"repository,omitempty"`
+	Remotes     []manifestRemote    `json:"remotes"`
+	Meta        map[string]any      `json:"_meta,omitempty"`
+}
+
+type manifestRepository struct {
+	URL    string `json:"url"`
+	Source string `json:"source"`
+}
+
+type manifestRemote struct {
+	Type    string           `json:"type"`
+	URL     string           `json:"url"`
+	Headers []manifestHeader `json:"headers,omitempty"`
+}
+
+type manifestHeader struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	IsRequired  bool   `json:"isRequired"`
+	IsSecret    bool   `json:"isSecret"`
+}
+
+type manifestTool struct {
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// records the endpoint of the server, unless it is resolved at deployment
+func (c *Gateway) manifestEndpoint(endpoint *string) {
+	if c.listing == nil {
+		return
+	}
+
+	c.endpoint = ManifestEndpoint
+	if !*awscdk.Token_IsUnresolved(endpoint) {
+		c.endpoint = *endpoint
+	}
+}
+
+// writes the manifest of the server into the cloud assembly
+func (c *Gateway) writeManifest(assembly cxapi.CloudAssembly) {
+	server, err := c.newServer()
+	if err != nil {
+		panic(fmt.Errorf("failed to create server %s: %w", servername(c.f), err))
+	}
+
+	snapshot, err := gateway.Snapshot(context.Background(), server)
+	if err != nil {
+		panic(fmt.Errorf("failed to snapshot tools of %s: %w", servername(c.f), err))
+	}
+
+	manifest := manifestServer{
+		Schema:      manifestSchema,
+		Name:        c.listing.Name,
+		Title:       c.listing.Title,
+		Description: c.listing.Description,
+		Version:     c.listing.Version,
+		WebsiteURL:  c.listing.WebsiteURL,
+		Remotes:     []manifestRemote{},
+	}
+
+	if info := snapshot.Initialize.ServerInfo; info != nil {
+		if manifest.Title == "" {
+			manifest.Title = info.Title
+		}
+		if manifest.Version == "" {
+			// registry expects semantic version (e.g. 1.0.0)
+			manifest.Version = strings.TrimPrefix(info.Version, "v")
+		}
+	}
+	if manifest.Version == "" {
+		panic(fmt.Errorf("version of %s is not defined, configure the listing", servername(c.f)))
+	}
+
+	if c.listing.Repository != "" {
+		uri, err := url.Parse(c.listing.Repository)
+		if err != nil {
+			panic(fmt.Errorf("invalid repository of the listing: %w", err))
+		}
+		source, _, _ := strings.Cut(strings.TrimPrefix(uri.Hostname(), "www."), ".")
+		manifest.Repository = &manifestRepository{URL: c.listing.Repository, Source: source}
+	}
+
+	if c.endpoint != "" {
+		manifest.Remotes = append(manifest.Remotes,
+			manifestRemote{Type: "streamable-http", URL: c.endpoint, Headers: c.manifestHeaders()},
+		)
+	}
+
+	tools := []manifestTool{}
+	for _, tool := range snapshot.Tools.Tools {
+		tools = append(tools, manifestTool{Name: tool.Name, Title: tool.Title, Description: tool.Description})
+	}
+	manifest.Meta = map[string]any{
+		"io.modelcontextprotocol.registry/publisher-provided": map[string]any{
+			"tools": tools,
+		},
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+
+	artifact := assembly.GetStackArtifact(c.stack.ArtifactId())
+	file := filepath.Join(*assembly.Directory(), *artifact.StackName()+".server.json")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		panic(err)
+	}
+}
+
+// headers required by the access policy of the gateway
+func (c *Gateway) manifestHeaders() []manifestHeader {
+	schemes := []string{}
+	if c.authkey != nil || c.authhsh != nil {
+		schemes = append(schemes, "Basic {api key}")
+	}
+	if c.authjwt != nil || c.authjwts != nil || c.authint != nil {
+		schemes = append(schemes, "Bearer {access token}")
+	}
+	if c.authiam != nil {
+		schemes = append(schemes, "AWS SigV4 signature (execute-api)")
+	}
+	if len(schemes) == 0 {
+		return nil
+	}
+
+	return []manifestHeader{
+		{
+			Name:        "Authorization",
+			Description: strings.Join(schemes, " or "),
+			IsRequired:  true,
+			IsSecret:    true,
+		},
+	}
+}
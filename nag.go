@@ -0,0 +1,309 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/cxapi"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// NagSuppression accepts the violation of the security rule, the reason is
+// the documented justification reviewed by security teams.
+type NagSuppression struct {
+	// Identity of the rule (e.g. AwsSolutions-IAM4)
+	Rule string
+
+	// Justification of the suppression, at least 10 characters
+	Reason string
+
+	// Path of constructs within the stack (e.g. server/ServiceRole), the
+	// suppression is applied to the stack if it is empty.
+	Path string
+}
+
+// Configures security posture checks of the synthesized stack. Resources are
+// checked against rules of the AwsSolutions pack of cdk-nag (see nagRules),
+// Build fails on violations unless they are suppressed. Suppressions are
+// recorded in the metadata of resources using the cdk-nag format, the
+// suppressions of own constructs made with cdk-nag are honoured as well.
+//
+//	cloudmcp.New(server.HelloWorld).
+//		WithNag(
+//			cloudmcp.NagSuppression{
+//				Rule:   "AwsSolutions-IAM4",
+//				Reason: "AWSLambdaBasicExecutionRole is approved for functions",
+//			},
+//		)
+func (c *Gateway) WithNag(suppressions ...NagSuppression) *Gateway {
+	for _, s := range suppressions {
+		if !strings.HasPrefix(s.Rule, "AwsSolutions-") {
+			panic(fmt.Errorf("invalid nag rule %q", s.Rule))
+		}
+		if len(s.Reason) < 10 {
+			panic(fmt.Errorf("suppression of %s requires reason of at least 10 characters", s.Rule))
+		}
+	}
+
+	c.nag = true
+	c.suppressions = append(c.suppressions, suppressions...)
+	return c
+}
+
+// metadata of suppressed rules, as it is defined by cdk-nag
+type nagMetadata struct {
+	RulesToSuppress []nagSuppressed `json:"rules_to_suppress"`
+}
+
+type nagSuppressed struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// records suppressions into metadata of resources, it is called before synth
+// once all constructs are defined. It returns paths of resources indexed by
+// logical id, the path is required to suppress the violation.
+func (c *Gateway) suppressNag() map[string]string {
+	root := *c.stack.Node().Path() + "/"
+	paths := map[string]string{}
+
+	for _, node := range *c.stack.Node().FindAll(constructs.ConstructOrder_PREORDER) {
+		cfn, ok := node.(awscdk.CfnResource)
+		if !ok {
+			continue
+		}
+
+		path := strings.TrimPrefix(*node.Node().Path(), root)
+		if id, ok := c.stack.Resolve(c.stack.GetLogicalId(cfn)).(string); ok {
+			paths[id] = path
+		}
+
+		rules := []nagSuppressed{}
+		for _, s := range c.suppressions {
+			if s.Path == "" || path == s.Path || strings.HasPrefix(path, s.Path+"/") {
+				rules = append(rules, nagSuppressed{ID: s.Rule, Reason: s.Reason})
+			}
+		}
+		if len(rules) == 0 {
+			continue
+		}
+
+		var meta nagMetadata
+		if existing := cfn.GetMetadata(jsii.String("cdk_nag")); existing != nil {
+			data, err := json.Marshal(existing)
+			if err != nil {
+				panic(err)
+			}
+			if err := json.Unmarshal(data, &meta); err != nil {
+				panic(err)
+			}
+		}
+		meta.RulesToSuppress = append(meta.RulesToSuppress, rules...)
+
+		cfn.AddMetadata(jsii.String("cdk_nag"), meta)
+	}
+
+	return paths
+}
+
+// subset of CloudFormation template required by checks
+type nagResource struct {
+	Type       string         `json:"Type"`
+	Properties map[string]any `json:"Properties"`
+	Metadata   struct {
+		Nag nagMetadata `json:"cdk_nag"`
+	} `json:"Metadata"`
+}
+
+type nagRule struct {
+	id    string
+	info  string
+	check func(r nagResource) bool
+}
+
+// Rules of the AwsSolutions pack applicable to resources of the gateway
+var nagRules = []nagRule{
+	{
+		id:   "AwsSolutions-IAM4",
+		info: "The IAM role uses AWS managed policies.",
+		check: func(r nagResource) bool {
+			switch r.Type {
+			case "AWS::IAM::Role", "AWS::IAM::User", "AWS::IAM::Group":
+				return strings.Contains(nagString(r.Properties["ManagedPolicyArns"]), ":iam::aws:policy/")
+			}
+			return false
+		},
+	},
+	{
+		id:   "AwsSolutions-IAM5",
+		info: "The IAM entity contains wildcard permissions.",
+		check: func(r nagResource) bool {
+			switch r.Type {
+			case "AWS::IAM::Policy", "AWS::IAM::ManagedPolicy":
+				return nagWildcard(r.Properties["PolicyDocument"])
+			case "AWS::IAM::Role", "AWS::IAM::User", "AWS::IAM::Group":
+				policies, _ := r.Properties["Policies"].([]any)
+				for _, policy := range policies {
+					if doc, ok := policy.(map[string]any); ok && nagWildcard(doc["PolicyDocument"]) {
+						return true
+					}
+				}
+			}
+			return false
+		},
+	},
+	{
+		id:   "AwsSolutions-APIG1",
+		info: "The API does not have access logging enabled.",
+		check: func(r nagResource) bool {
+			switch r.Type {
+			case "AWS::ApiGateway::Stage":
+				return r.Properties["AccessLogSetting"] == nil
+			case "AWS::ApiGatewayV2::Stage":
+				return r.Properties["AccessLogSettings"] == nil
+			}
+			return false
+		},
+	},
+	{
+		id:   "AwsSolutions-APIG4",
+		info: "The API does not implement authorization.",
+		check: func(r nagResource) bool {
+			switch r.Type {
+			case "AWS::ApiGateway::Method":
+				if r.Properties["HttpMethod"] == "OPTIONS" {
+					return false
+				}
+				return r.Properties["AuthorizationType"] == nil || r.Properties["AuthorizationType"] == "NONE"
+			case "AWS::ApiGatewayV2::Route":
+				return r.Properties["AuthorizationType"] == nil || r.Properties["AuthorizationType"] == "NONE"
+			}
+			return false
+		},
+	},
+	{
+		id:   "AwsSolutions-DDB3",
+		info: "The DynamoDB table does not have Point-in-time Recovery enabled.",
+		check: func(r nagResource) bool {
+			if r.Type != "AWS::DynamoDB::Table" {
+				return false
+			}
+			spec, _ := r.Properties["PointInTimeRecoverySpecification"].(map[string]any)
+			return spec == nil || spec["PointInTimeRecoveryEnabled"] != true
+		},
+	},
+	{
+		id:   "AwsSolutions-S1",
+		info: "The S3 bucket has server access logs disabled.",
+		check: func(r nagResource) bool {
+			return r.Type == "AWS::S3::Bucket" && r.Properties["LoggingConfiguration"] == nil
+		},
+	},
+}
+
+// Checks synthesized stack, violations fail the build
+func (c *Gateway) checkNag(assembly cxapi.CloudAssembly, paths map[string]string) {
+	artifact := assembly.GetStackArtifact(c.stack.ArtifactId())
+
+	data, err := json.Marshal(artifact.Template())
+	if err != nil {
+		panic(err)
+	}
+
+	var template struct {
+		Resources map[string]nagResource `json:"Resources"`
+	}
+	if err := json.Unmarshal(data, &template); err != nil {
+		panic(err)
+	}
+
+	violations := checkNagRules(template.Resources, paths)
+	if len(violations) > 0 {
+		panic(fmt.Errorf("stack %s violates security rules, fix or suppress them:\n%s",
+			*c.stack.StackName(), strings.Join(violations, "\n")))
+	}
+}
+
+func checkNagRules(resources map[string]nagResource, paths map[string]string) []string {
+	ids := make([]string, 0, len(resources))
+	for id := range resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	violations := []string{}
+	for _, id := range ids {
+		r := resources[id]
+		for _, rule := range nagRules {
+			if !rule.check(r) || nagSuppressedBy(r, rule.id) {
+				continue
+			}
+			path := id
+			if p, has := paths[id]; has {
+				path = p
+			}
+			violations = append(violations, fmt.Sprintf("  %s %s: %s", rule.id, path, rule.info))
+		}
+	}
+
+	return violations
+}
+
+func nagSuppressedBy(r nagResource, rule string) bool {
+	for _, s := range r.Metadata.Nag.RulesToSuppress {
+		if s.ID == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// policy document grants wildcard actions or resources
+func nagWildcard(doc any) bool {
+	policy, _ := doc.(map[string]any)
+	if policy == nil {
+		return false
+	}
+
+	statements, ok := policy["Statement"].([]any)
+	if !ok {
+		statements = []any{policy["Statement"]}
+	}
+
+	for _, s := range statements {
+		statement, _ := s.(map[string]any)
+		if statement == nil || statement["Effect"] != "Allow" {
+			continue
+		}
+		if strings.Contains(nagString(statement["Action"]), "*") ||
+			strings.Contains(nagString(statement["Resource"]), "*") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// JSON representation of the property, including intrinsic functions
+func nagString(v any) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,136 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Pins the name of the server, it names the stack and the log group instead
+// of the factory function (e.g. Named("my-mcp") names the stack my-mcp-dev).
+// The name derived from the factory changes if the function is renamed,
+// the deployment destroys the stack and creates new one. The name must be
+// configured before any other option of the builder except the stage and
+// the prefix.
+func (c *Gateway) Named(name string) *Gateway {
+	if c.configured() {
+		panic("name must be configured before other options")
+	}
+	if name == "" {
+		panic("name of the server is empty")
+	}
+
+	c.named = name
+	if c.embedded {
+		c.stack.Node().TryRemoveChild(jsii.String("Logs"))
+		c.initLogGroup(c.name())
+		return c
+	}
+
+	c.app.Node().TryRemoveChild(c.stack.Node().Id())
+	c.init()
+
+	return c
+}
+
+// metadata of the stack identifying the factory, it is recorded into the
+// cloud assembly (manifest.json) and does not change the template.
+const nameMetadata = "cloudmcp:factory"
+
+type nameIdentity struct {
+	Package string `json:"package"`
+	Stage   string `json:"stage,omitempty"`
+}
+
+func (c *Gateway) recordName() {
+	c.stack.Node().AddMetadata(jsii.String(nameMetadata),
+		nameIdentity{Package: factorypkg(c.f), Stage: c.stage},
+		nil,
+	)
+}
+
+// warns if the stack of the factory was synthesized under other name
+// before, as it is recorded by the previous cloud assembly at the output
+// directory. The deployment destroys the previous stack.
+func (c *Gateway) checkName() {
+	file := filepath.Join(*c.app.Outdir(), "manifest.json")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+
+	var manifest struct {
+		Artifacts map[string]struct {
+			Type       string `json:"type"`
+			Properties struct {
+				StackName string `json:"stackName"`
+			} `json:"properties"`
+			Metadata map[string][]struct {
+				Type string          `json:"type"`
+				Data json.RawMessage `json:"data"`
+			} `json:"metadata"`
+		} `json:"artifacts"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return
+	}
+
+	id := nameIdentity{Package: factorypkg(c.f), Stage: c.stage}
+	name := *c.stack.StackName()
+	renamed := []string{}
+
+	for artifact, spec := range manifest.Artifacts {
+		if spec.Type != "aws:cloudformation:stack" {
+			continue
+		}
+		stack := spec.Properties.StackName
+		if stack == "" {
+			stack = artifact
+		}
+		if stack == name {
+			return
+		}
+
+		for _, entries := range spec.Metadata {
+			for _, entry := range entries {
+				var seen nameIdentity
+				if entry.Type == nameMetadata && json.Unmarshal(entry.Data, &seen) == nil && seen == id {
+					renamed = append(renamed, stack)
+				}
+			}
+		}
+	}
+
+	for _, stack := range renamed {
+		fmt.Fprintf(os.Stderr, "%s: WARNING the factory was synthesized as stack %s, the deployment creates new stack instead of updating it\n",
+			name, stack)
+		if c.named == "" {
+			fmt.Fprintf(os.Stderr, "%s: the name is derived from the factory function, pin it with Named(...)\n", name)
+		}
+	}
+}
+
+// package of the factory function
+func factorypkg(f any) string {
+	fobj := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fobj == nil {
+		panic(fmt.Errorf("failed to discover function metadata"))
+	}
+
+	name := fobj.Name()
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
@@ -0,0 +1,142 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+	"github.com/fogfish/cloudmcp/internal/gateway"
+)
+
+// PartitionStrategy maps tools of the server to lambda functions
+type PartitionStrategy struct {
+	perTool bool
+	sets    []*ToolSet
+}
+
+// All tools are served by the single function (default)
+var PartitionMonolith = PartitionStrategy{}
+
+// Each tool is served by own function
+var PartitionPerTool = PartitionStrategy{perTool: true}
+
+// Tools of each set are served by own function, tools outside of sets are
+// served by the function of the gateway.
+func PartitionPerToolSet(sets ...*ToolSet) PartitionStrategy {
+	if len(sets) == 0 {
+		panic("partition per tool set requires sets")
+	}
+	return PartitionStrategy{sets: sets}
+}
+
+// Configures how tools map to lambda functions, trading the count of cold
+// starts against blast radius. The function of the gateway routes tools/call
+// to the function of the partition, once the call passes policies and limits
+// of the gateway. Other requests (e.g. initialize, tools/list) are served by
+// the function of the gateway. Partitions run the same factory, they share
+// the configuration and permissions of the gateway and they are not exposed
+// to clients. Tools are discovered by constructing the server at build time.
+// The deadline of calls and versions do not apply to partitions.
+//
+//	cloudmcp.New(server.Knowledge).
+//		Partition(cloudmcp.PartitionPerToolSet(wiki.Tools, jira.Tools))
+func (c *Gateway) Partition(strategy PartitionStrategy) *Gateway {
+	c.partition = &strategy
+	return c
+}
+
+// group of tools served by the function
+type partitionGroup struct {
+	name  string
+	tools []string
+}
+
+func (c *Gateway) partitionGroups() []partitionGroup {
+	if c.partition == nil || (!c.partition.perTool && len(c.partition.sets) == 0) {
+		return nil
+	}
+
+	server, err := c.newServer()
+	if err != nil {
+		panic(fmt.Errorf("failed to create server %s: %w", servername(c.f), err))
+	}
+
+	manifest, err := gateway.Snapshot(context.Background(), server)
+	if err != nil {
+		panic(fmt.Errorf("failed to snapshot tools of %s: %w", servername(c.f), err))
+	}
+
+	tools := []string{}
+	for _, tool := range manifest.Tools.Tools {
+		tools = append(tools, tool.Name)
+	}
+
+	groups := []partitionGroup{}
+	if c.partition.perTool {
+		for _, tool := range tools {
+			groups = append(groups, partitionGroup{name: tool, tools: []string{tool}})
+		}
+		return groups
+	}
+
+	for _, set := range c.partition.sets {
+		for _, tool := range set.Tools() {
+			if !slices.Contains(tools, tool) {
+				panic(fmt.Errorf("tool %s of set %s is not served by %s", tool, set.Name(), servername(c.f)))
+			}
+		}
+		groups = append(groups, partitionGroup{name: set.Name(), tools: set.Tools()})
+	}
+
+	return groups
+}
+
+// defines functions of partitions and routes of tools to them, the routes
+// are injected into the function of the gateway.
+func (c *Gateway) buildPartitions() []awslambda.Function {
+	groups := c.partitionGroups()
+	if len(groups) == 0 {
+		return nil
+	}
+
+	functions := []awslambda.Function{}
+	routes := map[string]*string{}
+
+	for _, group := range groups {
+		h := fnv.New32a()
+		h.Write([]byte(group.name))
+		id := fmt.Sprintf("%08x", h.Sum32())
+
+		env := maps.Clone(c.env)
+		env[envvar.Partition] = jsii.String(group.name)
+
+		props, _ := c.serverProps(c.f, &env)
+		props.FunctionProps.FunctionName = jsii.Sprintf("%s-p%s", *awscdk.Aws_STACK_NAME(), id)
+
+		server := NewServer(c.stack, jsii.String("Partition"+id), props)
+		c.configureServer(server)
+
+		for _, tool := range group.tools {
+			routes[tool] = server.Function.FunctionName()
+		}
+		functions = append(functions, server.Function)
+	}
+
+	c.env[envvar.Partitions] = c.stack.ToJsonString(routes, nil)
+
+	return functions
+}
@@ -0,0 +1,211 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package pipeline synthesizes CI/CD pipeline (CodePipeline and CodeBuild)
+// of MCP server from the same codebase. The pipeline builds and tests the
+// code on every push, deploys the gateway through stages (e.g. dev → prod)
+// and updates itself. The stage may require manual approval.
+//
+//	func main() {
+//		pipeline.New(
+//			pipeline.Props{
+//				Name:       "helloworld",
+//				Repository: "fogfish/cloudmcp",
+//				Connection: "arn:aws:codeconnections:...",
+//				Stages: []pipeline.Stage{
+//					{Name: "dev", Account: "111111111111"},
+//					{Name: "prod", Account: "222222222222", Approval: true},
+//				},
+//			},
+//			func(stack awscdk.Stack) {
+//				cloudmcp.NewInStack(stack, server.HelloWorld).
+//					Hostless().
+//					AccessPublic().
+//					Build()
+//			},
+//		)
+//	}
+//
+// The gateway is embedded into the stack of each stage (see
+// cloudmcp.NewInStack), the stage qualifies names of the stack and its
+// resources as Stage of the builder does.
+package pipeline
+
+import (
+	"os"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscodebuild"
+	"github.com/aws/aws-cdk-go/awscdk/v2/pipelines"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Props of the pipeline
+type Props struct {
+	// Name of the pipeline and stacks of stages (e.g. helloworld-dev)
+	Name string
+
+	// Repository ("owner/repo") and branch of the source code, the branch
+	// defaults to main.
+	Repository string
+	Branch     string
+
+	// ARN of CodeStar connection to GitHub, GitLab or Bitbucket
+	Connection string
+
+	// Account and region of the pipeline, defaults to CDK_DEFAULT_ACCOUNT
+	// and CDK_DEFAULT_REGION.
+	Account string
+	Region  string
+
+	// Commands building, testing and synthesizing the application, defaults
+	// to `go test ./...` and `npx cdk synth`.
+	Commands []string
+
+	// Stages of the deployment, in order.
+	Stages []Stage
+}
+
+// Stage of the deployment
+type Stage struct {
+	// Name of the stage (e.g. dev, prod)
+	Name string
+
+	// Account and region of the stage, default to the pipeline's ones.
+	// Accounts other than the pipeline's one are bootstrapped with --trust.
+	Account string
+	Region  string
+
+	// Manual approval required before deploying the stage
+	Approval bool
+}
+
+// New synthesizes the application with the pipeline, the deploy function
+// defines the gateway within the stack of each stage.
+func New(props Props, deploy func(stack awscdk.Stack)) {
+	if props.Name == "" || props.Repository == "" || props.Connection == "" {
+		panic("pipeline requires name, repository and connection")
+	}
+	if len(props.Stages) == 0 {
+		panic("pipeline requires stages")
+	}
+
+	app := awscdk.NewApp(nil)
+	Define(app, props, deploy)
+	app.Synth(nil)
+}
+
+// Define the pipeline within the application, it is used to compose the
+// pipeline with other stacks of the application.
+func Define(app awscdk.App, props Props, deploy func(stack awscdk.Stack)) pipelines.CodePipeline {
+	if props.Branch == "" {
+		props.Branch = "main"
+	}
+	if props.Account == "" {
+		props.Account = os.Getenv("CDK_DEFAULT_ACCOUNT")
+	}
+	if props.Region == "" {
+		props.Region = os.Getenv("CDK_DEFAULT_REGION")
+	}
+	if len(props.Commands) == 0 {
+		props.Commands = []string{"go test ./...", "npx cdk synth"}
+	}
+
+	stack := awscdk.NewStack(app, jsii.String(props.Name+"-pipeline"),
+		&awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Account: jsii.String(props.Account),
+				Region:  jsii.String(props.Region),
+			},
+		},
+	)
+
+	pipeline := pipelines.NewCodePipeline(stack, jsii.String("Pipeline"),
+		&pipelines.CodePipelineProps{
+			PipelineName:     jsii.String(props.Name),
+			CrossAccountKeys: jsii.Bool(crossAccount(props)),
+			Synth: pipelines.NewShellStep(jsii.String("Synth"),
+				&pipelines.ShellStepProps{
+					Input: pipelines.CodePipelineSource_Connection(
+						jsii.String(props.Repository),
+						jsii.String(props.Branch),
+						&pipelines.ConnectionSourceOptions{
+							ConnectionArn: jsii.String(props.Connection),
+						},
+					),
+					// the toolchain required by go.mod is downloaded by go itself
+					Env: &map[string]*string{
+						"GOTOOLCHAIN": jsii.String("auto"),
+					},
+					// the source code of functions is located at the checkout
+					InstallCommands: jsii.Strings(`export GITHUB_WORKSPACE="$CODEBUILD_SRC_DIR"`),
+					Commands:        jsii.Strings(props.Commands...),
+				},
+			),
+			CodeBuildDefaults: &pipelines.CodeBuildOptions{
+				BuildEnvironment: &awscodebuild.BuildEnvironment{
+					BuildImage: awscodebuild.LinuxBuildImage_STANDARD_7_0(),
+				},
+			},
+		},
+	)
+
+	for _, spec := range props.Stages {
+		account, region := spec.Account, spec.Region
+		if account == "" {
+			account = props.Account
+		}
+		if region == "" {
+			region = props.Region
+		}
+
+		stage := awscdk.NewStage(stack, jsii.String(spec.Name),
+			&awscdk.StageProps{
+				Env: &awscdk.Environment{
+					Account: jsii.String(account),
+					Region:  jsii.String(region),
+				},
+			},
+		)
+		// the gateway reads the stage from context
+		stage.Node().SetContext(jsii.String("stage"), jsii.String(spec.Name))
+
+		deploy(
+			awscdk.NewStack(stage, jsii.String(props.Name),
+				&awscdk.StackProps{
+					StackName: jsii.String(props.Name + "-" + spec.Name),
+				},
+			),
+		)
+
+		opts := &pipelines.AddStageOpts{}
+		if spec.Approval {
+			opts.Pre = &[]pipelines.Step{
+				pipelines.NewManualApprovalStep(jsii.String("Approve"),
+					&pipelines.ManualApprovalStepProps{
+						Comment: jsii.String("Deploy " + props.Name + " to " + spec.Name),
+					},
+				),
+			}
+		}
+
+		pipeline.AddStage(stage, opts)
+	}
+
+	return pipeline
+}
+
+// stages are deployed to other accounts
+func crossAccount(props Props) bool {
+	for _, stage := range props.Stages {
+		if stage.Account != "" && stage.Account != props.Account {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package audit implements tamper-evident trail of tool calls, kept in
+// append-only DynamoDB table (see cloudmcp.Gateway.Audit). Records of
+// the chain are linked by hashes: each record carries the hash of its
+// predecessor and its own hash over the content. Modification, removal or
+// insertion of records breaks the chain, which is detected by Verify.
+// Truncation of the chain is detected by comparing its length with
+// the previous verification. Chains are sharded per tenant and per day,
+// so that writers do not contend on a single head of the chain.
+//
+//	trail := audit.NewTrail(dynamodb.NewFromConfig(cfg), table)
+//
+//	n, err := trail.Verify(ctx, audit.Chain("myserver", "", day))
+//	if errors.Is(err, audit.ErrTampered) {
+//		...
+//	}
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrTampered is returned when the chain of records is broken
+var ErrTampered = errors.New("audit trail is tampered")
+
+// ErrCongested is returned when the record is not appended before deadline
+var ErrCongested = errors.New("audit trail is congested")
+
+// Append is bounded by the timeout if the context has no deadline
+const appendTimeout = 5 * time.Second
+
+// Backoff of conflicting writers
+const (
+	backoffBase = 10 * time.Millisecond
+	backoffMax  = 500 * time.Millisecond
+)
+
+// DynamoDB interface required by the trail
+type DynamoDB interface {
+	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// Record of the tool call. Arguments are not disclosed, the record carries
+// the digest of them, which is sufficient to prove the call given its input.
+// The table uses "chain" as partition key and "seq" as sort key.
+type Record struct {
+	Chain     string `json:"chain"               dynamodbav:"chain"`
+	Seq       int64  `json:"seq"                 dynamodbav:"seq"`
+	Timestamp int64  `json:"timestamp"           dynamodbav:"timestamp"`
+	Tool      string `json:"tool"                dynamodbav:"tool"`
+	Principal string `json:"principal,omitempty" dynamodbav:"principal,omitempty"`
+	Tenant    string `json:"tenant,omitempty"    dynamodbav:"tenant,omitempty"`
+	Session   string `json:"session,omitempty"   dynamodbav:"session,omitempty"`
+	Arguments string `json:"arguments"           dynamodbav:"arguments"`
+	Outcome   string `json:"outcome"             dynamodbav:"outcome"`
+	Prev      string `json:"prev,omitempty"      dynamodbav:"prev,omitempty"`
+	Hash      string `json:"hash"                dynamodbav:"hash"`
+}
+
+// Digest of the record content, including the hash of predecessor
+func (r Record) Digest() string {
+	r.Hash = ""
+
+	// JSON encoding of struct is deterministic, fields follow declaration
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Digest of tool arguments
+func Arguments(args []byte) string {
+	sum := sha256.Sum256(args)
+	return hex.EncodeToString(sum[:])
+}
+
+// Chain is the name of the shard of the server's trail, records are sharded
+// per tenant (if defined) and per day (UTC), e.g. myserver/acme/2025-01-31.
+func Chain(server, tenant string, t time.Time) string {
+	day := t.UTC().Format(time.DateOnly)
+	if tenant == "" {
+		return server + "/" + day
+	}
+	return server + "/" + tenant + "/" + day
+}
+
+// Trail of records in DynamoDB table
+type Trail struct {
+	api   DynamoDB
+	table string
+}
+
+// NewTrail creates client of the audit trail
+func NewTrail(api DynamoDB, table string) *Trail {
+	return &Trail{api: api, table: table}
+}
+
+// Append the record to the end of its chain. Sequence number and hashes
+// are assigned by the trail, concurrent writers are serialized by
+// the conditional write, the record is retried after the conflict with
+// jittered exponential backoff until the deadline of the context.
+func (t *Trail) Append(ctx context.Context, r Record) (Record, error) {
+	if _, has := ctx.Deadline(); !has {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, appendTimeout)
+		defer cancel()
+	}
+
+	for attempt := 0; ; attempt++ {
+		head, err := t.head(ctx, r.Chain)
+		if err != nil {
+			return r, err
+		}
+
+		r.Seq, r.Prev = head.Seq+1, head.Hash
+		r.Hash = r.Digest()
+
+		item, err := attributevalue.MarshalMap(r)
+		if err != nil {
+			return r, fmt.Errorf("failed to encode record: %w", err)
+		}
+
+		_, err = t.api.PutItem(ctx,
+			&dynamodb.PutItemInput{
+				TableName:                aws.String(t.table),
+				Item:                     item,
+				ConditionExpression:      aws.String("attribute_not_exists(#seq)"),
+				ExpressionAttributeNames: map[string]string{"#seq": "seq"},
+			},
+		)
+
+		var conflict *types.ConditionalCheckFailedException
+		if !errors.As(err, &conflict) {
+			return r, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return r, fmt.Errorf("%w: %w", ErrCongested, ctx.Err())
+		case <-time.After(backoff(attempt)):
+		}
+	}
+}
+
+// full jitter of exponential backoff
+func backoff(attempt int) time.Duration {
+	d := backoffMax
+	if attempt < 6 {
+		d = min(backoffBase<<attempt, backoffMax)
+	}
+	return rand.N(d) + time.Millisecond
+}
+
+// the last record of the chain, zero record for empty chain
+func (t *Trail) head(ctx context.Context, chain string) (Record, error) {
+	out, err := t.api.Query(ctx,
+		&dynamodb.QueryInput{
+			TableName:                 aws.String(t.table),
+			KeyConditionExpression:    aws.String("#chain = :chain"),
+			ExpressionAttributeNames:  map[string]string{"#chain": "chain"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":chain": &types.AttributeValueMemberS{Value: chain}},
+			ScanIndexForward:          aws.Bool(false),
+			ConsistentRead:            aws.Bool(true),
+			Limit:                     aws.Int32(1),
+		},
+	)
+	if err != nil {
+		return Record{}, err
+	}
+
+	var head Record
+	if len(out.Items) > 0 {
+		if err := attributevalue.UnmarshalMap(out.Items[0], &head); err != nil {
+			return Record{}, fmt.Errorf("failed to decode record: %w", err)
+		}
+	}
+
+	return head, nil
+}
+
+// Verify walks the chain from its beginning, recomputing hashes of records.
+// It returns the number of verified records, ErrTampered is returned if
+// any record is modified, removed or inserted.
+func (t *Trail) Verify(ctx context.Context, chain string) (int, error) {
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(t.table),
+		KeyConditionExpression:    aws.String("#chain = :chain"),
+		ExpressionAttributeNames:  map[string]string{"#chain": "chain"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":chain": &types.AttributeValueMemberS{Value: chain}},
+		ConsistentRead:            aws.Bool(true),
+	}
+
+	n := 0
+	prev := Record{}
+	pages := dynamodb.NewQueryPaginator(t.api, input)
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return n, err
+		}
+
+		for _, item := range page.Items {
+			var r Record
+			if err := attributevalue.UnmarshalMap(item, &r); err != nil {
+				return n, fmt.Errorf("failed to decode record: %w", err)
+			}
+
+			switch {
+			case r.Seq != prev.Seq+1:
+				return n, fmt.Errorf("%w: record %d is missing", ErrTampered, prev.Seq+1)
+			case r.Prev != prev.Hash:
+				return n, fmt.Errorf("%w: record %d is not linked to predecessor", ErrTampered, r.Seq)
+			case r.Hash != r.Digest():
+				return n, fmt.Errorf("%w: record %d is modified", ErrTampered, r.Seq)
+			}
+
+			prev = r
+			n++
+		}
+	}
+
+	return n, nil
+}
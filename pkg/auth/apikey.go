@@ -14,6 +14,7 @@ import (
 	"net/http"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Configure API Key authentication for MCP client
@@ -26,6 +27,16 @@ type ConfigApiKey struct {
 
 	// Custom HTTP client (if nil, default client will be used)
 	Client *http.Client
+
+	// Connection pool of default HTTP transport, it is ignored if custom
+	// HTTP client with transport is used.
+	ConfigHTTP
+
+	// OpenTelemetry tracer provider (if nil, tracing is disabled)
+	TracerProvider trace.TracerProvider
+
+	// Interceptors of requests and responses, called in order
+	Interceptors []Interceptor
 }
 
 // NewApiKey creates MCP transport with API Key authentication.
@@ -38,7 +49,7 @@ func NewTransportApiKey(spec ConfigApiKey) (*mcp.StreamableClientTransport, erro
 
 	sock := &apikeyTransport{
 		digest: digest,
-		socket: http.DefaultTransport,
+		socket: spec.ConfigHTTP.socket(),
 	}
 
 	if spec.Client != nil && spec.Client.Transport != nil {
@@ -48,7 +59,7 @@ func NewTransportApiKey(spec ConfigApiKey) (*mcp.StreamableClientTransport, erro
 	if spec.Client == nil {
 		spec.Client = &http.Client{}
 	}
-	spec.Client.Transport = sock
+	spec.Client.Transport = newTracingTransport(spec.TracerProvider, newInterceptTransport(spec.Interceptors, sock))
 
 	return &mcp.StreamableClientTransport{
 		Endpoint:   spec.Url,
@@ -0,0 +1,296 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cognito "github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Configure AWS Cognito user authentication for MCP client
+type ConfigCognito struct {
+	// Endpoint URL of MCP server
+	Url string
+
+	// Cognito User Pool ID (e.g. eu-west-1_XXXXXXXXX)
+	UserPool string
+
+	// Cognito App Client ID and optional secret
+	ClientID, ClientSecret string
+
+	// User credentials
+	Username, Password string
+
+	// Use USER_PASSWORD_AUTH flow instead of USER_SRP_AUTH. The flow
+	// sends password to Cognito, it must be enabled for App Client.
+	PasswordAuth bool
+
+	// Attach ID token instead of access token
+	IDToken bool
+
+	// Custom HTTP client (if nil, default client will be used)
+	Client *http.Client
+
+	// Connection pool of default HTTP transport, it is ignored if custom
+	// HTTP client with transport is used.
+	ConfigHTTP
+
+	// OpenTelemetry tracer provider (if nil, tracing is disabled)
+	TracerProvider trace.TracerProvider
+
+	// Interceptors of requests and responses, called in order
+	Interceptors []Interceptor
+}
+
+// NewTransportCognito creates MCP transport with AWS Cognito authentication.
+// It signs in the user, caches tokens and refreshes them before expiration.
+func NewTransportCognito(spec ConfigCognito) (*mcp.StreamableClientTransport, error) {
+	if len(spec.Url) == 0 {
+		return nil, errors.New("missing URL config")
+	}
+
+	region, _, ok := strings.Cut(spec.UserPool, "_")
+	if !ok {
+		return nil, fmt.Errorf("invalid user pool id %s", spec.UserPool)
+	}
+
+	if len(spec.ClientID) == 0 {
+		return nil, errors.New("missing Client ID config")
+	}
+
+	sock := &cognitoTransport{
+		spec:   spec,
+		socket: spec.ConfigHTTP.socket(),
+	}
+
+	if spec.Client != nil && spec.Client.Transport != nil {
+		sock.socket = spec.Client.Transport
+	}
+
+	// calls to Cognito share proxy and TLS config of the transport
+	sock.api = cognito.New(cognito.Options{Region: region, HTTPClient: &http.Client{Transport: sock.socket}})
+
+	if spec.Client == nil {
+		spec.Client = &http.Client{}
+	}
+	spec.Client.Transport = newTracingTransport(spec.TracerProvider, newInterceptTransport(spec.Interceptors, sock))
+
+	return &mcp.StreamableClientTransport{
+		Endpoint:   spec.Url,
+		HTTPClient: spec.Client,
+	}, nil
+}
+
+// Cognito Identity Provider API used by the transport
+type cognitoIdentityProvider interface {
+	InitiateAuth(context.Context, *cognito.InitiateAuthInput, ...func(*cognito.Options)) (*cognito.InitiateAuthOutput, error)
+	RespondToAuthChallenge(context.Context, *cognito.RespondToAuthChallengeInput, ...func(*cognito.Options)) (*cognito.RespondToAuthChallengeOutput, error)
+}
+
+type cognitoTransport struct {
+	sync.Mutex
+	spec   ConfigCognito
+	api    cognitoIdentityProvider
+	socket http.RoundTripper
+
+	user    string
+	token   string
+	refresh string
+	expires time.Time
+}
+
+// tokens are refreshed ahead of expiration
+const cognitoRefreshAhead = 60 * time.Second
+
+func (api *cognitoTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := api.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return api.socket.RoundTrip(req)
+}
+
+// Token returns valid token, signing in or refreshing it if needed
+func (api *cognitoTransport) Token(ctx context.Context) (string, error) {
+	api.Lock()
+	defer api.Unlock()
+
+	if api.token != "" && time.Until(api.expires) > cognitoRefreshAhead {
+		return api.token, nil
+	}
+
+	if api.refresh != "" {
+		if err := api.refreshToken(ctx); err == nil {
+			return api.token, nil
+		}
+	}
+
+	if err := api.signIn(ctx); err != nil {
+		return "", err
+	}
+
+	return api.token, nil
+}
+
+func (api *cognitoTransport) signIn(ctx context.Context) error {
+	api.user = api.spec.Username
+
+	if api.spec.PasswordAuth {
+		params := map[string]string{
+			"USERNAME": api.spec.Username,
+			"PASSWORD": api.spec.Password,
+		}
+		api.secretHash(params, api.spec.Username)
+
+		out, err := api.api.InitiateAuth(ctx,
+			&cognito.InitiateAuthInput{
+				AuthFlow:       types.AuthFlowTypeUserPasswordAuth,
+				ClientId:       aws.String(api.spec.ClientID),
+				AuthParameters: params,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		return api.accept(out.AuthenticationResult, out.ChallengeName)
+	}
+
+	srp, err := newSRP(api.spec.UserPool)
+	if err != nil {
+		return err
+	}
+
+	params := map[string]string{
+		"USERNAME": api.spec.Username,
+		"SRP_A":    srp.srpA(),
+	}
+	api.secretHash(params, api.spec.Username)
+
+	out, err := api.api.InitiateAuth(ctx,
+		&cognito.InitiateAuthInput{
+			AuthFlow:       types.AuthFlowTypeUserSrpAuth,
+			ClientId:       aws.String(api.spec.ClientID),
+			AuthParameters: params,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if out.ChallengeName != types.ChallengeNameTypePasswordVerifier {
+		return fmt.Errorf("unsupported challenge %s", out.ChallengeName)
+	}
+
+	userID := out.ChallengeParameters["USER_ID_FOR_SRP"]
+	api.user = userID
+	secretBlock := out.ChallengeParameters["SECRET_BLOCK"]
+	timestamp, signature, err := srp.verify(
+		userID,
+		api.spec.Password,
+		out.ChallengeParameters["SALT"],
+		out.ChallengeParameters["SRP_B"],
+		secretBlock,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	challenge := map[string]string{
+		"USERNAME":                    userID,
+		"PASSWORD_CLAIM_SECRET_BLOCK": secretBlock,
+		"PASSWORD_CLAIM_SIGNATURE":    signature,
+		"TIMESTAMP":                   timestamp,
+	}
+	api.secretHash(challenge, userID)
+
+	reply, err := api.api.RespondToAuthChallenge(ctx,
+		&cognito.RespondToAuthChallengeInput{
+			ChallengeName:      types.ChallengeNameTypePasswordVerifier,
+			ClientId:           aws.String(api.spec.ClientID),
+			ChallengeResponses: challenge,
+			Session:            out.Session,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return api.accept(reply.AuthenticationResult, reply.ChallengeName)
+}
+
+func (api *cognitoTransport) refreshToken(ctx context.Context) error {
+	params := map[string]string{
+		"REFRESH_TOKEN": api.refresh,
+	}
+	api.secretHash(params, api.user)
+
+	out, err := api.api.InitiateAuth(ctx,
+		&cognito.InitiateAuthInput{
+			AuthFlow:       types.AuthFlowTypeRefreshTokenAuth,
+			ClientId:       aws.String(api.spec.ClientID),
+			AuthParameters: params,
+		},
+	)
+	if err != nil {
+		api.refresh = ""
+		return err
+	}
+
+	return api.accept(out.AuthenticationResult, out.ChallengeName)
+}
+
+func (api *cognitoTransport) accept(result *types.AuthenticationResultType, challenge types.ChallengeNameType) error {
+	if result == nil {
+		return fmt.Errorf("unsupported challenge %s", challenge)
+	}
+
+	api.token = aws.ToString(result.AccessToken)
+	if api.spec.IDToken {
+		api.token = aws.ToString(result.IdToken)
+	}
+
+	// refresh token is not returned by REFRESH_TOKEN_AUTH flow
+	if result.RefreshToken != nil {
+		api.refresh = aws.ToString(result.RefreshToken)
+	}
+
+	api.expires = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+
+	return nil
+}
+
+// SECRET_HASH is required if App Client has a secret
+func (api *cognitoTransport) secretHash(params map[string]string, username string) {
+	if api.spec.ClientSecret == "" {
+		return
+	}
+
+	mac := hmac.New(sha256.New, []byte(api.spec.ClientSecret))
+	mac.Write([]byte(username + api.spec.ClientID))
+	params["SECRET_HASH"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,164 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Secure Remote Password (SRP-6a) protocol as implemented by AWS Cognito.
+// See https://github.com/aws-amplify/amplify-js (AuthenticationHelper)
+const srpHexN = "FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1" +
+	"29024E088A67CC74020BBEA63B139B22514A08798E3404DD" +
+	"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245" +
+	"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+	"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3D" +
+	"C2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F" +
+	"83655D23DCA3AD961C62F356208552BB9ED529077096966D" +
+	"670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+	"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9" +
+	"DE2BCBF6955817183995497CEA956AE515D2261898FA0510" +
+	"15728E5A8AAAC42DAD33170D04507A33A85521ABDF1CBA64" +
+	"ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+	"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6B" +
+	"F12FFA06D98A0864D87602733EC86A64521F2B18177B200C" +
+	"BBE117577A615D6C770988C0BAD946E208E24FA074E5AB31" +
+	"43DB5BFCE0FD108E4B82D120A93AD2CAFFFFFFFFFFFFFFFF"
+
+const srpInfo = "Caldera Derived Key"
+
+type srp struct {
+	n, g, k *big.Int
+	a, A    *big.Int
+	pool    string
+}
+
+// newSRP creates SRP client for user pool ("region_poolName")
+func newSRP(poolID string) (*srp, error) {
+	_, pool, ok := strings.Cut(poolID, "_")
+	if !ok {
+		return nil, errors.New("invalid user pool id " + poolID)
+	}
+
+	n, _ := new(big.Int).SetString(srpHexN, 16)
+	g := big.NewInt(2)
+	k := hexToInt(hexHash(padHex(n) + padHex(g)))
+
+	a, err := rand.Int(rand.Reader, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return &srp{
+		n: n, g: g, k: k,
+		a: a, A: new(big.Int).Exp(g, a, n),
+		pool: pool,
+	}, nil
+}
+
+// SRP_A parameter of USER_SRP_AUTH flow
+func (s *srp) srpA() string { return s.A.Text(16) }
+
+// Responds to PASSWORD_VERIFIER challenge, returning timestamp and signature
+func (s *srp) verify(userID, password, salt, srpB, secretBlock string, now time.Time) (string, string, error) {
+	B := hexToInt(srpB)
+	if new(big.Int).Mod(B, s.n).Sign() == 0 {
+		return "", "", errors.New("invalid SRP_B")
+	}
+
+	u := hexToInt(hexHash(padHex(s.A) + padHex(B)))
+	if u.Sign() == 0 {
+		return "", "", errors.New("invalid SRP scrambling parameter")
+	}
+
+	x := hexToInt(hexHash(padHex(hexToInt(salt)) + hash(s.pool+userID+":"+password)))
+
+	// S = (B - k * g^x) ^ (a + u * x) mod N
+	gx := new(big.Int).Exp(s.g, x, s.n)
+	base := new(big.Int).Sub(B, new(big.Int).Mul(s.k, gx))
+	base.Mod(base, s.n)
+	exp := new(big.Int).Add(s.a, new(big.Int).Mul(u, x))
+	S := new(big.Int).Exp(base, exp, s.n)
+
+	key := hkdf(hexToBytes(padHex(S)), hexToBytes(padHex(u)))
+
+	block, err := base64.StdEncoding.DecodeString(secretBlock)
+	if err != nil {
+		return "", "", err
+	}
+
+	timestamp := now.UTC().Format("Mon Jan 2 15:04:05 UTC 2006")
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s.pool))
+	mac.Write([]byte(userID))
+	mac.Write(block)
+	mac.Write([]byte(timestamp))
+
+	return timestamp, base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func hkdf(ikm, salt []byte) []byte {
+	prk := hmac.New(sha256.New, salt)
+	prk.Write(ikm)
+
+	mac := hmac.New(sha256.New, prk.Sum(nil))
+	mac.Write([]byte(srpInfo))
+	mac.Write([]byte{1})
+
+	return mac.Sum(nil)[:16]
+}
+
+func hash(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+func hexHash(s string) string {
+	h := sha256.Sum256(hexToBytes(s))
+	return hex.EncodeToString(h[:])
+}
+
+func hexToInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 16)
+	if n == nil {
+		return new(big.Int)
+	}
+	return n
+}
+
+func hexToBytes(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+func padHex(n *big.Int) string {
+	return padHexString(n.Text(16))
+}
+
+// Big integers are encoded as signed values, leading "00" is required if
+// the most significant bit is set.
+func padHexString(s string) string {
+	switch {
+	case len(s)%2 == 1:
+		return "0" + s
+	case strings.ContainsRune("89abcdefABCDEF", rune(s[0])):
+		return "00" + s
+	default:
+		return s
+	}
+}
@@ -0,0 +1,294 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Configure OAuth 2.0 Device Authorization Grant (RFC 8628) for MCP client.
+// The flow is designed for terminal clients, the user is asked to visit
+// verification URL and enter the code while the client polls for token.
+type ConfigDevice struct {
+	// Endpoint URL of MCP server
+	Url string
+
+	// Device Authorization and Token endpoints of identity provider
+	DeviceEndpoint, TokenEndpoint string
+
+	// OAuth2 public client
+	ClientID string
+
+	// Requested scopes and audience (optional)
+	Scopes   []string
+	Audience string
+
+	// Prompt shows the verification URL and user code to the user,
+	// default prints the instructions to stderr.
+	Prompt func(verificationURL, verificationURLComplete, userCode string)
+
+	// Custom HTTP client (if nil, default client will be used)
+	Client *http.Client
+
+	// Connection pool of default HTTP transport, it is ignored if custom
+	// HTTP client with transport is used.
+	ConfigHTTP
+
+	// OpenTelemetry tracer provider (if nil, tracing is disabled)
+	TracerProvider trace.TracerProvider
+
+	// Interceptors of requests and responses, called in order
+	Interceptors []Interceptor
+}
+
+// NewTransportDevice creates MCP transport authenticated with OAuth 2.0
+// device authorization grant. The user is prompted on the first request,
+// tokens are cached and refreshed before expiration.
+func NewTransportDevice(spec ConfigDevice) (*mcp.StreamableClientTransport, error) {
+	if len(spec.Url) == 0 {
+		return nil, errors.New("missing URL config")
+	}
+
+	if len(spec.DeviceEndpoint) == 0 || len(spec.TokenEndpoint) == 0 {
+		return nil, errors.New("missing device or token endpoint config")
+	}
+
+	if len(spec.ClientID) == 0 {
+		return nil, errors.New("missing Client ID config")
+	}
+
+	if spec.Prompt == nil {
+		spec.Prompt = promptDevice
+	}
+
+	sock := &deviceTransport{
+		spec:   spec,
+		socket: spec.ConfigHTTP.socket(),
+	}
+
+	if spec.Client != nil && spec.Client.Transport != nil {
+		sock.socket = spec.Client.Transport
+	}
+
+	if spec.Client == nil {
+		spec.Client = &http.Client{}
+	}
+	spec.Client.Transport = newTracingTransport(spec.TracerProvider, newInterceptTransport(spec.Interceptors, sock))
+
+	return &mcp.StreamableClientTransport{
+		Endpoint:   spec.Url,
+		HTTPClient: spec.Client,
+	}, nil
+}
+
+func promptDevice(verificationURL, verificationURLComplete, userCode string) {
+	if verificationURLComplete != "" {
+		fmt.Fprintf(os.Stderr, "To sign in, open %s\n", verificationURLComplete)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "To sign in, open %s and enter the code %s\n", verificationURL, userCode)
+}
+
+type deviceTransport struct {
+	sync.Mutex
+	spec   ConfigDevice
+	socket http.RoundTripper
+
+	token   string
+	refresh string
+	expires time.Time
+}
+
+// tokens are refreshed ahead of expiration
+const deviceRefreshAhead = 60 * time.Second
+
+func (api *deviceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := api.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return api.socket.RoundTrip(req)
+}
+
+// Token returns valid token, running device flow or refreshing it if needed
+func (api *deviceTransport) Token(ctx context.Context) (string, error) {
+	api.Lock()
+	defer api.Unlock()
+
+	if api.token != "" && (api.expires.IsZero() || time.Until(api.expires) > deviceRefreshAhead) {
+		return api.token, nil
+	}
+
+	if api.refresh != "" {
+		err := api.exchange(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {api.refresh},
+			"client_id":     {api.spec.ClientID},
+		})
+		if err == nil {
+			return api.token, nil
+		}
+		api.refresh = ""
+	}
+
+	if err := api.authorize(ctx); err != nil {
+		return "", err
+	}
+
+	return api.token, nil
+}
+
+type deviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type deviceToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Error        string `json:"error"`
+	Description  string `json:"error_description"`
+}
+
+var errAuthorizationPending = errors.New("authorization_pending")
+var errSlowDown = errors.New("slow_down")
+
+func (api *deviceTransport) authorize(ctx context.Context) error {
+	form := url.Values{"client_id": {api.spec.ClientID}}
+	if len(api.spec.Scopes) > 0 {
+		form.Set("scope", strings.Join(api.spec.Scopes, " "))
+	}
+	if api.spec.Audience != "" {
+		form.Set("audience", api.spec.Audience)
+	}
+
+	var code deviceCode
+	if err := api.post(ctx, api.spec.DeviceEndpoint, form, &code); err != nil {
+		return err
+	}
+	if code.DeviceCode == "" {
+		return errors.New("device authorization is rejected by identity provider")
+	}
+
+	api.spec.Prompt(code.VerificationURI, code.VerificationURIComplete, code.UserCode)
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for code.ExpiresIn == 0 || time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		err := api.exchange(ctx, url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"device_code": {code.DeviceCode},
+			"client_id":   {api.spec.ClientID},
+		})
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, errAuthorizationPending):
+			continue
+		case errors.Is(err, errSlowDown):
+			interval += 5 * time.Second
+		default:
+			return err
+		}
+	}
+
+	return errors.New("device code is expired")
+}
+
+func (api *deviceTransport) exchange(ctx context.Context, form url.Values) error {
+	var token deviceToken
+	if err := api.post(ctx, api.spec.TokenEndpoint, form, &token); err != nil {
+		return err
+	}
+
+	switch token.Error {
+	case "":
+	case "authorization_pending":
+		return errAuthorizationPending
+	case "slow_down":
+		return errSlowDown
+	default:
+		return fmt.Errorf("%s: %s", token.Error, token.Description)
+	}
+
+	api.token = token.AccessToken
+	if token.RefreshToken != "" {
+		api.refresh = token.RefreshToken
+	}
+
+	api.expires = time.Time{}
+	if token.ExpiresIn > 0 {
+		api.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	return nil
+}
+
+// post form to identity provider, error responses (4xx) are decoded as well
+func (api *deviceTransport) post(ctx context.Context, endpoint string, form url.Values, reply any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	rsp, err := api.socket.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+
+	if rsp.StatusCode >= 500 {
+		return fmt.Errorf("identity provider failed: %s", rsp.Status)
+	}
+
+	if err := json.Unmarshal(body, reply); err != nil {
+		return fmt.Errorf("identity provider failed: %s %w", rsp.Status, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,271 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Token types of OAuth 2.0 Token Exchange (RFC 8693)
+const (
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeIDToken     = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeJWT         = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// Configure OAuth 2.0 Token Exchange (RFC 8693) for MCP client. The agent
+// holding the upstream identity token (e.g. issued to the IDE) exchanges
+// it at the token endpoint for narrowly scoped token of the gateway, the
+// primary credentials are never shared with the gateway.
+type ConfigTokenExchange struct {
+	// Endpoint URL of MCP server
+	Url string
+
+	// Token endpoint of identity provider supporting token exchange
+	TokenEndpoint string
+
+	// OAuth2 client, the secret is optional (public client)
+	ClientID, ClientSecret string
+
+	// SubjectToken returns the upstream identity token, it is called for
+	// each exchange so that the token is refreshed by the caller.
+	SubjectToken func(context.Context) (string, error)
+
+	// Type of the subject token (default TokenTypeAccessToken)
+	SubjectTokenType string
+
+	// ActorToken returns the token of the agent acting on behalf of the
+	// subject (optional), the exchange is delegation instead of impersonation.
+	ActorToken func(context.Context) (string, error)
+
+	// Type of the actor token (default TokenTypeAccessToken)
+	ActorTokenType string
+
+	// Audience (e.g. client of the gateway), resource (e.g. URL of the
+	// gateway) and scopes of the issued token, they narrow the token.
+	Audience string
+	Resource string
+	Scopes   []string
+
+	// Custom HTTP client (if nil, default client will be used)
+	Client *http.Client
+
+	// Connection pool of default HTTP transport, it is ignored if custom
+	// HTTP client with transport is used.
+	ConfigHTTP
+
+	// OpenTelemetry tracer provider (if nil, tracing is disabled)
+	TracerProvider trace.TracerProvider
+
+	// Interceptors of requests and responses, called in order
+	Interceptors []Interceptor
+}
+
+// NewTransportTokenExchange creates MCP transport authenticated with token
+// issued by OAuth 2.0 token exchange. The token is cached and exchanged
+// again before expiration.
+func NewTransportTokenExchange(spec ConfigTokenExchange) (*mcp.StreamableClientTransport, error) {
+	if len(spec.Url) == 0 {
+		return nil, errors.New("missing URL config")
+	}
+
+	if len(spec.TokenEndpoint) == 0 {
+		return nil, errors.New("missing token endpoint config")
+	}
+
+	if len(spec.ClientID) == 0 {
+		return nil, errors.New("missing Client ID config")
+	}
+
+	if spec.SubjectToken == nil {
+		return nil, errors.New("missing subject token config")
+	}
+
+	if spec.SubjectTokenType == "" {
+		spec.SubjectTokenType = TokenTypeAccessToken
+	}
+
+	if spec.ActorTokenType == "" {
+		spec.ActorTokenType = TokenTypeAccessToken
+	}
+
+	sock := &exchangeTransport{
+		spec:   spec,
+		socket: spec.ConfigHTTP.socket(),
+	}
+
+	if spec.Client != nil && spec.Client.Transport != nil {
+		sock.socket = spec.Client.Transport
+	}
+
+	if spec.Client == nil {
+		spec.Client = &http.Client{}
+	}
+	spec.Client.Transport = newTracingTransport(spec.TracerProvider, newInterceptTransport(spec.Interceptors, sock))
+
+	return &mcp.StreamableClientTransport{
+		Endpoint:   spec.Url,
+		HTTPClient: spec.Client,
+	}, nil
+}
+
+type exchangeTransport struct {
+	sync.Mutex
+	spec   ConfigTokenExchange
+	socket http.RoundTripper
+
+	token   string
+	expires time.Time
+}
+
+// tokens are exchanged ahead of expiration
+const exchangeRefreshAhead = 60 * time.Second
+
+func (api *exchangeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := api.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return api.socket.RoundTrip(req)
+}
+
+// Token returns valid token, exchanging the subject token if needed
+func (api *exchangeTransport) Token(ctx context.Context) (string, error) {
+	api.Lock()
+	defer api.Unlock()
+
+	if api.token != "" && (api.expires.IsZero() || time.Until(api.expires) > exchangeRefreshAhead) {
+		return api.token, nil
+	}
+
+	if err := api.exchange(ctx); err != nil {
+		return "", err
+	}
+
+	return api.token, nil
+}
+
+type exchangeToken struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int    `json:"expires_in"`
+	Error           string `json:"error"`
+	Description     string `json:"error_description"`
+}
+
+func (api *exchangeTransport) exchange(ctx context.Context) error {
+	subject, err := api.spec.SubjectToken(ctx)
+	if err != nil {
+		return fmt.Errorf("subject token is not available: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type":           {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"subject_token":        {subject},
+		"subject_token_type":   {api.spec.SubjectTokenType},
+		"requested_token_type": {TokenTypeAccessToken},
+	}
+
+	if api.spec.ActorToken != nil {
+		actor, err := api.spec.ActorToken(ctx)
+		if err != nil {
+			return fmt.Errorf("actor token is not available: %w", err)
+		}
+		form.Set("actor_token", actor)
+		form.Set("actor_token_type", api.spec.ActorTokenType)
+	}
+
+	if api.spec.Audience != "" {
+		form.Set("audience", api.spec.Audience)
+	}
+	if api.spec.Resource != "" {
+		form.Set("resource", api.spec.Resource)
+	}
+	if len(api.spec.Scopes) > 0 {
+		form.Set("scope", strings.Join(api.spec.Scopes, " "))
+	}
+
+	var token exchangeToken
+	if err := api.post(ctx, form, &token); err != nil {
+		return err
+	}
+
+	if token.Error != "" {
+		return fmt.Errorf("%s: %s", token.Error, token.Description)
+	}
+	if token.AccessToken == "" {
+		return errors.New("token exchange is rejected by identity provider")
+	}
+	if !strings.EqualFold(token.TokenType, "Bearer") {
+		return fmt.Errorf("token type %q is not supported", token.TokenType)
+	}
+
+	api.token = token.AccessToken
+	api.expires = time.Time{}
+	if token.ExpiresIn > 0 {
+		api.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	return nil
+}
+
+// post form to identity provider, error responses (4xx) are decoded as well.
+// The confidential client authenticates with HTTP Basic (client_secret_basic).
+func (api *exchangeTransport) post(ctx context.Context, form url.Values, reply any) error {
+	if api.spec.ClientSecret == "" {
+		form.Set("client_id", api.spec.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, api.spec.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if api.spec.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(api.spec.ClientID), url.QueryEscape(api.spec.ClientSecret))
+	}
+
+	rsp, err := api.socket.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+
+	if rsp.StatusCode >= 500 {
+		return fmt.Errorf("identity provider failed: %s", rsp.Status)
+	}
+
+	if err := json.Unmarshal(body, reply); err != nil {
+		return fmt.Errorf("identity provider failed: %s %w", rsp.Status, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors of HMAC signature verification
+var (
+	ErrSignatureMissing = errors.New("signature is missing")
+	ErrSignatureInvalid = errors.New("signature is invalid")
+	ErrSignatureExpired = errors.New("signature is outside of replay window")
+	ErrSignatureReplay  = errors.New("signature is replayed")
+)
+
+// Configure verification of HMAC-SHA256 signed requests (webhook callbacks),
+// received by the server along with MCP traffic. See HMACGitHub and HMACSlack
+// for well-known conventions.
+type ConfigHMAC struct {
+	// Shared secrets, the request is valid if signed by any of them, which
+	// allows rotation of secrets.
+	Secrets []string
+
+	// Header carrying the signature (e.g. X-Hub-Signature-256)
+	SignatureHeader string
+
+	// Prefix of the signature value (e.g. "sha256=")
+	Prefix string
+
+	// Header carrying unix timestamp of the request (e.g. X-Slack-Request-Timestamp).
+	// If defined, the timestamp is signed along with the body as
+	// "{Version}:{timestamp}:{body}" and the replay window is enforced.
+	TimestampHeader string
+
+	// Version of signed payload with timestamp (e.g. "v0")
+	Version string
+
+	// Replay window, defaults to 5 minutes. Requests with the timestamp
+	// outside of window are rejected, the signature is accepted only once
+	// within the window.
+	Window time.Duration
+
+	// Max size of the body, defaults to 1 MiB
+	MaxBodySize int64
+
+	// Cache of signatures accepted within replay window, defaults to the
+	// cache local to the process. Lambda runs multiple instances of the
+	// function, use the cache shared across them (e.g. DynamoDB).
+	Replay ReplayCache
+}
+
+// ReplayCache records signatures accepted within replay window
+type ReplayCache interface {
+	// Once returns true if the key is seen for the first time, the key is
+	// kept for the given duration.
+	Once(key string, ttl time.Duration) (bool, error)
+}
+
+// HMACGitHub configures verification of GitHub webhooks. GitHub signs
+// neither timestamp nor delivery id (X-GitHub-Delivery), the replay window
+// is not enforced. Handlers deduplicate deliveries by X-GitHub-Delivery,
+// which is kept by redeliveries.
+func HMACGitHub(secrets ...string) ConfigHMAC {
+	return ConfigHMAC{
+		Secrets:         secrets,
+		SignatureHeader: "X-Hub-Signature-256",
+		Prefix:          "sha256=",
+	}
+}
+
+// HMACSlack configures verification of Slack requests
+func HMACSlack(secrets ...string) ConfigHMAC {
+	return ConfigHMAC{
+		Secrets:         secrets,
+		SignatureHeader: "X-Slack-Signature",
+		Prefix:          "v0=",
+		TimestampHeader: "X-Slack-Request-Timestamp",
+		Version:         "v0",
+	}
+}
+
+// VerifierHMAC verifies signatures of requests using constant-time comparison
+type VerifierHMAC struct {
+	spec  ConfigHMAC
+	clock func() time.Time
+
+	replay ReplayCache
+}
+
+// NewVerifierHMAC creates verifier of HMAC-signed requests
+func NewVerifierHMAC(spec ConfigHMAC) (*VerifierHMAC, error) {
+	if len(spec.Secrets) == 0 {
+		return nil, errors.New("missing secrets config")
+	}
+	if len(spec.SignatureHeader) == 0 {
+		return nil, errors.New("missing signature header config")
+	}
+	if spec.Window == 0 {
+		spec.Window = 5 * time.Minute
+	}
+	if spec.MaxBodySize == 0 {
+		spec.MaxBodySize = 1 << 20
+	}
+
+	v := &VerifierHMAC{spec: spec, clock: time.Now, replay: spec.Replay}
+	if v.replay == nil {
+		v.replay = &localReplayCache{clock: v.now, seen: map[string]time.Time{}}
+	}
+
+	return v, nil
+}
+
+// Verify the signature of the request, the body is read and returned to
+// the caller, the request's body is replaced so that handlers can read it.
+func (v *VerifierHMAC) Verify(r *http.Request) ([]byte, error) {
+	var body []byte
+	if r.Body != nil {
+		data, err := io.ReadAll(io.LimitReader(r.Body, v.spec.MaxBodySize+1))
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > v.spec.MaxBodySize {
+			return nil, errors.New("body is too large")
+		}
+		body = data
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := v.VerifyPayload(r.Header, body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// VerifyPayload verifies the signature of the payload using headers of
+// the request.
+func (v *VerifierHMAC) VerifyPayload(header http.Header, body []byte) error {
+	signature := header.Get(v.spec.SignatureHeader)
+	if signature == "" || !strings.HasPrefix(signature, v.spec.Prefix) {
+		return ErrSignatureMissing
+	}
+
+	digest, err := hex.DecodeString(strings.TrimPrefix(signature, v.spec.Prefix))
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+
+	payload := body
+	now := v.clock()
+	if v.spec.TimestampHeader != "" {
+		ts := header.Get(v.spec.TimestampHeader)
+		if ts == "" {
+			return ErrSignatureMissing
+		}
+
+		sec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return ErrSignatureInvalid
+		}
+
+		if d := now.Sub(time.Unix(sec, 0)); d > v.spec.Window || d < -v.spec.Window {
+			return ErrSignatureExpired
+		}
+
+		payload = make([]byte, 0, len(v.spec.Version)+len(ts)+len(body)+2)
+		payload = append(payload, v.spec.Version...)
+		payload = append(payload, ':')
+		payload = append(payload, ts...)
+		payload = append(payload, ':')
+		payload = append(payload, body...)
+	}
+
+	valid := false
+	for _, secret := range v.spec.Secrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(payload)
+		if hmac.Equal(mac.Sum(nil), digest) {
+			valid = true
+		}
+	}
+	if !valid {
+		return ErrSignatureInvalid
+	}
+
+	// the signature is accepted once within replay window, the key is the
+	// digest, encodings of the header (e.g. hex case) are not distinct.
+	if v.spec.TimestampHeader != "" {
+		first, err := v.replay.Once(hex.EncodeToString(digest), 2*v.spec.Window)
+		if err != nil {
+			return err
+		}
+		if !first {
+			return ErrSignatureReplay
+		}
+	}
+
+	return nil
+}
+
+func (v *VerifierHMAC) now() time.Time { return v.clock() }
+
+// replay cache local to the process
+type localReplayCache struct {
+	clock func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func (c *localReplayCache) Once(key string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock()
+	for k, t := range c.seen {
+		if now.After(t) {
+			delete(c.seen, k)
+		}
+	}
+
+	if _, has := c.seen[key]; has {
+		return false, nil
+	}
+
+	c.seen[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Handler rejects requests with invalid signature with HTTP 401
+func (v *VerifierHMAC) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := v.Verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
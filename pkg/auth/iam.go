@@ -16,6 +16,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -24,6 +25,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Configure IAM authentication for MCP client
@@ -37,11 +39,39 @@ type ConfigIAM struct {
 	// Optional External ID for AssumeRole operation
 	ExternalID string
 
+	// Roles assumed in order after Role (role chaining), e.g. the role of
+	// the broker account assumes the role of the account hosting the gateway.
+	// Sessions of chained roles are limited to 1 hour by AWS STS.
+	RoleChain []string
+
+	// Duration of assumed role sessions (default 15 minutes)
+	SessionDuration time.Duration
+
+	// Credentials are refreshed in background ahead of expiration, requests
+	// are not blocked by refresh of the session (default 5 minutes).
+	RefreshAhead time.Duration
+
 	// AWS SDK configuration (if nil, default config will be used)
 	Config *aws.Config
 
+	// Requests are signed with UNSIGNED-PAYLOAD if the body is larger than
+	// the threshold (bytes) and it cannot be re-read for hashing, the body
+	// is streamed without buffering. Zero disables unsigned payloads, bodies
+	// are buffered to compute the hash.
+	UnsignedPayloadThreshold int64
+
 	// Custom HTTP client (if nil, default client will be used)
 	Client *http.Client
+
+	// Connection pool of default HTTP transport, it is ignored if custom
+	// HTTP client with transport is used.
+	ConfigHTTP
+
+	// OpenTelemetry tracer provider (if nil, tracing is disabled)
+	TracerProvider trace.TracerProvider
+
+	// Interceptors of requests and responses, called in order
+	Interceptors []Interceptor
 }
 
 // NewTransportIAM creates MCP transport with AWS IAM authentication.
@@ -50,48 +80,69 @@ func NewTransportIAM(spec ConfigIAM) (*mcp.StreamableClientTransport, error) {
 		return nil, errors.New("missing URL config")
 	}
 
+	var socket http.RoundTripper = spec.ConfigHTTP.socket()
+	if spec.Client != nil && spec.Client.Transport != nil {
+		socket = spec.Client.Transport
+	}
+
+	// calls to STS share proxy and TLS config of the transport, unless
+	// AWS SDK configuration is given
 	if spec.Config == nil {
-		conf, err := config.LoadDefaultConfig(context.Background())
+		conf, err := config.LoadDefaultConfig(context.Background(),
+			config.WithHTTPClient(&http.Client{Transport: socket}),
+		)
 		if err != nil {
 			return nil, err
 		}
 		spec.Config = &conf
 	}
 
+	if spec.Role == "" && len(spec.RoleChain) > 0 {
+		return nil, errors.New("missing Role config for role chain")
+	}
+
+	if spec.RefreshAhead == 0 {
+		spec.RefreshAhead = 5 * time.Minute
+	}
+
+	provider := spec.Config.Credentials
 	if spec.Role != "" {
-		assumed, err := config.LoadDefaultConfig(context.Background(),
-			config.WithCredentialsProvider(
-				aws.NewCredentialsCache(
-					stscreds.NewAssumeRoleProvider(sts.NewFromConfig(*spec.Config), spec.Role,
-						func(aro *stscreds.AssumeRoleOptions) {
-							if spec.ExternalID != "" {
-								aro.ExternalID = aws.String(spec.ExternalID)
-							}
-						},
-					),
-				),
-			),
-		)
-		if err != nil {
-			return nil, err
+		for i, role := range append([]string{spec.Role}, spec.RoleChain...) {
+			if i > 0 {
+				// credentials of the previous hop are cached for the next one
+				provider = aws.NewCredentialsCache(provider)
+			}
+
+			conf := spec.Config.Copy()
+			conf.Credentials = provider
+			provider = stscreds.NewAssumeRoleProvider(sts.NewFromConfig(conf), role,
+				func(aro *stscreds.AssumeRoleOptions) {
+					if i == 0 && spec.ExternalID != "" {
+						aro.ExternalID = aws.String(spec.ExternalID)
+					}
+					if spec.SessionDuration > 0 {
+						aro.Duration = spec.SessionDuration
+					}
+				},
+			)
 		}
-		spec.Config = &assumed
 	}
 
-	sock := &iamTransport{
-		config: *spec.Config,
-		signer: v4.NewSigner(),
-		socket: http.DefaultTransport,
-	}
+	assumed := spec.Config.Copy()
+	assumed.Credentials = newIAMCredentials(provider, spec.RefreshAhead)
+	spec.Config = &assumed
 
-	if spec.Client != nil && spec.Client.Transport != nil {
-		sock.socket = spec.Client.Transport
+	sock := &iamTransport{
+		config:   *spec.Config,
+		signer:   v4.NewSigner(),
+		socket:   socket,
+		unsigned: spec.UnsignedPayloadThreshold,
 	}
 
 	if spec.Client == nil {
 		spec.Client = &http.Client{}
 	}
-	spec.Client.Transport = sock
+	spec.Client.Transport = newTracingTransport(spec.TracerProvider, newInterceptTransport(spec.Interceptors, sock))
 
 	return &mcp.StreamableClientTransport{
 		Endpoint:   spec.Url,
@@ -100,29 +151,24 @@ func NewTransportIAM(spec ConfigIAM) (*mcp.StreamableClientTransport, error) {
 }
 
 type iamTransport struct {
-	config aws.Config
-	signer *v4.Signer
-	socket http.RoundTripper
+	config   aws.Config
+	signer   *v4.Signer
+	socket   http.RoundTripper
+	unsigned int64
 }
 
+// hash of empty payload
+const emptyPayload = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
 func (api *iamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	credential, err := api.config.Credentials.Retrieve(req.Context())
 	if err != nil {
 		return nil, err
 	}
 
-	hash := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
-	if req.Body != nil {
-		buf := &bytes.Buffer{}
-		hasher := sha256.New()
-		stream := io.TeeReader(req.Body, hasher)
-		if _, err := io.Copy(buf, stream); err != nil {
-			return nil, err
-		}
-		hash = hex.EncodeToString(hasher.Sum(nil))
-
-		req.Body.Close()
-		req.Body = io.NopCloser(buf)
+	hash, err := api.payloadHash(req)
+	if err != nil {
+		return nil, err
 	}
 
 	err = api.signer.SignHTTP(
@@ -140,3 +186,102 @@ func (api *iamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	return api.socket.RoundTrip(req)
 }
+
+// hash of the payload, the body is hashed from its copy if it can be re-read
+// (e.g. bytes reader), it is buffered otherwise.
+func (api *iamTransport) payloadHash(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return emptyPayload, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+
+		hasher := sha256.New()
+		if _, err := io.Copy(hasher, body); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(hasher.Sum(nil)), nil
+	}
+
+	if api.unsigned > 0 && (req.ContentLength < 0 || req.ContentLength > api.unsigned) {
+		req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+		return "UNSIGNED-PAYLOAD", nil
+	}
+
+	buf := &bytes.Buffer{}
+	hasher := sha256.New()
+	stream := io.TeeReader(req.Body, hasher)
+	if _, err := io.Copy(buf, stream); err != nil {
+		return "", err
+	}
+
+	req.Body.Close()
+	req.Body = io.NopCloser(buf)
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// credentials cache refreshing credentials in background ahead of expiration,
+// concurrent requests use current credentials while the session is refreshed.
+// Requests wait for the refresh only if credentials are expired.
+type iamCredentials struct {
+	sync.Mutex
+	provider   aws.CredentialsProvider
+	ahead      time.Duration
+	creds      aws.Credentials
+	refreshing bool
+}
+
+func newIAMCredentials(provider aws.CredentialsProvider, ahead time.Duration) *iamCredentials {
+	return &iamCredentials{provider: provider, ahead: ahead}
+}
+
+func (c *iamCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.creds.HasKeys() && !c.creds.Expired() {
+		if c.creds.CanExpire && time.Until(c.creds.Expires) < c.ahead && !c.refreshing {
+			c.refreshing = true
+			go c.refresh()
+		}
+		return c.creds, nil
+	}
+
+	creds, err := c.retrieve(ctx)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	c.creds = creds
+
+	return creds, nil
+}
+
+func (c *iamCredentials) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	creds, err := c.retrieve(ctx)
+
+	c.Lock()
+	defer c.Unlock()
+
+	// current credentials are used until expiration if refresh fails
+	if err == nil {
+		c.creds = creds
+	}
+	c.refreshing = false
+}
+
+// credentials of default chain are cached by SDK, the cache is bypassed
+func (c *iamCredentials) retrieve(ctx context.Context) (aws.Credentials, error) {
+	if cache, ok := c.provider.(*aws.CredentialsCache); ok {
+		cache.Invalidate()
+	}
+
+	return c.provider.Retrieve(ctx)
+}
@@ -0,0 +1,140 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package auth
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// Interceptor of HTTP requests and responses of MCP client, e.g. logging,
+// stamping headers (trace ids, tenant) or request mutation. Interceptors are
+// called in order before the request is authenticated, so that headers and
+// body set by them are signed. Interceptors replacing the body set GetBody
+// and ContentLength as http.NewRequest does, the body is buffered otherwise.
+// Interceptors may read the body without replacing it (e.g. logging), the
+// body is restored for sending.
+type Interceptor struct {
+	// Request is called before the request is sent, it may mutate the
+	// request. The error aborts the request.
+	Request func(*http.Request) error
+
+	// Response is called once the response is received, the error aborts
+	// the request and the response body is closed.
+	Response func(*http.Response) error
+}
+
+type interceptTransport struct {
+	chain  []Interceptor
+	socket http.RoundTripper
+}
+
+func newInterceptTransport(chain []Interceptor, socket http.RoundTripper) http.RoundTripper {
+	if len(chain) == 0 {
+		return socket
+	}
+
+	return &interceptTransport{chain: chain, socket: socket}
+}
+
+func (api *interceptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the request given by the client is not modified
+	req = req.Clone(req.Context())
+
+	// copy of the body (GetBody) is valid only if interceptors keep the body,
+	// the payload is hashed from the copy by IAM transport.
+	var body *interceptBody
+	getBody := req.GetBody
+	if req.Body != nil && req.Body != http.NoBody {
+		body = &interceptBody{ReadCloser: req.Body}
+		req.Body = body
+		req.GetBody = nil
+	}
+
+	for _, f := range api.chain {
+		if f.Request != nil {
+			if err := f.Request(req); err != nil {
+				if req.Body != nil {
+					req.Body.Close()
+				}
+				if body != nil {
+					body.ReadCloser.Close()
+				}
+				return nil, err
+			}
+		}
+	}
+
+	if b, ok := req.Body.(*interceptBody); ok && b == body {
+		if err := body.restore(req, getBody); err != nil {
+			return nil, err
+		}
+	}
+
+	rsp, err := api.socket.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range api.chain {
+		if f.Response != nil {
+			if err := f.Response(rsp); err != nil {
+				rsp.Body.Close()
+				return nil, err
+			}
+		}
+	}
+
+	return rsp, nil
+}
+
+// body of the request, bytes read by interceptors are kept. The body is
+// closed by the transport once it is sent, not by interceptors.
+type interceptBody struct {
+	io.ReadCloser
+	read bytes.Buffer
+}
+
+func (b *interceptBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read.Write(p[:n])
+	return n, err
+}
+
+func (b *interceptBody) Close() error { return nil }
+
+// restores the body kept by interceptors, the copy of the body (GetBody) is
+// preferred, otherwise bytes read by interceptors precede the unread rest.
+func (b *interceptBody) restore(req *http.Request, getBody func() (io.ReadCloser, error)) error {
+	if req.GetBody == nil {
+		req.GetBody = getBody
+	}
+
+	if b.read.Len() == 0 {
+		req.Body = b.ReadCloser
+		return nil
+	}
+
+	if req.GetBody != nil {
+		b.ReadCloser.Close()
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		req.Body = body
+		return nil
+	}
+
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b.read.Bytes()), b.ReadCloser), b.ReadCloser}
+	return nil
+}
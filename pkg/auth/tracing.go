@@ -0,0 +1,134 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingTransport creates client span per JSON-RPC request and propagates
+// trace context both via W3C Trace Context headers and MCP `_meta` field.
+// It must be applied before signing the request, it mutates the body.
+type tracingTransport struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	socket     http.RoundTripper
+}
+
+func newTracingTransport(provider trace.TracerProvider, socket http.RoundTripper) http.RoundTripper {
+	if provider == nil {
+		return socket
+	}
+
+	return &tracingTransport{
+		tracer:     provider.Tracer("github.com/fogfish/cloudmcp/pkg/auth"),
+		propagator: propagation.TraceContext{},
+		socket:     socket,
+	}
+}
+
+func (api *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var msg map[string]json.RawMessage
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		// batches and malformed messages are passed as is
+		if err := json.Unmarshal(body, &msg); err != nil {
+			msg = nil
+		}
+	}
+
+	name := "mcp"
+	var method string
+	if raw, has := msg["method"]; has && json.Unmarshal(raw, &method) == nil {
+		name = "mcp " + method
+	}
+
+	ctx, span := api.tracer.Start(req.Context(), name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "jsonrpc"),
+			attribute.String("rpc.method", method),
+		),
+	)
+	defer span.End()
+
+	req = req.Clone(ctx)
+	api.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	if msg != nil && method != "" {
+		if body, err := api.injectMeta(ctx, msg); err == nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+			req.ContentLength = int64(len(body))
+		}
+	}
+
+	rsp, err := api.socket.RoundTrip(req)
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case rsp.StatusCode >= 400:
+		span.SetAttributes(attribute.Int("http.response.status_code", rsp.StatusCode))
+		span.SetStatus(codes.Error, rsp.Status)
+	default:
+		span.SetAttributes(attribute.Int("http.response.status_code", rsp.StatusCode))
+	}
+
+	return rsp, err
+}
+
+func (api *tracingTransport) injectMeta(ctx context.Context, msg map[string]json.RawMessage) ([]byte, error) {
+	params := map[string]json.RawMessage{}
+	if raw, has := msg["params"]; has {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, err
+		}
+	}
+
+	meta := map[string]any{}
+	if raw, has := params["_meta"]; has {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, err
+		}
+	}
+
+	carrier := propagation.MapCarrier{}
+	api.propagator.Inject(ctx, carrier)
+	for key, val := range carrier {
+		meta[key] = val
+	}
+
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	params["_meta"] = raw
+
+	if msg["params"], err = json.Marshal(params); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(msg)
+}
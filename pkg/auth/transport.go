@@ -0,0 +1,133 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Configure connection pool, egress proxy and TLS of HTTP transport used by
+// MCP client. Zero values are replaced with defaults tuned for high
+// concurrency.
+type ConfigHTTP struct {
+	// Maximum number of idle connections across all hosts (default 256)
+	MaxIdleConns int
+
+	// Maximum number of idle connections per host (default 64). The client
+	// usually talks to single gateway, the default of http.DefaultTransport (2)
+	// causes connection churn at high concurrency.
+	MaxIdleConnsPerHost int
+
+	// Maximum number of connections per host, zero means no limit
+	MaxConnsPerHost int
+
+	// How long idle connection remains in the pool (default 90s)
+	IdleConnTimeout time.Duration
+
+	// Disable HTTP/2, HTTP/1.1 is used only
+	DisableHTTP2 bool
+
+	// Egress proxy of the client, HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables are used if nil.
+	Proxy *url.URL
+
+	// Certificate authorities trusted by the client (e.g. private CA of the
+	// corporate network), the system pool is used if nil.
+	RootCAs *x509.CertPool
+
+	// Skip verification of the server certificate, use it for tests only
+	InsecureSkipVerify bool
+
+	// Timeout of establishing TCP connection (default 30s)
+	DialTimeout time.Duration
+
+	// Timeout of TLS handshake (default 10s)
+	TLSHandshakeTimeout time.Duration
+}
+
+const (
+	defaultMaxIdleConns        = 256
+	defaultMaxIdleConnsPerHost = 64
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultDialTimeout         = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// shared transport for configs with default values, so that all clients
+// reuse the same connection pool.
+var sharedTransport = sync.OnceValue(func() *http.Transport {
+	return newTransport(ConfigHTTP{})
+})
+
+// socket returns HTTP transport for the configuration
+func (c ConfigHTTP) socket() *http.Transport {
+	if c == (ConfigHTTP{}) {
+		return sharedTransport()
+	}
+
+	return newTransport(c)
+}
+
+func newTransport(c ConfigHTTP) *http.Transport {
+	if c.MaxIdleConns == 0 {
+		c.MaxIdleConns = defaultMaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost == 0 {
+		c.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout == 0 {
+		c.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	if c.DialTimeout == 0 {
+		c.DialTimeout = defaultDialTimeout
+	}
+	if c.TLSHandshakeTimeout == 0 {
+		c.TLSHandshakeTimeout = defaultTLSHandshakeTimeout
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if c.Proxy != nil {
+		proxy = http.ProxyURL(c.Proxy)
+	}
+
+	t := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   c.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     !c.DisableHTTP2,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       c.MaxConnsPerHost,
+		IdleConnTimeout:       c.IdleConnTimeout,
+		TLSHandshakeTimeout:   c.TLSHandshakeTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if c.RootCAs != nil || c.InsecureSkipVerify {
+		t.TLSClientConfig = &tls.Config{
+			RootCAs:            c.RootCAs,
+			InsecureSkipVerify: c.InsecureSkipVerify,
+		}
+	}
+
+	if c.DisableHTTP2 {
+		// non-nil empty map disables HTTP/2 upgrade
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return t
+}
@@ -0,0 +1,122 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package bluegreen shifts traffic of the server deployed in blue/green mode
+// (see cloudmcp.Gateway.BlueGreen). The gateway routes requests to the alias
+// "live", which serves the blue (stable) version of the function and the
+// given percent of traffic by the green (latest deployed) version. The
+// deployment does not shift traffic, the operator does it gradually while
+// verifying the green version.
+//
+//	api := lambda.NewFromConfig(cfg)
+//	traffic, err := bluegreen.Shift(ctx, api, function, green, 10)
+package bluegreen
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// Alias of the function serving the traffic
+const Alias = "live"
+
+// Lambda interface required by traffic shifting
+type Lambda interface {
+	GetAlias(context.Context, *lambda.GetAliasInput, ...func(*lambda.Options)) (*lambda.GetAliasOutput, error)
+	UpdateAlias(context.Context, *lambda.UpdateAliasInput, ...func(*lambda.Options)) (*lambda.UpdateAliasOutput, error)
+}
+
+// Traffic split between versions of the function
+type Traffic struct {
+	// Version serving the rest of traffic
+	Blue string
+
+	// Version serving the percent of traffic, empty if it is not shifted
+	Green string
+
+	// Percent of traffic served by the green version
+	Percent float64
+}
+
+func (t *Traffic) String() string {
+	if t.Green == "" {
+		return fmt.Sprintf("blue %s 100%%", t.Blue)
+	}
+	return fmt.Sprintf("blue %s %g%%, green %s %g%%", t.Blue, 100-t.Percent, t.Green, t.Percent)
+}
+
+// Status of the traffic split
+func Status(ctx context.Context, api Lambda, function string) (*Traffic, error) {
+	out, err := api.GetAlias(ctx,
+		&lambda.GetAliasInput{
+			FunctionName: aws.String(function),
+			Name:         aws.String(Alias),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return traffic(aws.ToString(out.FunctionVersion), out.RoutingConfig), nil
+}
+
+// Shift the percent of traffic to the green version. The green version is
+// promoted to blue at 100 percent, the traffic is rolled back to the blue
+// version at 0 percent.
+func Shift(ctx context.Context, api Lambda, function, green string, percent float64) (*Traffic, error) {
+	if percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("percent %g is out of range [0, 100]", percent)
+	}
+
+	status, err := Status(ctx, api, function)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &lambda.UpdateAliasInput{
+		FunctionName:    aws.String(function),
+		Name:            aws.String(Alias),
+		FunctionVersion: aws.String(status.Blue),
+		RoutingConfig:   &types.AliasRoutingConfiguration{AdditionalVersionWeights: map[string]float64{}},
+	}
+
+	switch {
+	case percent == 100:
+		input.FunctionVersion = aws.String(green)
+	case percent == 0:
+	case green == status.Blue:
+		return nil, fmt.Errorf("version %s is already blue, deploy the new version", green)
+	default:
+		input.RoutingConfig.AdditionalVersionWeights[green] = percent / 100
+	}
+
+	out, err := api.UpdateAlias(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return traffic(aws.ToString(out.FunctionVersion), out.RoutingConfig), nil
+}
+
+func traffic(blue string, config *types.AliasRoutingConfiguration) *Traffic {
+	t := &Traffic{Blue: blue}
+	if config == nil {
+		return t
+	}
+
+	for version, weight := range config.AdditionalVersionWeights {
+		t.Green, t.Percent = version, math.Round(weight*10000)/100
+	}
+
+	return t
+}
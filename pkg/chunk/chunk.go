@@ -0,0 +1,164 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package chunk lets tools emit partial results incrementally. The lambda
+// proxy does not stream responses, the runtime assembles chunks into ordered
+// content blocks of the final result, each block is annotated with the
+// sequence number and the timestamp of the chunk, so that clients see the
+// structured progress of the tool after completion.
+//
+//	func Tool(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, Output, error) {
+//		for i, page := range pages {
+//			chunk.Textf(ctx, "page %d of %d is processed", i+1, len(pages))
+//			...
+//		}
+//		return nil, Output{}, nil
+//	}
+package chunk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Meta keys of content blocks assembled from chunks
+const (
+	MetaSeq       = "chunk"
+	MetaTimestamp = "timestamp"
+)
+
+// Chunk of the tool output
+type Chunk struct {
+	Seq       int
+	Timestamp time.Time
+	Content   mcp.Content
+}
+
+type chunks struct {
+	sync.Mutex
+	seq []Chunk
+}
+
+type chunksKey struct{}
+
+// NewContext returns context collecting chunks emitted by the tool
+func NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, chunksKey{}, &chunks{})
+}
+
+// Emit the chunk of the tool output, it returns false if the context does
+// not collect chunks (e.g. the tool is called outside of the runtime).
+// Emit is safe for concurrent use.
+func Emit(ctx context.Context, content mcp.Content) bool {
+	c, ok := ctx.Value(chunksKey{}).(*chunks)
+	if !ok {
+		return false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.seq = append(c.seq, Chunk{Seq: len(c.seq), Timestamp: time.Now().UTC(), Content: content})
+	return true
+}
+
+// Text emits the text chunk of the tool output
+func Text(ctx context.Context, text string) bool {
+	return Emit(ctx, &mcp.TextContent{Text: text})
+}
+
+// Textf emits the formatted text chunk of the tool output
+func Textf(ctx context.Context, format string, args ...any) bool {
+	return Emit(ctx, &mcp.TextContent{Text: fmt.Sprintf(format, args...)})
+}
+
+// FromContext returns chunks emitted so far, ordered by sequence
+func FromContext(ctx context.Context) []Chunk {
+	c, ok := ctx.Value(chunksKey{}).(*chunks)
+	if !ok {
+		return nil
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	return append([]Chunk(nil), c.seq...)
+}
+
+// Middleware collects chunks emitted by tools and prepends them to content
+// of the result, it is installed by the lambda runtime.
+func Middleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method != "tools/call" {
+				return next(ctx, method, req)
+			}
+
+			ctx = NewContext(ctx)
+			result, err := next(ctx, method, req)
+			if err != nil {
+				return result, err
+			}
+
+			call, ok := result.(*mcp.CallToolResult)
+			if !ok || call == nil {
+				return result, err
+			}
+
+			seq := FromContext(ctx)
+			if len(seq) == 0 {
+				return result, err
+			}
+
+			content := make([]mcp.Content, 0, len(seq)+len(call.Content))
+			for _, chunk := range seq {
+				content = append(content, annotate(chunk))
+			}
+			call.Content = append(content, call.Content...)
+
+			return call, nil
+		}
+	}
+}
+
+// annotates content block with sequence number and timestamp of the chunk
+func annotate(chunk Chunk) mcp.Content {
+	meta := mcp.Meta{
+		MetaSeq:       chunk.Seq,
+		MetaTimestamp: chunk.Timestamp.Format(time.RFC3339Nano),
+	}
+
+	switch c := chunk.Content.(type) {
+	case *mcp.TextContent:
+		c.Meta = merge(c.Meta, meta)
+	case *mcp.ImageContent:
+		c.Meta = merge(c.Meta, meta)
+	case *mcp.AudioContent:
+		c.Meta = merge(c.Meta, meta)
+	case *mcp.ResourceLink:
+		c.Meta = merge(c.Meta, meta)
+	case *mcp.EmbeddedResource:
+		c.Meta = merge(c.Meta, meta)
+	}
+
+	return chunk.Content
+}
+
+func merge(a, b mcp.Meta) mcp.Meta {
+	if a == nil {
+		return b
+	}
+	for k, v := range b {
+		a[k] = v
+	}
+	return a
+}
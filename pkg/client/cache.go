@@ -0,0 +1,193 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// cache of listings (tools/list, resources/list and resources/templates/list)
+// at HTTP layer. The listing is served from the cache while it is fresh,
+// afterwards it is revalidated with the gateway using ETag, the gateway
+// replies 304 Not Modified if the listing is not changed.
+type cacheTransport struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*cacheEntry
+	socket  http.RoundTripper
+}
+
+type cacheEntry struct {
+	etag    string
+	result  json.RawMessage
+	expires time.Time
+}
+
+func newCacheTransport(ttl time.Duration, socket http.RoundTripper) *cacheTransport {
+	if socket == nil {
+		socket = http.DefaultTransport
+	}
+
+	return &cacheTransport{
+		ttl:     ttl,
+		entries: map[string]*cacheEntry{},
+		socket:  socket,
+	}
+}
+
+// wraps HTTP client of the transport with the cache, other transports
+// (e.g. stdio) are not cached.
+func withCache(transport mcp.Transport, ttl time.Duration) (mcp.Transport, *cacheTransport) {
+	st, ok := transport.(*mcp.StreamableClientTransport)
+	if !ok || ttl <= 0 {
+		return transport, nil
+	}
+
+	client := http.Client{}
+	if st.HTTPClient != nil {
+		client = *st.HTTPClient
+	}
+
+	cache := newCacheTransport(ttl, client.Transport)
+	client.Transport = cache
+
+	cached := *st
+	cached.HTTPClient = &client
+
+	return &cached, cache
+}
+
+// purge the cache, e.g. when the server notifies that listing is changed
+func (c *cacheTransport) purge(methods ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		for _, method := range methods {
+			if strings.HasPrefix(key, method+"\x00") {
+				delete(c.entries, key)
+			}
+		}
+	}
+}
+
+func (c *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || req.Body == nil || req.Body == http.NoBody {
+		return c.socket.RoundTrip(req)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }
+
+	var call struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(data, &call); err != nil || call.ID == nil || !isListing(call.Method) {
+		return c.socket.RoundTrip(req)
+	}
+
+	key := call.Method + "\x00" + req.URL.String() + "\x00" + string(call.Params)
+
+	c.mu.Lock()
+	entry, has := c.entries[key]
+	c.mu.Unlock()
+
+	if has && time.Now().Before(entry.expires) {
+		return reply(req, call.ID, entry.result), nil
+	}
+
+	if has {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+
+	rsp, err := c.socket.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case has && rsp.StatusCode == http.StatusNotModified:
+		rsp.Body.Close()
+		c.put(key, entry.etag, entry.result)
+		return reply(req, call.ID, entry.result), nil
+
+	case rsp.StatusCode != http.StatusOK || rsp.Header.Get("Etag") == "" ||
+		!strings.HasPrefix(rsp.Header.Get("Content-Type"), "application/json"):
+		return rsp, nil
+	}
+
+	body, err := io.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	rsp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var wire struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &wire); err == nil && wire.Result != nil {
+		c.put(key, rsp.Header.Get("Etag"), wire.Result)
+	}
+
+	return rsp, nil
+}
+
+func (c *cacheTransport) put(key, etag string, result json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = &cacheEntry{etag: etag, result: result, expires: time.Now().Add(c.ttl)}
+}
+
+func isListing(method string) bool {
+	switch method {
+	case "tools/list", "resources/list", "resources/templates/list":
+		return true
+	}
+	return false
+}
+
+// response to the request, served from the cache
+func reply(req *http.Request, id json.RawMessage, result json.RawMessage) *http.Response {
+	body, _ := json.Marshal(struct {
+		Version string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  json.RawMessage `json:"result"`
+	}{"2.0", id, result})
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
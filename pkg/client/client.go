@@ -0,0 +1,346 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package client connects to MCP server deployed by the gateway. It hides
+// boilerplate of MCP SDK: initialize, capability negotiation, ping
+// keep-alives and reconnection when the session is expired by the gateway
+// (HTTP 404), the session is re-initialized and the request is retried once.
+// Optionally, listings of tools and resources are cached by the client.
+//
+//	transport, err := auth.NewTransportApiKey(auth.ConfigApiKey{...})
+//
+//	session, err := client.Connect(ctx,
+//		client.Config{Transport: transport, Require: []string{client.Tools}},
+//	)
+//	defer session.Close()
+//
+//	out, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "sayer", Arguments: ...})
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Capabilities of the server, required by the client
+const (
+	Tools       = "tools"
+	Resources   = "resources"
+	Prompts     = "prompts"
+	Logging     = "logging"
+	Completions = "completions"
+)
+
+// Config of the client
+type Config struct {
+	// Transport to the server, configured with credentials
+	// (e.g. using github.com/fogfish/cloudmcp/pkg/auth).
+	Transport mcp.Transport
+
+	// Implementation of the client, defaults to cloudmcp-client
+	Client *mcp.Implementation
+
+	// Options of the client (e.g. handlers of sampling, elicitation and
+	// notifications), KeepAlive overrides options.
+	Options *mcp.ClientOptions
+
+	// Interval of ping requests, defaults to 30 seconds. Negative value
+	// disables keep-alives.
+	KeepAlive time.Duration
+
+	// Capabilities required by the client, the connection fails if the
+	// server does not declare them.
+	Require []string
+
+	// Listings of tools and resources are cached for the duration, later
+	// they are revalidated with ETag emitted by the gateway. The cache is
+	// purged when the server notifies that listing is changed. Zero
+	// disables the cache, only streamable HTTP transport is cached.
+	Cache time.Duration
+}
+
+// Session to MCP server, the session is transparently re-established if
+// it is expired by the gateway. The session is safe for concurrent use.
+type Session struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	client  *mcp.Client
+	config  Config
+	session *mcp.ClientSession
+}
+
+// Connect to MCP server, the session is ready for use once it returns. The
+// context bounds lifetime of the session, including re-established ones.
+func Connect(ctx context.Context, cfg Config) (*Session, error) {
+	if cfg.Transport == nil {
+		return nil, errors.New("transport is not defined")
+	}
+
+	if cfg.Client == nil {
+		cfg.Client = &mcp.Implementation{Name: "cloudmcp-client", Version: "v1.0.0"}
+	}
+
+	opts := mcp.ClientOptions{}
+	if cfg.Options != nil {
+		opts = *cfg.Options
+	}
+
+	switch {
+	case cfg.KeepAlive == 0:
+		opts.KeepAlive = 30 * time.Second
+	case cfg.KeepAlive > 0:
+		opts.KeepAlive = cfg.KeepAlive
+	default:
+		opts.KeepAlive = 0
+	}
+
+	var cache *cacheTransport
+	cfg.Transport, cache = withCache(cfg.Transport, cfg.Cache)
+	if cache != nil {
+		tools := opts.ToolListChangedHandler
+		opts.ToolListChangedHandler = func(ctx context.Context, req *mcp.ToolListChangedRequest) {
+			cache.purge("tools/list")
+			if tools != nil {
+				tools(ctx, req)
+			}
+		}
+
+		resources := opts.ResourceListChangedHandler
+		opts.ResourceListChangedHandler = func(ctx context.Context, req *mcp.ResourceListChangedRequest) {
+			cache.purge("resources/list", "resources/templates/list")
+			if resources != nil {
+				resources(ctx, req)
+			}
+		}
+	}
+
+	s := &Session{
+		ctx:    ctx,
+		client: mcp.NewClient(cfg.Client, &opts),
+		config: cfg,
+	}
+
+	session, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.session = session
+
+	return s, nil
+}
+
+func (s *Session) connect(ctx context.Context) (*mcp.ClientSession, error) {
+	session, err := s.client.Connect(ctx, s.config.Transport, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := require(session.InitializeResult(), s.config.Require); err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func require(init *mcp.InitializeResult, caps []string) error {
+	if len(caps) == 0 {
+		return nil
+	}
+
+	has := &mcp.ServerCapabilities{}
+	if init != nil && init.Capabilities != nil {
+		has = init.Capabilities
+	}
+
+	for _, c := range caps {
+		var ok bool
+		switch c {
+		case Tools:
+			ok = has.Tools != nil
+		case Resources:
+			ok = has.Resources != nil
+		case Prompts:
+			ok = has.Prompts != nil
+		case Logging:
+			ok = has.Logging != nil
+		case Completions:
+			ok = has.Completions != nil
+		default:
+			_, ok = has.Experimental[c]
+		}
+
+		if !ok {
+			return fmt.Errorf("server does not support capability %s", c)
+		}
+	}
+
+	return nil
+}
+
+// Session returns current session to the server, the session is replaced
+// when it is re-established.
+func (s *Session) Session() *mcp.ClientSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.session
+}
+
+// InitializeResult of the current session
+func (s *Session) InitializeResult() *mcp.InitializeResult {
+	session := s.Session()
+	if session == nil {
+		return nil
+	}
+
+	return session.InitializeResult()
+}
+
+// Close the session
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.session == nil {
+		return nil
+	}
+
+	err := s.session.Close()
+	s.session = nil
+	return err
+}
+
+// re-establishes the session, unless it is already replaced by concurrent
+// request.
+func (s *Session) reconnect(expired *mcp.ClientSession) (*mcp.ClientSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.session == nil {
+		return nil, mcp.ErrConnectionClosed
+	}
+
+	if s.session != expired {
+		return s.session, nil
+	}
+
+	session, err := s.connect(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// expired session is missing on the server, close releases resources only
+	expired.Close()
+	s.session = session
+
+	return session, nil
+}
+
+// the session is expired by the gateway (404) or closed by keep-alive check
+func isExpired(err error) bool {
+	return err != nil &&
+		(errors.Is(err, mcp.ErrConnectionClosed) || strings.Contains(err.Error(), "session not found"))
+}
+
+func call[T any](ctx context.Context, s *Session, f func(*mcp.ClientSession) (T, error)) (T, error) {
+	session := s.Session()
+	if session == nil {
+		return *new(T), mcp.ErrConnectionClosed
+	}
+
+	val, err := f(session)
+	if !isExpired(err) || ctx.Err() != nil {
+		return val, err
+	}
+
+	session, err = s.reconnect(session)
+	if err != nil {
+		return *new(T), err
+	}
+
+	return f(session)
+}
+
+// Ping the server
+func (s *Session) Ping(ctx context.Context, params *mcp.PingParams) error {
+	_, err := call(ctx, s, func(cs *mcp.ClientSession) (struct{}, error) {
+		return struct{}{}, cs.Ping(ctx, params)
+	})
+	return err
+}
+
+// ListTools of the server
+func (s *Session) ListTools(ctx context.Context, params *mcp.ListToolsParams) (*mcp.ListToolsResult, error) {
+	return call(ctx, s, func(cs *mcp.ClientSession) (*mcp.ListToolsResult, error) {
+		return cs.ListTools(ctx, params)
+	})
+}
+
+// CallTool of the server
+func (s *Session) CallTool(ctx context.Context, params *mcp.CallToolParams) (*mcp.CallToolResult, error) {
+	return call(ctx, s, func(cs *mcp.ClientSession) (*mcp.CallToolResult, error) {
+		return cs.CallTool(ctx, params)
+	})
+}
+
+// ListResources of the server
+func (s *Session) ListResources(ctx context.Context, params *mcp.ListResourcesParams) (*mcp.ListResourcesResult, error) {
+	return call(ctx, s, func(cs *mcp.ClientSession) (*mcp.ListResourcesResult, error) {
+		return cs.ListResources(ctx, params)
+	})
+}
+
+// ListResourceTemplates of the server
+func (s *Session) ListResourceTemplates(ctx context.Context, params *mcp.ListResourceTemplatesParams) (*mcp.ListResourceTemplatesResult, error) {
+	return call(ctx, s, func(cs *mcp.ClientSession) (*mcp.ListResourceTemplatesResult, error) {
+		return cs.ListResourceTemplates(ctx, params)
+	})
+}
+
+// ReadResource of the server
+func (s *Session) ReadResource(ctx context.Context, params *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+	return call(ctx, s, func(cs *mcp.ClientSession) (*mcp.ReadResourceResult, error) {
+		return cs.ReadResource(ctx, params)
+	})
+}
+
+// ListPrompts of the server
+func (s *Session) ListPrompts(ctx context.Context, params *mcp.ListPromptsParams) (*mcp.ListPromptsResult, error) {
+	return call(ctx, s, func(cs *mcp.ClientSession) (*mcp.ListPromptsResult, error) {
+		return cs.ListPrompts(ctx, params)
+	})
+}
+
+// GetPrompt of the server
+func (s *Session) GetPrompt(ctx context.Context, params *mcp.GetPromptParams) (*mcp.GetPromptResult, error) {
+	return call(ctx, s, func(cs *mcp.ClientSession) (*mcp.GetPromptResult, error) {
+		return cs.GetPrompt(ctx, params)
+	})
+}
+
+// Complete the argument of prompt or resource template
+func (s *Session) Complete(ctx context.Context, params *mcp.CompleteParams) (*mcp.CompleteResult, error) {
+	return call(ctx, s, func(cs *mcp.ClientSession) (*mcp.CompleteResult, error) {
+		return cs.Complete(ctx, params)
+	})
+}
+
+// SetLoggingLevel of the server
+func (s *Session) SetLoggingLevel(ctx context.Context, params *mcp.SetLoggingLevelParams) error {
+	_, err := call(ctx, s, func(cs *mcp.ClientSession) (struct{}, error) {
+		return struct{}{}, cs.SetLoggingLevel(ctx, params)
+	})
+	return err
+}
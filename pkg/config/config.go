@@ -0,0 +1,201 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package config loads configuration of tools from AWS SSM Parameter Store.
+// Parameters under the prefix granted by the builder (see
+// cloudmcp.Gateway.GrantConfig) are loaded at once, cached and reloaded
+// once TTL is expired, so that settings are changed without redeployment.
+// The runtime configuration (stage, environment and secrets) is injected
+// into the factory of the server (see Runtime).
+//
+//	func Tool(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, Output, error) {
+//		endpoint, err := config.Get(ctx, "search/endpoint")
+//		if err != nil {
+//			return nil, Output{}, err
+//		}
+//		...
+//	}
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+)
+
+// Environment variable with the prefix of parameters, injected by builder
+const EnvPrefix = envvar.ConfigPrefix
+
+// Default TTL of cached parameters
+const DefaultTTL = 5 * time.Minute
+
+// ErrNotFound is returned if the parameter is not defined
+var ErrNotFound = errors.New("config parameter is not found")
+
+// SSM interface required by the provider
+type SSM interface {
+	GetParametersByPath(context.Context, *ssm.GetParametersByPathInput, ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+}
+
+// Provider of configuration, parameters are addressed by the name relative
+// to the prefix (e.g. "search/endpoint" for "/myapp/search/endpoint").
+type Provider struct {
+	mu     sync.Mutex
+	api    SSM
+	prefix string
+	ttl    time.Duration
+	values map[string]string
+	loaded time.Time
+}
+
+// New creates provider of parameters under the prefix
+func New(api SSM, prefix string, ttl time.Duration) *Provider {
+	return &Provider{
+		api:    api,
+		prefix: "/" + strings.Trim(prefix, "/"),
+		ttl:    ttl,
+	}
+}
+
+// FromEnv creates provider of parameters under the prefix granted by builder
+func FromEnv(ctx context.Context) (*Provider, error) {
+	prefix, has := os.LookupEnv(EnvPrefix)
+	if !has {
+		return nil, errors.New("config prefix is not configured")
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(ssm.NewFromConfig(cfg), prefix, DefaultTTL), nil
+}
+
+// Get the parameter
+func (p *Provider) Get(ctx context.Context, key string) (string, error) {
+	values, err := p.load(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	val, has := values[strings.Trim(key, "/")]
+	if !has {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	return val, nil
+}
+
+// Decode the parameter as JSON
+func (p *Provider) Decode(ctx context.Context, key string, v any) error {
+	val, err := p.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(val), v)
+}
+
+// loads parameters if TTL is expired, stale parameters are used if SSM fails
+func (p *Provider) load(ctx context.Context) (map[string]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.values != nil && time.Since(p.loaded) < p.ttl {
+		return p.values, nil
+	}
+
+	values := map[string]string{}
+	input := &ssm.GetParametersByPathInput{
+		Path:           aws.String(p.prefix),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	}
+
+	for {
+		val, err := p.api.GetParametersByPath(ctx, input)
+		if err != nil {
+			if p.values != nil {
+				slog.Warn("failed to reload config, using stale parameters", "err", err)
+				return p.values, nil
+			}
+			return nil, err
+		}
+
+		for _, param := range val.Parameters {
+			key := strings.TrimPrefix(aws.ToString(param.Name), p.prefix)
+			values[strings.Trim(key, "/")] = aws.ToString(param.Value)
+		}
+
+		if val.NextToken == nil {
+			break
+		}
+		input.NextToken = val.NextToken
+	}
+
+	p.values = values
+	p.loaded = time.Now()
+
+	return p.values, nil
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	mu       sync.Mutex
+	provider *Provider
+)
+
+func defaultProvider(ctx context.Context) (*Provider, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if provider != nil {
+		return provider, nil
+	}
+
+	p, err := FromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	provider = p
+	return provider, nil
+}
+
+// Get the parameter using default provider
+func Get(ctx context.Context, key string) (string, error) {
+	p, err := defaultProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return p.Get(ctx, key)
+}
+
+// Decode the parameter as JSON using default provider
+func Decode(ctx context.Context, key string, v any) error {
+	p, err := defaultProvider(ctx)
+	if err != nil {
+		return err
+	}
+
+	return p.Decode(ctx, key, v)
+}
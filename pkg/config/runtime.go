@@ -0,0 +1,133 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+)
+
+// Environment variables of the runtime configuration, injected by builder
+const (
+	EnvStage   = envvar.Stage
+	EnvEnv     = envvar.Env
+	EnvSecrets = envvar.Secrets
+)
+
+// Runtime configuration of the server, it is injected into the factory
+// (see cloudmcp.FactoryWithConfig) so that construction of the server
+// differs per environment without global state.
+type Runtime struct {
+	// Stage of the deployment (e.g. dev, prod), empty if it is not staged
+	Stage string
+
+	// Environment of the server (see cloudmcp.Gateway.Env)
+	Env map[string]string
+
+	// Values of secrets (see cloudmcp.Gateway.Secrets), they are not
+	// available when the server is constructed at build time.
+	Secrets map[string]string
+}
+
+// SSMParameters interface required to load secrets
+type SSMParameters interface {
+	GetParameters(context.Context, *ssm.GetParametersInput, ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+}
+
+// RuntimeFromEnv reads configuration injected by builder, secrets are loaded
+// from SSM Parameter Store (SecureString parameters or Secrets Manager
+// references /aws/reference/secretsmanager/...).
+func RuntimeFromEnv(ctx context.Context) (Runtime, error) {
+	rt := Runtime{
+		Stage:   os.Getenv(EnvStage),
+		Env:     map[string]string{},
+		Secrets: map[string]string{},
+	}
+
+	if data, has := os.LookupEnv(EnvEnv); has {
+		if err := json.Unmarshal([]byte(data), &rt.Env); err != nil {
+			return Runtime{}, fmt.Errorf("invalid %s: %w", EnvEnv, err)
+		}
+	}
+
+	data, has := os.LookupEnv(EnvSecrets)
+	if !has {
+		return rt, nil
+	}
+
+	var params map[string]string
+	if err := json.Unmarshal([]byte(data), &params); err != nil {
+		return Runtime{}, fmt.Errorf("invalid %s: %w", EnvSecrets, err)
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return Runtime{}, err
+	}
+
+	rt.Secrets, err = LoadSecrets(ctx, ssm.NewFromConfig(cfg), params)
+	if err != nil {
+		return Runtime{}, err
+	}
+
+	return rt, nil
+}
+
+// LoadSecrets resolves values of secrets, params maps names of secrets to
+// parameters. All secrets must exist.
+func LoadSecrets(ctx context.Context, api SSMParameters, params map[string]string) (map[string]string, error) {
+	names := []string{}
+	for _, param := range params {
+		if !slices.Contains(names, param) {
+			names = append(names, param)
+		}
+	}
+
+	// SSM limits the batch to 10 parameters
+	values := map[string]string{}
+	for len(names) > 0 {
+		n := min(len(names), 10)
+		val, err := api.GetParameters(ctx,
+			&ssm.GetParametersInput{
+				Names:          names[:n],
+				WithDecryption: aws.Bool(true),
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(val.InvalidParameters) > 0 {
+			return nil, fmt.Errorf("%w: %v", ErrNotFound, val.InvalidParameters)
+		}
+
+		for _, param := range val.Parameters {
+			values[aws.ToString(param.Name)] = aws.ToString(param.Value)
+		}
+		names = names[n:]
+	}
+
+	secrets := make(map[string]string, len(params))
+	for name, param := range params {
+		val, has := values[param]
+		if !has {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, param)
+		}
+		secrets[name] = val
+	}
+
+	return secrets, nil
+}
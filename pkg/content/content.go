@@ -0,0 +1,217 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package content builds results of tool calls. Helpers take care of MIME
+// types and encoding of binary data: the SDK encodes binary data with
+// base64, helpers take raw bytes. The lambda response is limited to 6MB,
+// binary content exceeding MaxInlineSize is rejected, use resource links
+// (e.g. presigned URLs of pkg/store) instead.
+//
+//	func Tool(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, Output, error) {
+//		img, err := content.ImageFromS3(ctx, api, "bucket", "chart.png")
+//		if err != nil {
+//			return nil, Output{}, err
+//		}
+//
+//		return content.Result(content.Text("the chart"), img), Output{}, nil
+//	}
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MaxInlineSize of binary content embedded into the result, base64 encoding
+// inflates it by 1/3, which fits the lambda response limit.
+const MaxInlineSize = 4 << 20
+
+// S3 interface required to read objects
+type S3 interface {
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// Result of the tool call with given content items
+func Result(items ...mcp.Content) *mcp.CallToolResult {
+	return &mcp.CallToolResult{Content: items}
+}
+
+// Error result of the tool call, the error is reported to the model as
+// tool execution error rather than protocol error.
+func Error(format string, args ...any) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf(format, args...)}},
+		IsError: true,
+	}
+}
+
+// Structured result of the tool call, the value is also serialized as text
+// content for clients not supporting structured content.
+func Structured(v any) (*mcp.CallToolResult, error) {
+	text, err := JSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.CallToolResult{Content: []mcp.Content{text}, StructuredContent: v}, nil
+}
+
+// Text content
+func Text(text string) *mcp.TextContent {
+	return &mcp.TextContent{Text: text}
+}
+
+// JSON content, the value is serialized as text
+func JSON(v any) (*mcp.TextContent, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode content: %w", err)
+	}
+
+	return &mcp.TextContent{Text: string(data)}, nil
+}
+
+// Image content from raw bytes, the MIME type is detected if empty
+func Image(data []byte, mimeType string) (*mcp.ImageContent, error) {
+	mimeType, err := inline(data, mimeType, "image/")
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.ImageContent{Data: data, MIMEType: mimeType}, nil
+}
+
+// Audio content from raw bytes, the MIME type is detected if empty
+func Audio(data []byte, mimeType string) (*mcp.AudioContent, error) {
+	mimeType, err := inline(data, mimeType, "audio/")
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.AudioContent{Data: data, MIMEType: mimeType}, nil
+}
+
+// ImageFromS3 reads the image from S3 bucket, the MIME type is the content
+// type of the object, it is detected if the object is typed generically.
+func ImageFromS3(ctx context.Context, api S3, bucket, key string) (*mcp.ImageContent, error) {
+	data, mimeType, err := readS3(ctx, api, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return Image(data, mimeType)
+}
+
+// Resource embeds the resource, text is embedded as is, other data as blob.
+// The MIME type is inferred from the URI extension or detected if empty.
+func Resource(uri string, data []byte, mimeType string) (*mcp.EmbeddedResource, error) {
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(path.Ext(uri))
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	contents := &mcp.ResourceContents{URI: uri, MIMEType: mimeType}
+	if isText(mimeType) && utf8.Valid(data) {
+		contents.Text = string(data)
+	} else {
+		if len(data) > MaxInlineSize {
+			return nil, fmt.Errorf("resource %s is too large (%d bytes), use resource link", uri, len(data))
+		}
+		contents.Blob = data
+	}
+
+	return &mcp.EmbeddedResource{Resource: contents}, nil
+}
+
+// Link to the resource, the client reads it by URI (e.g. presigned URL)
+func Link(uri, name, mimeType string) *mcp.ResourceLink {
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(path.Ext(name))
+	}
+
+	return &mcp.ResourceLink{URI: uri, Name: name, MIMEType: mimeType}
+}
+
+func readS3(ctx context.Context, api S3, bucket, key string) ([]byte, string, error) {
+	out, err := api.GetObject(ctx,
+		&s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		},
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer out.Body.Close()
+
+	if aws.ToInt64(out.ContentLength) > MaxInlineSize {
+		return nil, "", fmt.Errorf("object %s is too large (%d bytes), use resource link", key, aws.ToInt64(out.ContentLength))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(out.Body, MaxInlineSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+
+	mimeType := aws.ToString(out.ContentType)
+	if mimeType == "binary/octet-stream" || mimeType == "application/octet-stream" {
+		mimeType = ""
+	}
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(path.Ext(key))
+	}
+
+	return data, mimeType, nil
+}
+
+// validates inline binary data and its MIME type of expected kind
+func inline(data []byte, mimeType, kind string) (string, error) {
+	if len(data) > MaxInlineSize {
+		return "", fmt.Errorf("content is too large (%d bytes), use resource link", len(data))
+	}
+
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	media, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return "", fmt.Errorf("invalid mime type %s: %w", mimeType, err)
+	}
+	if !strings.HasPrefix(media, kind) {
+		return "", fmt.Errorf("content is %s, expected %s*", media, kind)
+	}
+
+	return media, nil
+}
+
+func isText(mimeType string) bool {
+	media, _, _ := mime.ParseMediaType(mimeType)
+	switch {
+	case strings.HasPrefix(media, "text/"):
+		return true
+	case media == "application/json", media == "application/xml", media == "application/yaml",
+		strings.HasSuffix(media, "+json"), strings.HasSuffix(media, "+xml"):
+		return true
+	default:
+		return false
+	}
+}
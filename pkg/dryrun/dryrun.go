@@ -0,0 +1,134 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package dryrun simulates side-effecting tools, letting teams trial agent
+// workflows safely (see cloudmcp.Gateway.DryRun). In dry-run mode, calls of
+// mutating tools return simulated results instead of executing. Tools
+// register simulators within the server factory, tools declared by
+// the builder without simulator return the default result.
+//
+//	func HelloWorld() (*mcp.Server, error) {
+//		server := mcp.NewServer(/* ... */)
+//		mcp.AddTool(server, &mcp.Tool{Name: "delete_order"}, DeleteOrder)
+//
+//		dryrun.Simulate("delete_order",
+//			func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+//				return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "order is deleted"}}}, nil
+//			},
+//		)
+//		return server, nil
+//	}
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MetaKey of the result, set to true for simulated results
+const MetaKey = "dryRun"
+
+// Simulator produces the result of the tool call without executing it.
+// The structured content of the result shall match the output schema of
+// the tool.
+type Simulator = mcp.ToolHandler
+
+var (
+	mu         sync.RWMutex
+	simulators = map[string]Simulator{}
+)
+
+// Simulate registers the simulator of the tool, it is used only in dry-run
+// mode, the tool is executed as usual otherwise.
+func Simulate(tool string, sim Simulator) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	simulators[tool] = sim
+}
+
+func simulatorOf(tool string) (Simulator, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	sim, has := simulators[tool]
+	return sim, has
+}
+
+type dryRunKey struct{}
+
+// NewContext returns context in dry-run mode
+func NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// FromContext checks if the tool is called in dry-run mode, tools that
+// are not simulated shall avoid side effects themselves.
+func FromContext(ctx context.Context) bool {
+	enabled, _ := ctx.Value(dryRunKey{}).(bool)
+	return enabled
+}
+
+// Middleware enables dry-run mode, it is installed by the lambda runtime.
+// Calls of tools with registered simulator and tools matching patterns
+// (names or prefixes ending with "*") are simulated.
+func Middleware(tools ...string) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			ctx = NewContext(ctx)
+
+			call, ok := req.(*mcp.CallToolRequest)
+			if method != "tools/call" || !ok {
+				return next(ctx, method, req)
+			}
+
+			sim, has := simulatorOf(call.Params.Name)
+			if !has && !matches(tools, call.Params.Name) {
+				return next(ctx, method, req)
+			}
+
+			var result *mcp.CallToolResult
+			var err error
+			if has {
+				result, err = sim(ctx, call)
+			} else {
+				result = &mcp.CallToolResult{
+					Content: []mcp.Content{
+						&mcp.TextContent{Text: fmt.Sprintf("dry-run: tool %s is not executed", call.Params.Name)},
+					},
+				}
+			}
+			if err != nil || result == nil {
+				return result, err
+			}
+
+			if result.Meta == nil {
+				result.Meta = mcp.Meta{}
+			}
+			result.Meta[MetaKey] = true
+
+			return result, nil
+		}
+	}
+}
+
+func matches(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == name {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
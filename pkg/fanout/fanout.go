@@ -0,0 +1,280 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package fanout lets a composite tool call tools deployed as individual
+// lambdas (see cloudmcp.NewFunction) in parallel and aggregate their
+// results, e.g. "search everything" tool built from per-source search tools.
+// Downstream functions are invoked directly with the lambda API, bypassing
+// API Gateway and its authorizers. The server is granted the invocation by
+// the builder (see cloudmcp.Gateway.WithFunction).
+//
+//	func SearchAll(ctx context.Context, req *mcp.CallToolRequest, in Query) (*mcp.CallToolResult, any, error) {
+//		fan, err := fanout.FromEnv(ctx, 10*time.Second)
+//		if err != nil {
+//			return nil, nil, err
+//		}
+//
+//		results, err := fan.Call(ctx,
+//			fanout.Call{Function: "wiki", Tool: "SearchWiki", Arguments: in},
+//			fanout.Call{Function: "jira", Tool: "SearchJira", Arguments: in},
+//		)
+//		if err != nil {
+//			return nil, nil, err
+//		}
+//
+//		return fanout.Join(results...), nil, nil
+//	}
+package fanout
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/sync/errgroup"
+)
+
+// Environment variable with name of the function, injected by builder.
+// The function "wiki" is defined by CONFIG_CLOUDMCP_FUNCTION_WIKI.
+const EnvFunction = envvar.Function
+
+// Lambda interface required by fan-out
+type Lambda interface {
+	Invoke(context.Context, *lambda.InvokeInput, ...func(*lambda.Options)) (*lambda.InvokeOutput, error)
+}
+
+// Call of the tool deployed as lambda function
+type Call struct {
+	// Name of the function given to the builder, name or ARN of the function
+	// is used as is if it is not configured by the builder.
+	Function string
+
+	// Name of the tool
+	Tool string
+
+	// Arguments of the tool, encoded as JSON
+	Arguments any
+}
+
+// FanOut calls tools in parallel
+type FanOut struct {
+	api     Lambda
+	timeout time.Duration
+}
+
+// New creates fan-out, each call is cancelled after the timeout unless it
+// is zero.
+func New(api Lambda, timeout time.Duration) *FanOut {
+	return &FanOut{api: api, timeout: timeout}
+}
+
+var (
+	defaultLambda     Lambda
+	defaultLambdaOnce sync.Once
+	defaultLambdaErr  error
+)
+
+// FromEnv returns fan-out using the lambda client shared within the lambda.
+func FromEnv(ctx context.Context, timeout time.Duration) (*FanOut, error) {
+	defaultLambdaOnce.Do(func() {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			defaultLambdaErr = err
+			return
+		}
+
+		defaultLambda = lambda.NewFromConfig(cfg)
+	})
+	if defaultLambdaErr != nil {
+		return nil, defaultLambdaErr
+	}
+
+	return New(defaultLambda, timeout), nil
+}
+
+// Call tools in parallel and waits for all of them, results are ordered as
+// calls. The first failed call cancels the others and its error is returned.
+// The tool execution error (CallToolResult.IsError) is the result, not the
+// failure of the call.
+func (f *FanOut) Call(ctx context.Context, calls ...Call) ([]*mcp.CallToolResult, error) {
+	results := make([]*mcp.CallToolResult, len(calls))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, call := range calls {
+		g.Go(func() error {
+			result, err := f.call(gctx, call)
+			if err != nil {
+				return fmt.Errorf("tool %s of function %s has failed: %w", call.Tool, call.Function, err)
+			}
+			results[i] = result
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (f *FanOut) call(ctx context.Context, call Call) (*mcp.CallToolResult, error) {
+	if f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+
+	payload, err := encodeEvent(call)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := f.api.Invoke(ctx,
+		&lambda.InvokeInput{
+			FunctionName: aws.String(function(call.Function)),
+			Payload:      payload,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if out.FunctionError != nil {
+		return nil, fmt.Errorf("%s: %s", aws.ToString(out.FunctionError), out.Payload)
+	}
+
+	return decodeEvent(out.Payload)
+}
+
+// name of the function configured by builder
+func function(name string) string {
+	if val, has := os.LookupEnv(EnvFunction + envName(name)); has {
+		return val
+	}
+	return name
+}
+
+// API Gateway event with tools/call request, as the function receives it
+// from the gateway
+func encodeEvent(call Call) ([]byte, error) {
+	args, err := json.Marshal(call.Arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(
+		map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "tools/call",
+			"params": map[string]any{
+				"name":      call.Tool,
+				"arguments": json.RawMessage(args),
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(
+		events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodPost,
+			Path:       "/",
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"Accept":       "application/json, text/event-stream",
+			},
+			Body: string(body),
+		},
+	)
+}
+
+func decodeEvent(payload []byte) (*mcp.CallToolResult, error) {
+	var rsp events.APIGatewayProxyResponse
+	if err := json.Unmarshal(payload, &rsp); err != nil {
+		return nil, err
+	}
+
+	body := []byte(rsp.Body)
+	if rsp.IsBase64Encoded {
+		data, err := base64.StdEncoding.DecodeString(rsp.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	if len(body) == 0 {
+		return nil, fmt.Errorf("unexpected response %d", rsp.StatusCode)
+	}
+
+	msg, err := jsonrpc.DecodeMessage(body)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected response %d: %w", rsp.StatusCode, err)
+	}
+
+	reply, ok := msg.(*jsonrpc.Response)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response %d", rsp.StatusCode)
+	}
+	if reply.Error != nil {
+		return nil, reply.Error
+	}
+
+	var result mcp.CallToolResult
+	if err := json.Unmarshal(reply.Result, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Join results into one, the content is concatenated in order of results.
+// The result is an error if any of results is an error.
+func Join(results ...*mcp.CallToolResult) *mcp.CallToolResult {
+	joined := &mcp.CallToolResult{}
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		joined.Content = append(joined.Content, result.Content...)
+		joined.IsError = joined.IsError || result.IsError
+	}
+	return joined
+}
+
+func envName(name string) string {
+	return strings.Map(
+		func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+				return r
+			default:
+				return '_'
+			}
+		},
+		name,
+	)
+}
@@ -0,0 +1,198 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package gatewaytest runs MCP server through the gateway lambda code path
+// without deploying it. HTTP requests are converted into API Gateway proxy
+// events, the gateway response is converted back. The gateway is configured
+// from CONFIG_CLOUDMCP_* environment variables, same as the deployed lambda.
+//
+//	func TestSayer(t *testing.T) {
+//		server, _ := server.HelloWorld()
+//		h, err := gatewaytest.New(server, gatewaytest.WithApiKey("access"))
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//
+//		ts := httptest.NewServer(h)
+//		defer ts.Close()
+//
+//		client := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil)
+//		session, err := client.Connect(ctx, &mcp.StreamableClientTransport{Endpoint: ts.URL}, nil)
+//		...
+//	}
+package gatewaytest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/fogfish/cloudmcp/internal/gateway"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Handler serves HTTP requests through the gateway
+type Handler struct {
+	gw   *gateway.Gateway
+	auth []Option
+}
+
+// Option configures the identity of callers, as authenticated by API Gateway
+type Option func(*events.APIGatewayProxyRequest)
+
+// WithClaims authenticates callers with JWT claims (JWT authorizer)
+func WithClaims(claims map[string]any) Option {
+	return func(r *events.APIGatewayProxyRequest) {
+		r.RequestContext.Authorizer = map[string]any{"claims": claims}
+	}
+}
+
+// WithApiKey authenticates callers with API access key (Lambda authorizer)
+func WithApiKey(access string) Option {
+	return func(r *events.APIGatewayProxyRequest) {
+		r.RequestContext.Authorizer = map[string]any{"principalId": access}
+	}
+}
+
+// WithIAM authenticates callers with IAM principal (IAM authorizer)
+func WithIAM(arn string) Option {
+	return func(r *events.APIGatewayProxyRequest) {
+		r.RequestContext.Identity.UserArn = arn
+	}
+}
+
+// New creates handler of MCP server, the server and the gateway are
+// configured in the same way as the generated lambda does.
+func New(server *mcp.Server, opts ...Option) (*Handler, error) {
+	ctx := context.Background()
+
+	if err := gateway.ServerFromEnv(ctx, server); err != nil {
+		return nil, err
+	}
+
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		return server
+	}, &mcp.StreamableHTTPOptions{
+		Stateless:    true,
+		JSONResponse: true,
+	})
+
+	gwopts, err := gateway.FromEnv(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{gw: gateway.New(handler, gwopts...), auth: opts}, nil
+}
+
+// ServeHTTP converts the request into API Gateway event, invokes the gateway
+// as lambda runtime does and writes its response. Errors of the gateway are
+// served as 502, same as API Gateway does.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := NewRequest(r, h.auth...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	raw, err := json.Marshal(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rsp, err := h.gw.Handle(r.Context(), raw)
+	if err != nil || rsp == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"message":"Internal Server Error"}`))
+		return
+	}
+
+	WriteResponse(w, rsp)
+}
+
+// NewRequest converts HTTP request into API Gateway proxy event
+func NewRequest(r *http.Request, opts ...Option) (*events.APIGatewayProxyRequest, error) {
+	req := &events.APIGatewayProxyRequest{
+		HTTPMethod:                      r.Method,
+		Path:                            r.URL.Path,
+		Resource:                        r.URL.Path,
+		Headers:                         map[string]string{},
+		MultiValueHeaders:               map[string][]string{},
+		QueryStringParameters:           map[string]string{},
+		MultiValueQueryStringParameters: map[string][]string{},
+	}
+
+	for key, vals := range r.Header {
+		req.Headers[key] = vals[len(vals)-1]
+		req.MultiValueHeaders[key] = vals
+	}
+	if r.Host != "" {
+		req.Headers["Host"] = r.Host
+	}
+
+	for key, vals := range r.URL.Query() {
+		req.QueryStringParameters[key] = vals[len(vals)-1]
+		req.MultiValueQueryStringParameters[key] = vals
+	}
+
+	if r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		if utf8.Valid(body) {
+			req.Body = string(body)
+		} else {
+			req.Body = base64.StdEncoding.EncodeToString(body)
+			req.IsBase64Encoded = true
+		}
+	}
+
+	req.RequestContext.HTTPMethod = r.Method
+	req.RequestContext.Path = r.URL.Path
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		req.RequestContext.Identity.SourceIP = host
+	}
+
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	return req, nil
+}
+
+// WriteResponse writes API Gateway proxy response into HTTP response
+func WriteResponse(w http.ResponseWriter, rsp *events.APIGatewayProxyResponse) {
+	for key, val := range rsp.Headers {
+		w.Header().Set(key, val)
+	}
+	for key, vals := range rsp.MultiValueHeaders {
+		w.Header().Del(key)
+		for _, val := range vals {
+			w.Header().Add(key, val)
+		}
+	}
+
+	body := []byte(rsp.Body)
+	if rsp.IsBase64Encoded {
+		if data, err := base64.StdEncoding.DecodeString(rsp.Body); err == nil {
+			body = data
+		}
+	}
+
+	w.WriteHeader(rsp.StatusCode)
+	w.Write(body)
+}
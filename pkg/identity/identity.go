@@ -0,0 +1,134 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package identity exposes the authenticated caller to tool handlers. The
+// caller is resolved by the gateway from API Gateway request context: JWT
+// subject and claims, API access key or IAM ARN.
+//
+//	func Tool(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, Output, error) {
+//		caller, ok := identity.Caller(ctx)
+//		if !ok {
+//			return nil, Output{}, mcperr.Unauthorized("caller is not authenticated")
+//		}
+//		orders, err := db.OrdersOf(ctx, caller.ID)
+//		...
+//	}
+package identity
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Header carrying the caller encoded by the gateway, the gateway sets it for
+// every request, the value supplied by clients is discarded.
+const Header = "Mcp-Caller"
+
+// Kind of the authentication
+type Kind string
+
+// Kinds of the authentication supported by the gateway
+const (
+	JWT    Kind = "jwt"
+	APIKey Kind = "apikey"
+	IAM    Kind = "iam"
+	Opaque Kind = "opaque"
+)
+
+// Principal is the caller authenticated by API Gateway
+type Principal struct {
+	// Unique identity of the caller: JWT subject, API access key, IAM ARN or
+	// subject (client) of opaque token
+	ID string `json:"id"`
+
+	// Kind of the authentication
+	Kind Kind `json:"kind"`
+
+	// Claims of the caller (JWT claims, Lambda authorizer context or IAM
+	// account and caller)
+	Claims map[string]any `json:"claims,omitempty"`
+
+	// Tenant of the caller, if tenancy is configured
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Claim of the caller as string, empty if the claim is not defined
+func (p *Principal) Claim(name string) string {
+	switch v := p.Claims[name].(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// Encode the principal for the header
+func Encode(p *Principal) string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode the principal from the header
+func Decode(value string) (*Principal, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, err
+	}
+
+	var p Principal
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+type callerKey struct{}
+
+// NewContext returns context carrying the caller
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, callerKey{}, p)
+}
+
+// Caller returns the authenticated caller, false for public access
+func Caller(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(callerKey{}).(*Principal)
+	return p, ok && p != nil && p.ID != ""
+}
+
+// Middleware passes the caller of the request into context of handlers,
+// it is installed by the lambda runtime.
+func Middleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			extra := req.GetExtra()
+			if extra == nil || extra.Header == nil {
+				return next(ctx, method, req)
+			}
+
+			if value := extra.Header.Get(Header); value != "" {
+				if p, err := Decode(value); err == nil {
+					ctx = NewContext(ctx, p)
+				}
+			}
+
+			return next(ctx, method, req)
+		}
+	}
+}
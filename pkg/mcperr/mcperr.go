@@ -0,0 +1,154 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package mcperr defines the taxonomy of structured errors returned by
+// MCP tools. The gateway maps these errors to JSON-RPC error codes and
+// HTTP statuses, including Retry-After header.
+//
+//	func Tool(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, Output, error) {
+//		if in.Name == "" {
+//			return nil, Output{}, mcperr.InvalidInput("name is required")
+//		}
+//		...
+//	}
+//
+// Other errors returned by tools are reported as tool execution errors
+// (CallToolResult with IsError) as defined by MCP specification.
+package mcperr
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// JSON-RPC error codes of the taxonomy
+const (
+	CodeInvalidInput = -32602
+	CodeUnauthorized = -32001
+	CodeRateLimited  = -32002
+	CodeTimeout      = -32003
+
+	CodeElicitationPending = -32004
+
+	// The tool call waits for the decision of approver, the error is produced
+	// by the gateway, details carry the approval.
+	CodeApprovalPending = -32005
+)
+
+// Sentinel errors, use errors.Is to check the kind of error.
+var (
+	ErrInvalidInput = New(CodeInvalidInput, "invalid input", nil)
+	ErrUnauthorized = New(CodeUnauthorized, "unauthorized", nil)
+	ErrRateLimited  = New(CodeRateLimited, "rate limited", nil)
+	ErrTimeout      = New(CodeTimeout, "timeout", nil)
+
+	ErrElicitationPending = New(CodeElicitationPending, "elicitation pending", nil)
+	ErrApprovalPending    = New(CodeApprovalPending, "approval pending", nil)
+)
+
+// Data is structured details of the error
+type Data struct {
+	// Retry after the number of seconds
+	RetryAfter int `json:"retryAfter,omitempty"`
+
+	// Additional details
+	Details any `json:"details,omitempty"`
+}
+
+// InvalidInput error, the tool arguments are not valid.
+func InvalidInput(message string) error {
+	return New(CodeInvalidInput, message, nil)
+}
+
+// Unauthorized error, the caller is not allowed to perform the action.
+func Unauthorized(message string) error {
+	return New(CodeUnauthorized, message, nil)
+}
+
+// RateLimited error, the caller shall retry after given duration.
+func RateLimited(retryAfter time.Duration, message string) error {
+	return New(CodeRateLimited, message, &Data{RetryAfter: seconds(retryAfter)})
+}
+
+// Timeout error, the tool has not completed in time.
+func Timeout(message string) error {
+	return New(CodeTimeout, message, nil)
+}
+
+// ElicitationPending error, the tool waits for the answer of the user. The
+// elicitation request is passed within details, the client shall answer it
+// and retry the call. The error is produced by the gateway, tools shall
+// return it as is.
+func ElicitationPending(request any) error {
+	return New(CodeElicitationPending, "elicitation pending, answer it and retry the call", &Data{Details: request})
+}
+
+// New creates JSON-RPC error with given code. The error is an instance of
+// the wire error used by MCP SDK, therefore it is passed to the client as
+// JSON-RPC error rather than tool execution error.
+func New(code int64, message string, data *Data) error {
+	wire := struct {
+		Version string `json:"jsonrpc"`
+		ID      int    `json:"id"`
+		Error   struct {
+			Code    int64  `json:"code"`
+			Message string `json:"message"`
+			Data    *Data  `json:"data,omitempty"`
+		} `json:"error"`
+	}{Version: "2.0", ID: 1}
+	wire.Error.Code = code
+	wire.Error.Message = message
+	wire.Error.Data = data
+
+	// The only way to construct wire error of MCP SDK is decoding it.
+	raw, err := json.Marshal(wire)
+	if err != nil {
+		return err
+	}
+
+	msg, err := jsonrpc.DecodeMessage(raw)
+	if err != nil {
+		return err
+	}
+
+	rsp, ok := msg.(*jsonrpc.Response)
+	if !ok || rsp.Error == nil {
+		return errors.New(message)
+	}
+
+	return rsp.Error
+}
+
+// StatusCode maps JSON-RPC error code to HTTP status code. It returns 0
+// for codes outside of the taxonomy.
+func StatusCode(code int64) int {
+	switch code {
+	case CodeInvalidInput:
+		return http.StatusBadRequest
+	case CodeUnauthorized:
+		return http.StatusForbidden
+	case CodeRateLimited:
+		return http.StatusTooManyRequests
+	case CodeTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return 0
+	}
+}
+
+func seconds(d time.Duration) int {
+	s := int(d / time.Second)
+	if d%time.Second != 0 {
+		s++
+	}
+	return s
+}
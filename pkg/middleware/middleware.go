@@ -0,0 +1,83 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package middleware defines the middleware of tool calls, which is applied
+// inside the lambda runtime around every tool of the server (logging,
+// validation, caching, etc). Middlewares are declared as top-level
+// functions and attached by the builder (see cloudmcp.Gateway.Use).
+//
+//	func Logging(next middleware.ToolHandler) middleware.ToolHandler {
+//		return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+//			slog.Info("tool call", "tool", req.Params.Name)
+//			return next(ctx, req)
+//		}
+//	}
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ToolHandler handles tools/call request
+type ToolHandler = mcp.ToolHandler
+
+// Middleware of tools/call request
+type Middleware func(next ToolHandler) ToolHandler
+
+// Chain composes middlewares, the first one is the outermost.
+func Chain(mws ...Middleware) Middleware {
+	return func(next ToolHandler) ToolHandler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			next = mws[i](next)
+		}
+		return next
+	}
+}
+
+// Install applies middlewares to every tool call of the server
+func Install(server *mcp.Server, mws ...Middleware) {
+	if len(mws) == 0 {
+		return
+	}
+
+	chain := Chain(mws...)
+	server.AddReceivingMiddleware(
+		func(next mcp.MethodHandler) mcp.MethodHandler {
+			return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+				call, ok := req.(*mcp.CallToolRequest)
+				if method != "tools/call" || !ok {
+					return next(ctx, method, req)
+				}
+
+				handler := chain(func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					res, err := next(ctx, method, req)
+					if err != nil {
+						return nil, err
+					}
+
+					result, ok := res.(*mcp.CallToolResult)
+					if !ok {
+						return nil, fmt.Errorf("unexpected result %T of tools/call", res)
+					}
+
+					return result, nil
+				})
+
+				result, err := handler(ctx, call)
+				if err != nil {
+					return nil, err
+				}
+
+				return result, nil
+			}
+		},
+	)
+}
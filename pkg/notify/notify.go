@@ -0,0 +1,230 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package notify publishes MCP server notifications (resource updates, log
+// messages) to AppSync Events channels (see cloudmcp.Gateway.Notifications).
+// Clients subscribe to channels of the namespace "mcp" using real-time
+// endpoint of the API, it gives push semantic on top of stateless Lambda.
+//
+//	func Tool(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, Output, error) {
+//		...
+//		notify.ResourceUpdated(ctx, "file:///reports/daily.csv")
+//	}
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Environment variable with HTTP domain of AppSync Events API, injected by builder
+const EnvEndpoint = envvar.NotificationsEndpoint
+
+// Namespace of channels
+const Namespace = "mcp"
+
+// Channels of well-known notifications
+const (
+	ChannelResources = "resources"
+	ChannelLogs      = "logs"
+)
+
+// AppSync Events accepts up to 5 events per request
+const maxBatch = 5
+
+// Publisher of notifications to AppSync Events API
+type Publisher struct {
+	client   *http.Client
+	signer   *v4.Signer
+	config   aws.Config
+	endpoint string
+}
+
+// Creates publisher for the API, the endpoint is HTTP domain of the API
+func NewPublisher(cfg aws.Config, endpoint string) *Publisher {
+	if !strings.HasPrefix(endpoint, "https://") {
+		endpoint = "https://" + endpoint
+	}
+
+	return &Publisher{
+		client:   &http.Client{Timeout: 10 * time.Second},
+		signer:   v4.NewSigner(),
+		config:   cfg,
+		endpoint: strings.TrimSuffix(endpoint, "/") + "/event",
+	}
+}
+
+// Publish messages to the channel, the channel is relative to namespace
+// (e.g. "resources" or "sessions/abc").
+func (p *Publisher) Publish(ctx context.Context, channel string, msgs ...jsonrpc.Message) error {
+	events := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		data, err := jsonrpc.EncodeMessage(msg)
+		if err != nil {
+			return err
+		}
+		events = append(events, string(data))
+	}
+
+	for len(events) > 0 {
+		n := min(len(events), maxBatch)
+		if err := p.publish(ctx, "/"+Namespace+"/"+strings.Trim(channel, "/"), events[:n]); err != nil {
+			return err
+		}
+		events = events[n:]
+	}
+
+	return nil
+}
+
+// Notify the channel, sending JSON-RPC notification
+func (p *Publisher) Notify(ctx context.Context, channel string, method string, params any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	return p.Publish(ctx, channel, &jsonrpc.Request{Method: method, Params: raw})
+}
+
+func (p *Publisher) publish(ctx context.Context, channel string, events []string) error {
+	body, err := json.Marshal(map[string]any{"channel": channel, "events": events})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	creds, err := p.config.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+
+	hash := sha256.Sum256(body)
+	err = p.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "appsync", p.config.Region, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	rsp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+	defer rsp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rsp.Body, 64*1024))
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to publish to %s: status %d %s", channel, rsp.StatusCode, data)
+	}
+
+	var reply struct {
+		Failed []struct {
+			Index   int    `json:"index"`
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"failed"`
+	}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+
+	if len(reply.Failed) > 0 {
+		f := reply.Failed[0]
+		return fmt.Errorf("failed to publish %d event(s) to %s: %d %s", len(reply.Failed), channel, f.Code, f.Message)
+	}
+
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Publish messages to the channel using publisher configured from environment
+func Publish(ctx context.Context, channel string, msgs ...jsonrpc.Message) error {
+	p, err := publisher(ctx)
+	if err != nil {
+		return err
+	}
+
+	return p.Publish(ctx, channel, msgs...)
+}
+
+// Notify the channel using publisher configured from environment
+func Notify(ctx context.Context, channel string, method string, params any) error {
+	p, err := publisher(ctx)
+	if err != nil {
+		return err
+	}
+
+	return p.Notify(ctx, channel, method, params)
+}
+
+// ResourceUpdated notifies subscribers of the channel "resources" that the
+// resource is changed
+func ResourceUpdated(ctx context.Context, uri string) error {
+	return Notify(ctx, ChannelResources, "notifications/resources/updated",
+		&mcp.ResourceUpdatedNotificationParams{URI: uri},
+	)
+}
+
+// Log sends the log message to subscribers of the channel "logs"
+func Log(ctx context.Context, params *mcp.LoggingMessageParams) error {
+	return Notify(ctx, ChannelLogs, "notifications/message", params)
+}
+
+var (
+	mu  sync.Mutex
+	pub *Publisher
+)
+
+func publisher(ctx context.Context) (*Publisher, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if pub != nil {
+		return pub, nil
+	}
+
+	endpoint, has := os.LookupEnv(EnvEndpoint)
+	if !has {
+		return nil, errors.New("notifications endpoint is not configured")
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pub = NewPublisher(cfg, endpoint)
+	return pub, nil
+}
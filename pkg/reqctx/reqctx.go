@@ -0,0 +1,107 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package reqctx exposes API Gateway request context to tool handlers (see
+// cloudmcp.Gateway.RequestContext). The gateway propagates allowed fields of
+// the request context (source IP, user agent, stage, authorizer claims) into
+// `_meta` of MCP requests, the runtime passes them into context of handlers.
+//
+//	func Tool(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, Output, error) {
+//		rc, ok := reqctx.FromContext(ctx)
+//		if ok && rc.SourceIP != "" {
+//			region := geo.Lookup(rc.SourceIP)
+//			...
+//		}
+//	}
+package reqctx
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// MetaKey of the request context in `_meta` of MCP requests, the gateway
+// overrides the value supplied by clients.
+const MetaKey = "cloudmcp/requestContext"
+
+// Fields of API Gateway request context, claims are addressed individually
+// as "claims.{name}" or all at once as "claims.*".
+const (
+	SourceIP   = "sourceIp"
+	UserAgent  = "userAgent"
+	Stage      = "stage"
+	RequestID  = "requestId"
+	DomainName = "domainName"
+	Claims     = "claims."
+)
+
+// RequestContext is the allowed subset of API Gateway request context
+type RequestContext struct {
+	SourceIP   string         `json:"sourceIp,omitempty"`
+	UserAgent  string         `json:"userAgent,omitempty"`
+	Stage      string         `json:"stage,omitempty"`
+	RequestID  string         `json:"requestId,omitempty"`
+	DomainName string         `json:"domainName,omitempty"`
+	Claims     map[string]any `json:"claims,omitempty"`
+}
+
+type requestContextKey struct{}
+
+// NewContext returns context carrying the request context
+func NewContext(ctx context.Context, rc *RequestContext) context.Context {
+	return context.WithValue(ctx, requestContextKey{}, rc)
+}
+
+// FromContext returns the request context, false if it is not propagated
+func FromContext(ctx context.Context) (*RequestContext, bool) {
+	rc, ok := ctx.Value(requestContextKey{}).(*RequestContext)
+	return rc, ok && rc != nil
+}
+
+// FromMeta decodes the request context from `_meta` of MCP request
+func FromMeta(meta map[string]any) (*RequestContext, bool) {
+	val, has := meta[MetaKey]
+	if !has {
+		return nil, false
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, false
+	}
+
+	var rc RequestContext
+	if err := json.Unmarshal(data, &rc); err != nil {
+		return nil, false
+	}
+
+	return &rc, true
+}
+
+// Middleware passes the request context from `_meta` of MCP requests into
+// context of handlers, it is installed by the lambda runtime.
+func Middleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			// params of methods might be typed nil (e.g. tools/list)
+			params := req.GetParams()
+			if params == nil || reflect.ValueOf(params).IsNil() {
+				return next(ctx, method, req)
+			}
+
+			if rc, ok := FromMeta(params.GetMeta()); ok {
+				ctx = NewContext(ctx, rc)
+			}
+
+			return next(ctx, method, req)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package store gives tool handlers typed access to storages provisioned by
+// the builder: Aurora cluster via RDS Data API (see
+// cloudmcp.Gateway.WithAurora), DynamoDB tables (see
+// cloudmcp.Gateway.WithTable) and S3 buckets (see cloudmcp.Gateway.WithBucket).
+//
+//	type Order struct {
+//		ID     string  `json:"id"`
+//		Amount float64 `json:"amount"`
+//	}
+//
+//	func Tool(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, Output, error) {
+//		db, err := store.DatabaseFromEnv(ctx)
+//		if err != nil {
+//			return nil, Output{}, err
+//		}
+//
+//		orders, err := store.Query[Order](ctx, db,
+//			"SELECT id, amount FROM orders WHERE customer = :customer",
+//			store.Params{"customer": in.Customer},
+//		)
+//		...
+//	}
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/rdsdata"
+	"github.com/aws/aws-sdk-go-v2/service/rdsdata/types"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+)
+
+// Environment variables with coordinates of the database, injected by builder
+const (
+	EnvDatabaseCluster = envvar.DatabaseCluster
+	EnvDatabaseSecret  = envvar.DatabaseSecret
+	EnvDatabaseName    = envvar.DatabaseName
+)
+
+// DataAPI interface required by the database client
+type DataAPI interface {
+	ExecuteStatement(context.Context, *rdsdata.ExecuteStatementInput, ...func(*rdsdata.Options)) (*rdsdata.ExecuteStatementOutput, error)
+}
+
+// Params of SQL statement, referenced as :name
+type Params map[string]any
+
+// Database is the client of Aurora cluster via RDS Data API
+type Database struct {
+	api      DataAPI
+	cluster  string
+	secret   string
+	database string
+}
+
+// NewDatabase creates client of the database within the cluster
+func NewDatabase(api DataAPI, cluster, secret, database string) *Database {
+	return &Database{api: api, cluster: cluster, secret: secret, database: database}
+}
+
+var (
+	defaultDatabase *Database
+	defaultOnce     sync.Once
+	defaultErr      error
+)
+
+// DatabaseFromEnv returns client of the database provisioned by builder,
+// the client is shared within the lambda.
+func DatabaseFromEnv(ctx context.Context) (*Database, error) {
+	defaultOnce.Do(func() {
+		cluster, has := os.LookupEnv(EnvDatabaseCluster)
+		if !has {
+			defaultErr = errors.New("database is not configured")
+			return
+		}
+
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			defaultErr = err
+			return
+		}
+
+		defaultDatabase = NewDatabase(rdsdata.NewFromConfig(cfg), cluster, os.Getenv(EnvDatabaseSecret), os.Getenv(EnvDatabaseName))
+	})
+
+	return defaultDatabase, defaultErr
+}
+
+// Exec executes the statement, it returns number of affected rows
+func (db *Database) Exec(ctx context.Context, sql string, params Params) (int64, error) {
+	out, err := db.execute(ctx, sql, params, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return out.NumberOfRecordsUpdated, nil
+}
+
+// Query executes the statement, rows are decoded into T using JSON codec,
+// columns are mapped to fields by names.
+func Query[T any](ctx context.Context, db *Database, sql string, params Params) ([]T, error) {
+	out, err := db.execute(ctx, sql, params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	seq := []T{}
+	if out.FormattedRecords == nil {
+		return seq, nil
+	}
+
+	if err := json.Unmarshal([]byte(*out.FormattedRecords), &seq); err != nil {
+		return nil, fmt.Errorf("failed to decode records: %w", err)
+	}
+
+	return seq, nil
+}
+
+func (db *Database) execute(ctx context.Context, sql string, params Params, records bool) (*rdsdata.ExecuteStatementOutput, error) {
+	input := &rdsdata.ExecuteStatementInput{
+		ResourceArn: aws.String(db.cluster),
+		SecretArn:   aws.String(db.secret),
+		Sql:         aws.String(sql),
+	}
+	if db.database != "" {
+		input.Database = aws.String(db.database)
+	}
+	if records {
+		input.FormatRecordsAs = types.RecordsFormatTypeJson
+	}
+
+	for name, val := range params {
+		param, err := sqlParameter(name, val)
+		if err != nil {
+			return nil, err
+		}
+		input.Parameters = append(input.Parameters, param)
+	}
+
+	return db.api.ExecuteStatement(ctx, input)
+}
+
+func sqlParameter(name string, val any) (types.SqlParameter, error) {
+	param := types.SqlParameter{Name: aws.String(name)}
+
+	switch v := val.(type) {
+	case nil:
+		param.Value = &types.FieldMemberIsNull{Value: true}
+	case string:
+		param.Value = &types.FieldMemberStringValue{Value: v}
+	case bool:
+		param.Value = &types.FieldMemberBooleanValue{Value: v}
+	case int:
+		param.Value = &types.FieldMemberLongValue{Value: int64(v)}
+	case int32:
+		param.Value = &types.FieldMemberLongValue{Value: int64(v)}
+	case int64:
+		param.Value = &types.FieldMemberLongValue{Value: v}
+	case float32:
+		param.Value = &types.FieldMemberDoubleValue{Value: float64(v)}
+	case float64:
+		param.Value = &types.FieldMemberDoubleValue{Value: v}
+	case []byte:
+		param.Value = &types.FieldMemberBlobValue{Value: v}
+	case time.Time:
+		param.Value = &types.FieldMemberStringValue{Value: v.UTC().Format("2006-01-02 15:04:05.000")}
+		param.TypeHint = types.TypeHintTimestamp
+	default:
+		return param, fmt.Errorf("unsupported type %T of parameter %s", val, name)
+	}
+
+	return param, nil
+}
@@ -0,0 +1,116 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+)
+
+// Environment variable with name of the bucket, injected by builder.
+// The bucket "files" is defined by CONFIG_CLOUDMCP_BUCKET_FILES.
+const EnvBucket = envvar.Bucket
+
+// Presigner interface required by the bucket client
+type Presigner interface {
+	PresignGetObject(context.Context, *s3.GetObjectInput, ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+	PresignPutObject(context.Context, *s3.PutObjectInput, ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+// Bucket is the client of S3 bucket, it issues presigned URLs so that files
+// are exchanged with clients directly, bypassing the server and limits of
+// API Gateway payload.
+type Bucket struct {
+	api    Presigner
+	bucket string
+}
+
+// NewBucket creates client of the bucket
+func NewBucket(api Presigner, bucket string) *Bucket {
+	return &Bucket{api: api, bucket: bucket}
+}
+
+var (
+	defaultPresigner Presigner
+	defaultS3Once    sync.Once
+	defaultS3Err     error
+)
+
+// BucketFromEnv returns client of the bucket provisioned by builder with
+// Gateway.WithBucket(name, ...), the connection is shared within the lambda.
+func BucketFromEnv(ctx context.Context, name string) (*Bucket, error) {
+	bucket, has := os.LookupEnv(EnvBucket + envName(name))
+	if !has {
+		return nil, fmt.Errorf("bucket %s is not configured", name)
+	}
+
+	defaultS3Once.Do(func() {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			defaultS3Err = err
+			return
+		}
+
+		defaultPresigner = s3.NewPresignClient(s3.NewFromConfig(cfg))
+	})
+	if defaultS3Err != nil {
+		return nil, defaultS3Err
+	}
+
+	return NewBucket(defaultPresigner, bucket), nil
+}
+
+// Name of the bucket
+func (b *Bucket) Name() string { return b.bucket }
+
+// DownloadURL issues URL to read the object with HTTP GET, the URL expires
+// after ttl.
+func (b *Bucket) DownloadURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	req, err := b.api.PresignGetObject(ctx,
+		&s3.GetObjectInput{
+			Bucket: aws.String(b.bucket),
+			Key:    aws.String(key),
+		},
+		s3.WithPresignExpires(ttl),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download of %s: %w", key, err)
+	}
+
+	return req.URL, nil
+}
+
+// UploadURL issues URL to write the object with HTTP PUT, the URL expires
+// after ttl. The client must send Content-Type header equal to contentType
+// if it is defined.
+func (b *Bucket) UploadURL(ctx context.Context, key, contentType string, ttl time.Duration) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	req, err := b.api.PresignPutObject(ctx, input, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign upload of %s: %w", key, err)
+	}
+
+	return req.URL, nil
+}
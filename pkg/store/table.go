@@ -0,0 +1,242 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+)
+
+// Environment variables with coordinates of tables, injected by builder.
+// The table "orders" is defined by CONFIG_CLOUDMCP_TABLE_ORDERS (name of
+// the table) and CONFIG_CLOUDMCP_TABLE_ORDERS_KEYS (partition and sort keys).
+const (
+	EnvTable     = envvar.Table
+	EnvTableKeys = "_KEYS"
+)
+
+// ErrNotFound is returned when item does not exist
+var ErrNotFound = errors.New("not found")
+
+// DynamoDB interface required by the table client
+type DynamoDB interface {
+	GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// Table is the typed client of DynamoDB table. Items are encoded using
+// `dynamodbav` struct tags, keys are attributes of T named by the table
+// key schema.
+//
+//	type Order struct {
+//		Customer string  `dynamodbav:"id"`
+//		Order    string  `dynamodbav:"sk"`
+//		Amount   float64 `dynamodbav:"amount,omitempty"`
+//	}
+type Table[T any] struct {
+	api   DynamoDB
+	table string
+	keys  []string
+}
+
+// NewTable creates client of the table, keys are partition key and optional
+// sort key of the table.
+func NewTable[T any](api DynamoDB, table string, keys ...string) *Table[T] {
+	return &Table[T]{api: api, table: table, keys: keys}
+}
+
+var (
+	defaultDynamoDB DynamoDB
+	defaultDdbOnce  sync.Once
+	defaultDdbErr   error
+)
+
+// TableFromEnv returns client of the table provisioned by builder with
+// Gateway.WithTable(name, ...), the connection is shared within the lambda.
+func TableFromEnv[T any](ctx context.Context, name string) (*Table[T], error) {
+	env := EnvTable + envName(name)
+
+	table, has := os.LookupEnv(env)
+	if !has {
+		return nil, fmt.Errorf("table %s is not configured", name)
+	}
+
+	defaultDdbOnce.Do(func() {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			defaultDdbErr = err
+			return
+		}
+
+		defaultDynamoDB = dynamodb.NewFromConfig(cfg)
+	})
+	if defaultDdbErr != nil {
+		return nil, defaultDdbErr
+	}
+
+	keys := strings.Split(os.Getenv(env+EnvTableKeys), ",")
+	if keys[0] == "" {
+		return nil, fmt.Errorf("keys of table %s are not configured", name)
+	}
+
+	return NewTable[T](defaultDynamoDB, table, keys...), nil
+}
+
+// normalizes name of the table or bucket for environment variable, e.g. "user-orders"
+// is USER_ORDERS
+func envName(name string) string {
+	return strings.Map(
+		func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+				return r
+			default:
+				return '_'
+			}
+		},
+		name,
+	)
+}
+
+// Get reads the item, the key is T with key attributes defined
+func (t *Table[T]) Get(ctx context.Context, key T) (T, error) {
+	var item T
+
+	k, err := t.key(key, len(t.keys))
+	if err != nil {
+		return item, err
+	}
+
+	out, err := t.api.GetItem(ctx,
+		&dynamodb.GetItemInput{
+			TableName: aws.String(t.table),
+			Key:       k,
+		},
+	)
+	if err != nil {
+		return item, err
+	}
+
+	if len(out.Item) == 0 {
+		return item, ErrNotFound
+	}
+
+	if err := attributevalue.UnmarshalMap(out.Item, &item); err != nil {
+		return item, fmt.Errorf("failed to decode item: %w", err)
+	}
+
+	return item, nil
+}
+
+// Put writes the item, existing item is replaced
+func (t *Table[T]) Put(ctx context.Context, item T) error {
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return fmt.Errorf("failed to encode item: %w", err)
+	}
+
+	_, err = t.api.PutItem(ctx,
+		&dynamodb.PutItemInput{
+			TableName: aws.String(t.table),
+			Item:      av,
+		},
+	)
+
+	return err
+}
+
+// Remove deletes the item, the key is T with key attributes defined
+func (t *Table[T]) Remove(ctx context.Context, key T) error {
+	k, err := t.key(key, len(t.keys))
+	if err != nil {
+		return err
+	}
+
+	_, err = t.api.DeleteItem(ctx,
+		&dynamodb.DeleteItemInput{
+			TableName: aws.String(t.table),
+			Key:       k,
+		},
+	)
+
+	return err
+}
+
+// Query reads all items sharing the partition key of T, items are ordered
+// by sort key.
+func (t *Table[T]) Query(ctx context.Context, key T) ([]T, error) {
+	k, err := t.key(key, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(t.table),
+		KeyConditionExpression:    aws.String("#pk = :pk"),
+		ExpressionAttributeNames:  map[string]string{"#pk": t.keys[0]},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":pk": k[t.keys[0]]},
+	}
+
+	seq := []T{}
+	for {
+		out, err := t.api.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		page := []T{}
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, fmt.Errorf("failed to decode items: %w", err)
+		}
+		seq = append(seq, page...)
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return seq, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// extracts first n key attributes of the table from T
+func (t *Table[T]) key(val T, n int) (map[string]types.AttributeValue, error) {
+	if len(t.keys) < n || n == 0 {
+		return nil, fmt.Errorf("keys of table %s are not defined", t.table)
+	}
+
+	av, err := attributevalue.MarshalMap(val)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	key := make(map[string]types.AttributeValue, n)
+	for _, name := range t.keys[:n] {
+		v, has := av[name]
+		if !has {
+			return nil, fmt.Errorf("key attribute %s is missing", name)
+		}
+		key[name] = v
+	}
+
+	return key, nil
+}
@@ -0,0 +1,62 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package tenancy exposes the tenant of the caller to tool handlers. The
+// tenant is resolved by the gateway from JWT claim or API key mapping (see
+// cloudmcp.Gateway.Tenancy).
+//
+//	func Tool(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, Output, error) {
+//		tenant, ok := tenancy.FromContext(ctx)
+//		if !ok {
+//			return nil, Output{}, mcperr.Unauthorized("tenant is required")
+//		}
+//		...
+//	}
+package tenancy
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Header carrying tenant of the caller, the gateway sets it for every
+// tools/call, the value supplied by clients is discarded.
+const Header = "Mcp-Tenant-Id"
+
+type tenantKey struct{}
+
+// NewContext returns context carrying the tenant
+func NewContext(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// FromContext returns the tenant of the caller
+func FromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// Middleware passes the tenant of tools/call request into context of tool
+// handler, it is installed by the lambda runtime.
+func Middleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			extra := req.GetExtra()
+			if method != "tools/call" || extra == nil || extra.Header == nil {
+				return next(ctx, method, req)
+			}
+
+			if tenant := extra.Header.Get(Header); tenant != "" {
+				ctx = NewContext(ctx, tenant)
+			}
+
+			return next(ctx, method, req)
+		}
+	}
+}
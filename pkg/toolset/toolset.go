@@ -0,0 +1,150 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package toolset groups tools, prompts and resources into reusable sets,
+// maintained as libraries in separate Go packages. The Factory assembles
+// the server per deployment from the sets it needs.
+//
+//	package wiki
+//
+//	var Tools = toolset.New("wiki")
+//
+//	func init() {
+//		toolset.AddTool(Tools, &mcp.Tool{Name: "search_wiki"}, Search)
+//	}
+//
+//	package server
+//
+//	func Knowledge() (*mcp.Server, error) {
+//		return toolset.Compose(
+//			&mcp.Implementation{Name: "knowledge", Version: "v1.0.0"},
+//			wiki.Tools,
+//			jira.Tools,
+//		)
+//	}
+//
+// The package does not depend on AWS CDK, unlike the package cloudmcp,
+// therefore libraries and factories use it without bloating the lambda.
+package toolset
+
+import (
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ToolSet is a named group of tools, prompts and resources
+type ToolSet struct {
+	name      string
+	tools     []string
+	prompts   []string
+	resources []string
+	installs  []func(*mcp.Server)
+}
+
+// New creates empty set
+func New(name string) *ToolSet {
+	return &ToolSet{name: name}
+}
+
+// Name of the set
+func (set *ToolSet) Name() string { return set.name }
+
+// Tools returns names of tools in the set
+func (set *ToolSet) Tools() []string { return set.tools }
+
+// AddTool adds the tool and its typed handler to the set, see mcp.AddTool
+func AddTool[In, Out any](set *ToolSet, tool *mcp.Tool, handler mcp.ToolHandlerFor[In, Out]) {
+	set.tools = append(set.tools, tool.Name)
+	set.installs = append(set.installs, func(server *mcp.Server) {
+		mcp.AddTool(server, tool, handler)
+	})
+}
+
+// AddPrompt adds the prompt and its handler to the set
+func (set *ToolSet) AddPrompt(prompt *mcp.Prompt, handler mcp.PromptHandler) {
+	set.prompts = append(set.prompts, prompt.Name)
+	set.installs = append(set.installs, func(server *mcp.Server) {
+		server.AddPrompt(prompt, handler)
+	})
+}
+
+// AddResource adds the resource and its handler to the set
+func (set *ToolSet) AddResource(resource *mcp.Resource, handler mcp.ResourceHandler) {
+	set.resources = append(set.resources, resource.URI)
+	set.installs = append(set.installs, func(server *mcp.Server) {
+		server.AddResource(resource, handler)
+	})
+}
+
+// AddResourceTemplate adds the resource template and its handler to the set
+func (set *ToolSet) AddResourceTemplate(template *mcp.ResourceTemplate, handler mcp.ResourceHandler) {
+	set.resources = append(set.resources, template.URITemplate)
+	set.installs = append(set.installs, func(server *mcp.Server) {
+		server.AddResourceTemplate(template, handler)
+	})
+}
+
+// Install sets into the server. Names of tools and prompts, and URIs of
+// resources must be unique across sets, the server would silently replace
+// the definition otherwise.
+func Install(server *mcp.Server, sets ...*ToolSet) error {
+	if err := unique(sets); err != nil {
+		return err
+	}
+
+	for _, set := range sets {
+		for _, install := range set.installs {
+			install(server)
+		}
+	}
+
+	return nil
+}
+
+// Compose creates the server from sets, it is used by Factory.
+func Compose(impl *mcp.Implementation, sets ...*ToolSet) (*mcp.Server, error) {
+	server := mcp.NewServer(impl, nil)
+	if err := Install(server, sets...); err != nil {
+		return nil, err
+	}
+
+	return server, nil
+}
+
+func unique(sets []*ToolSet) error {
+	seen := map[string]string{}
+	check := func(kind, name, set string) error {
+		key := kind + " " + name
+		if other, has := seen[key]; has {
+			return fmt.Errorf("%s is defined by sets %s and %s", key, other, set)
+		}
+		seen[key] = set
+		return nil
+	}
+
+	for _, set := range sets {
+		for _, name := range set.tools {
+			if err := check("tool", name, set.name); err != nil {
+				return err
+			}
+		}
+		for _, name := range set.prompts {
+			if err := check("prompt", name, set.name); err != nil {
+				return err
+			}
+		}
+		for _, uri := range set.resources {
+			if err := check("resource", uri, set.name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package upload reads files uploaded by clients for tools (see
+// cloudmcp.Gateway.FileUploads). Large files do not fit the tool arguments,
+// the client requests presigned POST form from the gateway
+// `POST {endpoint}/uploads`, uploads the file directly to S3 bucket and
+// passes the key of the object to the tool. Objects are bound to the caller
+// and the session, the tool reads only objects uploaded by its caller.
+//
+//	type Input struct {
+//		File string `json:"file" jsonschema:"key of uploaded file"`
+//	}
+//
+//	func Tool(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, Output, error) {
+//		file, err := upload.Open(ctx, req, in.File)
+//		if err != nil {
+//			return nil, Output{}, mcperr.InvalidInput(err.Error())
+//		}
+//		defer file.Body.Close()
+//		...
+//	}
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// EnvBucket defines the bucket of uploaded files, injected by builder
+const EnvBucket = envvar.UploadBucket
+
+// Header carrying the key prefix of objects uploaded by the caller, the
+// gateway sets it for every tools/call, the value supplied by clients is
+// discarded.
+const Header = "Mcp-Upload-Prefix"
+
+// ErrNotAllowed is returned when the object is not uploaded by the caller
+var ErrNotAllowed = errors.New("file is not uploaded by the caller")
+
+// S3 interface required to read uploaded objects
+type S3 interface {
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// Object uploaded by the client, the body must be closed
+type Object struct {
+	Key         string
+	ContentType string
+	Size        int64
+	Body        io.ReadCloser
+}
+
+var (
+	defaultS3     S3
+	defaultS3Once sync.Once
+	defaultS3Err  error
+)
+
+// Open the object uploaded by the caller of the tool, the connection is
+// shared within the lambda.
+func Open(ctx context.Context, req *mcp.CallToolRequest, key string) (*Object, error) {
+	bucket, has := os.LookupEnv(EnvBucket)
+	if !has {
+		return nil, errors.New("uploads are not configured")
+	}
+
+	defaultS3Once.Do(func() {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			defaultS3Err = err
+			return
+		}
+
+		defaultS3 = s3.NewFromConfig(cfg)
+	})
+	if defaultS3Err != nil {
+		return nil, defaultS3Err
+	}
+
+	return OpenWith(ctx, defaultS3, bucket, req, key)
+}
+
+// OpenWith opens the object uploaded by the caller using given client
+func OpenWith(ctx context.Context, api S3, bucket string, req *mcp.CallToolRequest, key string) (*Object, error) {
+	if !IsAllowed(req, key) {
+		return nil, ErrNotAllowed
+	}
+
+	out, err := api.GetObject(ctx,
+		&s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", key, err)
+	}
+
+	return &Object{
+		Key:         key,
+		ContentType: aws.ToString(out.ContentType),
+		Size:        aws.ToInt64(out.ContentLength),
+		Body:        out.Body,
+	}, nil
+}
+
+// Read the object uploaded by the caller of the tool, no larger than max bytes
+func Read(ctx context.Context, req *mcp.CallToolRequest, key string, max int64) ([]byte, error) {
+	obj, err := Open(ctx, req, key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Body.Close()
+
+	if obj.Size > max {
+		return nil, fmt.Errorf("file %s is too large (%d bytes)", key, obj.Size)
+	}
+
+	return io.ReadAll(io.LimitReader(obj.Body, max))
+}
+
+// IsAllowed checks if the object is uploaded by the caller of the tool
+func IsAllowed(req *mcp.CallToolRequest, key string) bool {
+	extra := req.GetExtra()
+	if extra == nil || extra.Header == nil {
+		return false
+	}
+
+	prefix := extra.Header.Get(Header)
+	return prefix != "" && strings.HasPrefix(key, prefix) && !strings.Contains(key, "..")
+}
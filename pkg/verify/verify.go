@@ -0,0 +1,229 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package verify smoke tests the deployed MCP server, useful as post-deploy
+// gate of CI. Each authentication mode runs initialize, tools/list and
+// optional tools/call, the report is pass/fail matrix of modes and steps.
+//
+//	transport, err := auth.NewTransportApiKey(auth.ConfigApiKey{...})
+//
+//	report := verify.Run(ctx,
+//		verify.Config{Tool: "sayer", Input: json.RawMessage(`{"name":"world"}`)},
+//		verify.Mode{Name: "apikey", Transport: transport},
+//		verify.Mode{Name: "anonymous", Transport: &mcp.StreamableClientTransport{Endpoint: url}, Rejected: true},
+//	)
+//	report.WriteTo(os.Stdout)
+//	if !report.Passed() {
+//		os.Exit(1)
+//	}
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Steps of the smoke test
+const (
+	StepInitialize = "initialize"
+	StepToolsList  = "tools/list"
+	StepToolsCall  = "tools/call"
+)
+
+// Mode of authentication, the transport is configured with credentials
+// (e.g. using github.com/fogfish/cloudmcp/pkg/auth).
+type Mode struct {
+	Name      string
+	Transport mcp.Transport
+
+	// The server is expected to reject the client (e.g. anonymous access to
+	// protected server), the mode passes if initialize fails.
+	Rejected bool
+}
+
+// Config of the smoke test
+type Config struct {
+	// Name of the tool to call, the call is skipped if empty
+	Tool string
+
+	// Arguments of the tool, defaults to empty object
+	Input json.RawMessage
+
+	// Timeout of each mode, defaults to 30 seconds
+	Timeout time.Duration
+}
+
+// Result of the step
+type Result struct {
+	Mode     string
+	Step     string
+	Passed   bool
+	Duration time.Duration
+	Err      error
+}
+
+// Report of the smoke test
+type Report []Result
+
+// Passed is true if all steps of all modes are passed
+func (r Report) Passed() bool {
+	for _, x := range r {
+		if !x.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes pass/fail matrix of modes and steps, followed by errors
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	steps := []string{StepInitialize, StepToolsList, StepToolsCall}
+	modes := []string{}
+	cells := map[[2]string]Result{}
+	for _, x := range r {
+		if _, has := cells[[2]string{x.Mode, StepInitialize}]; !has && x.Step == StepInitialize {
+			modes = append(modes, x.Mode)
+		}
+		cells[[2]string{x.Mode, x.Step}] = x
+	}
+
+	cw := &countWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "MODE")
+	for _, step := range steps {
+		fmt.Fprintf(tw, "\t%s", step)
+	}
+	fmt.Fprintln(tw)
+
+	for _, mode := range modes {
+		fmt.Fprint(tw, mode)
+		for _, step := range steps {
+			x, has := cells[[2]string{mode, step}]
+			switch {
+			case !has:
+				fmt.Fprint(tw, "\t-")
+			case x.Passed:
+				fmt.Fprintf(tw, "\tPASS %s", x.Duration.Round(time.Millisecond))
+			default:
+				fmt.Fprint(tw, "\tFAIL")
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return cw.n, err
+	}
+
+	for _, x := range r {
+		if !x.Passed && x.Err != nil {
+			fmt.Fprintf(cw, "\n%s %s: %s", x.Mode, x.Step, x.Err)
+		}
+	}
+	if !r.Passed() {
+		fmt.Fprintln(cw)
+	}
+
+	return cw.n, cw.err
+}
+
+// Run the smoke test for each mode
+func Run(ctx context.Context, cfg Config, modes ...Mode) Report {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if len(cfg.Input) == 0 {
+		cfg.Input = json.RawMessage(`{}`)
+	}
+
+	report := Report{}
+	for _, mode := range modes {
+		report = append(report, run(ctx, cfg, mode)...)
+	}
+	return report
+}
+
+func run(ctx context.Context, cfg Config, mode Mode) []Result {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "cloudmcp-verify", Version: "v1.0.0"}, nil)
+
+	t := time.Now()
+	session, err := client.Connect(ctx, mode.Transport, nil)
+	if mode.Rejected {
+		if err == nil {
+			session.Close()
+			err = fmt.Errorf("access is not rejected")
+		} else {
+			err = nil
+		}
+		return []Result{result(mode, StepInitialize, t, err)}
+	}
+	if err != nil {
+		return []Result{result(mode, StepInitialize, t, err)}
+	}
+	defer session.Close()
+
+	seq := []Result{result(mode, StepInitialize, t, nil)}
+
+	t = time.Now()
+	_, err = session.ListTools(ctx, nil)
+	seq = append(seq, result(mode, StepToolsList, t, err))
+
+	if cfg.Tool != "" {
+		t = time.Now()
+		out, err := session.CallTool(ctx, &mcp.CallToolParams{Name: cfg.Tool, Arguments: cfg.Input})
+		if err == nil && out.IsError {
+			err = fmt.Errorf("tool %s failed: %s", cfg.Tool, text(out))
+		}
+		seq = append(seq, result(mode, StepToolsCall, t, err))
+	}
+
+	return seq
+}
+
+func result(mode Mode, step string, t time.Time, err error) Result {
+	return Result{
+		Mode:     mode.Name,
+		Step:     step,
+		Passed:   err == nil,
+		Duration: time.Since(t),
+		Err:      err,
+	}
+}
+
+func text(out *mcp.CallToolResult) string {
+	for _, c := range out.Content {
+		if t, ok := c.(*mcp.TextContent); ok {
+			return t.Text
+		}
+	}
+	return "unknown error"
+}
+
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
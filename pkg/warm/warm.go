@@ -0,0 +1,102 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package warm preloads expensive dependencies of tools (models, clients,
+// caches) during the init phase of the lambda, so that the first invocation
+// does not pay for them. The Factory is called once per execution
+// environment, tools register warmers while the server is constructed and
+// the runtime runs them before it accepts requests.
+//
+//	func HelloWorld() (*mcp.Server, error) {
+//		tool := &Search{}
+//		warm.Register(tool)
+//
+//		server := mcp.NewServer(...)
+//		mcp.AddTool(server, &mcp.Tool{Name: "search"}, tool.Call)
+//		return server, nil
+//	}
+//
+//	func (s *Search) Warm(ctx context.Context) error {
+//		index, err := loadIndex(ctx)
+//		if err != nil {
+//			return err
+//		}
+//		s.index = index
+//		return nil
+//	}
+package warm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Warmer preloads dependencies of the tool
+type Warmer interface {
+	Warm(context.Context) error
+}
+
+// Func is an adapter to use ordinary functions as Warmer
+type Func func(context.Context) error
+
+func (f Func) Warm(ctx context.Context) error { return f(ctx) }
+
+var (
+	mu      sync.Mutex
+	warmers []Warmer
+)
+
+// Register warmers, they are run once by the runtime before the first
+// invocation. Register is safe for concurrent use.
+func Register(ws ...Warmer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	warmers = append(warmers, ws...)
+}
+
+// Run registered warmers concurrently and waits for completion. Warmers are
+// run once, the following calls are no-op unless new warmers are registered.
+// The failure of any warmer fails the init phase of the lambda.
+func Run(ctx context.Context) error {
+	mu.Lock()
+	ws := warmers
+	warmers = nil
+	mu.Unlock()
+
+	if len(ws) == 0 {
+		return nil
+	}
+
+	t := time.Now()
+	errs := make([]error, len(ws))
+
+	var wg sync.WaitGroup
+	for i, w := range ws {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = w.Warm(ctx)
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "tools are warmed up",
+		"warmers", len(ws),
+		"duration", float64(time.Since(t).Microseconds())/1000,
+	)
+
+	return nil
+}
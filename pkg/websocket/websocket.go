@@ -0,0 +1,123 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+// Package websocket pushes server-initiated messages (notifications,
+// sampling or elicitation requests) to MCP clients connected via WebSocket
+// API Gateway (see cloudmcp.Gateway.WebSocket).
+//
+//	func Tool(ctx context.Context, req *mcp.CallToolRequest, in Input) (*mcp.CallToolResult, Output, error) {
+//		if conn, ok := websocket.ConnectionID(req.Extra); ok {
+//			websocket.Notify(ctx, conn, "notifications/message", &mcp.LoggingMessageParams{...})
+//		}
+//		...
+//	}
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewaymanagementapi"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewaymanagementapi/types"
+	"github.com/fogfish/cloudmcp/internal/envvar"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Environment variable with callback URL of WebSocket API, injected by builder
+const EnvEndpoint = envvar.WebSocketEndpoint
+
+// Header carrying connection id of the client
+const headerConnectionID = "Mcp-Connection-Id"
+
+// ErrGone is returned if the client is disconnected
+var ErrGone = errors.New("websocket connection is gone")
+
+// ConnectionID returns WebSocket connection of the client, if the request
+// is received via WebSocket API.
+func ConnectionID(extra *mcp.RequestExtra) (string, bool) {
+	if extra == nil {
+		return "", false
+	}
+
+	conn := http.Header(extra.Header).Get(headerConnectionID)
+	return conn, conn != ""
+}
+
+// Send the message to the client
+func Send(ctx context.Context, connection string, msg jsonrpc.Message) error {
+	data, err := jsonrpc.EncodeMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	api, err := client(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = api.PostToConnection(ctx,
+		&apigatewaymanagementapi.PostToConnectionInput{
+			ConnectionId: aws.String(connection),
+			Data:         data,
+		},
+	)
+
+	var gone *types.GoneException
+	if errors.As(err, &gone) {
+		return ErrGone
+	}
+
+	return err
+}
+
+// Notify the client, sending JSON-RPC notification
+func Notify(ctx context.Context, connection string, method string, params any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	return Send(ctx, connection, &jsonrpc.Request{Method: method, Params: raw})
+}
+
+var (
+	mu  sync.Mutex
+	api *apigatewaymanagementapi.Client
+)
+
+func client(ctx context.Context) (*apigatewaymanagementapi.Client, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if api != nil {
+		return api, nil
+	}
+
+	endpoint, has := os.LookupEnv(EnvEndpoint)
+	if !has {
+		return nil, errors.New("websocket endpoint is not configured")
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	api = apigatewaymanagementapi.NewFromConfig(cfg, func(o *apigatewaymanagementapi.Options) {
+		o.BaseEndpoint = aws.String(endpoint)
+	})
+
+	return api, nil
+}
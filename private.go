@@ -0,0 +1,128 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsapigateway"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Configures private REST API reachable only through given interface VPC
+// endpoints (com.amazonaws.{region}.execute-api), for servers exposing
+// internal data to in-VPC agent platforms. HTTP API does not support private
+// endpoints, the private mode deploys REST API instead of it, the resource
+// policy denies requests from other sources. It replaces Host or Hostless and
+// must be configured before access options. The endpoint is the boundary
+// of AccessPublic, AccessAwsIAM additionally requires signed requests. Other
+// access options, custom domain, edge, WebSocket and canary deployments are
+// not supported.
+func (c *Gateway) Private(vpceIds ...string) *Gateway {
+	if len(vpceIds) == 0 {
+		panic("private api requires vpc endpoint")
+	}
+	if c.gateway != nil {
+		panic("private api is exclusive with host")
+	}
+
+	c.private = vpceIds
+	return c
+}
+
+// builds private REST API routing the server's path to the function
+func (c *Gateway) buildPrivate(f awslambda.Function, uri string) {
+	switch {
+	case c.gateway != nil:
+		panic("private api is exclusive with host")
+	case c.authkey != nil, c.authhsh != nil, c.authjwt != nil, c.authjwts != nil, c.authint != nil:
+		panic("private api supports only public and iam access")
+	case c.websocket, c.edge, c.deploy != nil:
+		panic("private api does not support websocket, edge and canary")
+	}
+
+	endpoints := make([]awsec2.IVpcEndpoint, len(c.private))
+	for i, id := range c.private {
+		endpoints[i] = awsec2.InterfaceVpcEndpoint_FromInterfaceVpcEndpointAttributes(c.stack,
+			jsii.String(fmt.Sprintf("PrivateEndpoint%d", i)),
+			&awsec2.InterfaceVpcEndpointAttributes{
+				VpcEndpointId: jsii.String(id),
+				Port:          jsii.Number(443),
+			},
+		)
+	}
+
+	var principal awsiam.IPrincipal = awsiam.NewAnyPrincipal()
+	authorization := awsapigateway.AuthorizationType_NONE
+	if c.grantee != nil {
+		principal = c.grantee.GrantPrincipal()
+		authorization = awsapigateway.AuthorizationType_IAM
+	}
+
+	api := awsapigateway.NewRestApi(c.stack, jsii.String("PrivateGateway"),
+		&awsapigateway.RestApiProps{
+			RestApiName:    jsii.String(c.name()),
+			CloudWatchRole: jsii.Bool(false),
+			EndpointConfiguration: &awsapigateway.EndpointConfiguration{
+				Types:        &[]awsapigateway.EndpointType{awsapigateway.EndpointType_PRIVATE},
+				VpcEndpoints: &endpoints,
+			},
+			Policy: awsiam.NewPolicyDocument(
+				&awsiam.PolicyDocumentProps{
+					Statements: &[]awsiam.PolicyStatement{
+						awsiam.NewPolicyStatement(
+							&awsiam.PolicyStatementProps{
+								Effect:     awsiam.Effect_ALLOW,
+								Principals: &[]awsiam.IPrincipal{principal},
+								Actions:    jsii.Strings("execute-api:Invoke"),
+								Resources:  jsii.Strings("execute-api:/*"),
+							},
+						),
+						awsiam.NewPolicyStatement(
+							&awsiam.PolicyStatementProps{
+								Effect:     awsiam.Effect_DENY,
+								Principals: &[]awsiam.IPrincipal{awsiam.NewAnyPrincipal()},
+								Actions:    jsii.Strings("execute-api:Invoke"),
+								Resources:  jsii.Strings("execute-api:/*"),
+								Conditions: &map[string]any{
+									"StringNotEquals": map[string]any{
+										"aws:SourceVpce": c.private,
+									},
+								},
+							},
+						),
+					},
+				},
+			),
+		},
+	)
+
+	integration := awsapigateway.NewLambdaIntegration(f, nil)
+	options := &awsapigateway.MethodOptions{AuthorizationType: authorization}
+
+	resource := api.Root().ResourceForPath(jsii.String(uri))
+	resource.AddMethod(jsii.String("ANY"), integration, options)
+	resource.AddProxy(
+		&awsapigateway.ProxyResourceOptions{
+			DefaultIntegration:   integration,
+			DefaultMethodOptions: options,
+			AnyMethod:            jsii.Bool(true),
+		},
+	)
+
+	if c.grantee != nil {
+		c.outputPolicyIAM(api, uri)
+	}
+
+	c.output("Host", api.Url())
+	c.output("Endpoint", api.UrlForPath(jsii.String(uri)))
+}
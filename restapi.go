@@ -0,0 +1,146 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsapigateway"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscognito"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Attaches routes and authorizers of the server to pre-existing REST API
+// managed elsewhere, e.g. by platform team for centralized API governance,
+// instead of creating HTTP API. It replaces Host or Hostless and must be
+// configured before access options. The routes are published as deployment
+// of the REST API, the owner of the API promotes it to the stage. Public,
+// IAM, hashed API key and Cognito (without app clients) access are supported.
+// Other access options, custom domain, edge and canary deployments are not.
+func (c *Gateway) UseGateway(restApiId, rootResourceId string) *Gateway {
+	if c.gateway != nil || c.private != nil {
+		panic("imported api is exclusive with host and private api")
+	}
+
+	c.restapi = &restApi{
+		api: awsapigateway.RestApi_FromRestApiAttributes(c.stack, jsii.String("Gateway"),
+			&awsapigateway.RestApiAttributes{
+				RestApiId:      jsii.String(restApiId),
+				RootResourceId: jsii.String(rootResourceId),
+			},
+		),
+		options: &awsapigateway.MethodOptions{
+			AuthorizationType: awsapigateway.AuthorizationType_NONE,
+		},
+	}
+
+	return c
+}
+
+// routes of servers at imported REST API
+type restApi struct {
+	api     awsapigateway.IRestApi
+	options *awsapigateway.MethodOptions
+	methods []awsapigateway.Method
+	paths   []string
+	uris    []string
+}
+
+// authorizes requests with API key validated by lambda authorizer
+func (c *Gateway) restApiKeyHashed(access, hash string) {
+	f := newApiKeyHashedFunction(c.stack, access, hash, c.role)
+
+	c.restapi.options = &awsapigateway.MethodOptions{
+		AuthorizationType: awsapigateway.AuthorizationType_CUSTOM,
+		Authorizer: awsapigateway.NewRequestAuthorizer(c.stack, jsii.String("LambdaAuthorizerApiKey"),
+			&awsapigateway.RequestAuthorizerProps{
+				Handler:         f,
+				IdentitySources: &[]*string{awsapigateway.IdentitySource_Header(jsii.String("Authorization"))},
+				ResultsCacheTtl: awscdk.Duration_Seconds(jsii.Number(0)),
+			},
+		),
+	}
+}
+
+// authorizes requests with tokens of Cognito user pool
+func (c *Gateway) restApiCognito(cognitoArn string, clients ...string) {
+	if len(clients) > 0 {
+		panic("imported api does not validate app clients of cognito")
+	}
+
+	pool := awscognito.UserPool_FromUserPoolArn(c.stack, jsii.String("Cognito"), jsii.String(cognitoArn))
+
+	c.restapi.options = &awsapigateway.MethodOptions{
+		AuthorizationType: awsapigateway.AuthorizationType_COGNITO,
+		Authorizer: awsapigateway.NewCognitoUserPoolsAuthorizer(c.stack, jsii.String("CognitoAuthorizer"),
+			&awsapigateway.CognitoUserPoolsAuthorizerProps{
+				CognitoUserPools: &[]awscognito.IUserPool{pool},
+			},
+		),
+	}
+}
+
+// routes the server's path to the function
+func (c *Gateway) routeRestApi(server *Server) {
+	switch {
+	case c.authkey != nil, c.authjwt != nil, c.authjwts != nil, c.authint != nil:
+		panic("imported api supports only public, iam, hashed api key and cognito access")
+	case c.edge, c.deploy != nil:
+		panic("imported api does not support edge and canary")
+	}
+
+	options := c.restapi.options
+	if c.grantee != nil {
+		options = &awsapigateway.MethodOptions{AuthorizationType: awsapigateway.AuthorizationType_IAM}
+	}
+
+	integration := awsapigateway.NewLambdaIntegration(server.Function, nil)
+
+	resource := c.restapi.api.Root().ResourceForPath(jsii.String(server.uri))
+	proxy := resource.AddResource(jsii.String("{proxy+}"), nil)
+	c.restapi.methods = append(c.restapi.methods,
+		resource.AddMethod(jsii.String("ANY"), integration, options),
+		proxy.AddMethod(jsii.String("ANY"), integration, options),
+	)
+	c.restapi.paths = append(c.restapi.paths, server.uri+" "+string(options.AuthorizationType))
+	c.restapi.uris = append(c.restapi.uris, server.uri)
+}
+
+// routes the health check of the server, it is public
+func (c *Gateway) routeRestApiHealth(server *Server) {
+	resource := c.restapi.api.Root().ResourceForPath(jsii.String(server.uri + "/healthz"))
+	c.restapi.methods = append(c.restapi.methods,
+		resource.AddMethod(jsii.String("GET"), awsapigateway.NewLambdaIntegration(server.Function, nil),
+			&awsapigateway.MethodOptions{AuthorizationType: awsapigateway.AuthorizationType_NONE},
+		),
+	)
+	c.restapi.paths = append(c.restapi.paths, server.uri+"/healthz")
+}
+
+// publishes routes as deployment of the REST API. The deployment is replaced
+// when routes change, the owner of the API promotes it to the stage.
+func (c *Gateway) deployRestApi() {
+	deployment := awsapigateway.NewDeployment(c.stack, jsii.String("Deployment"),
+		&awsapigateway.DeploymentProps{
+			Api:         c.restapi.api,
+			Description: jsii.String(c.name()),
+		},
+	)
+	deployment.AddToLogicalId(jsii.String(strings.Join(c.restapi.paths, ",")))
+	for _, method := range c.restapi.methods {
+		deployment.Node().AddDependency(method)
+	}
+
+	if c.grantee != nil {
+		c.outputPolicyIAM(c.restapi.api, c.restapi.uris...)
+	}
+
+	c.output("DeploymentId", deployment.DeploymentId())
+}
@@ -9,6 +9,7 @@
 package cloudmcp
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -20,11 +21,16 @@ import (
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
 	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/cloudmcp/pkg/config"
+	"github.com/fogfish/cloudmcp/pkg/middleware"
 	"github.com/fogfish/scud"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// Factory is a function that constructs instance of MCP Server.
+// Factory is a function that constructs instance of MCP Server. It is called
+// once during the init phase of the lambda, the server is reused across
+// invocations. Tools preload expensive dependencies with pkg/warm.
 //
 //	func HelloWorld() (*mcp.Server, error) {
 //		server := mcp.NewServer(...)
@@ -33,16 +39,56 @@ import (
 //	}
 type Factory = func() (*mcp.Server, error)
 
+// FactoryWithConfig is a Factory receiving the runtime configuration of the
+// server (stage, environment and secrets), so that construction of the
+// server differs per environment without global state.
+//
+//	func HelloWorld(ctx context.Context, cfg cloudmcp.Config) (*mcp.Server, error) {
+//		api := search.New(cfg.Env["SEARCH_URL"], cfg.Secrets["SEARCH_TOKEN"])
+//		...
+//	}
+//
+// Packages of factories shall use config.Runtime directly, the package
+// cloudmcp links AWS CDK into the lambda otherwise.
+type FactoryWithConfig = func(context.Context, Config) (*mcp.Server, error)
+
+// Config is the runtime configuration of the server, see pkg/config.
+type Config = config.Runtime
+
+// Factories are forms of the factory accepted by builders
+type Factories interface {
+	Factory | FactoryWithConfig
+}
+
+// constructs the server using the factory of any form
+func newServer(ctx context.Context, f any, cfg Config) (*mcp.Server, error) {
+	switch factory := f.(type) {
+	case Factory:
+		return factory()
+	case FactoryWithConfig:
+		return factory(ctx, cfg)
+	}
+
+	panic(fmt.Errorf("unsupported factory %T", f))
+}
+
 // ServerProps defines properties for MCP Server as a Lambda function.
 // This construct assumes a function is running an instance of MCP Server.
 type ServerProps struct {
 	*scud.FunctionGoProps
-	Factory Factory
+	Factory any
 	AutoGen bool
+
+	// Path to Dockerfile, relative to the root of the module, the server
+	// is packaged as container image if defined.
+	Dockerfile string
+
+	// Middlewares applied to every tool call inside the lambda runtime
+	Middlewares []middleware.Middleware
 }
 
 // Helper utility to bind scud.FunctionGoProps with MCP Server Factory.
-func NewServerProps(f Factory, props *scud.FunctionGoProps) *ServerProps {
+func NewServerProps[F Factories](f F, props *scud.FunctionGoProps) *ServerProps {
 	return &ServerProps{
 		FunctionGoProps: props,
 		Factory:         f,
@@ -55,6 +101,22 @@ func (f *ServerProps) ForceAutoGen() *ServerProps {
 	return f
 }
 
+// Package the server as container image, built using the Dockerfile. The
+// root of the module is the build context, the path to the server's main
+// package is passed as build argument LAMBDA. Lambda layers are not
+// supported by container images, they have to be baked into the image.
+func (f *ServerProps) FromImage(dockerfile string) *ServerProps {
+	f.Dockerfile = dockerfile
+	return f
+}
+
+// Applies middlewares to every tool call of the server. Middlewares must be
+// exported top-level functions, the binding code references them by name.
+func (f *ServerProps) Use(mws ...middleware.Middleware) *ServerProps {
+	f.Middlewares = append(f.Middlewares, mws...)
+	return f
+}
+
 // L3 Construct for MCP Server as AWS Lambda function.
 // This construct assumes a function is running an instance of MCP Server.
 type Server struct {
@@ -64,14 +126,98 @@ type Server struct {
 
 // Defines MCP Server as a Lambda function.
 func NewServer(scope constructs.Construct, id *string, spec *ServerProps) *Server {
-	name, path := sautogen(spec.Factory, spec.SourceCodeModule, spec.AutoGen)
+	name, path := sautogen(spec.Factory, spec.Middlewares, spec.SourceCodeModule, spec.AutoGen)
 	uri := "/" + strings.ToLower(name)
-	spec.SourceCodeLambda = filepath.Join(path, serverdir)
+	spec.SourceCodeLambda = path + "/" + serverdir
+
+	if spec.Dockerfile != "" {
+		return &Server{uri: uri, Function: newDockerImageFunction(scope, id, spec)}
+	}
+
 	flambda := scud.NewFunctionGo(scope, id, spec.FunctionGoProps)
 
 	return &Server{uri: uri, Function: flambda}
 }
 
+// Lambda function of container image, props of the server are copied to
+// the function. Lambda does not support layers (incl. extensions configured
+// as layers), code signing, runtime management and SnapStart of container
+// images, the image bundles the runtime and extensions instead.
+func newDockerImageFunction(scope constructs.Construct, id *string, spec *ServerProps) awslambda.Function {
+	props := spec.FunctionProps
+	if props == nil {
+		props = &awslambda.FunctionProps{}
+	}
+
+	switch {
+	case props.Code != nil || props.Handler != nil || props.Runtime != nil:
+		panic("code, handler and runtime of docker image function are defined by the image")
+	case props.Layers != nil, props.InsightsVersion != nil, props.ParamsAndSecrets != nil, props.AdotInstrumentation != nil:
+		panic("docker image function does not support layers, bundle extensions into the image")
+	case props.CodeSigningConfig != nil:
+		panic("docker image function does not support code signing")
+	case props.RuntimeManagementMode != nil:
+		panic("docker image function does not support runtime management")
+	case props.SnapStart != nil:
+		panic("docker image function does not support SnapStart")
+	}
+
+	code := awslambda.DockerImageCode_FromImageAsset(
+		jsii.String(rootSourceCode(spec.SourceCodeModule)),
+		&awslambda.AssetImageCodeProps{
+			File: jsii.String(spec.Dockerfile),
+			BuildArgs: &map[string]*string{
+				"LAMBDA": jsii.String(strings.TrimPrefix(spec.SourceCodeLambda, "/")),
+			},
+		},
+	)
+
+	return awslambda.NewDockerImageFunction(scope, id,
+		&awslambda.DockerImageFunctionProps{
+			Code:                         code,
+			MaxEventAge:                  props.MaxEventAge,
+			OnFailure:                    props.OnFailure,
+			OnSuccess:                    props.OnSuccess,
+			RetryAttempts:                props.RetryAttempts,
+			AllowAllOutbound:             props.AllowAllOutbound,
+			AllowPublicSubnet:            props.AllowPublicSubnet,
+			Architecture:                 props.Architecture,
+			CurrentVersionOptions:        props.CurrentVersionOptions,
+			DeadLetterQueue:              props.DeadLetterQueue,
+			DeadLetterQueueEnabled:       props.DeadLetterQueueEnabled,
+			DeadLetterTopic:              props.DeadLetterTopic,
+			Description:                  props.Description,
+			Environment:                  props.Environment,
+			EnvironmentEncryption:        props.EnvironmentEncryption,
+			EphemeralStorageSize:         props.EphemeralStorageSize,
+			Events:                       props.Events,
+			Filesystem:                   props.Filesystem,
+			FunctionName:                 props.FunctionName,
+			InitialPolicy:                props.InitialPolicy,
+			LoggingFormat:                props.LoggingFormat,
+			LogFormat:                    props.LogFormat,
+			ApplicationLogLevel:          props.ApplicationLogLevel,
+			SystemLogLevel:               props.SystemLogLevel,
+			LogGroup:                     props.LogGroup,
+			LogRemovalPolicy:             props.LogRemovalPolicy,
+			LogRetention:                 props.LogRetention,
+			LogRetentionRetryOptions:     props.LogRetentionRetryOptions,
+			LogRetentionRole:             props.LogRetentionRole,
+			MemorySize:                   props.MemorySize,
+			Profiling:                    props.Profiling,
+			ProfilingGroup:               props.ProfilingGroup,
+			RecursiveLoop:                props.RecursiveLoop,
+			ReservedConcurrentExecutions: props.ReservedConcurrentExecutions,
+			Role:                         props.Role,
+			SecurityGroups:               props.SecurityGroups,
+			Timeout:                      props.Timeout,
+			Tracing:                      props.Tracing,
+			Vpc:                          props.Vpc,
+			VpcSubnets:                   props.VpcSubnets,
+		},
+	)
+}
+
 // Grants public access to the server via given public authorizer.
 func (c *Server) AllowAccessPublic(api *scud.AuthorizerPublic) {
 	api.AddResource(c.uri, c.Function)
@@ -82,6 +228,21 @@ func (c *Server) AllowAccessApiKey(api *scud.AuthorizerBasic) {
 	api.AddResource(c.uri, c.Function)
 }
 
+// Grants access to the server via given hashed API key authorizer.
+func (c *Server) AllowAccessApiKeyHashed(api *AuthorizerApiKeyHashed) {
+	api.AddResource(c.uri, c.Function)
+}
+
+// Grants access to the server via given token introspection authorizer.
+func (c *Server) AllowAccessIntrospection(api *AuthorizerIntrospection) {
+	api.AddResource(c.uri, c.Function)
+}
+
+// Grants access to the server via given multi-issuer JWT authorizer.
+func (c *Server) AllowAccessJwtIssuers(api *AuthorizerJwtIssuers) {
+	api.AddResource(c.uri, c.Function)
+}
+
 // Grants access to the server via given JWT authorizer.
 func (c *Server) AllowAccessJWT(api *scud.AuthorizerJwt, scope ...string) {
 	api.AddResource(c.uri, c.Function, scope...)
@@ -96,7 +257,7 @@ func (c *Server) AllowAccessIAM(api *scud.AuthorizerIAM, principal awsiam.IGrant
 
 const serverdir = "autogen"
 
-func sautogen(f Factory, scModule string, force bool) (string, string) {
+func sautogen(f any, mws []middleware.Middleware, scModule string, force bool) (string, string) {
 	fptr := reflect.ValueOf(f).Pointer()
 	fobj := runtime.FuncForPC(fptr)
 	if fobj == nil {
@@ -107,6 +268,8 @@ func sautogen(f Factory, scModule string, force bool) (string, string) {
 	serv := filepath.Ext(name)[1:]
 	path := strings.TrimSuffix(name, filepath.Ext(name))
 	base := filepath.Base(name)
+	imports, use := mautogen(mws)
+	fimports, call := factoryautogen(f, base)
 
 	code := fmt.Sprintf(`// DO NOT EDIT !!!
 // THE FILE IS AUTO GENERATED BY github.com/fogfish/cloudmcp
@@ -114,19 +277,25 @@ func sautogen(f Factory, scModule string, force bool) (string, string) {
 package main
 
 import (
+	"context"
 	"net/http"
 
   "github.com/aws/aws-lambda-go/lambda"
 	"github.com/fogfish/cloudmcp/internal/gateway"
+	"github.com/fogfish/cloudmcp/pkg/warm"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
-	"%s"
+	"%s"%s
 )
 
 func main() {
-	server, err := %s()
+	%s
 	if err != nil {
 		panic(err)
 	}
+%s
+	if err := gateway.ServerFromEnv(context.Background(), server); err != nil {
+		panic(err)
+	}
 
 	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
 		return server
@@ -135,18 +304,28 @@ func main() {
 		JSONResponse: true,
 	})
 
-	srv := gateway.New(handler)
+	opts, err := gateway.FromEnv(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	srv := gateway.New(handler, opts...)
+
+	if err := warm.Run(context.Background()); err != nil {
+		panic(err)
+	}
 
-	lambda.Start(srv.Serve)
+	lambda.Start(srv.Handle)
 }
-`, time.Now(), path, base)
+`, time.Now(), path, imports+fimports, call, use)
 
 	gofile, _ := fobj.FileLine(fptr)
 	codepath := filepath.Join(filepath.Dir(gofile), serverdir, "main.go")
 
 	if !force {
-		if _, err := os.Stat(codepath); err == nil {
-			// If the file already exists, we assume it has been generated before
+		if file, err := os.ReadFile(codepath); err == nil && isAutogen(file, use, call) {
+			// If the file already exists, we assume it has been generated before,
+			// unless middlewares are changed
 			return serv, strings.TrimPrefix(path, scModule)
 		}
 	}
@@ -162,3 +341,71 @@ func main() {
 
 	return serv, strings.TrimPrefix(path, scModule)
 }
+
+// checks if the binding code is generated by the current version of the
+// template with the same middlewares and form of the factory
+func isAutogen(file []byte, snippets ...string) bool {
+	if !strings.Contains(string(file), "warm.Run(") {
+		return false
+	}
+	for _, snippet := range snippets {
+		if !strings.Contains(string(file), snippet) {
+			return false
+		}
+	}
+	return true
+}
+
+// imports and statements constructing the server using the factory, the
+// configuration is read from environment if the factory requires it
+func factoryautogen(f any, base string) (string, string) {
+	switch f.(type) {
+	case Factory:
+		return "", fmt.Sprintf("server, err := %s()", base)
+	case FactoryWithConfig:
+		return "\n\truntimeconfig \"github.com/fogfish/cloudmcp/pkg/config\"",
+			fmt.Sprintf(`cfg, err := runtimeconfig.RuntimeFromEnv(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	server, err := %s(context.Background(), cfg)`, base)
+	}
+
+	panic(fmt.Errorf("unsupported factory %T", f))
+}
+
+// imports and statement installing middlewares into the server
+func mautogen(mws []middleware.Middleware) (string, string) {
+	if len(mws) == 0 {
+		return "", ""
+	}
+
+	pkgs := map[string]string{}
+	imports := "\n\t\"github.com/fogfish/cloudmcp/pkg/middleware\""
+	refs := make([]string, 0, len(mws))
+
+	for _, mw := range mws {
+		fobj := runtime.FuncForPC(reflect.ValueOf(mw).Pointer())
+		if fobj == nil {
+			panic(fmt.Errorf("failed to discover middleware metadata"))
+		}
+
+		name := fobj.Name()
+		path := strings.TrimSuffix(name, filepath.Ext(name))
+		fn := filepath.Ext(name)[1:]
+		if path == "main" || strings.Contains(filepath.Base(path), ".") || strings.HasPrefix(fn, "func") || strings.HasSuffix(fn, "-fm") {
+			panic(fmt.Errorf("middleware %s is not a top-level function", name))
+		}
+
+		alias, has := pkgs[path]
+		if !has {
+			alias = fmt.Sprintf("mw%d", len(pkgs))
+			pkgs[path] = alias
+			imports += fmt.Sprintf("\n\t%s \"%s\"", alias, path)
+		}
+		refs = append(refs, alias+"."+fn)
+	}
+
+	return imports, fmt.Sprintf("\n\tmiddleware.Install(server, %s)\n", strings.Join(refs, ", "))
+}
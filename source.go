@@ -0,0 +1,229 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Functions are hashed and built by scud from the source code located at
+// GITHUB_WORKSPACE or $GOPATH/src/{package}. Modules cloned outside of GOPATH
+// (the common case) are linked into the private GOPATH of the process.
+
+// root directories of modules discovered by go tooling
+var sourceCodeDirs = map[string]string{}
+
+// private GOPATH, it is defined once modules are linked
+var sourceCodePath string
+
+// modules of functions supplied by libraries (e.g. authorizers)
+var sourceCodeLibs = []string{
+	"github.com/fogfish/scud",
+	"github.com/fogfish/cloudmcp",
+}
+
+// module and package of the factory, relative to the module, e.g.
+// github.com/fogfish/cloudmcp and /examples/helloworld/server. The process
+// exits with the diagnostic listing paths searched if the module is not found.
+func sourcecode(f any) (string, string) {
+	fptr := reflect.ValueOf(f).Pointer()
+	fobj := runtime.FuncForPC(fptr)
+	if fobj == nil {
+		panic(fmt.Errorf("failed to discover function metadata"))
+	}
+
+	name := fobj.Name()
+	path := strings.TrimSuffix(name, filepath.Ext(name))
+
+	module, dir, err := goListModule(path)
+	if err == nil {
+		linkSourceCode(module, dir)
+		return module, strings.TrimPrefix(path, module)
+	}
+	searched := []string{"go list " + path + ": " + err.Error()}
+
+	// falls back to GOPATH layout if go tooling is not available
+	segs := strings.Split(path, "/")
+	for i := len(segs) - 1; i >= 0; i-- {
+		subpath := strings.Join(segs[:i], "/")
+		gomod := rootSourceCode(filepath.Join(subpath, "go.mod"))
+		if _, err := os.Stat(gomod); err == nil {
+			return subpath, strings.Join(segs[i:], "/")
+		}
+		searched = append(searched, gomod)
+	}
+
+	exitSourceCode(
+		fmt.Sprintf("unable to find Go module of the package %s, searched:", path),
+		searched,
+		"Run the application within the module (or go.work workspace) of the factory,",
+		"or configure the module and the package with SourceCode(module, lambda).",
+	)
+	return "", ""
+}
+
+// module of the package and its root directory, as resolved by go tooling
+// within the current module or workspace (go.work), including nested and
+// vendored modules.
+func goListModule(pkg string) (string, string, error) {
+	out, err := exec.Command("go", "list", "-f", "{{.Dir}}\t{{with .Module}}{{.Path}}\t{{.Dir}}{{end}}", pkg).Output()
+	if err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+			return "", "", errors.New(strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", "", err
+	}
+
+	seq := strings.Split(strings.TrimSpace(string(out)), "\t")
+	if len(seq) != 3 || !strings.HasPrefix(pkg, seq[1]) {
+		return "", "", fmt.Errorf("package %s is not in module", pkg)
+	}
+
+	dir, module, root := seq[0], seq[1], seq[2]
+	if root == "" {
+		// vendored module has no directory, it is the prefix of package's one
+		rel := filepath.FromSlash(strings.TrimPrefix(pkg, module))
+		root = strings.TrimSuffix(dir, rel)
+	}
+
+	return module, root, nil
+}
+
+func rootSourceCode(sourceCodeModule string) string {
+	if dir, has := sourceCodeDirs[sourceCodeModule]; has {
+		return dir
+	}
+
+	sourceCode := os.Getenv("GITHUB_WORKSPACE")
+	if sourceCode == "" {
+		sourceCode = filepath.Join(os.Getenv("GOPATH"), "src", filepath.FromSlash(sourceCodeModule))
+	}
+
+	return sourceCode
+}
+
+// links modules of library functions, it is required before any function is
+// defined. Libraries not resolved by go tooling are left for scud.
+func linkSourceCodeLibs() {
+	for _, lib := range sourceCodeLibs {
+		if module, dir, err := goListModule(lib); err == nil {
+			linkSourceCode(module, dir)
+		}
+	}
+}
+
+// makes the module available to scud at $GOPATH/src/{module}. The GOPATH is
+// left as is if it already contains the module (or GITHUB_WORKSPACE is
+// defined), otherwise the private GOPATH is used for all modules.
+func linkSourceCode(module, dir string) {
+	sourceCodeDirs[module] = dir
+
+	if os.Getenv("GITHUB_WORKSPACE") != "" {
+		return
+	}
+
+	if sourceCodePath == "" {
+		if sameDir(filepath.Join(os.Getenv("GOPATH"), "src", filepath.FromSlash(module)), dir) {
+			return
+		}
+		initSourceCodePath()
+		return
+	}
+
+	linkSourceCodeDir(module, dir)
+}
+
+// switches the process to the private GOPATH, linking all modules discovered
+// so far. The module cache is pinned, it is derived from GOPATH otherwise.
+func initSourceCodePath() {
+	gomodcache := os.Getenv("GOMODCACHE")
+	if gomodcache == "" {
+		out, err := exec.Command("go", "env", "GOMODCACHE").Output()
+		if err != nil {
+			exitSourceCode("unable to resolve GOMODCACHE: "+err.Error(), nil,
+				"Define GOMODCACHE (see go env) or GITHUB_WORKSPACE as the root of the module.",
+			)
+		}
+		gomodcache = strings.TrimSpace(string(out))
+	}
+
+	root, err := os.UserCacheDir()
+	if err != nil {
+		root = os.TempDir()
+	}
+	sourceCodePath = filepath.Join(root, "cloudmcp", "gopath")
+
+	for module, dir := range sourceCodeDirs {
+		linkSourceCodeDir(module, dir)
+	}
+
+	os.Setenv("GOMODCACHE", gomodcache)
+	os.Setenv("GOPATH", sourceCodePath)
+}
+
+func linkSourceCodeDir(module, dir string) {
+	link := filepath.Join(sourceCodePath, "src", filepath.FromSlash(module))
+	if sameDir(link, dir) {
+		return
+	}
+
+	// the link of the module cloned elsewhere is replaced
+	if stat, err := os.Lstat(link); err == nil && stat.Mode()&os.ModeSymlink != 0 {
+		os.Remove(link)
+	}
+
+	err := os.MkdirAll(filepath.Dir(link), 0755)
+	if err == nil {
+		err = os.Symlink(dir, link)
+	}
+	if err != nil {
+		exitSourceCode(
+			fmt.Sprintf("unable to link module %s into GOPATH, searched:", module),
+			[]string{
+				filepath.Join(os.Getenv("GOPATH"), "src", filepath.FromSlash(module)),
+				link + ": " + err.Error(),
+			},
+			"Clone the module at $GOPATH/src/"+module+", define GITHUB_WORKSPACE as",
+			"the root of the module or allow symbolic links (e.g. Developer Mode on Windows).",
+		)
+	}
+}
+
+func sameDir(a, b string) bool {
+	sa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	sb, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(sa, sb)
+}
+
+// reports the failure of source code discovery, the stack trace of panic
+// is meaningless for the configuration of the build environment.
+func exitSourceCode(reason string, searched []string, hints ...string) {
+	fmt.Fprintf(os.Stderr, "cloudmcp: %s\n", reason)
+	for _, path := range searched {
+		fmt.Fprintf(os.Stderr, "    %s\n", path)
+	}
+	for _, hint := range hints {
+		fmt.Fprintf(os.Stderr, "%s\n", hint)
+	}
+	os.Exit(1)
+}
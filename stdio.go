@@ -0,0 +1,68 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/fogfish/cloudmcp/pkg/config"
+	"github.com/fogfish/cloudmcp/pkg/middleware"
+	"github.com/fogfish/cloudmcp/pkg/warm"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RunStdio runs MCP server over stdio transport, so that the same Factory is
+// used by local clients (e.g. Claude Desktop, Cursor) and by the serverless
+// deployment. Middlewares are applied in the same way as Gateway.Use does.
+// The configuration of FactoryWithConfig is read from environment variables
+// of pkg/config (e.g. CONFIG_CLOUDMCP_ENV), secrets are loaded from SSM.
+// It blocks until the client disconnects or the process is interrupted.
+//
+//	func main() {
+//		if len(os.Args) > 1 && os.Args[1] == "stdio" {
+//			if err := cloudmcp.RunStdio(server.HelloWorld); err != nil {
+//				log.Fatal(err)
+//			}
+//			return
+//		}
+//
+//		cloudmcp.New(server.HelloWorld).AccessPublic().Build()
+//	}
+func RunStdio[F Factories](f F, mws ...middleware.Middleware) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := config.RuntimeFromEnv(ctx)
+	if err != nil {
+		return err
+	}
+
+	server, err := newServer(ctx, f, cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(mws) > 0 {
+		middleware.Install(server, mws...)
+	}
+
+	if err := warm.Run(ctx); err != nil {
+		return err
+	}
+
+	err = server.Run(ctx, &mcp.StdioTransport{})
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,780 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/cxapi"
+)
+
+// Configures Terraform (OpenTofu) output for organizations that do not deploy
+// with CDK. The synthesized stack is translated into Terraform JSON
+// configuration `{stack}.tf.json`, written into the cloud assembly next to
+// the assets of lambda functions:
+//
+//	go run . && cd cdk.out && terraform init && terraform apply
+//
+// The translation covers the architecture of the gateway: API Gateway (HTTP
+// API), routes, authorizers, lambda functions, IAM roles and policies, log
+// groups and DynamoDB tables. Build fails if the stack uses other resources
+// (e.g. Analytics, Aurora or container images).
+func (c *Gateway) Terraform() *Gateway {
+	c.terraform = true
+	return c
+}
+
+// Translates synthesized stack to Terraform configuration
+func (c *Gateway) writeTerraform(assembly cxapi.CloudAssembly) {
+	artifact := assembly.GetStackArtifact(c.stack.ArtifactId())
+
+	data, err := json.Marshal(artifact.Template())
+	if err != nil {
+		panic(err)
+	}
+
+	var template struct {
+		Resources map[string]struct {
+			Type       string         `json:"Type"`
+			Properties map[string]any `json:"Properties"`
+			DependsOn  any            `json:"DependsOn"`
+		} `json:"Resources"`
+		Outputs map[string]struct {
+			Value any `json:"Value"`
+		} `json:"Outputs"`
+	}
+	if err := json.Unmarshal(data, &template); err != nil {
+		panic(err)
+	}
+
+	tf := &tfConfig{
+		stack:     *artifact.StackName(),
+		dir:       *assembly.Directory(),
+		resources: map[string]string{},
+		addrs:     map[string][]string{},
+		config:    map[string]map[string]map[string]any{},
+		data:      map[string]map[string]map[string]any{},
+	}
+
+	ids := make([]string, 0, len(template.Resources))
+	unsupported := []string{}
+	for id, r := range template.Resources {
+		ids = append(ids, id)
+		if _, has := tfResources[r.Type]; has {
+			tf.resources[id] = r.Type
+		} else if r.Type != "AWS::CDK::Metadata" {
+			unsupported = append(unsupported, id+" ("+r.Type+")")
+		}
+	}
+	sort.Strings(ids)
+	sort.Strings(unsupported)
+
+	if len(unsupported) > 0 {
+		panic(fmt.Errorf("terraform output does not support resources: %s", strings.Join(unsupported, ", ")))
+	}
+
+	for _, id := range ids {
+		r := template.Resources[id]
+		if r.Type == "AWS::CDK::Metadata" {
+			continue
+		}
+
+		if err := tfConverters[r.Type](tf, id, r.Properties); err != nil {
+			panic(fmt.Errorf("terraform output failed for %s (%s): %w", id, r.Type, err))
+		}
+	}
+
+	// CloudFormation dependencies are preserved, e.g. the function is created
+	// after the policy of its role.
+	for _, id := range ids {
+		deps := []string{}
+		switch v := template.Resources[id].DependsOn.(type) {
+		case string:
+			deps = append(deps, v)
+		case []any:
+			for _, x := range v {
+				if s, ok := x.(string); ok {
+					deps = append(deps, s)
+				}
+			}
+		}
+
+		after := []string{}
+		for _, dep := range deps {
+			after = append(after, tf.addrs[dep]...)
+		}
+		if len(after) == 0 {
+			continue
+		}
+
+		for _, addr := range tf.addrs[id] {
+			typ, name, _ := strings.Cut(addr, ".")
+			tf.config[typ][name]["depends_on"] = after
+		}
+	}
+
+	outputs := map[string]any{}
+	for id, out := range template.Outputs {
+		val, err := tf.value(out.Value)
+		if err != nil {
+			panic(fmt.Errorf("terraform output failed for output %s: %w", id, err))
+		}
+		outputs[id] = map[string]any{"value": val}
+	}
+
+	config := map[string]any{
+		"terraform": map[string]any{
+			"required_providers": map[string]any{
+				"aws":     map[string]any{"source": "hashicorp/aws", "version": ">= 5.0"},
+				"archive": map[string]any{"source": "hashicorp/archive", "version": ">= 2.0"},
+			},
+		},
+		"resource": tf.config,
+	}
+	if region := *artifact.Environment().Region; !strings.HasPrefix(region, "unknown") {
+		config["provider"] = map[string]any{"aws": map[string]any{"region": region}}
+	}
+	if len(tf.data) > 0 {
+		config["data"] = tf.data
+	}
+	if len(outputs) > 0 {
+		config["output"] = outputs
+	}
+
+	file := filepath.Join(tf.dir, *c.stack.ArtifactId()+".tf.json")
+	out, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(file, out, 0644); err != nil {
+		panic(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: terraform configuration %s\n", tf.stack, file)
+}
+
+//------------------------------------------------------------------------------
+
+// Terraform configuration under construction
+type tfConfig struct {
+	stack string
+	dir   string
+
+	// types of CloudFormation resources by logical id
+	resources map[string]string
+
+	// terraform addresses of CloudFormation resources by logical id
+	addrs map[string][]string
+
+	// resource and data blocks: type -> name -> body
+	config map[string]map[string]map[string]any
+	data   map[string]map[string]map[string]any
+}
+
+func (tf *tfConfig) resource(id, typ, name string, body map[string]any) {
+	if tf.config[typ] == nil {
+		tf.config[typ] = map[string]map[string]any{}
+	}
+	tf.config[typ][name] = body
+	tf.addrs[id] = append(tf.addrs[id], typ+"."+name)
+}
+
+func (tf *tfConfig) datasource(typ, name string, body map[string]any) string {
+	if tf.data[typ] == nil {
+		tf.data[typ] = map[string]map[string]any{}
+	}
+	tf.data[typ][name] = body
+	return "data." + typ + "." + name
+}
+
+// terraform type of CloudFormation resource type, its Ref and Fn::GetAtt
+type tfResource struct {
+	typ   string
+	ref   string
+	attrs map[string]string
+}
+
+var tfResources = map[string]tfResource{
+	"AWS::Logs::LogGroup":            {typ: "aws_cloudwatch_log_group", ref: "name", attrs: map[string]string{"Arn": "arn"}},
+	"AWS::IAM::Role":                 {typ: "aws_iam_role", ref: "name", attrs: map[string]string{"Arn": "arn", "RoleId": "unique_id"}},
+	"AWS::IAM::Policy":               {typ: "aws_iam_role_policy", ref: "id"},
+	"AWS::Lambda::Function":          {typ: "aws_lambda_function", ref: "function_name", attrs: map[string]string{"Arn": "arn"}},
+	"AWS::Lambda::Permission":        {typ: "aws_lambda_permission", ref: "id"},
+	"AWS::ApiGatewayV2::Api":         {typ: "aws_apigatewayv2_api", ref: "id", attrs: map[string]string{"ApiEndpoint": "api_endpoint", "ApiId": "id"}},
+	"AWS::ApiGatewayV2::Stage":       {typ: "aws_apigatewayv2_stage", ref: "name"},
+	"AWS::ApiGatewayV2::Integration": {typ: "aws_apigatewayv2_integration", ref: "id"},
+	"AWS::ApiGatewayV2::Route":       {typ: "aws_apigatewayv2_route", ref: "id"},
+	"AWS::ApiGatewayV2::Authorizer":  {typ: "aws_apigatewayv2_authorizer", ref: "id"},
+	"AWS::DynamoDB::Table":           {typ: "aws_dynamodb_table", ref: "name", attrs: map[string]string{"Arn": "arn", "StreamArn": "stream_arn"}},
+}
+
+// translations of CloudFormation resources
+var tfConverters = map[string]func(tf *tfConfig, id string, p map[string]any) error{
+	"AWS::Logs::LogGroup": func(tf *tfConfig, id string, p map[string]any) error {
+		return tf.simple(id, "aws_cloudwatch_log_group", p, map[string]string{
+			"LogGroupName":    "name",
+			"RetentionInDays": "retention_in_days",
+			"KmsKeyId":        "kms_key_id",
+		})
+	},
+	"AWS::IAM::Role":        (*tfConfig).role,
+	"AWS::IAM::Policy":      (*tfConfig).policy,
+	"AWS::Lambda::Function": (*tfConfig).function,
+	"AWS::Lambda::Permission": func(tf *tfConfig, id string, p map[string]any) error {
+		return tf.simple(id, "aws_lambda_permission", p, map[string]string{
+			"Action":        "action",
+			"FunctionName":  "function_name",
+			"Principal":     "principal",
+			"SourceArn":     "source_arn",
+			"SourceAccount": "source_account",
+		})
+	},
+	"AWS::ApiGatewayV2::Api": (*tfConfig).api,
+	"AWS::ApiGatewayV2::Stage": func(tf *tfConfig, id string, p map[string]any) error {
+		return tf.simple(id, "aws_apigatewayv2_stage", p, map[string]string{
+			"ApiId":      "api_id",
+			"StageName":  "name",
+			"AutoDeploy": "auto_deploy",
+		})
+	},
+	"AWS::ApiGatewayV2::Integration": func(tf *tfConfig, id string, p map[string]any) error {
+		return tf.simple(id, "aws_apigatewayv2_integration", p, map[string]string{
+			"ApiId":                "api_id",
+			"IntegrationType":      "integration_type",
+			"IntegrationUri":       "integration_uri",
+			"IntegrationMethod":    "integration_method",
+			"PayloadFormatVersion": "payload_format_version",
+			"TimeoutInMillis":      "timeout_milliseconds",
+		})
+	},
+	"AWS::ApiGatewayV2::Route": func(tf *tfConfig, id string, p map[string]any) error {
+		return tf.simple(id, "aws_apigatewayv2_route", p, map[string]string{
+			"ApiId":               "api_id",
+			"RouteKey":            "route_key",
+			"Target":              "target",
+			"AuthorizationType":   "authorization_type",
+			"AuthorizerId":        "authorizer_id",
+			"AuthorizationScopes": "authorization_scopes",
+		})
+	},
+	"AWS::ApiGatewayV2::Authorizer": (*tfConfig).authorizer,
+	"AWS::DynamoDB::Table":          (*tfConfig).table,
+}
+
+// resource with one-to-one mapping of properties
+func (tf *tfConfig) simple(id, typ string, p map[string]any, props map[string]string) error {
+	body := map[string]any{}
+	if err := tf.props(body, p, props); err != nil {
+		return err
+	}
+
+	tf.resource(id, typ, id, body)
+	return nil
+}
+
+// maps properties into the body
+func (tf *tfConfig) props(body map[string]any, p map[string]any, props map[string]string) error {
+	for key, attr := range props {
+		v, has := p[key]
+		if !has {
+			continue
+		}
+
+		val, err := tf.value(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		body[attr] = val
+	}
+
+	return nil
+}
+
+// property as JSON document (e.g. IAM policy)
+func (tf *tfConfig) document(v any) (string, error) {
+	val, err := tf.value(v)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func (tf *tfConfig) role(id string, p map[string]any) error {
+	doc, err := tf.document(p["AssumeRolePolicyDocument"])
+	if err != nil {
+		return err
+	}
+
+	body := map[string]any{"assume_role_policy": doc}
+	if err := tf.props(body, p, map[string]string{
+		"RoleName":            "name",
+		"Path":                "path",
+		"PermissionsBoundary": "permissions_boundary",
+		"Description":         "description",
+	}); err != nil {
+		return err
+	}
+	tf.resource(id, "aws_iam_role", id, body)
+
+	arns, _ := p["ManagedPolicyArns"].([]any)
+	for i, arn := range arns {
+		val, err := tf.value(arn)
+		if err != nil {
+			return err
+		}
+
+		tf.resource(id, "aws_iam_role_policy_attachment", fmt.Sprintf("%s_%d", id, i),
+			map[string]any{
+				"role":       "${aws_iam_role." + id + ".name}",
+				"policy_arn": val,
+			},
+		)
+	}
+
+	policies, _ := p["Policies"].([]any)
+	for i, x := range policies {
+		policy, _ := x.(map[string]any)
+		doc, err := tf.document(policy["PolicyDocument"])
+		if err != nil {
+			return err
+		}
+
+		tf.resource(id, "aws_iam_role_policy", fmt.Sprintf("%s_inline_%d", id, i),
+			map[string]any{
+				"name":   policy["PolicyName"],
+				"role":   "${aws_iam_role." + id + ".name}",
+				"policy": doc,
+			},
+		)
+	}
+
+	return nil
+}
+
+func (tf *tfConfig) policy(id string, p map[string]any) error {
+	doc, err := tf.document(p["PolicyDocument"])
+	if err != nil {
+		return err
+	}
+
+	roles, _ := p["Roles"].([]any)
+	for i, role := range roles {
+		val, err := tf.value(role)
+		if err != nil {
+			return err
+		}
+
+		name := id
+		if i > 0 {
+			name = fmt.Sprintf("%s_%d", id, i)
+		}
+
+		tf.resource(id, "aws_iam_role_policy", name,
+			map[string]any{
+				"name":   p["PolicyName"],
+				"role":   val,
+				"policy": doc,
+			},
+		)
+	}
+
+	return nil
+}
+
+func (tf *tfConfig) function(id string, p map[string]any) error {
+	body := map[string]any{}
+	if err := tf.props(body, p, map[string]string{
+		"FunctionName":                 "function_name",
+		"Role":                         "role",
+		"Handler":                      "handler",
+		"Runtime":                      "runtime",
+		"Architectures":                "architectures",
+		"MemorySize":                   "memory_size",
+		"Timeout":                      "timeout",
+		"Layers":                       "layers",
+		"ReservedConcurrentExecutions": "reserved_concurrent_executions",
+		"Description":                  "description",
+	}); err != nil {
+		return err
+	}
+
+	if _, has := body["function_name"]; !has {
+		body["function_name"] = truncate(tf.stack+"-"+id, 64)
+	}
+
+	if env, ok := p["Environment"].(map[string]any); ok {
+		vars, err := tf.value(env["Variables"])
+		if err != nil {
+			return err
+		}
+		body["environment"] = map[string]any{"variables": vars}
+	}
+
+	if cfg, ok := p["LoggingConfig"].(map[string]any); ok {
+		block := map[string]any{"log_format": "Text"}
+		if err := tf.props(block, cfg, map[string]string{"LogGroup": "log_group", "LogFormat": "log_format"}); err != nil {
+			return err
+		}
+		body["logging_config"] = block
+	}
+
+	if cfg, ok := p["DeadLetterConfig"].(map[string]any); ok {
+		block := map[string]any{}
+		if err := tf.props(block, cfg, map[string]string{"TargetArn": "target_arn"}); err != nil {
+			return err
+		}
+		body["dead_letter_config"] = block
+	}
+
+	if cfg, ok := p["VpcConfig"].(map[string]any); ok {
+		block := map[string]any{}
+		if err := tf.props(block, cfg, map[string]string{"SubnetIds": "subnet_ids", "SecurityGroupIds": "security_group_ids"}); err != nil {
+			return err
+		}
+		body["vpc_config"] = block
+	}
+
+	if cfg, ok := p["TracingConfig"].(map[string]any); ok {
+		body["tracing_config"] = map[string]any{"mode": cfg["Mode"]}
+	}
+
+	code, _ := p["Code"].(map[string]any)
+	if code["ImageUri"] != nil {
+		return fmt.Errorf("container images are not supported")
+	}
+
+	key, _ := code["S3Key"].(string)
+	asset := "asset." + strings.TrimSuffix(key, ".zip")
+	if stat, err := os.Stat(filepath.Join(tf.dir, asset)); err == nil && stat.IsDir() {
+		archive := tf.datasource("archive_file", strings.ReplaceAll(asset, ".", "_"),
+			map[string]any{
+				"type":        "zip",
+				"source_dir":  "${path.module}/" + asset,
+				"output_path": "${path.module}/" + asset + ".zip",
+			},
+		)
+		body["filename"] = "${" + archive + ".output_path}"
+		body["source_code_hash"] = "${" + archive + ".output_base64sha256}"
+	} else {
+		if err := tf.props(body, code, map[string]string{"S3Bucket": "s3_bucket", "S3Key": "s3_key"}); err != nil {
+			return err
+		}
+	}
+
+	tf.resource(id, "aws_lambda_function", id, body)
+	return nil
+}
+
+func (tf *tfConfig) api(id string, p map[string]any) error {
+	body := map[string]any{}
+	if err := tf.props(body, p, map[string]string{
+		"Name":                      "name",
+		"ProtocolType":              "protocol_type",
+		"RouteSelectionExpression":  "route_selection_expression",
+		"DisableExecuteApiEndpoint": "disable_execute_api_endpoint",
+		"Description":               "description",
+	}); err != nil {
+		return err
+	}
+
+	if cors, ok := p["CorsConfiguration"].(map[string]any); ok {
+		block := map[string]any{}
+		if err := tf.props(block, cors, map[string]string{
+			"AllowOrigins":     "allow_origins",
+			"AllowMethods":     "allow_methods",
+			"AllowHeaders":     "allow_headers",
+			"ExposeHeaders":    "expose_headers",
+			"AllowCredentials": "allow_credentials",
+			"MaxAge":           "max_age",
+		}); err != nil {
+			return err
+		}
+		body["cors_configuration"] = block
+	}
+
+	tf.resource(id, "aws_apigatewayv2_api", id, body)
+	return nil
+}
+
+func (tf *tfConfig) authorizer(id string, p map[string]any) error {
+	body := map[string]any{}
+	if err := tf.props(body, p, map[string]string{
+		"ApiId":                          "api_id",
+		"Name":                           "name",
+		"AuthorizerType":                 "authorizer_type",
+		"AuthorizerUri":                  "authorizer_uri",
+		"AuthorizerPayloadFormatVersion": "authorizer_payload_format_version",
+		"AuthorizerResultTtlInSeconds":   "authorizer_result_ttl_in_seconds",
+		"EnableSimpleResponses":          "enable_simple_responses",
+		"IdentitySource":                 "identity_sources",
+	}); err != nil {
+		return err
+	}
+
+	if jwt, ok := p["JwtConfiguration"].(map[string]any); ok {
+		block := map[string]any{}
+		if err := tf.props(block, jwt, map[string]string{"Audience": "audience", "Issuer": "issuer"}); err != nil {
+			return err
+		}
+		body["jwt_configuration"] = block
+	}
+
+	tf.resource(id, "aws_apigatewayv2_authorizer", id, body)
+	return nil
+}
+
+func (tf *tfConfig) table(id string, p map[string]any) error {
+	body := map[string]any{"billing_mode": "PROVISIONED"}
+	if err := tf.props(body, p, map[string]string{
+		"TableName":   "name",
+		"BillingMode": "billing_mode",
+	}); err != nil {
+		return err
+	}
+
+	if _, has := body["name"]; !has {
+		body["name"] = tf.stack + "-" + id
+	}
+
+	schema, _ := p["KeySchema"].([]any)
+	for _, x := range schema {
+		key, _ := x.(map[string]any)
+		switch key["KeyType"] {
+		case "HASH":
+			body["hash_key"] = key["AttributeName"]
+		case "RANGE":
+			body["range_key"] = key["AttributeName"]
+		}
+	}
+
+	attrs := []any{}
+	defs, _ := p["AttributeDefinitions"].([]any)
+	for _, x := range defs {
+		def, _ := x.(map[string]any)
+		attrs = append(attrs, map[string]any{"name": def["AttributeName"], "type": def["AttributeType"]})
+	}
+	body["attribute"] = attrs
+
+	if ttl, ok := p["TimeToLiveSpecification"].(map[string]any); ok {
+		body["ttl"] = map[string]any{"attribute_name": ttl["AttributeName"], "enabled": ttl["Enabled"]}
+	}
+
+	if capacity, ok := p["ProvisionedThroughput"].(map[string]any); ok {
+		body["read_capacity"] = capacity["ReadCapacityUnits"]
+		body["write_capacity"] = capacity["WriteCapacityUnits"]
+	}
+
+	if indexes, _ := p["GlobalSecondaryIndexes"].([]any); len(indexes) > 0 {
+		return fmt.Errorf("global secondary indexes are not supported")
+	}
+
+	tf.resource(id, "aws_dynamodb_table", id, body)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// translates CloudFormation value to Terraform, intrinsic functions become
+// string templates (e.g. "${aws_iam_role.Role.arn}").
+func (tf *tfConfig) value(v any) (any, error) {
+	switch x := v.(type) {
+	case string:
+		return escape(x), nil
+	case []any:
+		seq := make([]any, len(x))
+		for i, e := range x {
+			val, err := tf.value(e)
+			if err != nil {
+				return nil, err
+			}
+			seq[i] = val
+		}
+		return seq, nil
+	case map[string]any:
+		if len(x) == 1 {
+			for fn := range x {
+				if fn == "Ref" || strings.HasPrefix(fn, "Fn::") {
+					return tf.template(x)
+				}
+			}
+		}
+
+		obj := make(map[string]any, len(x))
+		for k, e := range x {
+			val, err := tf.value(e)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = val
+		}
+		return obj, nil
+	default:
+		return v, nil
+	}
+}
+
+// string template of intrinsic function or literal
+func (tf *tfConfig) template(v any) (string, error) {
+	switch x := v.(type) {
+	case string:
+		return escape(x), nil
+	case map[string]any:
+		if ref, ok := x["Ref"].(string); ok {
+			if ref == "AWS::StackName" {
+				return escape(tf.stack), nil
+			}
+
+			expr, err := tf.ref(ref)
+			if err != nil {
+				return "", err
+			}
+			return "${" + expr + "}", nil
+		}
+
+		if args, ok := x["Fn::GetAtt"].([]any); ok && len(args) == 2 {
+			id, _ := args[0].(string)
+			attr, _ := args[1].(string)
+			expr, err := tf.attr(id, attr)
+			if err != nil {
+				return "", err
+			}
+			return "${" + expr + "}", nil
+		}
+
+		if args, ok := x["Fn::Join"].([]any); ok && len(args) == 2 {
+			delim, _ := args[0].(string)
+			parts, ok := args[1].([]any)
+			if !ok {
+				return "", fmt.Errorf("unsupported Fn::Join of %v", args[1])
+			}
+
+			seq := make([]string, len(parts))
+			for i, part := range parts {
+				s, err := tf.template(part)
+				if err != nil {
+					return "", err
+				}
+				seq[i] = s
+			}
+			return strings.Join(seq, escape(delim)), nil
+		}
+
+		if sub, ok := x["Fn::Sub"].(string); ok {
+			return tf.sub(sub)
+		}
+
+		for fn := range x {
+			return "", fmt.Errorf("unsupported intrinsic function %s", fn)
+		}
+	}
+
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// Fn::Sub, variables are pseudo parameters and logical ids (optionally with attribute)
+func (tf *tfConfig) sub(s string) (string, error) {
+	var out strings.Builder
+	for {
+		head, tail, found := strings.Cut(s, "${")
+		out.WriteString(escape(head))
+		if !found {
+			return out.String(), nil
+		}
+
+		name, rest, found := strings.Cut(tail, "}")
+		if !found {
+			return "", fmt.Errorf("invalid Fn::Sub %q", s)
+		}
+
+		var (
+			expr string
+			err  error
+		)
+		switch {
+		case strings.HasPrefix(name, "!"):
+			out.WriteString("$${" + name[1:] + "}")
+			s = rest
+			continue
+		case name == "AWS::StackName":
+			out.WriteString(escape(tf.stack))
+			s = rest
+			continue
+		case strings.Contains(name, "."):
+			id, attr, _ := strings.Cut(name, ".")
+			expr, err = tf.attr(id, attr)
+		default:
+			expr, err = tf.ref(name)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		out.WriteString("${" + expr + "}")
+		s = rest
+	}
+}
+
+// Ref of pseudo parameter or resource
+func (tf *tfConfig) ref(id string) (string, error) {
+	switch id {
+	case "AWS::Region":
+		return tf.datasource("aws_region", "current", map[string]any{}) + ".id", nil
+	case "AWS::AccountId":
+		return tf.datasource("aws_caller_identity", "current", map[string]any{}) + ".account_id", nil
+	case "AWS::Partition":
+		return tf.datasource("aws_partition", "current", map[string]any{}) + ".partition", nil
+	case "AWS::URLSuffix":
+		return tf.datasource("aws_partition", "current", map[string]any{}) + ".dns_suffix", nil
+	}
+
+	r, has := tfResources[tf.resources[id]]
+	if !has {
+		return "", fmt.Errorf("unsupported reference %s", id)
+	}
+
+	return r.typ + "." + id + "." + r.ref, nil
+}
+
+// Fn::GetAtt of resource
+func (tf *tfConfig) attr(id, attr string) (string, error) {
+	r, has := tfResources[tf.resources[id]]
+	if !has {
+		return "", fmt.Errorf("unsupported reference %s", id)
+	}
+
+	name, has := r.attrs[attr]
+	if !has {
+		return "", fmt.Errorf("unsupported attribute %s of %s", attr, id)
+	}
+
+	return r.typ + "." + id + "." + name, nil
+}
+
+// literal string is escaped from template interpolation
+func escape(s string) string {
+	return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/cloudmcp
+//
+
+package cloudmcp
+
+import (
+	"github.com/fogfish/cloudmcp/pkg/toolset"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ToolSet is a named group of tools, prompts and resources maintained as
+// reusable library, see pkg/toolset.
+type ToolSet = toolset.ToolSet
+
+// Compose creates the server from sets, it is used by Factory. Packages of
+// factories and tool libraries shall use pkg/toolset directly, the package
+// cloudmcp links AWS CDK into the lambda otherwise.
+//
+//	func Knowledge() (*mcp.Server, error) {
+//		return toolset.Compose(
+//			&mcp.Implementation{Name: "knowledge", Version: "v1.0.0"},
+//			wiki.Tools,
+//			jira.Tools,
+//		)
+//	}
+func Compose(impl *mcp.Implementation, sets ...*ToolSet) (*mcp.Server, error) {
+	return toolset.Compose(impl, sets...)
+}
@@ -10,11 +10,13 @@ package cloudmcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,6 +39,11 @@ type FunctionProps[A, B any] struct {
 	Handler Lambda[A, B]
 	About   string
 	AutoGen bool
+
+	// Metadata of the tool published to clients (title, annotations, input
+	// and output schema). Name and description default to the name of the
+	// handler and About, schemas are inferred from types A and B if omitted.
+	Tool *mcp.Tool
 }
 
 // Helper for NewFunctionProps to support automatic inference of types from function
@@ -54,6 +61,17 @@ func (f *FunctionProps[A, B]) ForceAutoGen() *FunctionProps[A, B] {
 	return f
 }
 
+// Defines metadata of the tool published to clients, e.g. annotations
+//
+//	WithTool(&mcp.Tool{
+//		Title:       "Say Hi",
+//		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+//	})
+func (f *FunctionProps[A, B]) WithTool(tool *mcp.Tool) *FunctionProps[A, B] {
+	f.Tool = tool
+	return f
+}
+
 // L3 Construct for MCP Tool Function as AWS Lambda function.
 // This construct assumes a function is running as an instance of MCP Server.
 type Function[A, B any] struct {
@@ -63,9 +81,9 @@ type Function[A, B any] struct {
 
 // Defines MCP Tool Function as a Lambda function.
 func NewFunction[A, B any](scope constructs.Construct, id *string, spec *FunctionProps[A, B]) *Function[A, B] {
-	name, path := fautogen(spec.Handler, spec.About, spec.SourceCodeModule, spec.AutoGen)
+	name, path := fautogen(spec.Handler, spec.About, spec.Tool, spec.SourceCodeModule, spec.AutoGen)
 	uri := "/" + strings.ToLower(name)
-	spec.SourceCodeLambda = filepath.Join(path, funcdir)
+	spec.SourceCodeLambda = path + "/" + funcdir
 	flambda := scud.NewFunctionGo(scope, id, spec.FunctionGoProps)
 
 	return &Function[A, B]{uri: uri, Function: flambda}
@@ -81,6 +99,21 @@ func (c *Function[A, B]) AllowAccessApiKey(api *scud.AuthorizerBasic) {
 	api.AddResource(c.uri, c.Function)
 }
 
+// Grants access to the function via given hashed API key authorizer.
+func (c *Function[A, B]) AllowAccessApiKeyHashed(api *AuthorizerApiKeyHashed) {
+	api.AddResource(c.uri, c.Function)
+}
+
+// Grants access to the function via given token introspection authorizer.
+func (c *Function[A, B]) AllowAccessIntrospection(api *AuthorizerIntrospection) {
+	api.AddResource(c.uri, c.Function)
+}
+
+// Grants access to the function via given multi-issuer JWT authorizer.
+func (c *Function[A, B]) AllowAccessJwtIssuers(api *AuthorizerJwtIssuers) {
+	api.AddResource(c.uri, c.Function)
+}
+
 // Grants access to the function via given JWT authorizer.
 func (c *Function[A, B]) AllowAccessJWT(api *scud.AuthorizerJwt, scope ...string) {
 	api.AddResource(c.uri, c.Function, scope...)
@@ -95,7 +128,7 @@ func (c *Function[A, B]) AllowAccessIAM(api *scud.AuthorizerIAM, principal awsia
 
 const funcdir = "autogen"
 
-func fautogen[A, B any](f Lambda[A, B], about, scModule string, force bool) (string, string) {
+func fautogen[A, B any](f Lambda[A, B], about string, spec *mcp.Tool, scModule string, force bool) (string, string) {
 	fptr := reflect.ValueOf(f).Pointer()
 	fobj := runtime.FuncForPC(fptr)
 	if fobj == nil {
@@ -106,6 +139,7 @@ func fautogen[A, B any](f Lambda[A, B], about, scModule string, force bool) (str
 	serv := filepath.Ext(name)[1:]
 	path := strings.TrimSuffix(name, filepath.Ext(name))
 	base := filepath.Base(name)
+	tool := tautogen(f, serv, about, spec)
 
 	code := fmt.Sprintf(`// DO NOT EDIT !!!
 // THE FILE IS AUTO GENERATED BY github.com/fogfish/cloudmcp
@@ -113,10 +147,13 @@ func fautogen[A, B any](f Lambda[A, B], about, scModule string, force bool) (str
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 
   "github.com/aws/aws-lambda-go/lambda"
 	"github.com/fogfish/cloudmcp/internal/gateway"
+	"github.com/fogfish/cloudmcp/pkg/warm"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"%s"
 )
@@ -126,7 +163,16 @@ func main() {
 		&mcp.Implementation{Name: "%s", Version: "v0.0.0"},
 		nil,
 	)
-	mcp.AddTool(server, &mcp.Tool{Name: "%s", Description: "%s"}, %s)
+
+	tool := &mcp.Tool{}
+	if err := json.Unmarshal([]byte(%s), tool); err != nil {
+		panic(err)
+	}
+	mcp.AddTool(server, tool, %s)
+
+	if err := gateway.ServerFromEnv(context.Background(), server); err != nil {
+		panic(err)
+	}
 
 	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
 		return server
@@ -135,18 +181,28 @@ func main() {
 		JSONResponse: true,
 	})
 
-	srv := gateway.New(handler)
+	opts, err := gateway.FromEnv(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	srv := gateway.New(handler, opts...)
 
-	lambda.Start(srv.Serve)
+	if err := warm.Run(context.Background()); err != nil {
+		panic(err)
+	}
+
+	lambda.Start(srv.Handle)
 }
-`, time.Now(), path, serv, serv, about, base)
+`, time.Now(), path, serv, tool, base)
 
 	gofile, _ := fobj.FileLine(fptr)
 	codepath := filepath.Join(filepath.Dir(gofile), serverdir, "main.go")
 
 	if !force {
-		if _, err := os.Stat(codepath); err == nil {
-			// If the file already exists, we assume it has been generated before
+		if file, err := os.ReadFile(codepath); err == nil && isAutogen(file, tool) {
+			// If the file already exists, we assume it has been generated before,
+			// unless metadata of the tool is changed
 			return serv, strings.TrimPrefix(path, scModule)
 		}
 	}
@@ -162,3 +218,33 @@ func main() {
 
 	return serv, strings.TrimPrefix(path, scModule)
 }
+
+// literal of the tool metadata, encoded as JSON. The tool is validated by
+// registering it at the server, so that invalid schema fails the synth
+// rather than the cold start of the lambda.
+func tautogen[A, B any](f Lambda[A, B], serv, about string, spec *mcp.Tool) string {
+	tool := mcp.Tool{}
+	if spec != nil {
+		tool = *spec
+	}
+	if tool.Name == "" {
+		tool.Name = serv
+	}
+	if tool.Description == "" {
+		tool.Description = about
+	}
+
+	data, err := json.Marshal(tool)
+	if err != nil {
+		panic(fmt.Errorf("invalid tool %s: %w", tool.Name, err))
+	}
+
+	// the tool is decoded by the lambda, the schemas must survive the codec
+	check := &mcp.Tool{}
+	if err := json.Unmarshal(data, check); err != nil {
+		panic(fmt.Errorf("invalid tool %s: %w", tool.Name, err))
+	}
+	mcp.AddTool(mcp.NewServer(&mcp.Implementation{Name: serv}, nil), check, f)
+
+	return strconv.Quote(string(data))
+}